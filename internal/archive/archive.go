@@ -0,0 +1,54 @@
+// Package archive archives completed todos for users who asked for it.
+package archive
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"todo-api/internal/models"
+)
+
+// AutoArchiver archives todos completed more than each user's
+// AutoArchiveDays ago.
+type AutoArchiver struct {
+	DB  *gorm.DB
+	Now func() time.Time
+}
+
+// Run archives every todo that is due for it. It is meant to be registered
+// as a scheduler job.
+func (a *AutoArchiver) Run(ctx context.Context) error {
+	db := a.DB.WithContext(ctx)
+	now := a.now()
+
+	var users []models.User
+	if err := db.Select("id", "auto_archive_days").Where("auto_archive_days > 0").Find(&users).Error; err != nil {
+		return err
+	}
+
+	for _, user := range users {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		cutoff := now.AddDate(0, 0, -user.AutoArchiveDays)
+		err := db.Model(&models.Todo{}).
+			Where("user_id = ? AND completed = ? AND archived = ? AND completed_at < ?", user.ID, true, false, cutoff).
+			Updates(map[string]interface{}{
+				"archived":    true,
+				"archived_at": now,
+			}).Error
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (a *AutoArchiver) now() time.Time {
+	if a.Now != nil {
+		return a.Now()
+	}
+	return time.Now()
+}
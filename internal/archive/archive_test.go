@@ -0,0 +1,41 @@
+package archive
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"todo-api/internal/models"
+	"todo-api/internal/test"
+)
+
+func TestAutoArchiverRun(t *testing.T) {
+	db, err := test.SetupTestDB()
+	assert.NoError(t, err)
+	test.ClearTestData(db)
+
+	now := time.Now()
+	longAgo := now.AddDate(0, 0, -10)
+	recently := now.AddDate(0, 0, -2)
+
+	opted := &models.User{Email: "opted@example.com", AutoArchiveDays: 7}
+	other := &models.User{Email: "other@example.com"}
+	db.Create(opted)
+	db.Create(other)
+
+	old := &models.Todo{Title: "Old", UserID: opted.ID, Completed: true, CompletedAt: &longAgo}
+	fresh := &models.Todo{Title: "Fresh", UserID: opted.ID, Completed: true, CompletedAt: &recently}
+	open := &models.Todo{Title: "Open", UserID: opted.ID}
+	othersOld := &models.Todo{Title: "Not opted in", UserID: other.ID, Completed: true, CompletedAt: &longAgo}
+	for _, todo := range []*models.Todo{old, fresh, open, othersOld} {
+		db.Create(todo)
+	}
+
+	archiver := &AutoArchiver{DB: db, Now: func() time.Time { return now }}
+	assert.NoError(t, archiver.Run(context.Background()))
+
+	var archived []string
+	db.Model(&models.Todo{}).Where("archived = ?", true).Pluck("title", &archived)
+	assert.Equal(t, []string{"Old"}, archived)
+}
@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"todo-api/internal/database"
+	"todo-api/internal/models"
+)
+
+// @Summary Archive a todo
+// @Description Archive a todo and its subtasks. Archived todos are hidden from listings unless asked for. Only the todo's creator can archive it.
+// @Tags todos
+// @Produce json
+// @Security Bearer
+// @Param id path int true "Todo ID"
+// @Success 200 {object} models.Todo
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/todos/{id}/archive [post]
+func ArchiveTodo(c *gin.Context) {
+	setArchived(c, true)
+}
+
+// @Summary Unarchive a todo
+// @Description Bring an archived todo and its subtasks back into listings
+// @Tags todos
+// @Produce json
+// @Security Bearer
+// @Param id path int true "Todo ID"
+// @Success 200 {object} models.Todo
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/todos/{id}/unarchive [post]
+func UnarchiveTodo(c *gin.Context) {
+	setArchived(c, false)
+}
+
+func setArchived(c *gin.Context, archived bool) {
+	userID, _ := c.Get("userID")
+	id := c.Param("id")
+	var todo models.Todo
+
+	if err := database.GetDB().Where("id = ? AND user_id = ?", id, userID).First(&todo).Error; err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Todo not found"})
+		return
+	}
+
+	before := snapshotTodo(&todo)
+	err := database.GetDB().Transaction(func(tx *gorm.DB) error {
+		ids, err := descendantIDs(tx, todo.ID)
+		if err != nil {
+			return err
+		}
+		ids = append(ids, todo.ID)
+
+		var archivedAt *time.Time
+		if archived {
+			now := time.Now()
+			archivedAt = &now
+		}
+		err = tx.Model(&models.Todo{}).Where("id IN ? AND archived = ?", ids, !archived).Updates(map[string]interface{}{
+			"archived":    archived,
+			"archived_at": archivedAt,
+		}).Error
+		if err != nil {
+			return err
+		}
+		if err := recordRevision(tx, before, todo.ID, userID.(uint)); err != nil {
+			return err
+		}
+		return runRules(tx, models.TriggerUpdated, &todo)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	database.GetDB().Preload("Tags").First(&todo, todo.ID)
+	c.JSON(http.StatusOK, todo)
+}
@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"todo-api/internal/models"
+	"todo-api/internal/test"
+)
+
+func TestArchiveTodo(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db, _ := test.SetupTestDB()
+	testUser, err := test.CreateTestUser(db)
+	assert.NoError(t, err)
+	test.ClearTestData(db)
+
+	todo := &models.Todo{Title: "File taxes", UserID: testUser.ID, Completed: true}
+	db.Create(todo)
+	subtask := &models.Todo{Title: "Collect receipts", UserID: testUser.ID, Completed: true, ParentID: &todo.ID}
+	db.Create(subtask)
+	open := &models.Todo{Title: "Book flights", UserID: testUser.ID}
+	db.Create(open)
+
+	router := gin.New()
+	router.POST("/todos/:id/archive", func(c *gin.Context) {
+		c.Set("userID", testUser.ID)
+		ArchiveTodo(c)
+	})
+	router.POST("/todos/:id/unarchive", func(c *gin.Context) {
+		c.Set("userID", testUser.ID)
+		UnarchiveTodo(c)
+	})
+	router.GET("/todos", func(c *gin.Context) {
+		c.Set("userID", testUser.ID)
+		GetTodos(c)
+	})
+
+	listTitles := func(query string) []string {
+		req, _ := http.NewRequest("GET", "/todos"+query, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var todos []models.Todo
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &todos))
+		titles := make([]string, len(todos))
+		for i, todo := range todos {
+			titles[i] = todo.Title
+		}
+		return titles
+	}
+
+	req, _ := http.NewRequest("POST", fmt.Sprintf("/todos/%d/archive", todo.ID), nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response models.Todo
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.True(t, response.Archived)
+	assert.NotNil(t, response.ArchivedAt)
+	assert.True(t, response.Completed)
+
+	assert.ElementsMatch(t, []string{"Book flights"}, listTitles(""))
+	assert.ElementsMatch(t, []string{"File taxes", "Collect receipts"}, listTitles("?archived=only"))
+	assert.ElementsMatch(t, []string{"File taxes", "Collect receipts", "Book flights"}, listTitles("?archived=true"))
+
+	req, _ = http.NewRequest("GET", "/todos?archived=maybe", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	req, _ = http.NewRequest("POST", fmt.Sprintf("/todos/%d/unarchive", todo.ID), nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.ElementsMatch(t, []string{"File taxes", "Collect receipts", "Book flights"}, listTitles(""))
+}
@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"todo-api/internal/database"
+	"todo-api/internal/models"
+)
+
+// @Summary Assign a todo
+// @Description Make a user responsible for a todo. The assignee must be able to see the todo: its creator or a member of the project it is in.
+// @Tags todos
+// @Produce json
+// @Security Bearer
+// @Param id path int true "Todo ID"
+// @Param userId path int true "User ID of the assignee"
+// @Success 200 {object} models.Todo
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/todos/{id}/assignees/{userId} [post]
+func AssignTodo(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	db := database.GetDB()
+
+	todo, err := findAccessibleTodo(db, userID.(uint), c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Todo not found"})
+		return
+	}
+
+	assigneeID, err := strconv.ParseUint(c.Param("userId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "User ID must be a number"})
+		return
+	}
+	var assignee models.User
+	if err := db.First(&assignee, assigneeID).Error; err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "User not found"})
+		return
+	}
+	if _, err := findAccessibleTodo(db, assignee.ID, todo.ID); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "The user has no access to this todo, share its project with them first"})
+		return
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(todo).Association("Assignees").Append(&assignee); err != nil {
+			return err
+		}
+		return runRules(tx, models.TriggerUpdated, todo)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	db.Preload("User").Preload("Assignees").Preload("Tags").First(todo, todo.ID)
+
+	c.JSON(http.StatusOK, todo)
+}
+
+// @Summary Unassign a todo
+// @Description Remove a user from a todo's assignees
+// @Tags todos
+// @Produce json
+// @Security Bearer
+// @Param id path int true "Todo ID"
+// @Param userId path int true "User ID of the assignee"
+// @Success 200 {object} models.Todo
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/todos/{id}/assignees/{userId} [delete]
+func UnassignTodo(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	db := database.GetDB()
+
+	todo, err := findAccessibleTodo(db, userID.(uint), c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Todo not found"})
+		return
+	}
+
+	assigneeID, err := strconv.ParseUint(c.Param("userId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "User ID must be a number"})
+		return
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("DELETE FROM todo_assignees WHERE todo_id = ? AND user_id = ?", todo.ID, assigneeID).Error; err != nil {
+			return err
+		}
+		return runRules(tx, models.TriggerUpdated, todo)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	db.Preload("User").Preload("Assignees").Preload("Tags").First(todo, todo.ID)
+
+	c.JSON(http.StatusOK, todo)
+}
+
+// pruneAssignees unassigns users from the given todos once they can no
+// longer see them, after a todo changes project or a member leaves one.
+func pruneAssignees(tx *gorm.DB, todoIDs interface{}) error {
+	return tx.Exec(`DELETE FROM todo_assignees WHERE todo_id IN (?)
+		AND user_id NOT IN (SELECT todos.user_id FROM todos WHERE todos.id = todo_assignees.todo_id)
+		AND user_id NOT IN (SELECT project_members.user_id FROM project_members
+			JOIN todos ON todos.project_id = project_members.project_id
+			WHERE todos.id = todo_assignees.todo_id)`, todoIDs).Error
+}
@@ -0,0 +1,288 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"todo-api/internal/models"
+	"todo-api/internal/test"
+)
+
+func TestAssignTodo(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db, _ := test.SetupTestDB()
+	test.ClearTestData(db)
+	owner, err := test.CreateTestUser(db)
+	assert.NoError(t, err)
+	member := &models.User{Email: "member@example.com"}
+	db.Create(member)
+	outsider := &models.User{Email: "outsider@example.com"}
+	db.Create(outsider)
+
+	shared := &models.Project{Name: "Household", UserID: owner.ID}
+	db.Create(shared)
+	db.Create(&models.ProjectMember{ProjectID: shared.ID, UserID: member.ID})
+	private := &models.Project{Name: "Private", UserID: owner.ID}
+	db.Create(private)
+
+	sharedTodo := &models.Todo{Title: "Fix the sink", UserID: owner.ID, ProjectID: &shared.ID}
+	db.Create(sharedTodo)
+	privateTodo := &models.Todo{Title: "Diary", UserID: owner.ID, ProjectID: &private.ID}
+	db.Create(privateTodo)
+	othersTodo := &models.Todo{Title: "Not shared", UserID: outsider.ID}
+	db.Create(othersTodo)
+
+	router := gin.New()
+	router.POST("/todos/:id/assignees/:userId", func(c *gin.Context) {
+		c.Set("userID", owner.ID)
+		AssignTodo(c)
+	})
+
+	tests := []struct {
+		name          string
+		todoID        uint
+		assignee      string
+		expectedCode  int
+		expectedCount int
+	}{
+		{
+			name:          "Assign the creator",
+			todoID:        sharedTodo.ID,
+			assignee:      fmt.Sprint(owner.ID),
+			expectedCode:  http.StatusOK,
+			expectedCount: 1,
+		},
+		{
+			name:          "Assign a project member",
+			todoID:        sharedTodo.ID,
+			assignee:      fmt.Sprint(member.ID),
+			expectedCode:  http.StatusOK,
+			expectedCount: 2,
+		},
+		{
+			name:          "Assigning twice changes nothing",
+			todoID:        sharedTodo.ID,
+			assignee:      fmt.Sprint(member.ID),
+			expectedCode:  http.StatusOK,
+			expectedCount: 2,
+		},
+		{
+			name:         "Member of another project",
+			todoID:       privateTodo.ID,
+			assignee:     fmt.Sprint(member.ID),
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "User without access",
+			todoID:       sharedTodo.ID,
+			assignee:     fmt.Sprint(outsider.ID),
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "Unknown user",
+			todoID:       sharedTodo.ID,
+			assignee:     "99999",
+			expectedCode: http.StatusNotFound,
+		},
+		{
+			name:         "Invalid user ID",
+			todoID:       sharedTodo.ID,
+			assignee:     "me",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "Another user's todo",
+			todoID:       othersTodo.ID,
+			assignee:     fmt.Sprint(owner.ID),
+			expectedCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest("POST", fmt.Sprintf("/todos/%d/assignees/%s", tt.todoID, tt.assignee), nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+			if w.Code == http.StatusOK {
+				var response models.Todo
+				assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+				assert.Len(t, response.Assignees, tt.expectedCount)
+				assert.Equal(t, owner.ID, response.UserID)
+			}
+		})
+	}
+}
+
+func TestUnassignTodo(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db, _ := test.SetupTestDB()
+	test.ClearTestData(db)
+	owner, err := test.CreateTestUser(db)
+	assert.NoError(t, err)
+	member := &models.User{Email: "member@example.com"}
+	db.Create(member)
+
+	shared := &models.Project{Name: "Household", UserID: owner.ID}
+	db.Create(shared)
+	db.Create(&models.ProjectMember{ProjectID: shared.ID, UserID: member.ID})
+	todo := &models.Todo{Title: "Fix the sink", UserID: owner.ID, ProjectID: &shared.ID}
+	db.Create(todo)
+	db.Exec("INSERT INTO todo_assignees (todo_id, user_id) VALUES (?, ?), (?, ?)", todo.ID, owner.ID, todo.ID, member.ID)
+
+	// Members can hand a todo back
+	router := gin.New()
+	router.DELETE("/todos/:id/assignees/:userId", func(c *gin.Context) {
+		c.Set("userID", member.ID)
+		UnassignTodo(c)
+	})
+
+	req, _ := http.NewRequest("DELETE", fmt.Sprintf("/todos/%d/assignees/%d", todo.ID, member.ID), nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var response models.Todo
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	if assert.Len(t, response.Assignees, 1) {
+		assert.Equal(t, owner.ID, response.Assignees[0].ID)
+	}
+}
+
+func TestGetTodosByAssignee(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db, _ := test.SetupTestDB()
+	test.ClearTestData(db)
+	owner, err := test.CreateTestUser(db)
+	assert.NoError(t, err)
+	member := &models.User{Email: "member@example.com"}
+	db.Create(member)
+
+	shared := &models.Project{Name: "Household", UserID: owner.ID}
+	db.Create(shared)
+	db.Create(&models.ProjectMember{ProjectID: shared.ID, UserID: member.ID})
+	private := &models.Project{Name: "Private", UserID: owner.ID}
+	db.Create(private)
+
+	mine := &models.Todo{Title: "Fix the sink", UserID: owner.ID, ProjectID: &shared.ID}
+	db.Create(mine)
+	theirs := &models.Todo{Title: "Water plants", UserID: owner.ID, ProjectID: &shared.ID}
+	db.Create(theirs)
+	unassigned := &models.Todo{Title: "Buy bulbs", UserID: owner.ID, ProjectID: &shared.ID}
+	db.Create(unassigned)
+	hidden := &models.Todo{Title: "Diary", UserID: owner.ID, ProjectID: &private.ID}
+	db.Create(hidden)
+	own := &models.Todo{Title: "Own errand", UserID: member.ID}
+	db.Create(own)
+	db.Exec("INSERT INTO todo_assignees (todo_id, user_id) VALUES (?, ?), (?, ?)", mine.ID, member.ID, theirs.ID, owner.ID)
+
+	router := gin.New()
+	router.GET("/todos", func(c *gin.Context) {
+		c.Set("userID", member.ID)
+		GetTodos(c)
+	})
+
+	tests := []struct {
+		name           string
+		query          string
+		expectedCode   int
+		expectedTitles []string
+	}{
+		{
+			name:           "Own and shared todos",
+			expectedCode:   http.StatusOK,
+			expectedTitles: []string{"Fix the sink", "Water plants", "Buy bulbs", "Own errand"},
+		},
+		{
+			name:           "Assigned to me",
+			query:          "?assignee=me",
+			expectedCode:   http.StatusOK,
+			expectedTitles: []string{"Fix the sink"},
+		},
+		{
+			name:           "Assigned to someone else",
+			query:          fmt.Sprintf("?assignee=%d", owner.ID),
+			expectedCode:   http.StatusOK,
+			expectedTitles: []string{"Water plants"},
+		},
+		{
+			name:           "Unassigned",
+			query:          "?assignee=none",
+			expectedCode:   http.StatusOK,
+			expectedTitles: []string{"Buy bulbs", "Own errand"},
+		},
+		{
+			name:         "Invalid assignee",
+			query:        "?assignee=someone",
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", "/todos"+tt.query, nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+			if w.Code == http.StatusOK {
+				var response []models.Todo
+				assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+				var titles []string
+				for _, todo := range response {
+					titles = append(titles, todo.Title)
+				}
+				assert.ElementsMatch(t, tt.expectedTitles, titles)
+			}
+		})
+	}
+}
+
+func TestMoveTodoDropsAssigneesWithoutAccess(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db, _ := test.SetupTestDB()
+	test.ClearTestData(db)
+	owner, err := test.CreateTestUser(db)
+	assert.NoError(t, err)
+	member := &models.User{Email: "member@example.com"}
+	db.Create(member)
+
+	shared := &models.Project{Name: "Household", UserID: owner.ID}
+	db.Create(shared)
+	db.Create(&models.ProjectMember{ProjectID: shared.ID, UserID: member.ID})
+	private := &models.Project{Name: "Private", UserID: owner.ID}
+	db.Create(private)
+	todo := &models.Todo{Title: "Fix the sink", UserID: owner.ID, ProjectID: &shared.ID}
+	db.Create(todo)
+	db.Exec("INSERT INTO todo_assignees (todo_id, user_id) VALUES (?, ?), (?, ?)", todo.ID, owner.ID, todo.ID, member.ID)
+
+	router := gin.New()
+	router.POST("/todos/:id/move", func(c *gin.Context) {
+		c.Set("userID", owner.ID)
+		MoveTodo(c)
+	})
+
+	body, _ := json.Marshal(map[string]uint{"project_id": private.ID})
+	req, _ := http.NewRequest("POST", fmt.Sprintf("/todos/%d/move", todo.ID), bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var response models.Todo
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	if assert.Len(t, response.Assignees, 1) {
+		assert.Equal(t, owner.ID, response.Assignees[0].ID)
+	}
+}
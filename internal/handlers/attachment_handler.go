@@ -0,0 +1,346 @@
+package handlers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"path/filepath"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"todo-api/internal/database"
+	"todo-api/internal/models"
+	"todo-api/internal/storage"
+)
+
+var errQuotaExceeded = errors.New("storage quota exceeded")
+
+// @Summary Upload an attachment
+// @Description Attach a file to a todo. The file type is detected from its contents, and identical files are stored once.
+// @Tags attachments
+// @Accept multipart/form-data
+// @Produce json
+// @Security Bearer
+// @Param id path int true "Todo ID"
+// @Param file formData file true "File to attach"
+// @Success 201 {object} models.Attachment
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 413 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/todos/{id}/attachments [post]
+func UploadAttachment(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	db := database.GetDB()
+
+	todo, err := findAccessibleTodo(db, userID.(uint), c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Todo not found"})
+		return
+	}
+
+	// Leave room for the multipart framing around the file itself
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, storage.MaxUploadSize+1<<20)
+	header, err := c.FormFile("file")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			c.JSON(http.StatusRequestEntityTooLarge, ErrorResponse{Error: fmt.Sprintf("Files can be at most %d bytes", storage.MaxUploadSize)})
+			return
+		}
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "A file is required in the 'file' field"})
+		return
+	}
+	if header.Size > storage.MaxUploadSize {
+		c.JSON(http.StatusRequestEntityTooLarge, ErrorResponse{Error: fmt.Sprintf("Files can be at most %d bytes", storage.MaxUploadSize)})
+		return
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+	defer file.Close()
+
+	// Sniff the type from the first bytes rather than trusting the client
+	head := make([]byte, 512)
+	n, err := io.ReadFull(file, head)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+	mimeType := http.DetectContentType(head[:n])
+
+	hash := sha256.New()
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+	if _, err := io.Copy(hash, file); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+	checksum := hex.EncodeToString(hash.Sum(nil))
+
+	usage, owned, err := storageUsage(db, userID.(uint), checksum)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+	if !owned && usage+header.Size > storage.UserQuota {
+		c.JSON(http.StatusRequestEntityTooLarge, ErrorResponse{Error: "Storage quota exceeded"})
+		return
+	}
+
+	attachment := models.Attachment{
+		TodoID:   todo.ID,
+		UserID:   userID.(uint),
+		Filename: filepath.Base(header.Filename),
+		MimeType: mimeType,
+		Size:     header.Size,
+		Checksum: checksum,
+	}
+	created := false
+	err = db.Transaction(func(tx *gorm.DB) error {
+		// Recheck under a lock on the user so parallel uploads are counted
+		// one at a time
+		var user models.User
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Limit(1).Find(&user, userID).Error; err != nil {
+			return err
+		}
+		usage, owned, err := storageUsage(tx, userID.(uint), checksum)
+		if err != nil {
+			return err
+		}
+		if !owned && usage+header.Size > storage.UserQuota {
+			return errQuotaExceeded
+		}
+		created, err = claimBlob(tx, checksum, header.Size)
+		if err != nil {
+			return err
+		}
+		return tx.Create(&attachment).Error
+	})
+	if errors.Is(err, errQuotaExceeded) {
+		c.JSON(http.StatusRequestEntityTooLarge, ErrorResponse{Error: "Storage quota exceeded"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	// The attachment now keeps the blob from being removed as an orphan, so
+	// the file can be written outside the transaction. It is also written
+	// when an earlier upload left the row without a file.
+	err = func() error {
+		if !created {
+			missing, err := blobMissing(c.Request.Context(), checksum)
+			if err != nil || !missing {
+				return err
+			}
+		}
+		if _, err := file.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+		return storage.GetStore().Put(c.Request.Context(), checksum, file)
+	}()
+	if err != nil {
+		db.Delete(&attachment)
+		storage.RemoveOrphans(c.Request.Context(), db, storage.GetStore(), []string{checksum})
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, attachment)
+}
+
+// @Summary Get a todo's attachments
+// @Description Get the files attached to a todo
+// @Tags attachments
+// @Produce json
+// @Security Bearer
+// @Param id path int true "Todo ID"
+// @Success 200 {array} models.Attachment
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/todos/{id}/attachments [get]
+func GetAttachments(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	db := database.GetDB()
+
+	todo, err := findAccessibleTodo(db, userID.(uint), c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Todo not found"})
+		return
+	}
+
+	var attachments []models.Attachment
+	result := db.Where("todo_id = ?", todo.ID).Order("created_at, id").Find(&attachments)
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: result.Error.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, attachments)
+}
+
+// @Summary Download an attachment
+// @Description Download the contents of a file attached to a todo
+// @Tags attachments
+// @Produce octet-stream
+// @Security Bearer
+// @Param id path int true "Todo ID"
+// @Param attachmentId path int true "Attachment ID"
+// @Success 200 {file} file
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/todos/{id}/attachments/{attachmentId} [get]
+func DownloadAttachment(c *gin.Context) {
+	attachment, ok := findAttachment(c)
+	if !ok {
+		return
+	}
+
+	blob, err := storage.GetStore().Get(c.Request.Context(), attachment.Checksum)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			c.JSON(http.StatusNotFound, ErrorResponse{Error: "Attachment contents not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+	defer blob.Close()
+
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": attachment.Filename}))
+	c.DataFromReader(http.StatusOK, attachment.Size, attachment.MimeType, blob, nil)
+}
+
+// @Summary Delete an attachment
+// @Description Remove a file from a todo. Only the uploader or the todo's owner can remove it. Its contents are deleted once no other attachment uses them.
+// @Tags attachments
+// @Security Bearer
+// @Param id path int true "Todo ID"
+// @Param attachmentId path int true "Attachment ID"
+// @Success 204 "No Content"
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/todos/{id}/attachments/{attachmentId} [delete]
+func DeleteAttachment(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	attachment, ok := findAttachment(c)
+	if !ok {
+		return
+	}
+
+	db := database.GetDB()
+	if attachment.UserID != userID.(uint) {
+		var todo models.Todo
+		if err := db.First(&todo, attachment.TodoID).Error; err != nil || todo.UserID != userID.(uint) {
+			c.JSON(http.StatusNotFound, ErrorResponse{Error: "Attachment not found"})
+			return
+		}
+	}
+	if err := db.Delete(attachment).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+	if err := storage.RemoveOrphans(c.Request.Context(), db, storage.GetStore(), []string{attachment.Checksum}); err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// findAttachment loads an attachment of a todo the user can see, writing a
+// 404 when there is none.
+func findAttachment(c *gin.Context) (*models.Attachment, bool) {
+	userID, _ := c.Get("userID")
+	db := database.GetDB()
+
+	todo, err := findAccessibleTodo(db, userID.(uint), c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Todo not found"})
+		return nil, false
+	}
+
+	var attachment models.Attachment
+	if err := db.Where("id = ? AND todo_id = ?", c.Param("attachmentId"), todo.ID).First(&attachment).Error; err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Attachment not found"})
+		return nil, false
+	}
+	return &attachment, true
+}
+
+// storageUsage returns the bytes a user's attachments take up, counting
+// identical files once, and whether the user already stored a file with
+// the given checksum, which costs nothing to upload again.
+func storageUsage(db *gorm.DB, userID uint, checksum string) (int64, bool, error) {
+	var usage int64
+	owned := db.Model(&models.Attachment{}).Select("checksum").Where("user_id = ?", userID)
+	err := db.Model(&models.Blob{}).
+		Select("COALESCE(SUM(size), 0)").
+		Where("checksum IN (?)", owned).
+		Scan(&usage).Error
+	if err != nil {
+		return 0, false, err
+	}
+
+	var count int64
+	err = db.Model(&models.Attachment{}).Where("user_id = ? AND checksum = ?", userID, checksum).Count(&count).Error
+	return usage, count > 0, err
+}
+
+// claimBlob creates the row of a blob, or locks the existing one so that
+// storage.RemoveOrphans cannot delete it before an attachment referring to
+// it is committed. It reports whether the row is new.
+func claimBlob(tx *gorm.DB, checksum string, size int64) (bool, error) {
+	blob := models.Blob{Checksum: checksum, Size: size}
+	result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&blob)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	if result.RowsAffected == 1 {
+		return true, nil
+	}
+
+	var existing []models.Blob
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("checksum = ?", checksum).Find(&existing).Error; err != nil {
+		return false, err
+	}
+	if len(existing) > 0 {
+		return false, nil
+	}
+	// Removed as an orphan since the insert
+	if err := tx.Create(&blob).Error; err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// blobMissing reports whether the store has no file for a blob.
+func blobMissing(ctx context.Context, checksum string) (bool, error) {
+	contents, err := storage.GetStore().Get(ctx, checksum)
+	if errors.Is(err, storage.ErrNotFound) {
+		return true, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	contents.Close()
+	return false, nil
+}
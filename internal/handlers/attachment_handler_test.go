@@ -0,0 +1,282 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"todo-api/internal/models"
+	"todo-api/internal/storage"
+	"todo-api/internal/test"
+)
+
+var pngHeader = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+
+// setupTestStore keeps uploaded files in a directory removed after the test.
+func setupTestStore(t *testing.T) {
+	storage.InitStore(&storage.Local{Root: t.TempDir()})
+}
+
+func uploadRequest(url string, filename string, contents []byte) *http.Request {
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, _ := form.CreateFormFile("file", filename)
+	part.Write(contents)
+	form.Close()
+
+	req, _ := http.NewRequest("POST", url, &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	return req
+}
+
+func TestUploadAttachment(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db, _ := test.SetupTestDB()
+	setupTestStore(t)
+	testUser, err := test.CreateTestUser(db)
+	assert.NoError(t, err)
+
+	defaultMax, defaultQuota := storage.MaxUploadSize, storage.UserQuota
+	defer func() { storage.MaxUploadSize, storage.UserQuota = defaultMax, defaultQuota }()
+
+	tests := []struct {
+		name         string
+		filename     string
+		contents     []byte
+		maxSize      int64
+		quota        int64
+		expectedCode int
+		wantMime     string
+	}{
+		{
+			name:         "Type is sniffed from the contents",
+			filename:     "screenshot.txt",
+			contents:     pngHeader,
+			expectedCode: http.StatusCreated,
+			wantMime:     "image/png",
+		},
+		{
+			name:         "Path in the filename is dropped",
+			filename:     "../../notes.txt",
+			contents:     []byte("meeting notes"),
+			expectedCode: http.StatusCreated,
+			wantMime:     "text/plain; charset=utf-8",
+		},
+		{
+			name:         "File too large",
+			filename:     "big.bin",
+			contents:     bytes.Repeat([]byte("x"), 64),
+			maxSize:      32,
+			expectedCode: http.StatusRequestEntityTooLarge,
+		},
+		{
+			name:         "Quota exceeded",
+			filename:     "notes.txt",
+			contents:     bytes.Repeat([]byte("x"), 64),
+			quota:        32,
+			expectedCode: http.StatusRequestEntityTooLarge,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+
+			test.ClearTestData(db)
+			storage.MaxUploadSize, storage.UserQuota = defaultMax, defaultQuota
+			if tt.maxSize > 0 {
+				storage.MaxUploadSize = tt.maxSize
+			}
+			if tt.quota > 0 {
+				storage.UserQuota = tt.quota
+			}
+			todo := &models.Todo{Title: "Fix login page", UserID: testUser.ID}
+			db.Create(todo)
+
+			router.POST("/todos/:id/attachments", func(c *gin.Context) {
+				c.Set("userID", testUser.ID)
+				UploadAttachment(c)
+			})
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, uploadRequest(fmt.Sprintf("/todos/%d/attachments", todo.ID), tt.filename, tt.contents))
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+			if tt.expectedCode == http.StatusCreated {
+				var response models.Attachment
+				err := json.Unmarshal(w.Body.Bytes(), &response)
+				assert.NoError(t, err)
+				assert.Equal(t, tt.wantMime, response.MimeType)
+				assert.NotContains(t, response.Filename, "/")
+				assert.Equal(t, int64(len(tt.contents)), response.Size)
+			}
+		})
+	}
+}
+
+func TestAttachmentBlobLifecycle(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db, _ := test.SetupTestDB()
+	setupTestStore(t)
+	testUser, err := test.CreateTestUser(db)
+	assert.NoError(t, err)
+	test.ClearTestData(db)
+
+	first := &models.Todo{Title: "Fix login page", UserID: testUser.ID}
+	second := &models.Todo{Title: "Fix signup page", UserID: testUser.ID}
+	db.Create(first)
+	db.Create(second)
+
+	router := gin.New()
+	router.POST("/todos/:id/attachments", func(c *gin.Context) {
+		c.Set("userID", testUser.ID)
+		UploadAttachment(c)
+	})
+	router.GET("/todos/:id/attachments/:attachmentId", func(c *gin.Context) {
+		c.Set("userID", testUser.ID)
+		DownloadAttachment(c)
+	})
+	router.DELETE("/todos/:id/attachments/:attachmentId", func(c *gin.Context) {
+		c.Set("userID", testUser.ID)
+		DeleteAttachment(c)
+	})
+	router.DELETE("/todos/:id", func(c *gin.Context) {
+		c.Set("userID", testUser.ID)
+		DeleteTodo(c)
+	})
+	router.DELETE("/todos/trash/:id", func(c *gin.Context) {
+		c.Set("userID", testUser.ID)
+		PurgeTodo(c)
+	})
+
+	upload := func(todo *models.Todo) models.Attachment {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, uploadRequest(fmt.Sprintf("/todos/%d/attachments", todo.ID), "screenshot.png", pngHeader))
+		assert.Equal(t, http.StatusCreated, w.Code)
+		var attachment models.Attachment
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &attachment))
+		return attachment
+	}
+	send := func(method string, url string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, url, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	blobExists := func(checksum string) bool {
+		blob, err := storage.GetStore().Get(context.Background(), checksum)
+		if err != nil {
+			return false
+		}
+		blob.Close()
+		return true
+	}
+
+	// The same file on two todos is stored once
+	onFirst := upload(first)
+	onSecond := upload(second)
+	assert.Equal(t, onFirst.Checksum, onSecond.Checksum)
+	var blobs int64
+	db.Model(&models.Blob{}).Count(&blobs)
+	assert.Equal(t, int64(1), blobs)
+
+	w := send("GET", fmt.Sprintf("/todos/%d/attachments/%d", first.ID, onFirst.ID))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, pngHeader, w.Body.Bytes())
+	assert.Equal(t, "image/png", w.Header().Get("Content-Type"))
+	assert.Equal(t, "nosniff", w.Header().Get("X-Content-Type-Options"))
+
+	// Attachments are only reachable through their own todo
+	w = send("GET", fmt.Sprintf("/todos/%d/attachments/%d", second.ID, onFirst.ID))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = send("DELETE", fmt.Sprintf("/todos/%d/attachments/%d", first.ID, onFirst.ID))
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.True(t, blobExists(onFirst.Checksum))
+
+	// Trashing keeps the file so the todo can be restored; purging removes it
+	w = send("DELETE", fmt.Sprintf("/todos/%d", second.ID))
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.True(t, blobExists(onSecond.Checksum))
+
+	w = send("DELETE", fmt.Sprintf("/todos/trash/%d", second.ID))
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.False(t, blobExists(onSecond.Checksum))
+	db.Model(&models.Blob{}).Count(&blobs)
+	assert.Equal(t, int64(0), blobs)
+
+	// A blob row left without its file, as when orphan removal raced an
+	// upload, gets the file back from the next upload of the same contents
+	db.Create(&models.Blob{Checksum: onFirst.Checksum, Size: int64(len(pngHeader))})
+	again := upload(first)
+	assert.True(t, blobExists(again.Checksum))
+	w = send("GET", fmt.Sprintf("/todos/%d/attachments/%d", first.ID, again.ID))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, pngHeader, w.Body.Bytes())
+}
+
+func TestDeleteSharedAttachment(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db, _ := test.SetupTestDB()
+	setupTestStore(t)
+	test.ClearTestData(db)
+	owner, err := test.CreateTestUser(db)
+	assert.NoError(t, err)
+	member := &models.User{Email: "member@example.com"}
+	db.Create(member)
+	other := &models.User{Email: "other@example.com"}
+	db.Create(other)
+
+	shared := &models.Project{Name: "Household", UserID: owner.ID}
+	db.Create(shared)
+	db.Create(&models.ProjectMember{ProjectID: shared.ID, UserID: member.ID})
+	db.Create(&models.ProjectMember{ProjectID: shared.ID, UserID: other.ID})
+	todo := &models.Todo{Title: "Fix the sink", UserID: owner.ID, ProjectID: &shared.ID}
+	db.Create(todo)
+
+	as := func(user *models.User, handler gin.HandlerFunc) gin.HandlerFunc {
+		return func(c *gin.Context) {
+			c.Set("userID", user.ID)
+			handler(c)
+		}
+	}
+	router := gin.New()
+	router.POST("/members/:id/attachments", as(member, UploadAttachment))
+	router.DELETE("/owner/:id/attachments/:attachmentId", as(owner, DeleteAttachment))
+	router.DELETE("/members/:id/attachments/:attachmentId", as(member, DeleteAttachment))
+	router.DELETE("/others/:id/attachments/:attachmentId", as(other, DeleteAttachment))
+
+	upload := func() models.Attachment {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, uploadRequest(fmt.Sprintf("/members/%d/attachments", todo.ID), "leak.png", pngHeader))
+		assert.Equal(t, http.StatusCreated, w.Code)
+		var attachment models.Attachment
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &attachment))
+		return attachment
+	}
+	remove := func(as string, attachment models.Attachment) int {
+		req, _ := http.NewRequest("DELETE", fmt.Sprintf("/%s/%d/attachments/%d", as, todo.ID, attachment.ID), nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	// Another member can see the attachment but not remove it
+	attachment := upload()
+	assert.Equal(t, http.StatusNotFound, remove("others", attachment))
+	assert.Equal(t, http.StatusNoContent, remove("members", attachment))
+
+	// The todo's owner can remove what members attached
+	attachment = upload()
+	assert.Equal(t, http.StatusNoContent, remove("owner", attachment))
+}
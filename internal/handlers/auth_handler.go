@@ -1,14 +1,18 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 	"todo-api/internal/auth"
 	"todo-api/internal/database"
 	"todo-api/internal/models"
 )
 
+var errEmailTaken = errors.New("email already exists")
+
 type SignupRequest struct {
 	Email    string `json:"email" binding:"required,email" example:"user@example.com"`
 	Password string `json:"password" binding:"required,min=6" example:"password123"`
@@ -50,11 +54,25 @@ func Signup(c *gin.Context) {
 		return
 	}
 
-	result := database.GetDB().Create(&user)
-	if result.Error != nil {
+	// The account only exists once its inbox and default views do, so a
+	// failed signup can be retried with the same email
+	err := database.GetDB().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&user).Error; err != nil {
+			return errEmailTaken
+		}
+		if _, err := inboxFor(tx, user.ID); err != nil {
+			return err
+		}
+		return seedViews(tx, user.ID)
+	})
+	if errors.Is(err, errEmailTaken) {
 		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Email already exists"})
 		return
 	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to create account"})
+		return
+	}
 
 	token, err := auth.GenerateToken(user.ID)
 	if err != nil {
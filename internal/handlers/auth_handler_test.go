@@ -3,12 +3,15 @@ package handlers
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"todo-api/internal/models"
 	"todo-api/internal/test"
 
 	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
 )
 
 func TestSignup(t *testing.T) {
@@ -50,10 +53,53 @@ func TestSignup(t *testing.T) {
 			router.ServeHTTP(w, req)
 
 			assert.Equal(t, tt.wantStatus, w.Code)
+			if w.Code == http.StatusCreated {
+				// New users start with the default smart lists
+				var views int64
+				db.Model(&models.SavedView{}).Count(&views)
+				assert.Equal(t, int64(len(defaultViews)), views)
+			}
 		})
 	}
 }
 
+func TestSignupIsAtomic(t *testing.T) {
+	router := setupTestRouter()
+	router.POST("/auth/signup", Signup)
+
+	db, _ := test.SetupTestDB()
+	test.ClearTestData(db)
+	body, _ := json.Marshal(map[string]interface{}{"email": "test@example.com", "password": "password123"})
+	signup := func() int {
+		req, _ := http.NewRequest("POST", "/auth/signup", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	// Seeding the default views fails, which must not leave the account behind
+	failViews := db.Callback().Create().Before("gorm:create")
+	assert.NoError(t, failViews.Register("test:fail_views", func(tx *gorm.DB) {
+		if tx.Statement.Table == "saved_views" {
+			tx.AddError(errors.New("disk full"))
+		}
+	}))
+	assert.Equal(t, http.StatusInternalServerError, signup())
+	var users, projects int64
+	db.Model(&models.User{}).Count(&users)
+	db.Model(&models.Project{}).Count(&projects)
+	assert.Zero(t, users)
+	assert.Zero(t, projects)
+
+	// Retrying once the problem is gone works, and signing up twice does not
+	assert.NoError(t, db.Callback().Create().Remove("test:fail_views"))
+	assert.Equal(t, http.StatusCreated, signup())
+	assert.Equal(t, http.StatusBadRequest, signup())
+	db.Model(&models.User{}).Count(&users)
+	assert.Equal(t, int64(1), users)
+}
+
 func TestLogin(t *testing.T) {
 	router := setupTestRouter()
 	router.POST("/auth/login", Login)
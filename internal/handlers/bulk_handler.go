@@ -0,0 +1,293 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"todo-api/internal/database"
+	"todo-api/internal/models"
+)
+
+// Bulk actions
+const (
+	bulkComplete    = "complete"
+	bulkUncomplete  = "uncomplete"
+	bulkDelete      = "delete"
+	bulkMove        = "move"
+	bulkTag         = "tag"
+	bulkSetPriority = "set_priority"
+)
+
+// MaxBulkSize is the most todos one bulk request may change.
+var MaxBulkSize = 500
+
+var (
+	errBulkNotFound = errors.New("todo not found")
+	errBulkNotOwner = errors.New("only the todo's creator can do this")
+)
+
+type BulkRequest struct {
+	Action    string   `json:"action" binding:"required,oneof=complete uncomplete delete move tag set_priority" example:"complete"`
+	IDs       []uint   `json:"ids" example:"1,2,3"`
+	Query     string   `json:"query" example:"status:open tag:someday"`
+	ProjectID uint     `json:"project_id" example:"2"`
+	Tags      []string `json:"tags" example:"work,urgent"`
+	Priority  *int     `json:"priority" binding:"omitempty,min=0,max=3" example:"3"`
+	Cascade   string   `json:"cascade" binding:"omitempty,oneof=delete promote reparent" example:"delete"`
+	Force     bool     `json:"force" example:"false"`
+}
+
+// BulkItemResult is what happened to one todo of a bulk request
+type BulkItemResult struct {
+	ID    uint   `json:"id" example:"1"`
+	OK    bool   `json:"ok" example:"false"`
+	Error string `json:"error,omitempty" example:"blocked by open todos: #4 Order parts"`
+}
+
+// BulkResult reports the outcome of a bulk request, todo by todo
+type BulkResult struct {
+	Action    string           `json:"action" example:"complete"`
+	Succeeded int              `json:"succeeded" example:"2"`
+	Failed    int              `json:"failed" example:"1"`
+	Results   []BulkItemResult `json:"results"`
+}
+
+// @Summary Change many todos at once
+// @Description Complete, reopen, delete, move, tag or set the priority of the todos listed in ids or matched by a filter query, in one transaction. A todo that cannot be changed is reported in the results and left as it was, without undoing the others; the response is then 207 Multi-Status.
+// @Description move needs project_id, tag needs tags (created if missing) and set_priority needs priority. delete takes the same cascade as deleting one todo and complete the same force.
+// @Tags todos
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body BulkRequest true "Action and the todos to apply it to"
+// @Success 200 {object} BulkResult
+// @Success 207 {object} BulkResult
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/todos/bulk [post]
+func BulkUpdateTodos(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	db := database.GetDB()
+
+	var req BulkRequest
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	if err := validateBulkRequest(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	ids, err := bulkTargets(db, userID.(uint), &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	var project *models.Project
+	if req.Action == bulkMove {
+		if project, err = findActiveProject(db, userID.(uint), req.ProjectID); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+			return
+		}
+	}
+
+	result := BulkResult{Action: req.Action, Results: make([]BulkItemResult, 0, len(ids))}
+	err = db.Transaction(func(tx *gorm.DB) error {
+		var tags []models.Tag
+		if req.Action == bulkTag {
+			byName, err := findOrCreateTags(tx, userID.(uint), req.Tags)
+			if err != nil {
+				return err
+			}
+			tagIDs := make([]uint, 0, len(byName))
+			for _, id := range byName {
+				tagIDs = append(tagIDs, id)
+			}
+			if err := tx.Where("id IN ?", tagIDs).Find(&tags).Error; err != nil {
+				return err
+			}
+		}
+
+		// Subtasks trashed along with their parent count as deleted
+		trashed := make(map[uint]bool)
+		for _, id := range ids {
+			item := BulkItemResult{ID: id, OK: true}
+			if !trashed[id] {
+				// Each todo gets a savepoint, so that one failing leaves
+				// the others in place
+				err := tx.Transaction(func(tx *gorm.DB) error {
+					removed, err := applyBulkAction(tx, userID.(uint), id, &req, project, tags)
+					for _, removedID := range removed {
+						trashed[removedID] = true
+					}
+					return err
+				})
+				if err != nil {
+					item.OK = false
+					item.Error = err.Error()
+				}
+			}
+
+			if item.OK {
+				result.Succeeded++
+			} else {
+				result.Failed++
+			}
+			result.Results = append(result.Results, item)
+		}
+		return nil
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	status := http.StatusOK
+	if result.Failed > 0 {
+		status = http.StatusMultiStatus
+	}
+	c.JSON(status, result)
+}
+
+// validateBulkRequest checks that a bulk request names its todos one way
+// and carries what its action needs.
+func validateBulkRequest(req *BulkRequest) error {
+	if (len(req.IDs) > 0) == (req.Query != "") {
+		return errors.New("give either ids or query")
+	}
+	if len(req.IDs) > MaxBulkSize {
+		return fmt.Errorf("at most %d todos can be changed at once", MaxBulkSize)
+	}
+
+	switch req.Action {
+	case bulkMove:
+		if req.ProjectID == 0 {
+			return errors.New("project_id is required to move todos")
+		}
+	case bulkTag:
+		if len(req.Tags) == 0 {
+			return errors.New("tags is required to tag todos")
+		}
+	case bulkSetPriority:
+		if req.Priority == nil {
+			return errors.New("priority is required to set the priority of todos")
+		}
+	}
+	if req.Cascade == "" {
+		req.Cascade = "delete"
+	}
+	return nil
+}
+
+// bulkTargets lists the IDs a bulk request applies to, without duplicates.
+// A query is run against the todos the user can see.
+func bulkTargets(db *gorm.DB, userID uint, req *BulkRequest) ([]uint, error) {
+	if req.Query == "" {
+		seen := make(map[uint]bool, len(req.IDs))
+		ids := make([]uint, 0, len(req.IDs))
+		for _, id := range req.IDs {
+			if !seen[id] {
+				seen[id] = true
+				ids = append(ids, id)
+			}
+		}
+		return ids, nil
+	}
+
+	compiled, scope, err := parseTodoQuery(db, userID, req.Query)
+	if err != nil {
+		return nil, fmt.Errorf("invalid query: %w", err)
+	}
+	query := accessibleTodos(db, userID).Scopes(scope)
+	// As when listing, archived and snoozed todos are left out unless the
+	// query asks for them
+	if !compiled.archived {
+		query = query.Where("todos.archived = ?", false)
+	}
+	if !compiled.snoozed {
+		query = query.Where("(todos.hidden_until IS NULL OR todos.hidden_until <= ?)", time.Now().UTC())
+	}
+	var ids []uint
+	if err := query.Model(&models.Todo{}).Order("todos.id").Limit(MaxBulkSize+1).Pluck("todos.id", &ids).Error; err != nil {
+		return nil, err
+	}
+	if len(ids) > MaxBulkSize {
+		return nil, fmt.Errorf("query matches more than %d todos, which is more than can be changed at once", MaxBulkSize)
+	}
+	return ids, nil
+}
+
+// applyBulkAction applies a bulk request to one todo and returns the IDs of
+// the todos it trashed.
+func applyBulkAction(tx *gorm.DB, userID uint, id uint, req *BulkRequest, project *models.Project, tags []models.Tag) ([]uint, error) {
+	var todo models.Todo
+	if err := accessibleTodos(tx, userID).Where("todos.id = ?", id).First(&todo).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errBulkNotFound
+		}
+		return nil, err
+	}
+
+	// Like their single todo counterparts, these are for the creator only
+	switch req.Action {
+	case bulkDelete, bulkMove, bulkTag:
+		if todo.UserID != userID {
+			return nil, errBulkNotOwner
+		}
+	}
+
+	if req.Action == bulkDelete {
+		return trashTodo(tx, &todo, req.Cascade)
+	}
+
+	before := snapshotTodo(&todo)
+	switch req.Action {
+	case bulkComplete:
+		if !todo.Completed && !req.Force {
+			blockers, err := openBlockers(tx, todo.ID)
+			if err != nil {
+				return nil, err
+			}
+			if len(blockers) > 0 {
+				return nil, errors.New(blockedError(blockers))
+			}
+		}
+		if err := checkCompletion(tx, &todo, true); err != nil {
+			return nil, err
+		}
+		if err := setCompletion(tx, &todo, true); err != nil {
+			return nil, err
+		}
+	case bulkUncomplete:
+		if err := checkCompletion(tx, &todo, false); err != nil {
+			return nil, err
+		}
+		if err := setCompletion(tx, &todo, false); err != nil {
+			return nil, err
+		}
+	case bulkMove:
+		if err := moveTodo(tx, &todo, project.ID); err != nil {
+			return nil, err
+		}
+	case bulkTag:
+		if err := tx.Model(&todo).Association("Tags").Append(&tags); err != nil {
+			return nil, err
+		}
+	case bulkSetPriority:
+		if err := tx.Model(&todo).Update("priority", *req.Priority).Error; err != nil {
+			return nil, err
+		}
+	}
+	if err := recordRevision(tx, before, todo.ID, userID); err != nil {
+		return nil, err
+	}
+	return nil, runRules(tx, models.TriggerUpdated, &todo)
+}
@@ -0,0 +1,260 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"todo-api/internal/models"
+	"todo-api/internal/test"
+)
+
+func bulkRouter(userID uint) *gin.Engine {
+	router := gin.New()
+	router.POST("/todos/bulk", func(c *gin.Context) {
+		c.Set("userID", userID)
+		BulkUpdateTodos(c)
+	})
+	return router
+}
+
+func postBulk(router *gin.Engine, payload map[string]interface{}) (*httptest.ResponseRecorder, BulkResult) {
+	body, _ := json.Marshal(payload)
+	req, _ := http.NewRequest("POST", "/todos/bulk", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	var result BulkResult
+	json.Unmarshal(w.Body.Bytes(), &result)
+	return w, result
+}
+
+func TestBulkUpdateTodosValidation(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db, _ := test.SetupTestDB()
+	test.ClearTestData(db)
+	testUser, err := test.CreateTestUser(db)
+	assert.NoError(t, err)
+
+	defer func(max int) { MaxBulkSize = max }(MaxBulkSize)
+	MaxBulkSize = 2
+	for i := 0; i < 3; i++ {
+		db.Create(&models.Todo{Title: "Todo", UserID: testUser.ID})
+	}
+	archived := &models.Project{Name: "Old", UserID: testUser.ID, Archived: true}
+	db.Create(archived)
+
+	tests := []struct {
+		name    string
+		payload map[string]interface{}
+	}{
+		{
+			name:    "Unknown action",
+			payload: map[string]interface{}{"action": "explode", "ids": []uint{1}},
+		},
+		{
+			name:    "Neither ids nor query",
+			payload: map[string]interface{}{"action": "complete"},
+		},
+		{
+			name:    "Both ids and query",
+			payload: map[string]interface{}{"action": "complete", "ids": []uint{1}, "query": "status:open"},
+		},
+		{
+			name:    "Too many ids",
+			payload: map[string]interface{}{"action": "complete", "ids": []uint{1, 2, 3}},
+		},
+		{
+			name:    "Query matching too many todos",
+			payload: map[string]interface{}{"action": "complete", "query": "status:open"},
+		},
+		{
+			name:    "Invalid query",
+			payload: map[string]interface{}{"action": "complete", "query": "due<someday"},
+		},
+		{
+			name:    "Move without a project",
+			payload: map[string]interface{}{"action": "move", "ids": []uint{1}},
+		},
+		{
+			name:    "Move to an archived project",
+			payload: map[string]interface{}{"action": "move", "ids": []uint{1}, "project_id": archived.ID},
+		},
+		{
+			name:    "Tag without tags",
+			payload: map[string]interface{}{"action": "tag", "ids": []uint{1}},
+		},
+		{
+			name:    "Priority out of range",
+			payload: map[string]interface{}{"action": "set_priority", "ids": []uint{1}, "priority": 7},
+		},
+		{
+			name:    "Unknown cascade",
+			payload: map[string]interface{}{"action": "delete", "ids": []uint{1}, "cascade": "orphan"},
+		},
+	}
+
+	router := bulkRouter(testUser.ID)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w, _ := postBulk(router, tt.payload)
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+		})
+	}
+
+	var completed int64
+	db.Model(&models.Todo{}).Where("completed = ?", true).Count(&completed)
+	assert.Zero(t, completed)
+}
+
+func TestBulkCompleteTodos(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db, _ := test.SetupTestDB()
+	test.ClearTestData(db)
+	testUser, err := test.CreateTestUser(db)
+	assert.NoError(t, err)
+
+	first := &models.Todo{Title: "Write report", UserID: testUser.ID}
+	db.Create(first)
+	blocker := &models.Todo{Title: "Order parts", UserID: testUser.ID}
+	db.Create(blocker)
+	blocked := &models.Todo{Title: "Build shelf", UserID: testUser.ID}
+	db.Create(blocked)
+	db.Create(&models.TodoDependency{TodoID: blocked.ID, BlockedByID: blocker.ID})
+	other := &models.Todo{Title: "Not mine", UserID: testUser.ID + 1}
+	db.Create(other)
+
+	router := bulkRouter(testUser.ID)
+	w, result := postBulk(router, map[string]interface{}{
+		"action": "complete",
+		"ids":    []uint{first.ID, blocked.ID, other.ID, first.ID},
+	})
+
+	assert.Equal(t, http.StatusMultiStatus, w.Code)
+	assert.Equal(t, 1, result.Succeeded)
+	assert.Equal(t, 2, result.Failed)
+	if assert.Len(t, result.Results, 3) {
+		assert.Equal(t, BulkItemResult{ID: first.ID, OK: true}, result.Results[0])
+		assert.False(t, result.Results[1].OK)
+		assert.Contains(t, result.Results[1].Error, "Order parts")
+		assert.Equal(t, BulkItemResult{ID: other.ID, Error: "todo not found"}, result.Results[2])
+	}
+
+	var reloaded models.Todo
+	db.First(&reloaded, first.ID)
+	assert.True(t, reloaded.Completed)
+	assert.NotNil(t, reloaded.CompletedAt)
+	var stillBlocked models.Todo
+	db.First(&stillBlocked, blocked.ID)
+	assert.False(t, stillBlocked.Completed)
+
+	var revisions int64
+	db.Model(&models.TodoRevision{}).Where("todo_id = ?", first.ID).Count(&revisions)
+	assert.Equal(t, int64(1), revisions)
+
+	// Completing the blocker first lets the blocked todo through
+	w, result = postBulk(router, map[string]interface{}{
+		"action": "complete",
+		"ids":    []uint{blocker.ID, blocked.ID},
+	})
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, 2, result.Succeeded)
+
+	w, result = postBulk(router, map[string]interface{}{
+		"action": "uncomplete",
+		"query":  "status:done",
+	})
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, 3, result.Succeeded)
+	var completed int64
+	db.Model(&models.Todo{}).Where("user_id = ? AND completed = ?", testUser.ID, true).Count(&completed)
+	assert.Zero(t, completed)
+}
+
+func TestBulkDeleteTodos(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db, _ := test.SetupTestDB()
+	test.ClearTestData(db)
+	testUser, err := test.CreateTestUser(db)
+	assert.NoError(t, err)
+
+	parent := &models.Todo{Title: "Move house", UserID: testUser.ID}
+	db.Create(parent)
+	child := &models.Todo{Title: "Pack books", UserID: testUser.ID, ParentID: &parent.ID}
+	db.Create(child)
+	kept := &models.Todo{Title: "Water plants", UserID: testUser.ID}
+	db.Create(kept)
+
+	w, result := postBulk(bulkRouter(testUser.ID), map[string]interface{}{
+		"action": "delete",
+		"ids":    []uint{parent.ID, child.ID},
+	})
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, 2, result.Succeeded)
+	assert.Zero(t, result.Failed)
+
+	var remaining []models.Todo
+	db.Find(&remaining)
+	if assert.Len(t, remaining, 1) {
+		assert.Equal(t, kept.ID, remaining[0].ID)
+	}
+	var trashed int64
+	db.Unscoped().Model(&models.Todo{}).Where("deleted_at IS NOT NULL").Count(&trashed)
+	assert.Equal(t, int64(2), trashed)
+}
+
+func TestBulkUpdateTodosByQuery(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db, _ := test.SetupTestDB()
+	test.ClearTestData(db)
+	testUser, err := test.CreateTestUser(db)
+	assert.NoError(t, err)
+
+	someday := &models.Tag{Name: "someday", UserID: testUser.ID}
+	db.Create(someday)
+	project := &models.Project{Name: "Later", UserID: testUser.ID}
+	db.Create(project)
+	tagged := &models.Todo{Title: "Learn Welsh", UserID: testUser.ID, Tags: []models.Tag{*someday}}
+	db.Create(tagged)
+	untagged := &models.Todo{Title: "Pay rent", UserID: testUser.ID}
+	db.Create(untagged)
+
+	router := bulkRouter(testUser.ID)
+
+	w, result := postBulk(router, map[string]interface{}{"action": "set_priority", "query": "tag:someday", "priority": models.PriorityLow})
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, 1, result.Succeeded)
+
+	w, _ = postBulk(router, map[string]interface{}{"action": "move", "query": "tag:someday", "project_id": project.ID})
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w, _ = postBulk(router, map[string]interface{}{"action": "tag", "ids": []uint{tagged.ID, untagged.ID}, "tags": []string{"Review", "someday"}})
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var reloaded models.Todo
+	db.Preload("Tags").First(&reloaded, tagged.ID)
+	assert.Equal(t, models.PriorityLow, reloaded.Priority)
+	if assert.NotNil(t, reloaded.ProjectID) {
+		assert.Equal(t, project.ID, *reloaded.ProjectID)
+	}
+	assert.Len(t, reloaded.Tags, 2)
+
+	var other models.Todo
+	db.Preload("Tags").First(&other, untagged.ID)
+	assert.Equal(t, models.PriorityNone, other.Priority)
+	assert.Len(t, other.Tags, 2)
+
+	var tags int64
+	db.Model(&models.Tag{}).Where("user_id = ?", testUser.ID).Count(&tags)
+	assert.Equal(t, int64(2), tags)
+}
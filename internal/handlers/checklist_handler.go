@@ -0,0 +1,153 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"todo-api/internal/database"
+	"todo-api/internal/markdown"
+	"todo-api/internal/models"
+)
+
+var errChecklistItem = errors.New("checklist item not found")
+
+type ChecklistItemRequest struct {
+	Checked *bool `json:"checked" example:"true"`
+}
+
+// @Summary Tick or clear a checklist item
+// @Description Set one "- [ ]" checkbox in a todo's Markdown description without sending the whole description. Items are numbered from 0 in the order they appear. Without a body the checkbox is toggled.
+// @Tags todos
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "Todo ID"
+// @Param index path int true "Checklist item, counting from 0"
+// @Param render query string false "Also return the description rendered as sanitized HTML" Enums(html)
+// @Param request body ChecklistItemRequest false "Whether the item is checked"
+// @Success 200 {object} models.Todo
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/todos/{id}/checklist/{index} [post]
+func SetChecklistItem(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	db := database.GetDB()
+
+	render, err := renderOption(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	var todo models.Todo
+	if err := accessibleTodos(db, userID.(uint)).Where("todos.id = ?", c.Param("id")).First(&todo).Error; err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Todo not found"})
+		return
+	}
+
+	index, err := strconv.Atoi(c.Param("index"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Checklist item must be a number"})
+		return
+	}
+
+	var req ChecklistItemRequest
+	if err := bindOptionalJSON(c, &req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	// The description is read again under a row lock, so concurrent
+	// changes to other items are not overwritten
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&todo, todo.ID).Error; err != nil {
+			return err
+		}
+		tasks := markdown.Tasks(todo.Description)
+		if index < 0 || index >= len(tasks) {
+			return errChecklistItem
+		}
+		checked := !tasks[index].Checked
+		if req.Checked != nil {
+			checked = *req.Checked
+		}
+
+		description, err := markdown.SetTask(todo.Description, index, checked)
+		if err != nil || description == todo.Description {
+			return err
+		}
+		before := snapshotTodo(&todo)
+		if err := tx.Model(&todo).Update("description", description).Error; err != nil {
+			return err
+		}
+		if err := recordRevision(tx, before, todo.ID, userID.(uint)); err != nil {
+			return err
+		}
+		return runRules(tx, models.TriggerUpdated, &todo)
+	})
+	if errors.Is(err, errChecklistItem) {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Checklist item not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	db.Preload("User").Preload("Assignees").Preload("Tags").Preload("CustomFields").First(&todo, todo.ID)
+	describeTodo(&todo, render)
+
+	c.JSON(http.StatusOK, todo)
+}
+
+// bindOptionalJSON binds a request body that may be left out. An empty body
+// leaves req as it is, whether or not the client sent a Content-Length.
+func bindOptionalJSON(c *gin.Context, req interface{}) error {
+	if c.Request.Body == nil {
+		return nil
+	}
+	if err := c.ShouldBindJSON(req); err != nil && !errors.Is(err, io.EOF) {
+		return err
+	}
+	return nil
+}
+
+// renderOption reads the render query parameter, which asks for
+// descriptions rendered to HTML.
+func renderOption(c *gin.Context) (bool, error) {
+	switch c.Query("render") {
+	case "":
+		return false, nil
+	case "html":
+		return true, nil
+	default:
+		return false, fmt.Errorf("render must be 'html'")
+	}
+}
+
+// describeTodo fills in the checklist progress of a todo's description and,
+// when asked, the description rendered to sanitized HTML.
+func describeTodo(todo *models.Todo, render bool) {
+	todo.Checklist = nil
+	if tasks := markdown.Tasks(todo.Description); len(tasks) > 0 {
+		todo.Checklist = &models.ChecklistProgress{Total: len(tasks)}
+		for _, task := range tasks {
+			if task.Checked {
+				todo.Checklist.Checked++
+			}
+		}
+	}
+
+	todo.DescriptionHTML = ""
+	if render {
+		todo.DescriptionHTML = markdown.Render(todo.Description)
+	}
+}
@@ -0,0 +1,195 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"todo-api/internal/models"
+	"todo-api/internal/test"
+)
+
+func TestGetTodoRendersDescription(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db, _ := test.SetupTestDB()
+	test.ClearTestData(db)
+	testUser, err := test.CreateTestUser(db)
+	assert.NoError(t, err)
+
+	todo := &models.Todo{
+		Title:       "Trip",
+		Description: "Read [the guide](https://example.com) <script>x</script>\n\n- [x] Book flights\n- [ ] Pack",
+		UserID:      testUser.ID,
+	}
+	db.Create(todo)
+
+	router := gin.New()
+	router.GET("/todos/:id", func(c *gin.Context) {
+		c.Set("userID", testUser.ID)
+		GetTodo(c)
+	})
+
+	tests := []struct {
+		name         string
+		query        string
+		expectedCode int
+		expectedHTML string
+	}{
+		{
+			name:         "Plain",
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "Rendered",
+			query:        "?render=html",
+			expectedCode: http.StatusOK,
+			expectedHTML: `<p>Read <a href="https://example.com" rel="nofollow noopener noreferrer">the guide</a> &lt;script&gt;x&lt;/script&gt;</p>` + "\n" +
+				"<ul>\n" +
+				`<li class="task-list-item"><input type="checkbox" disabled data-task="0" checked> Book flights` + "\n</li>\n" +
+				`<li class="task-list-item"><input type="checkbox" disabled data-task="1"> Pack` + "\n</li>\n" +
+				"</ul>\n",
+		},
+		{
+			name:         "Unknown format",
+			query:        "?render=pdf",
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", fmt.Sprintf("/todos/%d%s", todo.ID, tt.query), nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+			if w.Code == http.StatusOK {
+				var response models.Todo
+				assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+				assert.Equal(t, tt.expectedHTML, response.DescriptionHTML)
+				assert.Equal(t, &models.ChecklistProgress{Checked: 1, Total: 2}, response.Checklist)
+			}
+		})
+	}
+}
+
+func TestSetChecklistItem(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db, _ := test.SetupTestDB()
+	test.ClearTestData(db)
+	testUser, err := test.CreateTestUser(db)
+	assert.NoError(t, err)
+
+	description := "Packing list:\n- [ ] Passport\n- [x] Charger\n  - [ ] Adapter\n\n```\n- [ ] not a task\n```"
+	todo := &models.Todo{Title: "Trip", Description: description, UserID: testUser.ID}
+	db.Create(todo)
+	other := &models.Todo{Title: "Not mine", Description: "- [ ] Secret", UserID: testUser.ID + 1}
+	db.Create(other)
+
+	router := gin.New()
+	router.POST("/todos/:id/checklist/:index", func(c *gin.Context) {
+		c.Set("userID", testUser.ID)
+		SetChecklistItem(c)
+	})
+
+	tests := []struct {
+		name                string
+		todoID              uint
+		index               string
+		payload             interface{}
+		chunked             bool
+		expectedCode        int
+		expectedDescription string
+		expectedChecked     int
+	}{
+		{
+			name:                "Toggle without a body",
+			todoID:              todo.ID,
+			index:               "0",
+			expectedCode:        http.StatusOK,
+			expectedDescription: "Packing list:\n- [x] Passport\n- [x] Charger\n  - [ ] Adapter\n\n```\n- [ ] not a task\n```",
+			expectedChecked:     2,
+		},
+		{
+			name:                "Clear a checked item",
+			todoID:              todo.ID,
+			index:               "1",
+			payload:             map[string]bool{"checked": false},
+			expectedCode:        http.StatusOK,
+			expectedDescription: "Packing list:\n- [x] Passport\n- [ ] Charger\n  - [ ] Adapter\n\n```\n- [ ] not a task\n```",
+			expectedChecked:     1,
+		},
+		{
+			name:                "Setting the current state changes nothing",
+			todoID:              todo.ID,
+			index:               "2",
+			payload:             map[string]bool{"checked": false},
+			expectedCode:        http.StatusOK,
+			expectedDescription: "Packing list:\n- [x] Passport\n- [ ] Charger\n  - [ ] Adapter\n\n```\n- [ ] not a task\n```",
+			expectedChecked:     1,
+		},
+		{
+			name:                "Toggle with an empty chunked body",
+			todoID:              todo.ID,
+			index:               "2",
+			chunked:             true,
+			expectedCode:        http.StatusOK,
+			expectedDescription: "Packing list:\n- [x] Passport\n- [ ] Charger\n  - [x] Adapter\n\n```\n- [ ] not a task\n```",
+			expectedChecked:     2,
+		},
+		{
+			name:         "Checkbox inside a code block",
+			todoID:       todo.ID,
+			index:        "3",
+			expectedCode: http.StatusNotFound,
+		},
+		{
+			name:         "Invalid index",
+			todoID:       todo.ID,
+			index:        "first",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "Another user's todo",
+			todoID:       other.ID,
+			index:        "0",
+			expectedCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var body []byte
+			if tt.payload != nil {
+				body, _ = json.Marshal(tt.payload)
+			}
+			req, _ := http.NewRequest("POST", fmt.Sprintf("/todos/%d/checklist/%s", tt.todoID, tt.index), bytes.NewBuffer(body))
+			req.Header.Set("Content-Type", "application/json")
+			if tt.chunked {
+				req.ContentLength = -1
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+			if w.Code == http.StatusOK {
+				var response models.Todo
+				assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+				assert.Equal(t, tt.expectedDescription, response.Description)
+				assert.Equal(t, &models.ChecklistProgress{Checked: tt.expectedChecked, Total: 3}, response.Checklist)
+			}
+		})
+	}
+
+	// Only the three real changes are kept in the history
+	var revisions int64
+	db.Model(&models.TodoRevision{}).Where("todo_id = ?", todo.ID).Count(&revisions)
+	assert.Equal(t, int64(3), revisions)
+}
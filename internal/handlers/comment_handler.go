@@ -0,0 +1,261 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"todo-api/internal/database"
+	"todo-api/internal/models"
+)
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+type CommentRequest struct {
+	Body string `json:"body" binding:"required" example:"Waiting on the designs before starting"`
+}
+
+// CommentPage is one page of a todo's comment thread
+type CommentPage struct {
+	Comments []models.Comment `json:"comments"`
+	Page     int              `json:"page" example:"1"`
+	PageSize int              `json:"page_size" example:"20"`
+	Total    int64            `json:"total" example:"42"`
+}
+
+// @Summary Get a todo's comments
+// @Description Get a page of the comments on a todo, oldest first
+// @Tags comments
+// @Produce json
+// @Security Bearer
+// @Param id path int true "Todo ID"
+// @Param page query int false "Page number, starting at 1"
+// @Param page_size query int false "Comments per page (at most 100)"
+// @Success 200 {object} CommentPage
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/todos/{id}/comments [get]
+func GetComments(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	db := database.GetDB()
+
+	todo, err := findAccessibleTodo(db, userID.(uint), c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Todo not found"})
+		return
+	}
+
+	page, pageSize, err := pageParams(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	result := CommentPage{Page: page, PageSize: pageSize}
+	thread := db.Model(&models.Comment{}).Where("todo_id = ?", todo.ID)
+	if err := thread.Session(&gorm.Session{}).Count(&result.Total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+	err = thread.Preload("Author").
+		Order("created_at, id").
+		Offset((page - 1) * pageSize).
+		Limit(pageSize).
+		Find(&result.Comments).Error
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// @Summary Comment on a todo
+// @Description Add a comment to a todo's thread
+// @Tags comments
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "Todo ID"
+// @Param request body CommentRequest true "Comment"
+// @Success 201 {object} models.Comment
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/todos/{id}/comments [post]
+func CreateComment(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	db := database.GetDB()
+
+	todo, err := findAccessibleTodo(db, userID.(uint), c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Todo not found"})
+		return
+	}
+
+	var req CommentRequest
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	comment := models.Comment{TodoID: todo.ID, UserID: userID.(uint), Body: req.Body}
+	if err := db.Omit("Author").Create(&comment).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	db.Preload("Author").First(&comment, comment.ID)
+	c.JSON(http.StatusCreated, comment)
+}
+
+// @Summary Edit a comment
+// @Description Change the text of one of the user's comments. The previous text is kept in the comment's history.
+// @Tags comments
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "Comment ID"
+// @Param request body CommentRequest true "Comment"
+// @Success 200 {object} models.Comment
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/comments/{id} [put]
+func UpdateComment(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	id := c.Param("id")
+	var comment models.Comment
+
+	if err := database.GetDB().Where("id = ? AND user_id = ?", id, userID).First(&comment).Error; err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Comment not found"})
+		return
+	}
+
+	var req CommentRequest
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	if req.Body != comment.Body {
+		err := database.GetDB().Transaction(func(tx *gorm.DB) error {
+			revision := models.CommentRevision{CommentID: comment.ID, Body: comment.Body}
+			if err := tx.Create(&revision).Error; err != nil {
+				return err
+			}
+
+			now := time.Now()
+			if err := tx.Model(&comment).Updates(map[string]interface{}{"body": req.Body, "edited_at": now}).Error; err != nil {
+				return err
+			}
+			comment.Body = req.Body
+			comment.EditedAt = &now
+			return nil
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+			return
+		}
+	}
+
+	database.GetDB().Preload("Author").First(&comment, comment.ID)
+	c.JSON(http.StatusOK, comment)
+}
+
+// @Summary Delete a comment
+// @Description Delete one of the user's comments
+// @Tags comments
+// @Security Bearer
+// @Param id path int true "Comment ID"
+// @Success 204 "No Content"
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/comments/{id} [delete]
+func DeleteComment(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	id := c.Param("id")
+	var comment models.Comment
+
+	if err := database.GetDB().Where("id = ? AND user_id = ?", id, userID).First(&comment).Error; err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Comment not found"})
+		return
+	}
+
+	result := database.GetDB().Delete(&comment)
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: result.Error.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// @Summary Get a comment's edit history
+// @Description Get the earlier versions of a comment, oldest first
+// @Tags comments
+// @Produce json
+// @Security Bearer
+// @Param id path int true "Comment ID"
+// @Success 200 {array} models.CommentRevision
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/comments/{id}/history [get]
+func GetCommentHistory(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	db := database.GetDB()
+	var comment models.Comment
+
+	if err := db.First(&comment, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Comment not found"})
+		return
+	}
+	if _, err := findAccessibleTodo(db, userID.(uint), comment.TodoID); err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Comment not found"})
+		return
+	}
+
+	var revisions []models.CommentRevision
+	result := db.Where("comment_id = ?", comment.ID).Order("created_at, id").Find(&revisions)
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: result.Error.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, revisions)
+}
+
+// pageParams reads the page and page_size query parameters.
+func pageParams(c *gin.Context) (int, int, error) {
+	page := 1
+	if raw := c.Query("page"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 {
+			return 0, 0, errors.New("page must be a positive number")
+		}
+		page = n
+	}
+
+	pageSize := defaultPageSize
+	if raw := c.Query("page_size"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 || n > maxPageSize {
+			return 0, 0, errors.New("page_size must be between 1 and 100")
+		}
+		pageSize = n
+	}
+
+	return page, pageSize, nil
+}
@@ -0,0 +1,161 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"todo-api/internal/models"
+	"todo-api/internal/test"
+)
+
+func TestGetComments(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db, _ := test.SetupTestDB()
+	test.ClearTestData(db)
+	testUser, err := test.CreateTestUser(db)
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name         string
+		query        string
+		expectedCode int
+		wantBodies   []string
+	}{
+		{
+			name:         "First page by default",
+			expectedCode: http.StatusOK,
+			wantBodies:   []string{"comment 1", "comment 2", "comment 3", "comment 4", "comment 5"},
+		},
+		{
+			name:         "Second page",
+			query:        "?page=2&page_size=2",
+			expectedCode: http.StatusOK,
+			wantBodies:   []string{"comment 3", "comment 4"},
+		},
+		{
+			name:         "Page past the end",
+			query:        "?page=4&page_size=2",
+			expectedCode: http.StatusOK,
+			wantBodies:   []string{},
+		},
+		{
+			name:         "Page size too large",
+			query:        "?page_size=500",
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+
+			db.Exec("DELETE FROM comments")
+			todo := &models.Todo{Title: "Plan offsite", UserID: testUser.ID}
+			db.Create(todo)
+			for i := 1; i <= 5; i++ {
+				db.Create(&models.Comment{TodoID: todo.ID, UserID: testUser.ID, Body: fmt.Sprintf("comment %d", i)})
+			}
+
+			router.GET("/todos/:id/comments", func(c *gin.Context) {
+				c.Set("userID", testUser.ID)
+				GetComments(c)
+			})
+
+			req, _ := http.NewRequest("GET", fmt.Sprintf("/todos/%d/comments%s", todo.ID, tt.query), nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+			if tt.expectedCode == http.StatusOK {
+				var response CommentPage
+				err := json.Unmarshal(w.Body.Bytes(), &response)
+				assert.NoError(t, err)
+				assert.Equal(t, int64(5), response.Total)
+				bodies := make([]string, len(response.Comments))
+				for i, comment := range response.Comments {
+					bodies[i] = comment.Body
+					assert.Equal(t, testUser.Email, comment.Author.Email)
+				}
+				assert.Equal(t, tt.wantBodies, bodies)
+			}
+		})
+	}
+}
+
+func TestEditComment(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db, _ := test.SetupTestDB()
+	test.ClearTestData(db)
+	testUser, err := test.CreateTestUser(db)
+	assert.NoError(t, err)
+
+	other := &models.User{Email: "other@example.com"}
+	db.Create(other)
+	todo := &models.Todo{Title: "Plan offsite", UserID: testUser.ID}
+	db.Create(todo)
+	foreign := &models.Comment{TodoID: todo.ID, UserID: other.ID, Body: "Not yours"}
+	db.Create(foreign)
+
+	router := gin.New()
+	router.POST("/todos/:id/comments", func(c *gin.Context) {
+		c.Set("userID", testUser.ID)
+		CreateComment(c)
+	})
+	router.PUT("/comments/:id", func(c *gin.Context) {
+		c.Set("userID", testUser.ID)
+		UpdateComment(c)
+	})
+	router.GET("/comments/:id/history", func(c *gin.Context) {
+		c.Set("userID", testUser.ID)
+		GetCommentHistory(c)
+	})
+	router.DELETE("/comments/:id", func(c *gin.Context) {
+		c.Set("userID", testUser.ID)
+		DeleteComment(c)
+	})
+
+	send := func(method string, url string, payload interface{}) (int, []byte) {
+		jsonBody, _ := json.Marshal(payload)
+		req, _ := http.NewRequest(method, url, bytes.NewBuffer(jsonBody))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code, w.Body.Bytes()
+	}
+
+	code, body := send("POST", fmt.Sprintf("/todos/%d/comments", todo.ID), map[string]interface{}{"body": "Venue booked"})
+	assert.Equal(t, http.StatusCreated, code)
+	var comment models.Comment
+	assert.NoError(t, json.Unmarshal(body, &comment))
+	assert.Nil(t, comment.EditedAt)
+
+	code, body = send("PUT", fmt.Sprintf("/comments/%d", comment.ID), map[string]interface{}{"body": "Venue booked for Friday"})
+	assert.Equal(t, http.StatusOK, code)
+	assert.NoError(t, json.Unmarshal(body, &comment))
+	assert.Equal(t, "Venue booked for Friday", comment.Body)
+	assert.NotNil(t, comment.EditedAt)
+
+	code, body = send("GET", fmt.Sprintf("/comments/%d/history", comment.ID), nil)
+	assert.Equal(t, http.StatusOK, code)
+	var history []models.CommentRevision
+	assert.NoError(t, json.Unmarshal(body, &history))
+	assert.Len(t, history, 1)
+	assert.Equal(t, "Venue booked", history[0].Body)
+
+	// Only the author can change a comment
+	code, _ = send("PUT", fmt.Sprintf("/comments/%d", foreign.ID), map[string]interface{}{"body": "Changed"})
+	assert.Equal(t, http.StatusNotFound, code)
+	code, _ = send("DELETE", fmt.Sprintf("/comments/%d", foreign.ID), nil)
+	assert.Equal(t, http.StatusNotFound, code)
+
+	code, _ = send("DELETE", fmt.Sprintf("/comments/%d", comment.ID), nil)
+	assert.Equal(t, http.StatusNoContent, code)
+}
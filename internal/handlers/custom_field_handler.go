@@ -0,0 +1,309 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"todo-api/internal/database"
+	"todo-api/internal/models"
+)
+
+// @Summary Create a custom field
+// @Description Define a typed field the authenticated user can set on their todos. Select fields need at least one option.
+// @Tags custom-fields
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param field body models.CustomField true "Custom field"
+// @Success 201 {object} models.CustomField
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/custom-fields [post]
+func CreateCustomField(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	var field models.CustomField
+	if err := c.BindJSON(&field); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	field.Name = strings.TrimSpace(field.Name)
+	field.UserID = userID.(uint)
+	if err := validateFieldOptions(&field); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	if fieldNameTaken(field.UserID, field.Name, 0) {
+		c.JSON(http.StatusConflict, ErrorResponse{Error: "Custom field already exists"})
+		return
+	}
+
+	if err := database.GetDB().Create(&field).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, field)
+}
+
+// @Summary Get all custom fields
+// @Description Get the custom fields the authenticated user has defined
+// @Tags custom-fields
+// @Produce json
+// @Security Bearer
+// @Success 200 {array} models.CustomField
+// @Failure 401 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/custom-fields [get]
+func GetCustomFields(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	var fields []models.CustomField
+	result := database.GetDB().Where("user_id = ?", userID).Order("name").Find(&fields)
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: result.Error.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, fields)
+}
+
+// @Summary Get a custom field
+// @Description Get a specific custom field by ID
+// @Tags custom-fields
+// @Produce json
+// @Security Bearer
+// @Param id path int true "Custom field ID"
+// @Success 200 {object} models.CustomField
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/custom-fields/{id} [get]
+func GetCustomField(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	var field models.CustomField
+
+	if err := database.GetDB().Where("id = ? AND user_id = ?", c.Param("id"), userID).First(&field).Error; err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Custom field not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, field)
+}
+
+// @Summary Update a custom field
+// @Description Rename a custom field or change the options of a select field. The type of a field cannot change, and options still in use cannot be removed.
+// @Tags custom-fields
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "Custom field ID"
+// @Param field body models.CustomField true "Custom field"
+// @Success 200 {object} models.CustomField
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/custom-fields/{id} [put]
+func UpdateCustomField(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	db := database.GetDB()
+	var field models.CustomField
+
+	if err := db.Where("id = ? AND user_id = ?", c.Param("id"), userID).First(&field).Error; err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Custom field not found"})
+		return
+	}
+
+	var updateData models.CustomField
+	if err := c.BindJSON(&updateData); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+	if updateData.Type != field.Type {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "The type of a custom field cannot change"})
+		return
+	}
+
+	updateData.Name = strings.TrimSpace(updateData.Name)
+	if err := validateFieldOptions(&updateData); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+	if fieldNameTaken(field.UserID, updateData.Name, field.ID) {
+		c.JSON(http.StatusConflict, ErrorResponse{Error: "Another custom field already has this name"})
+		return
+	}
+
+	if field.Type == models.FieldSelect {
+		var used []string
+		err := db.Model(&models.CustomFieldValue{}).
+			Distinct().
+			Where("field_id = ? AND value NOT IN ?", field.ID, []string(updateData.Options)).
+			Pluck("value", &used).Error
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+			return
+		}
+		if len(used) > 0 {
+			c.JSON(http.StatusConflict, ErrorResponse{Error: fmt.Sprintf("Options still in use: %s", strings.Join(used, ", "))})
+			return
+		}
+	}
+
+	result := db.Model(&field).Updates(map[string]interface{}{
+		"name":    updateData.Name,
+		"options": updateData.Options,
+	})
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: result.Error.Error()})
+		return
+	}
+
+	db.First(&field, field.ID)
+	c.JSON(http.StatusOK, field)
+}
+
+// @Summary Delete a custom field
+// @Description Delete a custom field and clear it from every todo
+// @Tags custom-fields
+// @Security Bearer
+// @Param id path int true "Custom field ID"
+// @Success 204 "No Content"
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/custom-fields/{id} [delete]
+func DeleteCustomField(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	var field models.CustomField
+
+	if err := database.GetDB().Where("id = ? AND user_id = ?", c.Param("id"), userID).First(&field).Error; err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Custom field not found"})
+		return
+	}
+
+	err := database.GetDB().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("field_id = ?", field.ID).Delete(&models.CustomFieldValue{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&field).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// validateFieldOptions checks the options of a select field and drops the
+// options of other fields, which have no use for them.
+func validateFieldOptions(field *models.CustomField) error {
+	if field.Type != models.FieldSelect {
+		field.Options = models.StringList{}
+		return nil
+	}
+	if len(field.Options) == 0 {
+		return errors.New("select fields need at least one option")
+	}
+
+	seen := make(map[string]bool)
+	for i, option := range field.Options {
+		option = strings.TrimSpace(option)
+		if option == "" {
+			return errors.New("options cannot be empty")
+		}
+		if seen[option] {
+			return fmt.Errorf("option %q is listed twice", option)
+		}
+		seen[option] = true
+		field.Options[i] = option
+	}
+	return nil
+}
+
+// fieldNameTaken reports whether the user already has a custom field with
+// this name, ignoring case and the field with exceptID.
+func fieldNameTaken(userID uint, name string, exceptID uint) bool {
+	var count int64
+	database.GetDB().Model(&models.CustomField{}).
+		Where("user_id = ? AND LOWER(name) = LOWER(?) AND id <> ?", userID, name, exceptID).
+		Count(&count)
+	return count > 0
+}
+
+// normalizeCustomFields validates custom field values sent for a todo and
+// returns them in canonical form. An empty value clears the field.
+func normalizeCustomFields(db *gorm.DB, userID uint, values []models.CustomFieldValue) ([]models.CustomFieldValue, error) {
+	if len(values) == 0 {
+		return values, nil
+	}
+
+	ids := make([]uint, len(values))
+	for i, value := range values {
+		ids[i] = value.FieldID
+	}
+	var fields []models.CustomField
+	if err := db.Where("id IN ? AND user_id = ?", ids, userID).Find(&fields).Error; err != nil {
+		return nil, err
+	}
+	byID := make(map[uint]*models.CustomField, len(fields))
+	for i := range fields {
+		byID[fields[i].ID] = &fields[i]
+	}
+
+	seen := make(map[uint]bool)
+	normalized := make([]models.CustomFieldValue, 0, len(values))
+	for _, value := range values {
+		field := byID[value.FieldID]
+		if field == nil {
+			return nil, fmt.Errorf("custom field %d not found", value.FieldID)
+		}
+		if seen[field.ID] {
+			return nil, fmt.Errorf("custom field %s is set twice", field.Name)
+		}
+		seen[field.ID] = true
+
+		if value.Value == "" {
+			continue
+		}
+		text, number, err := field.Normalize(value.Value)
+		if err != nil {
+			return nil, err
+		}
+		normalized = append(normalized, models.CustomFieldValue{FieldID: field.ID, Value: text, Number: number})
+	}
+	return normalized, nil
+}
+
+// replaceCustomFields sets a todo's custom field values, clearing the ones
+// left out.
+func replaceCustomFields(tx *gorm.DB, todoID uint, values []models.CustomFieldValue) error {
+	if err := tx.Where("todo_id = ?", todoID).Delete(&models.CustomFieldValue{}).Error; err != nil {
+		return err
+	}
+	if len(values) == 0 {
+		return nil
+	}
+
+	rows := make([]models.CustomFieldValue, len(values))
+	for i, value := range values {
+		value.TodoID = todoID
+		rows[i] = value
+	}
+	return tx.Create(&rows).Error
+}
+
+// copyCustomFields gives a todo the custom field values of another one.
+func copyCustomFields(tx *gorm.DB, from, to uint) error {
+	return tx.Exec("INSERT INTO custom_field_values (todo_id, field_id, value, number) SELECT ?, field_id, value, number FROM custom_field_values WHERE todo_id = ?", to, from).Error
+}
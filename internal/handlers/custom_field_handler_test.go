@@ -0,0 +1,307 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"todo-api/internal/models"
+	"todo-api/internal/test"
+)
+
+func TestCreateCustomField(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db, _ := test.SetupTestDB()
+	test.ClearTestData(db)
+	testUser, err := test.CreateTestUser(db)
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name         string
+		payload      map[string]interface{}
+		expectedCode int
+		wantOptions  []string
+	}{
+		{
+			name:         "Number field",
+			payload:      map[string]interface{}{"name": "Story points", "type": "number", "options": []string{"ignored"}},
+			expectedCode: http.StatusCreated,
+			wantOptions:  []string{},
+		},
+		{
+			name:         "Select field",
+			payload:      map[string]interface{}{"name": "Size", "type": "select", "options": []string{" small", "large "}},
+			expectedCode: http.StatusCreated,
+			wantOptions:  []string{"small", "large"},
+		},
+		{
+			name:         "Duplicate name",
+			payload:      map[string]interface{}{"name": "story POINTS", "type": "text"},
+			expectedCode: http.StatusConflict,
+		},
+		{
+			name:         "Select without options",
+			payload:      map[string]interface{}{"name": "Team", "type": "select"},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "Repeated option",
+			payload:      map[string]interface{}{"name": "Team", "type": "select", "options": []string{"a", "a"}},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "Unknown type",
+			payload:      map[string]interface{}{"name": "Color", "type": "color"},
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+
+	router := gin.New()
+	router.POST("/custom-fields", func(c *gin.Context) {
+		c.Set("userID", testUser.ID)
+		CreateCustomField(c)
+	})
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			jsonBody, _ := json.Marshal(tt.payload)
+			req, _ := http.NewRequest("POST", "/custom-fields", bytes.NewBuffer(jsonBody))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+			if tt.expectedCode == http.StatusCreated {
+				var response models.CustomField
+				assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+				assert.Equal(t, testUser.ID, response.UserID)
+				assert.Equal(t, tt.wantOptions, []string(response.Options))
+			}
+		})
+	}
+}
+
+func TestUpdateCustomField(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db, _ := test.SetupTestDB()
+	test.ClearTestData(db)
+	testUser, err := test.CreateTestUser(db)
+	assert.NoError(t, err)
+
+	size := &models.CustomField{Name: "Size", Type: models.FieldSelect, Options: models.StringList{"small", "large"}, UserID: testUser.ID}
+	db.Create(size)
+	todo := &models.Todo{Title: "Ship it", UserID: testUser.ID}
+	db.Create(todo)
+	db.Create(&models.CustomFieldValue{TodoID: todo.ID, FieldID: size.ID, Value: "large"})
+
+	router := gin.New()
+	router.PUT("/custom-fields/:id", func(c *gin.Context) {
+		c.Set("userID", testUser.ID)
+		UpdateCustomField(c)
+	})
+
+	tests := []struct {
+		name         string
+		payload      map[string]interface{}
+		expectedCode int
+	}{
+		{
+			name:         "Option in use cannot be removed",
+			payload:      map[string]interface{}{"name": "Size", "type": "select", "options": []string{"small", "medium"}},
+			expectedCode: http.StatusConflict,
+		},
+		{
+			name:         "Type cannot change",
+			payload:      map[string]interface{}{"name": "Size", "type": "text"},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "Rename and add an option",
+			payload:      map[string]interface{}{"name": "T-shirt size", "type": "select", "options": []string{"small", "medium", "large"}},
+			expectedCode: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			jsonBody, _ := json.Marshal(tt.payload)
+			req, _ := http.NewRequest("PUT", fmt.Sprintf("/custom-fields/%d", size.ID), bytes.NewBuffer(jsonBody))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+			if tt.expectedCode == http.StatusOK {
+				var response models.CustomField
+				assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+				assert.Equal(t, "T-shirt size", response.Name)
+				assert.Equal(t, []string{"small", "medium", "large"}, []string(response.Options))
+			}
+		})
+	}
+}
+
+func TestTodoCustomFields(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db, _ := test.SetupTestDB()
+	test.ClearTestData(db)
+	testUser, err := test.CreateTestUser(db)
+	assert.NoError(t, err)
+
+	points := &models.CustomField{Name: "Points", Type: models.FieldNumber, UserID: testUser.ID}
+	db.Create(points)
+	customer := &models.CustomField{Name: "Customer", Type: models.FieldText, UserID: testUser.ID}
+	db.Create(customer)
+	billable := &models.CustomField{Name: "Billable", Type: models.FieldCheckbox, UserID: testUser.ID}
+	db.Create(billable)
+	foreign := &models.CustomField{Name: "Points", Type: models.FieldNumber, UserID: testUser.ID + 1}
+	db.Create(foreign)
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set("userID", testUser.ID)
+	})
+	router.POST("/todos", CreateTodo)
+	router.PUT("/todos/:id", UpdateTodo)
+	router.GET("/todos", GetTodos)
+	router.GET("/todos/export", ExportTodos)
+
+	send := func(method, path string, body interface{}) *httptest.ResponseRecorder {
+		data, _ := json.Marshal(body)
+		req, _ := http.NewRequest(method, path, bytes.NewBuffer(data))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	values := func(todo models.Todo) map[uint]string {
+		found := map[uint]string{}
+		for _, value := range todo.CustomFields {
+			found[value.FieldID] = value.Value
+		}
+		return found
+	}
+	create := func(title string, fields []map[string]interface{}) models.Todo {
+		w := send("POST", "/todos", map[string]interface{}{"title": title, "custom_fields": fields})
+		assert.Equal(t, http.StatusCreated, w.Code)
+		var todo models.Todo
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &todo))
+		return todo
+	}
+
+	t.Run("validation", func(t *testing.T) {
+		tests := []struct {
+			name   string
+			fields []map[string]interface{}
+		}{
+			{name: "Not a number", fields: []map[string]interface{}{{"field_id": points.ID, "value": "lots"}}},
+			{name: "Not a boolean", fields: []map[string]interface{}{{"field_id": billable.ID, "value": "maybe"}}},
+			{name: "Another user's field", fields: []map[string]interface{}{{"field_id": foreign.ID, "value": 3}}},
+			{name: "Field set twice", fields: []map[string]interface{}{{"field_id": points.ID, "value": 1}, {"field_id": points.ID, "value": 2}}},
+		}
+		for _, tt := range tests {
+			w := send("POST", "/todos", map[string]interface{}{"title": tt.name, "custom_fields": tt.fields})
+			assert.Equal(t, http.StatusBadRequest, w.Code, tt.name)
+		}
+	})
+
+	small := create("Fix typo", []map[string]interface{}{
+		{"field_id": points.ID, "value": 1},
+		{"field_id": customer.ID, "value": "Acme"},
+	})
+	assert.Equal(t, map[uint]string{points.ID: "1", customer.ID: "Acme"}, values(small))
+
+	large := create("Rewrite billing", []map[string]interface{}{
+		{"field_id": points.ID, "value": "13.0"},
+		{"field_id": billable.ID, "value": true},
+	})
+	assert.Equal(t, map[uint]string{points.ID: "13", billable.ID: "true"}, values(large))
+
+	medium := create("Add search", []map[string]interface{}{{"field_id": points.ID, "value": 5}})
+	create("Unestimated", nil)
+
+	t.Run("update keeps or replaces values", func(t *testing.T) {
+		w := send("PUT", fmt.Sprintf("/todos/%d", medium.ID), map[string]interface{}{"title": "Add full-text search"})
+		assert.Equal(t, http.StatusOK, w.Code)
+		var updated models.Todo
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &updated))
+		assert.Equal(t, map[uint]string{points.ID: "5"}, values(updated))
+
+		w = send("PUT", fmt.Sprintf("/todos/%d", medium.ID), map[string]interface{}{
+			"title":         "Add full-text search",
+			"custom_fields": []map[string]interface{}{{"field_id": points.ID, "value": 8}, {"field_id": customer.ID, "value": "acme"}},
+		})
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &updated))
+		assert.Equal(t, map[uint]string{points.ID: "8", customer.ID: "acme"}, values(updated))
+	})
+
+	list := func(query string) (int, []string) {
+		w := send("GET", "/todos"+query, nil)
+		var todos []models.Todo
+		if w.Code == http.StatusOK {
+			assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &todos))
+		}
+		titles := make([]string, len(todos))
+		for i, todo := range todos {
+			titles[i] = todo.Title
+		}
+		return w.Code, titles
+	}
+
+	t.Run("filter", func(t *testing.T) {
+		code, titles := list(fmt.Sprintf("?cf_%d=ACME", customer.ID))
+		assert.Equal(t, http.StatusOK, code)
+		assert.ElementsMatch(t, []string{"Fix typo", "Add full-text search"}, titles)
+
+		_, titles = list(fmt.Sprintf("?cf_%d=13", points.ID))
+		assert.Equal(t, []string{"Rewrite billing"}, titles)
+
+		code, _ = list(fmt.Sprintf("?cf_%d=lots", points.ID))
+		assert.Equal(t, http.StatusBadRequest, code)
+		code, _ = list(fmt.Sprintf("?cf_%d=3", foreign.ID))
+		assert.Equal(t, http.StatusBadRequest, code)
+	})
+
+	t.Run("sort", func(t *testing.T) {
+		// Numbers sort numerically, and todos without a value come last
+		code, titles := list(fmt.Sprintf("?sort=cf_%d", points.ID))
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, []string{"Fix typo", "Add full-text search", "Rewrite billing", "Unestimated"}, titles)
+
+		_, titles = list(fmt.Sprintf("?sort=-cf_%d", points.ID))
+		assert.Equal(t, []string{"Rewrite billing", "Add full-text search", "Fix typo", "Unestimated"}, titles)
+
+		_, titles = list("?sort=title")
+		assert.Equal(t, []string{"Add full-text search", "Fix typo", "Rewrite billing", "Unestimated"}, titles)
+
+		code, _ = list("?sort=user_id")
+		assert.Equal(t, http.StatusBadRequest, code)
+	})
+
+	t.Run("export", func(t *testing.T) {
+		db.Model(&models.Todo{}).Where("id = ?", small.ID).Update("title", "=HYPERLINK(\"http://example.com\")")
+
+		w := send("GET", fmt.Sprintf("/todos/export?sort=cf_%d", points.ID), nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Header().Get("Content-Type"), "text/csv")
+
+		records, err := csv.NewReader(w.Body).ReadAll()
+		assert.NoError(t, err)
+		if assert.Len(t, records, 5) {
+			assert.Equal(t, append(append([]string{}, exportColumns...), "Billable", "Customer", "Points"), records[0])
+			assert.Equal(t, "'=HYPERLINK(\"http://example.com\")", records[1][1])
+			assert.Equal(t, []string{"", "Acme", "1"}, records[1][len(exportColumns):])
+			assert.Equal(t, []string{"true", "", "13"}, records[3][len(exportColumns):])
+		}
+	})
+}
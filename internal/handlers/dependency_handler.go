@@ -0,0 +1,251 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"todo-api/internal/database"
+	"todo-api/internal/models"
+)
+
+var errDependencyCycle = errors.New("a todo cannot be blocked by itself or by a todo it blocks")
+
+type DependencyRequest struct {
+	BlockedByID uint `json:"blocked_by_id" binding:"required" example:"1"`
+}
+
+// DependencyGraph is every todo connected to a todo through dependencies,
+// upstream and downstream
+type DependencyGraph struct {
+	TodoID uint                    `json:"todo_id" example:"2"`
+	Nodes  []models.Todo           `json:"nodes"`
+	Edges  []models.TodoDependency `json:"edges"`
+}
+
+// @Summary Add a dependency
+// @Description Mark a todo as blocked by another todo. Dependencies that would form a cycle are rejected.
+// @Tags dependencies
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "Blocked todo ID"
+// @Param request body DependencyRequest true "Blocking todo"
+// @Success 201 {object} models.TodoDependency
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/todos/{id}/dependencies [post]
+func AddDependency(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	id := c.Param("id")
+	var todo models.Todo
+
+	if err := accessibleTodos(database.GetDB(), userID.(uint)).Where("todos.id = ?", id).First(&todo).Error; err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Todo not found"})
+		return
+	}
+
+	var req DependencyRequest
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	var blocker models.Todo
+	if err := accessibleTodos(database.GetDB(), userID.(uint)).Where("todos.id = ?", req.BlockedByID).First(&blocker).Error; err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Blocking todo not found"})
+		return
+	}
+
+	if err := validateDependency(database.GetDB(), todo.ID, blocker.ID); err != nil {
+		if errors.Is(err, errDependencyCycle) {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	dependency := models.TodoDependency{TodoID: todo.ID, BlockedByID: blocker.ID}
+	result := database.GetDB().Clauses(clause.OnConflict{DoNothing: true}).Create(&dependency)
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: result.Error.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, dependency)
+}
+
+// @Summary Remove a dependency
+// @Description Stop a todo from being blocked by another todo
+// @Tags dependencies
+// @Security Bearer
+// @Param id path int true "Blocked todo ID"
+// @Param blockerId path int true "Blocking todo ID"
+// @Success 204 "No Content"
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/todos/{id}/dependencies/{blockerId} [delete]
+func RemoveDependency(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	id := c.Param("id")
+	var todo models.Todo
+
+	if err := accessibleTodos(database.GetDB(), userID.(uint)).Where("todos.id = ?", id).First(&todo).Error; err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Todo not found"})
+		return
+	}
+
+	result := database.GetDB().Where("todo_id = ? AND blocked_by_id = ?", todo.ID, c.Param("blockerId")).Delete(&models.TodoDependency{})
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: result.Error.Error()})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Dependency not found"})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// @Summary Get a todo's dependency graph
+// @Description Get every todo that blocks this todo or is blocked by it, directly or through other todos
+// @Tags dependencies
+// @Produce json
+// @Security Bearer
+// @Param id path int true "Todo ID"
+// @Success 200 {object} DependencyGraph
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/todos/{id}/dependencies [get]
+func GetDependencies(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	id := c.Param("id")
+	var todo models.Todo
+
+	if err := accessibleTodos(database.GetDB(), userID.(uint)).Where("todos.id = ?", id).First(&todo).Error; err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Todo not found"})
+		return
+	}
+
+	db := database.GetDB()
+	upstream, err := walkDependencies(db, todo.ID, true)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+	downstream, err := walkDependencies(db, todo.ID, false)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	edges := append(upstream, downstream...)
+	ids := []uint{todo.ID}
+	for _, edge := range edges {
+		ids = append(ids, edge.TodoID, edge.BlockedByID)
+	}
+	graph := DependencyGraph{TodoID: todo.ID}
+	if err := accessibleTodos(db, userID.(uint)).Where("todos.id IN ?", ids).Order("id").Find(&graph.Nodes).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+	// Todos in projects the user cannot see are left out, with their edges
+	visible := make(map[uint]bool, len(graph.Nodes))
+	for _, node := range graph.Nodes {
+		visible[node.ID] = true
+	}
+	for _, edge := range edges {
+		if visible[edge.TodoID] && visible[edge.BlockedByID] {
+			graph.Edges = append(graph.Edges, edge)
+		}
+	}
+
+	c.JSON(http.StatusOK, graph)
+}
+
+// walkDependencies follows dependency edges from a todo, upstream to its
+// blockers or downstream to the todos it blocks, and returns every edge it
+// crossed.
+func walkDependencies(db *gorm.DB, todoID uint, upstream bool) ([]models.TodoDependency, error) {
+	from := "blocked_by_id"
+	if upstream {
+		from = "todo_id"
+	}
+
+	var edges []models.TodoDependency
+	seen := map[uint]bool{todoID: true}
+	frontier := []uint{todoID}
+	db = db.Session(&gorm.Session{})
+
+	for len(frontier) > 0 {
+		var level []models.TodoDependency
+		if err := db.Where(from+" IN ?", frontier).Order("todo_id, blocked_by_id").Find(&level).Error; err != nil {
+			return nil, err
+		}
+
+		frontier = frontier[:0]
+		for _, edge := range level {
+			next := edge.TodoID
+			if upstream {
+				next = edge.BlockedByID
+			}
+			if !seen[next] {
+				seen[next] = true
+				frontier = append(frontier, next)
+			}
+		}
+		edges = append(edges, level...)
+	}
+
+	return edges, nil
+}
+
+// validateDependency rejects a dependency of todoID on blockerID when the
+// blocker already depends on the todo, directly or through other todos.
+func validateDependency(db *gorm.DB, todoID uint, blockerID uint) error {
+	if todoID == blockerID {
+		return errDependencyCycle
+	}
+
+	edges, err := walkDependencies(db, blockerID, true)
+	if err != nil {
+		return err
+	}
+	for _, edge := range edges {
+		if edge.BlockedByID == todoID {
+			return errDependencyCycle
+		}
+	}
+
+	return nil
+}
+
+// openBlockers returns the todos that still block a todo.
+func openBlockers(db *gorm.DB, todoID uint) ([]models.Todo, error) {
+	var blockers []models.Todo
+	err := db.Joins("JOIN todo_dependencies ON todo_dependencies.blocked_by_id = todos.id").
+		Where("todo_dependencies.todo_id = ? AND todos.completed = ?", todoID, false).
+		Order("todos.id").
+		Find(&blockers).Error
+	return blockers, err
+}
+
+// blockedError describes the open blockers of a todo that was about to be
+// completed.
+func blockedError(blockers []models.Todo) string {
+	titles := make([]string, len(blockers))
+	for i, blocker := range blockers {
+		titles[i] = fmt.Sprintf("%q (%d)", blocker.Title, blocker.ID)
+	}
+	return "Todo is blocked by open todos: " + strings.Join(titles, ", ") + "; pass force=true to complete it anyway"
+}
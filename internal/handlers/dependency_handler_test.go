@@ -0,0 +1,175 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"todo-api/internal/models"
+	"todo-api/internal/test"
+)
+
+func TestAddDependency(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db, _ := test.SetupTestDB()
+	testUser, err := test.CreateTestUser(db)
+	assert.NoError(t, err)
+
+	// design <- build <- ship, where ship is blocked by build and build by design
+	tests := []struct {
+		name         string
+		todo         string
+		blockedBy    string
+		blockerID    uint
+		expectedCode int
+	}{
+		{
+			name:         "Add a dependency",
+			todo:         "ship",
+			blockedBy:    "design",
+			expectedCode: http.StatusCreated,
+		},
+		{
+			name:         "Adding an existing dependency again",
+			todo:         "build",
+			blockedBy:    "design",
+			expectedCode: http.StatusCreated,
+		},
+		{
+			name:         "Todo blocked by itself",
+			todo:         "build",
+			blockedBy:    "build",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "Direct cycle",
+			todo:         "design",
+			blockedBy:    "build",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "Cycle through another todo",
+			todo:         "design",
+			blockedBy:    "ship",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "Blocking todo not found",
+			todo:         "ship",
+			blockerID:    999,
+			expectedCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+
+			test.ClearTestData(db)
+			todos := map[string]*models.Todo{}
+			for _, title := range []string{"design", "build", "ship"} {
+				todos[title] = &models.Todo{Title: title, UserID: testUser.ID}
+				db.Create(todos[title])
+			}
+			db.Create(&models.TodoDependency{TodoID: todos["build"].ID, BlockedByID: todos["design"].ID})
+			db.Create(&models.TodoDependency{TodoID: todos["ship"].ID, BlockedByID: todos["build"].ID})
+
+			router.POST("/todos/:id/dependencies", func(c *gin.Context) {
+				c.Set("userID", testUser.ID)
+				AddDependency(c)
+			})
+
+			blockerID := tt.blockerID
+			if blockerID == 0 {
+				blockerID = todos[tt.blockedBy].ID
+			}
+			jsonBody, _ := json.Marshal(map[string]interface{}{"blocked_by_id": blockerID})
+			req, _ := http.NewRequest("POST", fmt.Sprintf("/todos/%d/dependencies", todos[tt.todo].ID), bytes.NewBuffer(jsonBody))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+		})
+	}
+}
+
+func TestBlockedTodos(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db, _ := test.SetupTestDB()
+	testUser, err := test.CreateTestUser(db)
+	assert.NoError(t, err)
+	test.ClearTestData(db)
+
+	design := &models.Todo{Title: "design", UserID: testUser.ID}
+	build := &models.Todo{Title: "build", UserID: testUser.ID}
+	ship := &models.Todo{Title: "ship", UserID: testUser.ID}
+	docs := &models.Todo{Title: "docs", UserID: testUser.ID}
+	for _, todo := range []*models.Todo{design, build, ship, docs} {
+		db.Create(todo)
+	}
+	db.Create(&models.TodoDependency{TodoID: build.ID, BlockedByID: design.ID})
+	db.Create(&models.TodoDependency{TodoID: ship.ID, BlockedByID: build.ID})
+
+	router := gin.New()
+	router.GET("/todos", func(c *gin.Context) {
+		c.Set("userID", testUser.ID)
+		GetTodos(c)
+	})
+	router.GET("/todos/:id/dependencies", func(c *gin.Context) {
+		c.Set("userID", testUser.ID)
+		GetDependencies(c)
+	})
+	router.PUT("/todos/:id", func(c *gin.Context) {
+		c.Set("userID", testUser.ID)
+		UpdateTodo(c)
+	})
+
+	actionable := func() []string {
+		req, _ := http.NewRequest("GET", "/todos?actionable=true", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var todos []models.Todo
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &todos))
+		titles := make([]string, len(todos))
+		for i, todo := range todos {
+			titles[i] = todo.Title
+		}
+		return titles
+	}
+	complete := func(todo *models.Todo, query string) int {
+		jsonBody, _ := json.Marshal(map[string]interface{}{"title": todo.Title, "completed": true})
+		req, _ := http.NewRequest("PUT", fmt.Sprintf("/todos/%d%s", todo.ID, query), bytes.NewBuffer(jsonBody))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	assert.ElementsMatch(t, []string{"design", "docs"}, actionable())
+
+	req, _ := http.NewRequest("GET", fmt.Sprintf("/todos/%d/dependencies", build.ID), nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var graph DependencyGraph
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &graph))
+	assert.Len(t, graph.Nodes, 3)
+	assert.Len(t, graph.Edges, 2)
+
+	assert.Equal(t, http.StatusConflict, complete(build, ""))
+	assert.Equal(t, http.StatusOK, complete(design, ""))
+	assert.ElementsMatch(t, []string{"build", "docs"}, actionable())
+
+	assert.Equal(t, http.StatusOK, complete(ship, "?force=true"))
+	assert.ElementsMatch(t, []string{"build", "docs"}, actionable())
+}
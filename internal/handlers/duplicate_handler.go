@@ -0,0 +1,207 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"todo-api/internal/database"
+	"todo-api/internal/models"
+)
+
+type DuplicateRequest struct {
+	Title        string `json:"title" example:"Spring launch plan"` // Title of the copy; the original's when left out
+	Subtasks     *bool  `json:"subtasks" example:"true"`
+	Tags         *bool  `json:"tags" example:"true"`
+	Attachments  *bool  `json:"attachments" example:"true"`
+	CustomFields *bool  `json:"custom_fields" example:"true"`
+	Shift        string `json:"shift" example:"1w"` // Moves every due date, such as 1w, 3d or -2d4h
+	ProjectID    *uint  `json:"project_id" example:"1"`
+}
+
+// duplicateOptions says what a copy takes over from the original.
+type duplicateOptions struct {
+	subtasks     bool
+	tags         bool
+	attachments  bool
+	customFields bool
+	shift        string
+	loc          *time.Location
+}
+
+// @Summary Duplicate a todo
+// @Description Copy a todo, by default together with its subtasks, tags, attachments and custom field values. Each of these can be left out. The copies start out open, and shift moves all of their due dates by the same offset. Only the todo's creator can duplicate it.
+// @Description The copy goes next to the original, or to the top level of project_id when given. Attachments are shared with the original rather than uploaded again.
+// @Tags todos
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "Todo ID"
+// @Param request body DuplicateRequest false "What to copy and where to"
+// @Success 201 {object} models.Todo
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/todos/{id}/duplicate [post]
+func DuplicateTodo(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	db := database.GetDB()
+	var todo models.Todo
+
+	if err := db.Where("id = ? AND user_id = ?", c.Param("id"), userID).First(&todo).Error; err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Todo not found"})
+		return
+	}
+
+	var req DuplicateRequest
+	if err := bindOptionalJSON(c, &req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	loc, err := userLocation(db, todo.UserID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+	opts := duplicateOptions{
+		subtasks:     req.Subtasks == nil || *req.Subtasks,
+		tags:         req.Tags == nil || *req.Tags,
+		attachments:  req.Attachments == nil || *req.Attachments,
+		customFields: req.CustomFields == nil || *req.CustomFields,
+		shift:        req.Shift,
+		loc:          loc,
+	}
+	if opts.shift != "" {
+		if _, err := models.AddOffset(time.Now(), opts.shift); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "shift " + err.Error()})
+			return
+		}
+	}
+
+	// The copy sits next to the original unless it goes to another project
+	base := models.Todo{Title: strings.TrimSpace(req.Title), ProjectID: todo.ProjectID, ParentID: todo.ParentID}
+	if req.ProjectID != nil {
+		project, err := findActiveProject(db, todo.UserID, *req.ProjectID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+			return
+		}
+		base.ProjectID = &project.ID
+		base.ParentID = nil
+	}
+	if base.Title == "" {
+		base.Title = todo.Title
+	}
+
+	var descendants []models.Todo
+	if opts.subtasks {
+		if descendants, err = loadDescendants(db, todo.ID); err != nil {
+			c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+			return
+		}
+	}
+	children := make(map[uint][]models.Todo)
+	for _, descendant := range descendants {
+		children[*descendant.ParentID] = append(children[*descendant.ParentID], descendant)
+	}
+
+	var created *models.Todo
+	err = db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		created, err = duplicateTree(tx, &todo, base, children, &opts)
+		if err != nil {
+			return err
+		}
+		return rollUpCompletion(tx, created)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	db.Preload("User").Preload("Assignees").Preload("Tags").Preload("CustomFields").First(created, created.ID)
+	copies, err := loadDescendants(db.Preload("Tags").Preload("CustomFields"), created.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+	buildTree(created, copies)
+
+	c.JSON(http.StatusCreated, created)
+}
+
+// duplicateTree copies a todo and, below the copy, its children. base
+// carries the title, project and parent of the new todo.
+func duplicateTree(tx *gorm.DB, original *models.Todo, base models.Todo, children map[uint][]models.Todo, opts *duplicateOptions) (*models.Todo, error) {
+	due := original.DueDate
+	if due != nil && opts.shift != "" {
+		shifted, err := models.AddOffset(due.In(opts.loc), opts.shift)
+		if err != nil {
+			return nil, err
+		}
+		shifted = shifted.UTC()
+		due = &shifted
+	}
+
+	todo := models.Todo{
+		Title:               base.Title,
+		Description:         original.Description,
+		Priority:            original.Priority,
+		UserID:              original.UserID,
+		ProjectID:           base.ProjectID,
+		ParentID:            base.ParentID,
+		AutoComplete:        original.AutoComplete,
+		DueDate:             due,
+		Recurrence:          original.Recurrence,
+		RecurFromCompletion: original.RecurFromCompletion,
+	}
+	if err := tx.Omit(clause.Associations).Create(&todo).Error; err != nil {
+		return nil, err
+	}
+
+	if opts.tags {
+		err := tx.Exec("INSERT INTO todo_tags (todo_id, tag_id) SELECT ?, tag_id FROM todo_tags WHERE todo_id = ?", todo.ID, original.ID).Error
+		if err != nil {
+			return nil, err
+		}
+	}
+	if opts.customFields {
+		if err := copyCustomFields(tx, original.ID, todo.ID); err != nil {
+			return nil, err
+		}
+	}
+	if opts.attachments {
+		// The files are shared, and stay counted towards their uploader
+		err := tx.Exec("INSERT INTO attachments (created_at, todo_id, user_id, filename, mime_type, size, checksum) SELECT ?, ?, user_id, filename, mime_type, size, checksum FROM attachments WHERE todo_id = ? ORDER BY id", time.Now(), todo.ID, original.ID).Error
+		if err != nil {
+			return nil, err
+		}
+	}
+	// A copied recurring todo starts a series of its own
+	if err := startSeries(tx, &todo); err != nil {
+		return nil, err
+	}
+	if err := syncStatus(tx, &todo); err != nil {
+		return nil, err
+	}
+	if err := recordRevision(tx, todoFields{}, todo.ID, todo.UserID); err != nil {
+		return nil, err
+	}
+	if err := runRules(tx, models.TriggerCreated, &todo); err != nil {
+		return nil, err
+	}
+
+	for i := range children[original.ID] {
+		child := &children[original.ID][i]
+		base := models.Todo{Title: child.Title, ProjectID: todo.ProjectID, ParentID: &todo.ID}
+		if _, err := duplicateTree(tx, child, base, children, opts); err != nil {
+			return nil, err
+		}
+	}
+	return &todo, nil
+}
@@ -0,0 +1,167 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"todo-api/internal/models"
+	"todo-api/internal/test"
+)
+
+func TestDuplicateTodo(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db, _ := test.SetupTestDB()
+	test.ClearTestData(db)
+	testUser, err := test.CreateTestUser(db)
+	assert.NoError(t, err)
+
+	launch := &models.Tag{Name: "launch", UserID: testUser.ID}
+	db.Create(launch)
+	points := &models.CustomField{Name: "Points", Type: models.FieldNumber, UserID: testUser.ID}
+	db.Create(points)
+	project := &models.Project{Name: "Spring", UserID: testUser.ID}
+	db.Create(project)
+	archived := &models.Project{Name: "Old", UserID: testUser.ID, Archived: true}
+	db.Create(archived)
+
+	due := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	stepDue := due.AddDate(0, 0, -3)
+	plan := &models.Todo{Title: "Launch plan", Description: "Q1", UserID: testUser.ID, Priority: models.PriorityHigh, DueDate: &due, Recurrence: "FREQ=MONTHLY", Tags: []models.Tag{*launch}}
+	db.Create(plan)
+	step := &models.Todo{Title: "Write copy", UserID: testUser.ID, ParentID: &plan.ID, DueDate: &stepDue, Completed: true, Tags: []models.Tag{*launch}}
+	db.Create(step)
+	substep := &models.Todo{Title: "Draft headline", UserID: testUser.ID, ParentID: &step.ID}
+	db.Create(substep)
+	trashed := &models.Todo{Title: "Dropped idea", UserID: testUser.ID, ParentID: &plan.ID}
+	db.Create(trashed)
+	db.Delete(trashed)
+	db.Exec("INSERT INTO custom_field_values (todo_id, field_id, value, number) VALUES (?, ?, ?, ?)", step.ID, points.ID, "5", 5)
+	db.Create(&models.Blob{Checksum: "abc", Size: 3})
+	db.Create(&models.Attachment{TodoID: plan.ID, UserID: testUser.ID, Filename: "brief.pdf", MimeType: "application/pdf", Size: 3, Checksum: "abc"})
+	other := &models.Todo{Title: "Not mine", UserID: testUser.ID + 1}
+	db.Create(other)
+
+	router := gin.New()
+	router.POST("/todos/:id/duplicate", func(c *gin.Context) {
+		c.Set("userID", testUser.ID)
+		DuplicateTodo(c)
+	})
+
+	tests := []struct {
+		name            string
+		todoID          uint
+		payload         map[string]interface{}
+		expectedCode    int
+		wantTitle       string
+		wantDue         time.Time
+		wantSubtasks    int
+		wantTags        int
+		wantAttachments int64
+		wantFieldValues int64
+	}{
+		{
+			name:            "Deep copy shifted by a week",
+			todoID:          plan.ID,
+			payload:         map[string]interface{}{"shift": "1w"},
+			expectedCode:    http.StatusCreated,
+			wantTitle:       "Launch plan",
+			wantDue:         due.AddDate(0, 0, 7),
+			wantSubtasks:    1,
+			wantTags:        1,
+			wantAttachments: 1,
+			wantFieldValues: 1,
+		},
+		{
+			name:         "Shallow copy into another project",
+			todoID:       plan.ID,
+			payload:      map[string]interface{}{"title": "Summer launch", "subtasks": false, "tags": false, "attachments": false, "project_id": project.ID},
+			expectedCode: http.StatusCreated,
+			wantTitle:    "Summer launch",
+			wantDue:      due,
+		},
+		{
+			name:         "Invalid shift",
+			todoID:       plan.ID,
+			payload:      map[string]interface{}{"shift": "next week"},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "Archived project",
+			todoID:       plan.ID,
+			payload:      map[string]interface{}{"project_id": archived.ID},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "Another user's todo",
+			todoID:       other.ID,
+			expectedCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, _ := json.Marshal(tt.payload)
+			req, _ := http.NewRequest("POST", fmt.Sprintf("/todos/%d/duplicate", tt.todoID), bytes.NewBuffer(body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+			if w.Code != http.StatusCreated {
+				return
+			}
+
+			var response models.Todo
+			assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+			assert.NotEqual(t, plan.ID, response.ID)
+			assert.Equal(t, tt.wantTitle, response.Title)
+			assert.Equal(t, "Q1", response.Description)
+			assert.Equal(t, models.PriorityHigh, response.Priority)
+			if assert.NotNil(t, response.DueDate) {
+				assert.True(t, tt.wantDue.Equal(*response.DueDate), "due %s, want %s", response.DueDate, tt.wantDue)
+			}
+			if assert.NotNil(t, response.SeriesID) {
+				assert.Equal(t, response.ID, *response.SeriesID)
+			}
+			assert.Len(t, response.Tags, tt.wantTags)
+			assert.Len(t, response.Subtasks, tt.wantSubtasks)
+
+			var attachments, fieldValues int64
+			copies := []uint{response.ID}
+			if tt.wantSubtasks > 0 {
+				copied := response.Subtasks[0]
+				assert.Equal(t, "Write copy", copied.Title)
+				assert.False(t, copied.Completed)
+				assert.Len(t, copied.Tags, 1)
+				if assert.NotNil(t, copied.DueDate) {
+					assert.True(t, stepDue.AddDate(0, 0, 7).Equal(*copied.DueDate))
+				}
+				if assert.Len(t, copied.Subtasks, 1) {
+					assert.Equal(t, "Draft headline", copied.Subtasks[0].Title)
+				}
+				copies = append(copies, copied.ID)
+			}
+			db.Model(&models.Attachment{}).Where("todo_id = ?", response.ID).Count(&attachments)
+			assert.Equal(t, tt.wantAttachments, attachments)
+			db.Model(&models.CustomFieldValue{}).Where("todo_id IN ?", copies).Count(&fieldValues)
+			assert.Equal(t, tt.wantFieldValues, fieldValues)
+		})
+	}
+
+	// The original is left as it was
+	var original models.Todo
+	db.Preload("Tags").First(&original, plan.ID)
+	assert.Equal(t, "Launch plan", original.Title)
+	assert.True(t, due.Equal(*original.DueDate))
+	var attachments int64
+	db.Model(&models.Attachment{}).Where("todo_id = ?", plan.ID).Count(&attachments)
+	assert.Equal(t, int64(1), attachments)
+}
@@ -0,0 +1,500 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"todo-api/internal/database"
+	"todo-api/internal/models"
+)
+
+type CheckInRequest struct {
+	Day   string `json:"day" example:"2026-01-31"`                  // Defaults to today, or yesterday within the habit's grace hours
+	Count int    `json:"count" example:"1" binding:"min=0,max=100"` // 0 means 1
+}
+
+type HabitDay struct {
+	Day   string `json:"day" example:"2026-01-31"`
+	Count int    `json:"count" example:"1"`
+}
+
+type HabitHeatmap struct {
+	Timezone string     `json:"timezone" example:"Europe/Berlin"`
+	From     string     `json:"from" example:"2025-02-01"`
+	To       string     `json:"to" example:"2026-01-31"`
+	Target   int        `json:"target" example:"1"`
+	Days     []HabitDay `json:"days"`
+}
+
+// @Summary Create a habit
+// @Description Create a daily or weekly habit to check in on
+// @Tags habits
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param habit body models.Habit true "Habit"
+// @Success 201 {object} models.Habit
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/habits [post]
+func CreateHabit(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	db := database.GetDB()
+
+	var habit models.Habit
+	if err := c.BindJSON(&habit); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	habit.UserID = userID.(uint)
+	if err := validateHabit(&habit); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	if err := db.Create(&habit).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+	if err := fillHabitStats(db, habit.UserID, []*models.Habit{&habit}); err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, habit)
+}
+
+// @Summary Get all habits
+// @Description Get the authenticated user's habits with their streaks. Archived habits are left out unless archived=true.
+// @Tags habits
+// @Produce json
+// @Security Bearer
+// @Param archived query bool false "Include archived habits"
+// @Success 200 {array} models.Habit
+// @Failure 401 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/habits [get]
+func GetHabits(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	db := database.GetDB()
+
+	query := db.Where("user_id = ?", userID)
+	if c.Query("archived") != "true" {
+		query = query.Where("archived = ?", false)
+	}
+	var habits []models.Habit
+	if err := query.Order("id").Find(&habits).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	refs := make([]*models.Habit, len(habits))
+	for i := range habits {
+		refs[i] = &habits[i]
+	}
+	if err := fillHabitStats(db, userID.(uint), refs); err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, habits)
+}
+
+// @Summary Get a habit
+// @Description Get a specific habit by ID with its streaks
+// @Tags habits
+// @Produce json
+// @Security Bearer
+// @Param id path int true "Habit ID"
+// @Success 200 {object} models.Habit
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/habits/{id} [get]
+func GetHabit(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	db := database.GetDB()
+	var habit models.Habit
+
+	if err := db.Where("id = ? AND user_id = ?", c.Param("id"), userID).First(&habit).Error; err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Habit not found"})
+		return
+	}
+	if err := fillHabitStats(db, habit.UserID, []*models.Habit{&habit}); err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, habit)
+}
+
+// @Summary Update a habit
+// @Description Replace a habit's name, description, frequency, target and grace rules, or archive it. Streaks are recounted from the existing check-ins.
+// @Tags habits
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "Habit ID"
+// @Param habit body models.Habit true "Habit"
+// @Success 200 {object} models.Habit
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/habits/{id} [put]
+func UpdateHabit(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	db := database.GetDB()
+	var habit models.Habit
+
+	if err := db.Where("id = ? AND user_id = ?", c.Param("id"), userID).First(&habit).Error; err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Habit not found"})
+		return
+	}
+
+	var updateData models.Habit
+	if err := c.BindJSON(&updateData); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+	if err := validateHabit(&updateData); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	result := db.Model(&habit).Updates(map[string]interface{}{
+		"name":          updateData.Name,
+		"description":   updateData.Description,
+		"frequency":     updateData.Frequency,
+		"target":        updateData.Target,
+		"grace_periods": updateData.GracePeriods,
+		"grace_hours":   updateData.GraceHours,
+		"archived":      updateData.Archived,
+	})
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: result.Error.Error()})
+		return
+	}
+	if err := fillHabitStats(db, habit.UserID, []*models.Habit{&habit}); err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, habit)
+}
+
+// @Summary Delete a habit
+// @Description Delete a habit together with its check-ins
+// @Tags habits
+// @Security Bearer
+// @Param id path int true "Habit ID"
+// @Success 204 "No Content"
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/habits/{id} [delete]
+func DeleteHabit(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	var habit models.Habit
+
+	if err := database.GetDB().Where("id = ? AND user_id = ?", c.Param("id"), userID).First(&habit).Error; err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Habit not found"})
+		return
+	}
+
+	err := database.GetDB().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("habit_id = ?", habit.ID).Delete(&models.HabitCheckIn{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&habit).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// @Summary Check in on a habit
+// @Description Record that a habit was done, today unless a past day is given. Checking in again on the same day adds to its count.
+// @Tags habits
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "Habit ID"
+// @Param request body CheckInRequest false "Day and count"
+// @Success 201 {object} models.Habit
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/habits/{id}/checkins [post]
+func CheckInHabit(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	db := database.GetDB()
+	var habit models.Habit
+
+	if err := db.Where("id = ? AND user_id = ?", c.Param("id"), userID).First(&habit).Error; err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Habit not found"})
+		return
+	}
+	if habit.Archived {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Habit is archived"})
+		return
+	}
+
+	var req CheckInRequest
+	if err := bindOptionalJSON(c, &req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+	if req.Count == 0 {
+		req.Count = 1
+	}
+
+	loc, err := userLocation(db, habit.UserID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+	now := time.Now().In(loc)
+	day := startOfDay(now.Add(-time.Duration(habit.GraceHours) * time.Hour))
+	if req.Day != "" {
+		if day, err = time.ParseInLocation(reportDateLayout, req.Day, loc); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "day must be a date like 2026-01-31"})
+			return
+		}
+		if day.After(startOfDay(now)) {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "day must not be in the future"})
+			return
+		}
+	}
+
+	checkIn := models.HabitCheckIn{
+		HabitID: habit.ID,
+		UserID:  habit.UserID,
+		Day:     day.Format(reportDateLayout),
+		Count:   req.Count,
+	}
+	err = db.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "habit_id"}, {Name: "day"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"count":      gorm.Expr("habit_check_ins.count + ?", req.Count),
+			"updated_at": time.Now(),
+		}),
+	}).Create(&checkIn).Error
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+	if err := fillHabitStats(db, habit.UserID, []*models.Habit{&habit}); err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, habit)
+}
+
+// @Summary Undo a check-in
+// @Description Remove all of a habit's check-ins on a day
+// @Tags habits
+// @Security Bearer
+// @Param id path int true "Habit ID"
+// @Param day path string true "Day, such as 2026-01-31"
+// @Success 204 "No Content"
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/habits/{id}/checkins/{day} [delete]
+func DeleteCheckIn(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	db := database.GetDB()
+	var habit models.Habit
+
+	if err := db.Where("id = ? AND user_id = ?", c.Param("id"), userID).First(&habit).Error; err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Habit not found"})
+		return
+	}
+
+	result := db.Where("habit_id = ? AND day = ?", habit.ID, c.Param("day")).Delete(&models.HabitCheckIn{})
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: result.Error.Error()})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Check-in not found"})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// @Summary Get a habit's heatmap
+// @Description Get the number of check-ins on every day of the last year, ending today in the user's timezone
+// @Tags habits
+// @Produce json
+// @Security Bearer
+// @Param id path int true "Habit ID"
+// @Success 200 {object} HabitHeatmap
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/habits/{id}/heatmap [get]
+func GetHabitHeatmap(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	db := database.GetDB()
+	var habit models.Habit
+
+	if err := db.Where("id = ? AND user_id = ?", c.Param("id"), userID).First(&habit).Error; err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Habit not found"})
+		return
+	}
+
+	loc, err := userLocation(db, habit.UserID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+	to := startOfDay(time.Now().In(loc))
+	from := to.AddDate(-1, 0, 1)
+
+	var checkIns []models.HabitCheckIn
+	err = db.Where("habit_id = ? AND day >= ? AND day <= ?", habit.ID, from.Format(reportDateLayout), to.Format(reportDateLayout)).
+		Find(&checkIns).Error
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+	counts := make(map[string]int, len(checkIns))
+	for _, checkIn := range checkIns {
+		counts[checkIn.Day] = checkIn.Count
+	}
+
+	heatmap := HabitHeatmap{
+		Timezone: loc.String(),
+		From:     from.Format(reportDateLayout),
+		To:       to.Format(reportDateLayout),
+		Target:   habit.Goal(),
+		Days:     []HabitDay{},
+	}
+	for day := from; !day.After(to); day = day.AddDate(0, 0, 1) {
+		key := day.Format(reportDateLayout)
+		heatmap.Days = append(heatmap.Days, HabitDay{Day: key, Count: counts[key]})
+	}
+
+	c.JSON(http.StatusOK, heatmap)
+}
+
+// validateHabit tidies a habit in place and checks its name.
+func validateHabit(habit *models.Habit) error {
+	habit.Name = strings.TrimSpace(habit.Name)
+	if habit.Name == "" {
+		return errors.New("name must not be empty")
+	}
+	return nil
+}
+
+// fillHabitStats counts the streaks of the user's habits as of today in
+// their timezone.
+func fillHabitStats(db *gorm.DB, userID uint, habits []*models.Habit) error {
+	if len(habits) == 0 {
+		return nil
+	}
+	loc, err := userLocation(db, userID)
+	if err != nil {
+		return err
+	}
+	today := startOfDay(time.Now().In(loc))
+
+	ids := make([]uint, len(habits))
+	for i, habit := range habits {
+		ids[i] = habit.ID
+	}
+	var checkIns []models.HabitCheckIn
+	if err := db.Where("habit_id IN ?", ids).Find(&checkIns).Error; err != nil {
+		return err
+	}
+	byHabit := make(map[uint][]models.HabitCheckIn)
+	for _, checkIn := range checkIns {
+		byHabit[checkIn.HabitID] = append(byHabit[checkIn.HabitID], checkIn)
+	}
+
+	for _, habit := range habits {
+		habit.Stats = habitStats(habit, byHabit[habit.ID], today)
+	}
+	return nil
+}
+
+// habitStats works out a habit's streaks from its check-ins. A streak is a
+// run of days or weeks that reached the target, where up to GracePeriods
+// missed ones in a row are forgiven without counting towards it. Today, or
+// this week, only counts once it reaches the target and never breaks a
+// streak while it is still going.
+func habitStats(habit *models.Habit, checkIns []models.HabitCheckIn, today time.Time) *models.HabitStats {
+	stats := &models.HabitStats{}
+	group := "day"
+	if habit.Frequency == models.HabitWeekly {
+		group = "week"
+	}
+
+	totals := make(map[string]int)
+	var first time.Time
+	for _, checkIn := range checkIns {
+		day, err := time.ParseInLocation(reportDateLayout, checkIn.Day, today.Location())
+		if err != nil {
+			continue
+		}
+		period := bucketStart(day, group)
+		totals[period.Format(reportDateLayout)] += checkIn.Count
+		stats.TotalCheckIns += checkIn.Count
+		if first.IsZero() || period.Before(first) {
+			first = period
+		}
+		if checkIn.Day > stats.LastCheckIn {
+			stats.LastCheckIn = checkIn.Day
+		}
+	}
+
+	current := bucketStart(today, group)
+	stats.PeriodCount = totals[current.Format(reportDateLayout)]
+	stats.PeriodDone = stats.PeriodCount >= habit.Goal()
+	if first.IsZero() {
+		return stats
+	}
+
+	streak, missed := 0, 0
+	for period := first; period.Before(current); period = nextHabitPeriod(period, group) {
+		if totals[period.Format(reportDateLayout)] >= habit.Goal() {
+			streak++
+			missed = 0
+		} else if missed++; missed > habit.GracePeriods {
+			streak = 0
+		}
+		if streak > stats.LongestStreak {
+			stats.LongestStreak = streak
+		}
+	}
+	if stats.PeriodDone {
+		streak++
+		if streak > stats.LongestStreak {
+			stats.LongestStreak = streak
+		}
+	}
+	stats.CurrentStreak = streak
+	return stats
+}
+
+func nextHabitPeriod(period time.Time, group string) time.Time {
+	if group == "week" {
+		return period.AddDate(0, 0, 7)
+	}
+	return period.AddDate(0, 0, 1)
+}
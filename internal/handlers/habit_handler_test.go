@@ -0,0 +1,415 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"todo-api/internal/models"
+	"todo-api/internal/test"
+)
+
+func TestCreateHabit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db, _ := test.SetupTestDB()
+	test.ClearTestData(db)
+	testUser, err := test.CreateTestUser(db)
+	assert.NoError(t, err)
+
+	router := gin.New()
+	router.POST("/habits", func(c *gin.Context) {
+		c.Set("userID", testUser.ID)
+		CreateHabit(c)
+	})
+
+	tests := []struct {
+		name         string
+		payload      map[string]interface{}
+		expectedCode int
+	}{
+		{
+			name:         "Daily habit",
+			payload:      map[string]interface{}{"name": "Read", "frequency": "daily", "grace_hours": 3},
+			expectedCode: http.StatusCreated,
+		},
+		{
+			name:         "Weekly habit with a target",
+			payload:      map[string]interface{}{"name": "Run", "frequency": "weekly", "target": 3, "grace_periods": 1},
+			expectedCode: http.StatusCreated,
+		},
+		{
+			name:         "Blank name",
+			payload:      map[string]interface{}{"name": "  ", "frequency": "daily"},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "Unknown frequency",
+			payload:      map[string]interface{}{"name": "Read", "frequency": "hourly"},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "Too much grace",
+			payload:      map[string]interface{}{"name": "Read", "frequency": "daily", "grace_hours": 13},
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, _ := json.Marshal(tt.payload)
+			req, _ := http.NewRequest("POST", "/habits", bytes.NewBuffer(body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+			if w.Code == http.StatusCreated {
+				var response models.Habit
+				assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+				assert.Equal(t, testUser.ID, response.UserID)
+				assert.Equal(t, tt.payload["name"], response.Name)
+				if assert.NotNil(t, response.Stats) {
+					assert.Zero(t, response.Stats.CurrentStreak)
+				}
+			}
+		})
+	}
+}
+
+func TestGetHabits(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db, _ := test.SetupTestDB()
+	test.ClearTestData(db)
+	testUser, err := test.CreateTestUser(db)
+	assert.NoError(t, err)
+
+	today := startOfDay(time.Now().UTC()).Format(reportDateLayout)
+	read := &models.Habit{Name: "Read", Frequency: models.HabitDaily, UserID: testUser.ID}
+	db.Create(read)
+	db.Create(&models.HabitCheckIn{HabitID: read.ID, UserID: testUser.ID, Day: today, Count: 1})
+	db.Create(&models.Habit{Name: "Old habit", Frequency: models.HabitDaily, UserID: testUser.ID, Archived: true})
+	db.Create(&models.Habit{Name: "Not mine", Frequency: models.HabitDaily, UserID: testUser.ID + 1})
+
+	router := gin.New()
+	router.GET("/habits", func(c *gin.Context) {
+		c.Set("userID", testUser.ID)
+		GetHabits(c)
+	})
+
+	tests := []struct {
+		name          string
+		query         string
+		expectedNames []string
+	}{
+		{name: "Active habits", expectedNames: []string{"Read"}},
+		{name: "With archived habits", query: "?archived=true", expectedNames: []string{"Read", "Old habit"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", "/habits"+tt.query, nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusOK, w.Code)
+			var response []models.Habit
+			assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+			var names []string
+			for _, habit := range response {
+				names = append(names, habit.Name)
+			}
+			assert.Equal(t, tt.expectedNames, names)
+			if assert.NotNil(t, response[0].Stats) {
+				assert.Equal(t, 1, response[0].Stats.CurrentStreak)
+				assert.True(t, response[0].Stats.PeriodDone)
+				assert.Equal(t, today, response[0].Stats.LastCheckIn)
+			}
+		})
+	}
+}
+
+func TestCheckInHabit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db, _ := test.SetupTestDB()
+	test.ClearTestData(db)
+	testUser, err := test.CreateTestUser(db)
+	assert.NoError(t, err)
+	db.Model(testUser).Update("timezone", "Pacific/Auckland")
+	loc, _ := time.LoadLocation("Pacific/Auckland")
+	today := startOfDay(time.Now().In(loc))
+
+	read := &models.Habit{Name: "Read", Frequency: models.HabitDaily, UserID: testUser.ID}
+	db.Create(read)
+	lateNight := &models.Habit{Name: "Journal", Frequency: models.HabitDaily, GraceHours: 12, UserID: testUser.ID}
+	db.Create(lateNight)
+	archived := &models.Habit{Name: "Old habit", Frequency: models.HabitDaily, UserID: testUser.ID, Archived: true}
+	db.Create(archived)
+	other := &models.Habit{Name: "Not mine", Frequency: models.HabitDaily, UserID: testUser.ID + 1}
+	db.Create(other)
+
+	router := gin.New()
+	router.POST("/habits/:id/checkins", func(c *gin.Context) {
+		c.Set("userID", testUser.ID)
+		CheckInHabit(c)
+	})
+	router.DELETE("/habits/:id/checkins/:day", func(c *gin.Context) {
+		c.Set("userID", testUser.ID)
+		DeleteCheckIn(c)
+	})
+
+	tests := []struct {
+		name         string
+		habitID      uint
+		payload      map[string]interface{}
+		expectedCode int
+		wantDay      string
+		wantCount    int
+	}{
+		{
+			name:         "Today in the user's timezone",
+			habitID:      read.ID,
+			expectedCode: http.StatusCreated,
+			wantDay:      today.Format(reportDateLayout),
+			wantCount:    1,
+		},
+		{
+			name:         "Checking in again adds to the day",
+			habitID:      read.ID,
+			payload:      map[string]interface{}{"count": 2},
+			expectedCode: http.StatusCreated,
+			wantDay:      today.Format(reportDateLayout),
+			wantCount:    3,
+		},
+		{
+			name:         "A past day",
+			habitID:      read.ID,
+			payload:      map[string]interface{}{"day": today.AddDate(0, 0, -1).Format(reportDateLayout)},
+			expectedCode: http.StatusCreated,
+			wantDay:      today.AddDate(0, 0, -1).Format(reportDateLayout),
+			wantCount:    1,
+		},
+		{
+			name:         "Grace hours count the small hours for the day before",
+			habitID:      lateNight.ID,
+			expectedCode: http.StatusCreated,
+			wantDay:      startOfDay(time.Now().In(loc).Add(-12 * time.Hour)).Format(reportDateLayout),
+			wantCount:    1,
+		},
+		{
+			name:         "A future day",
+			habitID:      read.ID,
+			payload:      map[string]interface{}{"day": today.AddDate(0, 0, 1).Format(reportDateLayout)},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "Not a date",
+			habitID:      read.ID,
+			payload:      map[string]interface{}{"day": "yesterday"},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "Archived habit",
+			habitID:      archived.ID,
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "Another user's habit",
+			habitID:      other.ID,
+			expectedCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var body []byte
+			if tt.payload != nil {
+				body, _ = json.Marshal(tt.payload)
+			}
+			req, _ := http.NewRequest("POST", fmt.Sprintf("/habits/%d/checkins", tt.habitID), bytes.NewBuffer(body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+			if w.Code != http.StatusCreated {
+				return
+			}
+			var response models.Habit
+			assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+			assert.NotNil(t, response.Stats)
+
+			var checkIn models.HabitCheckIn
+			assert.NoError(t, db.Where("habit_id = ? AND day = ?", tt.habitID, tt.wantDay).First(&checkIn).Error)
+			assert.Equal(t, tt.wantCount, checkIn.Count)
+		})
+	}
+
+	var habit models.Habit
+	db.First(&habit, read.ID)
+	assert.NoError(t, fillHabitStats(db, testUser.ID, []*models.Habit{&habit}))
+	assert.Equal(t, 2, habit.Stats.CurrentStreak)
+	assert.Equal(t, 4, habit.Stats.TotalCheckIns)
+
+	// Undoing a day's check-ins
+	day := today.Format(reportDateLayout)
+	req, _ := http.NewRequest("DELETE", fmt.Sprintf("/habits/%d/checkins/%s", read.ID, day), nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+
+	req, _ = http.NewRequest("DELETE", fmt.Sprintf("/habits/%d/checkins/%s", read.ID, day), nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestGetHabitHeatmap(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db, _ := test.SetupTestDB()
+	test.ClearTestData(db)
+	testUser, err := test.CreateTestUser(db)
+	assert.NoError(t, err)
+
+	today := startOfDay(time.Now().UTC())
+	from := today.AddDate(-1, 0, 1)
+	habit := &models.Habit{Name: "Read", Frequency: models.HabitDaily, Target: 2, UserID: testUser.ID}
+	db.Create(habit)
+	for day, count := range map[time.Time]int{today: 2, today.AddDate(0, 0, -3): 1, from: 1, from.AddDate(0, 0, -1): 5} {
+		db.Create(&models.HabitCheckIn{HabitID: habit.ID, UserID: testUser.ID, Day: day.Format(reportDateLayout), Count: count})
+	}
+
+	router := gin.New()
+	router.GET("/habits/:id/heatmap", func(c *gin.Context) {
+		c.Set("userID", testUser.ID)
+		GetHabitHeatmap(c)
+	})
+
+	req, _ := http.NewRequest("GET", fmt.Sprintf("/habits/%d/heatmap", habit.ID), nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var heatmap HabitHeatmap
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &heatmap))
+	assert.Equal(t, "UTC", heatmap.Timezone)
+	assert.Equal(t, 2, heatmap.Target)
+	assert.Equal(t, from.Format(reportDateLayout), heatmap.From)
+	assert.Equal(t, today.Format(reportDateLayout), heatmap.To)
+	assert.Equal(t, int(today.Sub(from).Hours()/24)+1, len(heatmap.Days))
+
+	total := 0
+	for _, day := range heatmap.Days {
+		total += day.Count
+	}
+	assert.Equal(t, 4, total)
+	assert.Equal(t, HabitDay{Day: from.Format(reportDateLayout), Count: 1}, heatmap.Days[0])
+	assert.Equal(t, HabitDay{Day: today.Format(reportDateLayout), Count: 2}, heatmap.Days[len(heatmap.Days)-1])
+}
+
+func TestHabitStats(t *testing.T) {
+	loc, _ := time.LoadLocation("America/New_York")
+	// A Wednesday
+	today := time.Date(2026, 3, 11, 0, 0, 0, 0, loc)
+
+	days := func(offsets ...int) []models.HabitCheckIn {
+		var checkIns []models.HabitCheckIn
+		for _, offset := range offsets {
+			checkIns = append(checkIns, models.HabitCheckIn{Day: today.AddDate(0, 0, offset).Format(reportDateLayout), Count: 1})
+		}
+		return checkIns
+	}
+
+	tests := []struct {
+		name        string
+		habit       models.Habit
+		checkIns    []models.HabitCheckIn
+		wantCurrent int
+		wantLongest int
+		wantDone    bool
+	}{
+		{
+			name:  "No check-ins",
+			habit: models.Habit{Frequency: models.HabitDaily},
+		},
+		{
+			name:        "Today counts once done",
+			habit:       models.Habit{Frequency: models.HabitDaily},
+			checkIns:    days(-2, -1, 0),
+			wantCurrent: 3,
+			wantLongest: 3,
+			wantDone:    true,
+		},
+		{
+			name:        "Today does not break a streak while it is still going",
+			habit:       models.Habit{Frequency: models.HabitDaily},
+			checkIns:    days(-2, -1),
+			wantCurrent: 2,
+			wantLongest: 2,
+		},
+		{
+			name:        "A missed day breaks the streak",
+			habit:       models.Habit{Frequency: models.HabitDaily},
+			checkIns:    days(-7, -6, -5, -4, -2, -1),
+			wantCurrent: 2,
+			wantLongest: 4,
+		},
+		{
+			name:        "Grace forgives a missed day",
+			habit:       models.Habit{Frequency: models.HabitDaily, GracePeriods: 1},
+			checkIns:    days(-7, -6, -5, -4, -2, -1),
+			wantCurrent: 6,
+			wantLongest: 6,
+		},
+		{
+			name:        "Grace does not forgive two missed days",
+			habit:       models.Habit{Frequency: models.HabitDaily, GracePeriods: 1},
+			checkIns:    days(-5, -4, -1),
+			wantCurrent: 1,
+			wantLongest: 2,
+		},
+		{
+			name:        "Days below the target do not count",
+			habit:       models.Habit{Frequency: models.HabitDaily, Target: 2},
+			checkIns:    append(days(-2, -2, -1), models.HabitCheckIn{Day: today.Format(reportDateLayout), Count: 2}),
+			wantCurrent: 1,
+			wantLongest: 1,
+			wantDone:    true,
+		},
+		{
+			name:  "Weeks start on Monday",
+			habit: models.Habit{Frequency: models.HabitWeekly, Target: 3},
+			// Three check-ins in each of the two weeks before this one, two so far this week
+			checkIns:    days(-16, -15, -10, -9, -8, -7, -2, -1),
+			wantCurrent: 2,
+			wantLongest: 2,
+		},
+		{
+			name:        "A missed week breaks the streak",
+			habit:       models.Habit{Frequency: models.HabitWeekly},
+			checkIns:    days(-22, -15, 0),
+			wantCurrent: 1,
+			wantLongest: 2,
+			wantDone:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stats := habitStats(&tt.habit, tt.checkIns, today)
+			assert.Equal(t, tt.wantCurrent, stats.CurrentStreak, "current streak")
+			assert.Equal(t, tt.wantLongest, stats.LongestStreak, "longest streak")
+			assert.Equal(t, tt.wantDone, stats.PeriodDone)
+			assert.Equal(t, len(tt.checkIns) > 0, stats.TotalCheckIns > 0)
+		})
+	}
+}
@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"todo-api/internal/database"
+	"todo-api/internal/models"
+)
+
+// @Summary Get notifications
+// @Description Get the authenticated user's in-app notifications, newest first
+// @Tags notifications
+// @Produce json
+// @Security Bearer
+// @Param unread query bool false "Only unread notifications"
+// @Success 200 {array} models.Notification
+// @Failure 401 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/notifications [get]
+func GetNotifications(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	query := database.GetDB().Where("user_id = ?", userID)
+	if c.Query("unread") == "true" {
+		query = query.Where("read_at IS NULL")
+	}
+
+	var notifications []models.Notification
+	result := query.Order("created_at DESC").Find(&notifications)
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: result.Error.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, notifications)
+}
+
+// @Summary Mark a notification as read
+// @Description Mark one of the user's notifications as read
+// @Tags notifications
+// @Produce json
+// @Security Bearer
+// @Param id path int true "Notification ID"
+// @Success 200 {object} models.Notification
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/notifications/{id}/read [post]
+func MarkNotificationRead(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	id := c.Param("id")
+	var notification models.Notification
+
+	if err := database.GetDB().Where("id = ? AND user_id = ?", id, userID).First(&notification).Error; err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Notification not found"})
+		return
+	}
+
+	if notification.ReadAt == nil {
+		now := time.Now()
+		notification.ReadAt = &now
+		result := database.GetDB().Model(&notification).Update("read_at", now)
+		if result.Error != nil {
+			c.JSON(http.StatusInternalServerError, ErrorResponse{Error: result.Error.Error()})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, notification)
+}
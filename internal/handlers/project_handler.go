@@ -0,0 +1,439 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"todo-api/internal/database"
+	"todo-api/internal/models"
+)
+
+const inboxName = "Inbox"
+
+var (
+	errProjectNotFound = errors.New("project not found")
+	errProjectArchived = errors.New("project is archived")
+)
+
+type MoveTodoRequest struct {
+	ProjectID uint `json:"project_id" binding:"required" example:"2"`
+}
+
+type ProjectStats struct {
+	ProjectID      uint    `json:"project_id" example:"1"`
+	Total          int64   `json:"total" example:"10"`
+	Completed      int64   `json:"completed" example:"4"`
+	Open           int64   `json:"open" example:"6"`
+	CompletionRate float64 `json:"completion_rate" example:"0.4"`
+	TrackedSeconds int64   `json:"tracked_seconds" example:"27000"`
+}
+
+// @Summary Create a new project
+// @Description Create a new project for the authenticated user
+// @Tags projects
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param project body models.Project true "Project object"
+// @Success 201 {object} models.Project
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/projects [post]
+func CreateProject(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	var project models.Project
+	if err := c.BindJSON(&project); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	project.UserID = userID.(uint)
+	project.Inbox = false
+
+	result := database.GetDB().Create(&project)
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: result.Error.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, project)
+}
+
+// @Summary Get all projects
+// @Description Get the authenticated user's projects and those shared with them, in sort order
+// @Tags projects
+// @Produce json
+// @Security Bearer
+// @Param archived query bool false "Include archived projects"
+// @Success 200 {array} models.Project
+// @Failure 401 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/projects [get]
+func GetProjects(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	if _, err := inboxFor(database.GetDB(), userID.(uint)); err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	query := accessibleProjects(database.GetDB(), userID.(uint))
+	if c.Query("archived") != "true" {
+		query = query.Where("archived = ?", false)
+	}
+
+	var projects []models.Project
+	result := query.Order("inbox DESC, sort_order, name").Find(&projects)
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: result.Error.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, projects)
+}
+
+// @Summary Get a project
+// @Description Get a specific project by ID
+// @Tags projects
+// @Produce json
+// @Security Bearer
+// @Param id path int true "Project ID"
+// @Success 200 {object} models.Project
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/projects/{id} [get]
+func GetProject(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	id := c.Param("id")
+	var project models.Project
+
+	if err := accessibleProjects(database.GetDB(), userID.(uint)).Where("projects.id = ?", id).First(&project).Error; err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Project not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, project)
+}
+
+// @Summary Update a project
+// @Description Rename, recolor, reorder or archive a project
+// @Tags projects
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "Project ID"
+// @Param project body models.Project true "Project object"
+// @Success 200 {object} models.Project
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/projects/{id} [put]
+func UpdateProject(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	id := c.Param("id")
+	var project models.Project
+
+	if err := database.GetDB().Where("id = ? AND user_id = ?", id, userID).First(&project).Error; err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Project not found"})
+		return
+	}
+
+	var updateData models.Project
+	if err := c.BindJSON(&updateData); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	if project.Inbox && updateData.Archived {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "The inbox cannot be archived"})
+		return
+	}
+
+	result := database.GetDB().Model(&project).Updates(map[string]interface{}{
+		"name":       updateData.Name,
+		"color":      updateData.Color,
+		"archived":   updateData.Archived,
+		"sort_order": updateData.SortOrder,
+	})
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: result.Error.Error()})
+		return
+	}
+
+	database.GetDB().First(&project, project.ID)
+
+	c.JSON(http.StatusOK, project)
+}
+
+// @Summary Delete a project
+// @Description Delete a project. Its todos are moved to the inbox unless todos=delete is given.
+// @Tags projects
+// @Security Bearer
+// @Param id path int true "Project ID"
+// @Param todos query string false "What happens to the project's todos" Enums(move, delete)
+// @Success 204 "No Content"
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/projects/{id} [delete]
+func DeleteProject(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	id := c.Param("id")
+	var project models.Project
+
+	if err := database.GetDB().Where("id = ? AND user_id = ?", id, userID).First(&project).Error; err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Project not found"})
+		return
+	}
+
+	if project.Inbox {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "The inbox cannot be deleted"})
+		return
+	}
+
+	mode := c.DefaultQuery("todos", "move")
+	if mode != "move" && mode != "delete" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "todos must be 'move' or 'delete'"})
+		return
+	}
+
+	err := database.GetDB().Transaction(func(tx *gorm.DB) error {
+		// Members lose access to the project's todos either way
+		if err := tx.Where("project_id = ?", project.ID).Delete(&models.ProjectMember{}).Error; err != nil {
+			return err
+		}
+		todos := tx.Session(&gorm.Session{NewDB: true}).Unscoped().Model(&models.Todo{}).Select("id").Where("project_id = ?", project.ID)
+		if err := pruneAssignees(tx, todos); err != nil {
+			return err
+		}
+
+		if mode == "delete" {
+			if err := tx.Where("project_id = ?", project.ID).Delete(&models.Todo{}).Error; err != nil {
+				return err
+			}
+			return tx.Delete(&project).Error
+		}
+
+		inbox, err := inboxFor(tx, project.UserID)
+		if err != nil {
+			return err
+		}
+		if err := tx.Model(&models.Todo{}).Where("project_id = ?", project.ID).Update("project_id", inbox.ID).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&project).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// @Summary Get project statistics
+// @Description Get todo counts, the completion rate and the time tracked on a project
+// @Tags projects
+// @Produce json
+// @Security Bearer
+// @Param id path int true "Project ID"
+// @Success 200 {object} ProjectStats
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/projects/{id}/stats [get]
+func GetProjectStats(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	id := c.Param("id")
+	var project models.Project
+
+	if err := accessibleProjects(database.GetDB(), userID.(uint)).Where("projects.id = ?", id).First(&project).Error; err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Project not found"})
+		return
+	}
+
+	stats := ProjectStats{ProjectID: project.ID}
+	todos := database.GetDB().Model(&models.Todo{}).Where("project_id = ?", project.ID)
+	if err := todos.Session(&gorm.Session{}).Count(&stats.Total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+	if err := todos.Session(&gorm.Session{}).Where("completed = ?", true).Count(&stats.Completed).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	entries := database.GetDB().Model(&models.TimeEntry{}).Where("todo_id IN (?)", todos.Session(&gorm.Session{}).Select("id"))
+	tracked, err := trackedSeconds(entries, time.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+	stats.TrackedSeconds = tracked
+
+	stats.Open = stats.Total - stats.Completed
+	if stats.Total > 0 {
+		stats.CompletionRate = float64(stats.Completed) / float64(stats.Total)
+	}
+
+	c.JSON(http.StatusOK, stats)
+}
+
+// @Summary Move a todo to another project
+// @Description Move a todo and all of its subtasks into another project. Only the todo's creator can move it.
+// @Tags todos
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "Todo ID"
+// @Param request body MoveTodoRequest true "Destination project"
+// @Success 200 {object} models.Todo
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/todos/{id}/move [post]
+func MoveTodo(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	id := c.Param("id")
+	var todo models.Todo
+
+	if err := database.GetDB().Where("id = ? AND user_id = ?", id, userID).First(&todo).Error; err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Todo not found"})
+		return
+	}
+
+	var req MoveTodoRequest
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	project, err := findActiveProject(database.GetDB(), todo.UserID, req.ProjectID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	before := snapshotTodo(&todo)
+	err = database.GetDB().Transaction(func(tx *gorm.DB) error {
+		if err := moveTodo(tx, &todo, project.ID); err != nil {
+			return err
+		}
+		if err := recordRevision(tx, before, todo.ID, userID.(uint)); err != nil {
+			return err
+		}
+		return runRules(tx, models.TriggerUpdated, &todo)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	database.GetDB().Preload("User").Preload("Assignees").Preload("Tags").First(&todo, todo.ID)
+
+	c.JSON(http.StatusOK, todo)
+}
+
+// moveTodo moves a todo and its subtree into a project. A subtask moved on
+// its own is detached from a parent that stays behind.
+func moveTodo(tx *gorm.DB, todo *models.Todo, projectID uint) error {
+	ids, err := descendantIDs(tx, todo.ID)
+	if err != nil {
+		return err
+	}
+	ids = append(ids, todo.ID)
+
+	if err := tx.Model(&models.Todo{}).Where("id IN ?", ids).Update("project_id", projectID).Error; err != nil {
+		return err
+	}
+	if err := pruneAssignees(tx, ids); err != nil {
+		return err
+	}
+
+	// The new project may follow another workflow
+	var moved []models.Todo
+	if err := tx.Where("id IN ?", ids).Find(&moved).Error; err != nil {
+		return err
+	}
+	for i := range moved {
+		if err := syncStatus(tx, &moved[i]); err != nil {
+			return err
+		}
+	}
+
+	if todo.ParentID != nil {
+		var parent models.Todo
+		if err := tx.First(&parent, *todo.ParentID).Error; err == nil && (parent.ProjectID == nil || *parent.ProjectID != projectID) {
+			if err := tx.Model(todo).Update("parent_id", nil).Error; err != nil {
+				return err
+			}
+			if err := rollUpCompletion(tx, &models.Todo{ParentID: &parent.ID}); err != nil {
+				return err
+			}
+		}
+	}
+
+	todo.ProjectID = &projectID
+	return nil
+}
+
+// inboxFor returns the user's inbox project, creating it if needed.
+func inboxFor(db *gorm.DB, userID uint) (*models.Project, error) {
+	var inbox models.Project
+	err := db.Where(models.Project{UserID: userID, Inbox: true}).
+		Attrs(models.Project{Name: inboxName}).
+		FirstOrCreate(&inbox).Error
+	if err != nil {
+		return nil, err
+	}
+	return &inbox, nil
+}
+
+// findActiveProject loads a project of the user's, or one shared with them,
+// that can take new todos.
+func findActiveProject(db *gorm.DB, userID uint, projectID uint) (*models.Project, error) {
+	var project models.Project
+	if err := accessibleProjects(db, userID).Where("projects.id = ?", projectID).First(&project).Error; err != nil {
+		return nil, errProjectNotFound
+	}
+	if project.Archived {
+		return nil, errProjectArchived
+	}
+	return &project, nil
+}
+
+// assignProject validates the project a new todo asks for, or picks its
+// parent's project or the user's inbox when none is given.
+func assignProject(db *gorm.DB, todo *models.Todo) error {
+	if todo.ProjectID != nil {
+		project, err := findActiveProject(db, todo.UserID, *todo.ProjectID)
+		if err != nil {
+			return err
+		}
+		todo.ProjectID = &project.ID
+		return nil
+	}
+
+	if todo.ParentID != nil {
+		var parent models.Todo
+		if err := db.Select("project_id").First(&parent, *todo.ParentID).Error; err == nil && parent.ProjectID != nil {
+			todo.ProjectID = parent.ProjectID
+			return nil
+		}
+	}
+
+	inbox, err := inboxFor(db, todo.UserID)
+	if err != nil {
+		return err
+	}
+	todo.ProjectID = &inbox.ID
+	return nil
+}
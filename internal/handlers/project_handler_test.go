@@ -0,0 +1,245 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"todo-api/internal/models"
+	"todo-api/internal/test"
+)
+
+func TestSignupCreatesInbox(t *testing.T) {
+	db, _ := test.SetupTestDB()
+	router := setupTestRouter()
+	router.POST("/auth/signup", Signup)
+
+	jsonBody, _ := json.Marshal(map[string]interface{}{
+		"email":    "inbox@example.com",
+		"password": "password123",
+	})
+	req, _ := http.NewRequest("POST", "/auth/signup", bytes.NewBuffer(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	var projects []models.Project
+	db.Where("inbox = ?", true).Find(&projects)
+	assert.Len(t, projects, 1)
+	assert.Equal(t, "Inbox", projects[0].Name)
+}
+
+func TestCreateTodoProject(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db, _ := test.SetupTestDB()
+	testUser, err := test.CreateTestUser(db)
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name        string
+		project     *models.Project
+		wantStatus  int
+		wantInInbox bool
+	}{
+		{
+			name:        "Defaults to the inbox",
+			wantStatus:  http.StatusCreated,
+			wantInInbox: true,
+		},
+		{
+			name:       "Explicit project",
+			project:    &models.Project{Name: "Work"},
+			wantStatus: http.StatusCreated,
+		},
+		{
+			name:       "Archived project is rejected",
+			project:    &models.Project{Name: "Old", Archived: true},
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+
+			test.ClearTestData(db)
+			reqBody := map[string]interface{}{"title": "Todo"}
+			if tt.project != nil {
+				tt.project.UserID = testUser.ID
+				db.Create(tt.project)
+				reqBody["project_id"] = tt.project.ID
+			}
+
+			router.POST("/todos", func(c *gin.Context) {
+				c.Set("userID", testUser.ID)
+				CreateTodo(c)
+			})
+
+			jsonBody, _ := json.Marshal(reqBody)
+			req, _ := http.NewRequest("POST", "/todos", bytes.NewBuffer(jsonBody))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+
+			if w.Code == http.StatusCreated {
+				var response models.Todo
+				err := json.Unmarshal(w.Body.Bytes(), &response)
+				assert.NoError(t, err)
+				assert.NotNil(t, response.ProjectID)
+
+				var project models.Project
+				db.First(&project, *response.ProjectID)
+				assert.Equal(t, tt.wantInInbox, project.Inbox)
+			}
+		})
+	}
+}
+
+func TestMoveTodo(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db, _ := test.SetupTestDB()
+	testUser, err := test.CreateTestUser(db)
+	assert.NoError(t, err)
+
+	source := &models.Project{Name: "Source", UserID: testUser.ID}
+	target := &models.Project{Name: "Target", UserID: testUser.ID}
+	db.Create(source)
+	db.Create(target)
+	parent := &models.Todo{Title: "Parent", UserID: testUser.ID, ProjectID: &source.ID}
+	db.Create(parent)
+	child := &models.Todo{Title: "Child", UserID: testUser.ID, ProjectID: &source.ID, ParentID: &parent.ID}
+	db.Create(child)
+
+	router := gin.New()
+	router.POST("/todos/:id/move", func(c *gin.Context) {
+		c.Set("userID", testUser.ID)
+		MoveTodo(c)
+	})
+
+	jsonBody, _ := json.Marshal(map[string]interface{}{"project_id": target.ID})
+	req, _ := http.NewRequest("POST", fmt.Sprintf("/todos/%d/move", parent.ID), bytes.NewBuffer(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var reloaded models.Todo
+	db.First(&reloaded, child.ID)
+	assert.Equal(t, target.ID, *reloaded.ProjectID)
+	assert.Equal(t, parent.ID, *reloaded.ParentID)
+}
+
+func TestDeleteProject(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db, _ := test.SetupTestDB()
+	testUser, err := test.CreateTestUser(db)
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name       string
+		inbox      bool
+		query      string
+		wantStatus int
+		wantTodos  int64
+	}{
+		{
+			name:       "Todos move to the inbox",
+			wantStatus: http.StatusNoContent,
+			wantTodos:  1,
+		},
+		{
+			name:       "Todos are deleted with the project",
+			query:      "?todos=delete",
+			wantStatus: http.StatusNoContent,
+			wantTodos:  0,
+		},
+		{
+			name:       "Inbox cannot be deleted",
+			inbox:      true,
+			wantStatus: http.StatusBadRequest,
+			wantTodos:  1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+
+			test.ClearTestData(db)
+			project := &models.Project{Name: "Project", UserID: testUser.ID, Inbox: tt.inbox}
+			db.Create(project)
+			todo := &models.Todo{Title: "Todo", UserID: testUser.ID, ProjectID: &project.ID}
+			db.Create(todo)
+
+			router.DELETE("/projects/:id", func(c *gin.Context) {
+				c.Set("userID", testUser.ID)
+				DeleteProject(c)
+			})
+
+			req, _ := http.NewRequest("DELETE", fmt.Sprintf("/projects/%d%s", project.ID, tt.query), nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+
+			var count int64
+			db.Model(&models.Todo{}).Where("user_id = ?", testUser.ID).Count(&count)
+			assert.Equal(t, tt.wantTodos, count)
+
+			if tt.wantStatus == http.StatusNoContent && tt.wantTodos > 0 {
+				var reloaded models.Todo
+				db.First(&reloaded, todo.ID)
+				var inbox models.Project
+				db.First(&inbox, *reloaded.ProjectID)
+				assert.True(t, inbox.Inbox)
+			}
+		})
+	}
+}
+
+func TestGetProjectStats(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db, _ := test.SetupTestDB()
+	testUser, err := test.CreateTestUser(db)
+	assert.NoError(t, err)
+
+	project := &models.Project{Name: "Project", UserID: testUser.ID}
+	db.Create(project)
+	db.Create(&models.Todo{Title: "Done", UserID: testUser.ID, ProjectID: &project.ID, Completed: true})
+	db.Create(&models.Todo{Title: "Open", UserID: testUser.ID, ProjectID: &project.ID})
+	db.Create(&models.Todo{Title: "Also open", UserID: testUser.ID, ProjectID: &project.ID})
+	db.Create(&models.Todo{Title: "Elsewhere", UserID: testUser.ID})
+
+	router := gin.New()
+	router.GET("/projects/:id/stats", func(c *gin.Context) {
+		c.Set("userID", testUser.ID)
+		GetProjectStats(c)
+	})
+
+	req, _ := http.NewRequest("GET", fmt.Sprintf("/projects/%d/stats", project.ID), nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response ProjectStats
+	err = json.Unmarshal(w.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), response.Total)
+	assert.Equal(t, int64(1), response.Completed)
+	assert.Equal(t, int64(2), response.Open)
+}
@@ -0,0 +1,158 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"todo-api/internal/database"
+	"todo-api/internal/models"
+)
+
+type ProjectMemberRequest struct {
+	Email string `json:"email" binding:"required,email" example:"friend@example.com"`
+}
+
+// @Summary Get project members
+// @Description Get the users a project is shared with. The owner and the members can see them.
+// @Tags projects
+// @Produce json
+// @Security Bearer
+// @Param id path int true "Project ID"
+// @Success 200 {array} models.ProjectMember
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/projects/{id}/members [get]
+func GetProjectMembers(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	db := database.GetDB()
+
+	var project models.Project
+	if err := accessibleProjects(db, userID.(uint)).Where("projects.id = ?", c.Param("id")).First(&project).Error; err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Project not found"})
+		return
+	}
+
+	var members []models.ProjectMember
+	result := db.Preload("User").Where("project_id = ?", project.ID).Order("created_at, user_id").Find(&members)
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: result.Error.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, members)
+}
+
+// @Summary Share a project
+// @Description Share one of the user's projects with another user, found by email. Members can see and edit the project's todos and be assigned to them.
+// @Tags projects
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "Project ID"
+// @Param request body ProjectMemberRequest true "The user to share with"
+// @Success 201 {object} models.ProjectMember
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/projects/{id}/members [post]
+func AddProjectMember(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	db := database.GetDB()
+
+	var project models.Project
+	if err := db.Where("id = ? AND user_id = ?", c.Param("id"), userID).First(&project).Error; err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Project not found"})
+		return
+	}
+	if project.Inbox {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "The inbox cannot be shared"})
+		return
+	}
+
+	var req ProjectMemberRequest
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	var user models.User
+	if err := db.Where("email = ?", req.Email).First(&user).Error; err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "User not found"})
+		return
+	}
+	if user.ID == project.UserID {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "The owner is already part of the project"})
+		return
+	}
+
+	var count int64
+	db.Model(&models.ProjectMember{}).Where("project_id = ? AND user_id = ?", project.ID, user.ID).Count(&count)
+	if count > 0 {
+		c.JSON(http.StatusConflict, ErrorResponse{Error: "The project is already shared with this user"})
+		return
+	}
+
+	member := models.ProjectMember{ProjectID: project.ID, UserID: user.ID}
+	if err := db.Omit("User").Create(&member).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+	member.User = user
+
+	c.JSON(http.StatusCreated, member)
+}
+
+// @Summary Stop sharing a project
+// @Description Remove a member from a project. The owner can remove anyone and members can remove themselves. The member is unassigned from the project's todos.
+// @Tags projects
+// @Security Bearer
+// @Param id path int true "Project ID"
+// @Param userId path int true "User ID of the member"
+// @Success 204 "No Content"
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/projects/{id}/members/{userId} [delete]
+func RemoveProjectMember(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	db := database.GetDB()
+
+	memberID, err := strconv.ParseUint(c.Param("userId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "User ID must be a number"})
+		return
+	}
+
+	var project models.Project
+	if err := db.First(&project, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Project not found"})
+		return
+	}
+
+	var member models.ProjectMember
+	err = db.Where("project_id = ? AND user_id = ?", project.ID, memberID).First(&member).Error
+	if err != nil || (project.UserID != userID.(uint) && member.UserID != userID.(uint)) {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Member not found"})
+		return
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("project_id = ? AND user_id = ?", member.ProjectID, member.UserID).Delete(&models.ProjectMember{}).Error; err != nil {
+			return err
+		}
+		todos := tx.Session(&gorm.Session{NewDB: true}).Unscoped().Model(&models.Todo{}).Select("id").Where("project_id = ?", project.ID)
+		return pruneAssignees(tx, todos)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,425 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"todo-api/internal/models"
+	"todo-api/internal/test"
+)
+
+func TestAddProjectMember(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db, _ := test.SetupTestDB()
+	test.ClearTestData(db)
+	owner, err := test.CreateTestUser(db)
+	assert.NoError(t, err)
+	friend := &models.User{Email: "friend@example.com"}
+	db.Create(friend)
+
+	project := &models.Project{Name: "Household", UserID: owner.ID}
+	db.Create(project)
+	inbox := &models.Project{Name: "Inbox", Inbox: true, UserID: owner.ID}
+	db.Create(inbox)
+	others := &models.Project{Name: "Theirs", UserID: friend.ID}
+	db.Create(others)
+
+	router := gin.New()
+	router.POST("/projects/:id/members", func(c *gin.Context) {
+		c.Set("userID", owner.ID)
+		AddProjectMember(c)
+	})
+
+	tests := []struct {
+		name         string
+		projectID    uint
+		email        string
+		expectedCode int
+	}{
+		{
+			name:         "Share with a user",
+			projectID:    project.ID,
+			email:        "friend@example.com",
+			expectedCode: http.StatusCreated,
+		},
+		{
+			name:         "Already shared",
+			projectID:    project.ID,
+			email:        "friend@example.com",
+			expectedCode: http.StatusConflict,
+		},
+		{
+			name:         "Share with the owner",
+			projectID:    project.ID,
+			email:        owner.Email,
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "Unknown email",
+			projectID:    project.ID,
+			email:        "nobody@example.com",
+			expectedCode: http.StatusNotFound,
+		},
+		{
+			name:         "Invalid email",
+			projectID:    project.ID,
+			email:        "friend",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "Inbox",
+			projectID:    inbox.ID,
+			email:        "friend@example.com",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "Another user's project",
+			projectID:    others.ID,
+			email:        owner.Email,
+			expectedCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, _ := json.Marshal(ProjectMemberRequest{Email: tt.email})
+			req, _ := http.NewRequest("POST", fmt.Sprintf("/projects/%d/members", tt.projectID), bytes.NewBuffer(body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+			if w.Code == http.StatusCreated {
+				var response models.ProjectMember
+				assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+				assert.Equal(t, friend.ID, response.UserID)
+				assert.Equal(t, "friend@example.com", response.User.Email)
+			}
+		})
+	}
+}
+
+func TestGetProjectMembers(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db, _ := test.SetupTestDB()
+	test.ClearTestData(db)
+	owner, err := test.CreateTestUser(db)
+	assert.NoError(t, err)
+	friend := &models.User{Email: "friend@example.com"}
+	db.Create(friend)
+	outsider := &models.User{Email: "outsider@example.com"}
+	db.Create(outsider)
+
+	project := &models.Project{Name: "Household", UserID: owner.ID}
+	db.Create(project)
+	db.Create(&models.ProjectMember{ProjectID: project.ID, UserID: friend.ID})
+
+	tests := []struct {
+		name         string
+		userID       uint
+		expectedCode int
+	}{
+		{name: "Owner", userID: owner.ID, expectedCode: http.StatusOK},
+		{name: "Member", userID: friend.ID, expectedCode: http.StatusOK},
+		{name: "Outsider", userID: outsider.ID, expectedCode: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			router.GET("/projects/:id/members", func(c *gin.Context) {
+				c.Set("userID", tt.userID)
+				GetProjectMembers(c)
+			})
+
+			req, _ := http.NewRequest("GET", fmt.Sprintf("/projects/%d/members", project.ID), nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+			if w.Code == http.StatusOK {
+				var response []models.ProjectMember
+				assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+				if assert.Len(t, response, 1) {
+					assert.Equal(t, friend.ID, response[0].UserID)
+				}
+			}
+		})
+	}
+}
+
+func TestRemoveProjectMember(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db, _ := test.SetupTestDB()
+	test.ClearTestData(db)
+	owner, err := test.CreateTestUser(db)
+	assert.NoError(t, err)
+	friend := &models.User{Email: "friend@example.com"}
+	db.Create(friend)
+	other := &models.User{Email: "other@example.com"}
+	db.Create(other)
+
+	project := &models.Project{Name: "Household", UserID: owner.ID}
+	db.Create(project)
+	db.Create(&models.ProjectMember{ProjectID: project.ID, UserID: friend.ID})
+	db.Create(&models.ProjectMember{ProjectID: project.ID, UserID: other.ID})
+	todo := &models.Todo{Title: "Fix the sink", UserID: owner.ID, ProjectID: &project.ID}
+	db.Create(todo)
+	db.Exec("INSERT INTO todo_assignees (todo_id, user_id) VALUES (?, ?), (?, ?), (?, ?)", todo.ID, owner.ID, todo.ID, friend.ID, todo.ID, other.ID)
+
+	tests := []struct {
+		name         string
+		userID       uint
+		memberID     uint
+		expectedCode int
+	}{
+		{
+			name:         "Members cannot remove each other",
+			userID:       other.ID,
+			memberID:     friend.ID,
+			expectedCode: http.StatusNotFound,
+		},
+		{
+			name:         "Owner removes a member",
+			userID:       owner.ID,
+			memberID:     friend.ID,
+			expectedCode: http.StatusNoContent,
+		},
+		{
+			name:         "Member leaves",
+			userID:       other.ID,
+			memberID:     other.ID,
+			expectedCode: http.StatusNoContent,
+		},
+		{
+			name:         "No longer a member",
+			userID:       owner.ID,
+			memberID:     friend.ID,
+			expectedCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			router.DELETE("/projects/:id/members/:userId", func(c *gin.Context) {
+				c.Set("userID", tt.userID)
+				RemoveProjectMember(c)
+			})
+
+			req, _ := http.NewRequest("DELETE", fmt.Sprintf("/projects/%d/members/%d", project.ID, tt.memberID), nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+		})
+	}
+
+	// Only the creator is still assigned
+	var assignees []uint
+	db.Table("todo_assignees").Where("todo_id = ?", todo.ID).Pluck("user_id", &assignees)
+	assert.Equal(t, []uint{owner.ID}, assignees)
+}
+
+func TestMemberAccessToSharedTodos(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db, _ := test.SetupTestDB()
+	test.ClearTestData(db)
+	owner, err := test.CreateTestUser(db)
+	assert.NoError(t, err)
+	member := &models.User{Email: "member@example.com"}
+	db.Create(member)
+
+	shared := &models.Project{Name: "Household", UserID: owner.ID}
+	db.Create(shared)
+	db.Create(&models.ProjectMember{ProjectID: shared.ID, UserID: member.ID})
+	private := &models.Project{Name: "Private", UserID: owner.ID}
+	db.Create(private)
+	other := &models.Project{Name: "Garden", UserID: owner.ID}
+	db.Create(other)
+
+	sharedTodo := &models.Todo{Title: "Fix the sink", Description: "- [ ] Buy a washer", UserID: owner.ID, ProjectID: &shared.ID}
+	db.Create(sharedTodo)
+	blocker := &models.Todo{Title: "Turn off the water", UserID: owner.ID, ProjectID: &shared.ID}
+	db.Create(blocker)
+	privateTodo := &models.Todo{Title: "Diary", Description: "- [ ] Write", UserID: owner.ID, ProjectID: &private.ID}
+	db.Create(privateTodo)
+
+	router := gin.New()
+	as := func(handler gin.HandlerFunc) gin.HandlerFunc {
+		return func(c *gin.Context) {
+			c.Set("userID", member.ID)
+			handler(c)
+		}
+	}
+	router.POST("/todos/:id/status", as(ChangeStatus))
+	router.POST("/todos/:id/checklist/:index", as(SetChecklistItem))
+	router.POST("/todos/:id/dependencies", as(AddDependency))
+	router.GET("/todos/:id/dependencies", as(GetDependencies))
+	router.POST("/todos/:id/snooze", as(SnoozeTodo))
+	router.DELETE("/todos/:id/snooze", as(UnsnoozeTodo))
+	router.POST("/todos/:id/reminders", as(CreateReminder))
+	router.GET("/todos/:id/reminders", as(GetReminders))
+	router.GET("/todos/:id/subtree", as(GetSubtree))
+	router.GET("/todos/:id/occurrences", as(GetOccurrences))
+	router.POST("/todos/:id/move", as(MoveTodo))
+	router.POST("/todos/:id/archive", as(ArchiveTodo))
+	router.POST("/todos/:id/duplicate", as(DuplicateTodo))
+	router.POST("/todos/:id/revert/:revision", as(RevertTodo))
+	router.DELETE("/todos/:id", as(DeleteTodo))
+
+	tests := []struct {
+		name         string
+		method       string
+		path         string
+		body         interface{}
+		expectedCode int
+	}{
+		{"Change status", "POST", "/todos/%d/status", map[string]interface{}{"status_id": 1}, http.StatusBadRequest}, // no workflow, but found
+		{"Tick a checklist item", "POST", "/todos/%d/checklist/0", nil, http.StatusOK},
+		{"Add a dependency", "POST", "/todos/%d/dependencies", map[string]interface{}{"blocked_by_id": blocker.ID}, http.StatusCreated},
+		{"Get dependencies", "GET", "/todos/%d/dependencies", nil, http.StatusOK},
+		{"Snooze", "POST", "/todos/%d/snooze", map[string]interface{}{"preset": "tomorrow"}, http.StatusOK},
+		{"Wake", "DELETE", "/todos/%d/snooze", nil, http.StatusOK},
+		{"Add a reminder", "POST", "/todos/%d/reminders", map[string]interface{}{"remind_at": "2030-01-31T08:00:00Z"}, http.StatusCreated},
+		{"Get reminders", "GET", "/todos/%d/reminders", nil, http.StatusOK},
+		{"Get subtree", "GET", "/todos/%d/subtree", nil, http.StatusOK},
+		{"Get occurrences", "GET", "/todos/%d/occurrences", nil, http.StatusOK},
+		{"Move stays with the creator", "POST", "/todos/%d/move", map[string]interface{}{"project_id": other.ID}, http.StatusNotFound},
+		{"Archive stays with the creator", "POST", "/todos/%d/archive", nil, http.StatusNotFound},
+		{"Duplicate stays with the creator", "POST", "/todos/%d/duplicate", map[string]interface{}{}, http.StatusNotFound},
+		{"Revert stays with the creator", "POST", "/todos/%d/revert/1", nil, http.StatusNotFound},
+		{"Delete stays with the creator", "DELETE", "/todos/%d", nil, http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			send := func(todoID uint) int {
+				var body bytes.Buffer
+				if tt.body != nil {
+					json.NewEncoder(&body).Encode(tt.body)
+				}
+				req, _ := http.NewRequest(tt.method, fmt.Sprintf(tt.path, todoID), &body)
+				req.Header.Set("Content-Type", "application/json")
+				w := httptest.NewRecorder()
+				router.ServeHTTP(w, req)
+				return w.Code
+			}
+
+			assert.Equal(t, tt.expectedCode, send(sharedTodo.ID))
+			assert.Equal(t, http.StatusNotFound, send(privateTodo.ID))
+		})
+	}
+
+	// The reminder is the member's own
+	var reminder models.Reminder
+	db.Where("todo_id = ? AND event = ?", sharedTodo.ID, models.EventReminder).First(&reminder)
+	assert.Equal(t, member.ID, reminder.UserID)
+}
+
+func TestMemberAccessToSharedProjects(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db, _ := test.SetupTestDB()
+	test.ClearTestData(db)
+	owner, err := test.CreateTestUser(db)
+	assert.NoError(t, err)
+	member := &models.User{Email: "member@example.com"}
+	db.Create(member)
+
+	shared := &models.Project{Name: "Household", UserID: owner.ID}
+	db.Create(shared)
+	db.Create(&models.ProjectMember{ProjectID: shared.ID, UserID: member.ID})
+	private := &models.Project{Name: "Private", UserID: owner.ID}
+	db.Create(private)
+	db.Create(&models.Todo{Title: "Fix the sink", UserID: owner.ID, ProjectID: &shared.ID})
+
+	as := func(user *models.User, handler gin.HandlerFunc) gin.HandlerFunc {
+		return func(c *gin.Context) {
+			c.Set("userID", user.ID)
+			handler(c)
+		}
+	}
+	router := gin.New()
+	router.POST("/workflows", as(owner, CreateWorkflow))
+	router.GET("/projects", as(member, GetProjects))
+	router.GET("/projects/:id", as(member, GetProject))
+	router.GET("/projects/:id/stats", as(member, GetProjectStats))
+	router.GET("/workflows/:id/board", as(member, GetBoard))
+	router.POST("/todos", as(member, CreateTodo))
+	router.POST("/todos/:id/move", as(member, MoveTodo))
+	send := func(method string, url string, payload interface{}) (int, []byte) {
+		jsonBody, _ := json.Marshal(payload)
+		req, _ := http.NewRequest(method, url, bytes.NewBuffer(jsonBody))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code, w.Body.Bytes()
+	}
+
+	code, body := send("POST", "/workflows", map[string]interface{}{"name": "Chores", "project_id": shared.ID, "statuses": kanbanStatuses, "transitions": kanbanTransitions})
+	assert.Equal(t, http.StatusCreated, code)
+	var workflow models.Workflow
+	assert.NoError(t, json.Unmarshal(body, &workflow))
+
+	// The shared project is listed next to the member's inbox, the private one is not
+	code, body = send("GET", "/projects", nil)
+	assert.Equal(t, http.StatusOK, code)
+	var projects []models.Project
+	assert.NoError(t, json.Unmarshal(body, &projects))
+	var names []string
+	for _, project := range projects {
+		names = append(names, project.Name)
+	}
+	assert.ElementsMatch(t, []string{inboxName, "Household"}, names)
+
+	code, _ = send("GET", fmt.Sprintf("/projects/%d", shared.ID), nil)
+	assert.Equal(t, http.StatusOK, code)
+	code, _ = send("GET", fmt.Sprintf("/projects/%d", private.ID), nil)
+	assert.Equal(t, http.StatusNotFound, code)
+	code, body = send("GET", fmt.Sprintf("/projects/%d/stats", shared.ID), nil)
+	assert.Equal(t, http.StatusOK, code)
+	var stats ProjectStats
+	assert.NoError(t, json.Unmarshal(body, &stats))
+	assert.Equal(t, int64(1), stats.Total)
+	code, _ = send("GET", fmt.Sprintf("/projects/%d/stats", private.ID), nil)
+	assert.Equal(t, http.StatusNotFound, code)
+
+	// Todos the member adds follow the project's workflow
+	code, body = send("POST", "/todos", map[string]interface{}{"title": "Buy a washer", "project_id": shared.ID})
+	assert.Equal(t, http.StatusCreated, code)
+	var created models.Todo
+	assert.NoError(t, json.Unmarshal(body, &created))
+	if assert.NotNil(t, created.StatusID) {
+		assert.Equal(t, workflow.Statuses[0].ID, *created.StatusID)
+	}
+	code, _ = send("POST", "/todos", map[string]interface{}{"title": "Read the diary", "project_id": private.ID})
+	assert.Equal(t, http.StatusBadRequest, code)
+
+	own := &models.Todo{Title: "Call the plumber", UserID: member.ID}
+	db.Create(own)
+	code, _ = send("POST", fmt.Sprintf("/todos/%d/move", own.ID), map[string]interface{}{"project_id": shared.ID})
+	assert.Equal(t, http.StatusOK, code)
+
+	code, body = send("GET", fmt.Sprintf("/workflows/%d/board", workflow.ID), nil)
+	assert.Equal(t, http.StatusOK, code)
+	var board Board
+	assert.NoError(t, json.Unmarshal(body, &board))
+	var titles []string
+	for _, column := range board.Columns {
+		for _, todo := range column.Todos {
+			titles = append(titles, todo.Title)
+		}
+	}
+	assert.ElementsMatch(t, []string{"Fix the sink", "Buy a washer", "Call the plumber"}, titles)
+}
@@ -0,0 +1,167 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"todo-api/internal/database"
+	"todo-api/internal/models"
+	"todo-api/internal/quickadd"
+)
+
+type QuickAddRequest struct {
+	Text string `json:"text" example:"Pay rent every month on the 1st #finance !high" binding:"required"`
+}
+
+// QuickAddResponse pairs what was understood in the text with the todo it
+// makes. In a preview the todo is not saved and has no ID.
+type QuickAddResponse struct {
+	Parsed quickadd.Result `json:"parsed"`
+	Todo   models.Todo     `json:"todo"`
+}
+
+// @Summary Preview a quick-add
+// @Description Parse a line of text into a todo without creating it, so clients can show what will be created
+// @Tags todos
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body QuickAddRequest true "Text to parse"
+// @Success 200 {object} QuickAddResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/todos/quick-add/preview [post]
+func PreviewQuickAdd(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	var req QuickAddRequest
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	parsed, todo, err := parseQuickAdd(database.GetDB(), userID.(uint), req.Text)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	for _, name := range parsed.Tags {
+		var tags []models.Tag
+		if err := database.GetDB().Where("user_id = ? AND LOWER(name) = ?", todo.UserID, strings.ToLower(name)).Limit(1).Find(&tags).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+			return
+		}
+		if len(tags) == 0 {
+			tags = append(tags, models.Tag{Name: name, UserID: todo.UserID})
+		}
+		todo.Tags = append(todo.Tags, tags[0])
+	}
+
+	c.JSON(http.StatusOK, QuickAddResponse{Parsed: *parsed, Todo: *todo})
+}
+
+// @Summary Quick-add a todo
+// @Description Create a todo from a line of text such as "Pay rent every month on the 1st #finance !high". Dates are read in the user's timezone, #tags that do not exist yet are created and +project must name one of the user's projects.
+// @Tags todos
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body QuickAddRequest true "Text to parse"
+// @Success 201 {object} QuickAddResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/todos/quick-add [post]
+func QuickAdd(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	var req QuickAddRequest
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	parsed, todo, err := parseQuickAdd(database.GetDB(), userID.(uint), req.Text)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	err = database.GetDB().Transaction(func(tx *gorm.DB) error {
+		tagIDs, err := findOrCreateTags(tx, todo.UserID, parsed.Tags)
+		if err != nil {
+			return err
+		}
+		if err := tx.Omit(clause.Associations).Create(todo).Error; err != nil {
+			return err
+		}
+		for _, tagID := range tagIDs {
+			if err := tx.Exec("INSERT INTO todo_tags (todo_id, tag_id) VALUES (?, ?)", todo.ID, tagID).Error; err != nil {
+				return err
+			}
+		}
+		if err := startSeries(tx, todo); err != nil {
+			return err
+		}
+		if err := afterCompletionChange(tx, todo, false); err != nil {
+			return err
+		}
+		if err := recordRevision(tx, todoFields{}, todo.ID, todo.UserID); err != nil {
+			return err
+		}
+		return runRules(tx, models.TriggerCreated, todo)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	database.GetDB().Preload("User").Preload("Assignees").Preload("Tags").Preload("CustomFields").First(todo, todo.ID)
+
+	c.JSON(http.StatusCreated, QuickAddResponse{Parsed: *parsed, Todo: *todo})
+}
+
+// parseQuickAdd parses text in the user's timezone and builds the todo it
+// describes, with its project resolved but without tags.
+func parseQuickAdd(db *gorm.DB, userID uint, text string) (*quickadd.Result, *models.Todo, error) {
+	loc, err := userLocation(db, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	parsed := quickadd.Parse(text, time.Now().In(loc))
+	if parsed.Title == "" {
+		return nil, nil, fmt.Errorf("text must contain a title besides its date, tags and other details")
+	}
+
+	todo := &models.Todo{
+		Title:      parsed.Title,
+		UserID:     userID,
+		Priority:   parsed.Priority,
+		DueDate:    parsed.DueDate,
+		Recurrence: parsed.Recurrence,
+	}
+	if parsed.Project != "" {
+		var projects []models.Project
+		err := accessibleProjects(db, userID).Where("projects.archived = ? AND LOWER(projects.name) = ?", false, strings.ToLower(parsed.Project)).
+			Order("projects.id").Limit(1).Find(&projects).Error
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(projects) == 0 {
+			return nil, nil, fmt.Errorf("project %q not found", parsed.Project)
+		}
+		todo.ProjectID = &projects[0].ID
+	}
+	if err := assignProject(db, todo); err != nil {
+		return nil, nil, err
+	}
+	return &parsed, todo, nil
+}
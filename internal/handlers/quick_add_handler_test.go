@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"todo-api/internal/models"
+	"todo-api/internal/test"
+)
+
+func TestQuickAdd(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db, _ := test.SetupTestDB()
+	test.ClearTestData(db)
+	testUser, err := test.CreateTestUser(db)
+	assert.NoError(t, err)
+	db.Model(testUser).Update("timezone", "Asia/Tokyo")
+	tokyo, _ := time.LoadLocation("Asia/Tokyo")
+
+	home := &models.Project{Name: "Home", UserID: testUser.ID}
+	db.Create(home)
+	finance := &models.Tag{Name: "Finance", UserID: testUser.ID}
+	db.Create(finance)
+
+	router := gin.New()
+	router.POST("/todos/quick-add", func(c *gin.Context) {
+		c.Set("userID", testUser.ID)
+		QuickAdd(c)
+	})
+	router.POST("/todos/quick-add/preview", func(c *gin.Context) {
+		c.Set("userID", testUser.ID)
+		PreviewQuickAdd(c)
+	})
+	post := func(url, text string) *httptest.ResponseRecorder {
+		jsonBody, _ := json.Marshal(map[string]string{"text": text})
+		req, _ := http.NewRequest("POST", url, bytes.NewBuffer(jsonBody))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("creates the parsed todo", func(t *testing.T) {
+		w := post("/todos/quick-add", "Pay rent every month on the 1st #finance #bills !high +home")
+		assert.Equal(t, http.StatusCreated, w.Code)
+
+		var response QuickAddResponse
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Len(t, response.Parsed.Tokens, 5)
+
+		todo := response.Todo
+		assert.NotZero(t, todo.ID)
+		assert.Equal(t, "Pay rent", todo.Title)
+		assert.Equal(t, models.PriorityHigh, todo.Priority)
+		assert.Equal(t, "FREQ=MONTHLY;BYMONTHDAY=1", todo.Recurrence)
+		assert.Equal(t, home.ID, *todo.ProjectID)
+		assert.Equal(t, todo.ID, *todo.SeriesID)
+		// Due at midnight on the next 1st of the month in Tokyo
+		if assert.NotNil(t, todo.DueDate) {
+			due := todo.DueDate.In(tokyo)
+			assert.Equal(t, 1, due.Day())
+			assert.Equal(t, 0, due.Hour())
+			assert.True(t, due.After(time.Now()))
+		}
+
+		names := map[string]uint{}
+		for _, tag := range todo.Tags {
+			names[tag.Name] = tag.ID
+		}
+		assert.Equal(t, finance.ID, names["Finance"])
+		assert.NotZero(t, names["bills"])
+
+		var revisions int64
+		db.Model(&models.TodoRevision{}).Where("todo_id = ?", todo.ID).Count(&revisions)
+		assert.Equal(t, int64(1), revisions)
+	})
+
+	t.Run("preview saves nothing", func(t *testing.T) {
+		var todosBefore, tagsBefore int64
+		db.Model(&models.Todo{}).Count(&todosBefore)
+		db.Model(&models.Tag{}).Count(&tagsBefore)
+
+		w := post("/todos/quick-add/preview", "Call the bank tomorrow at 9am #errands !low")
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var response QuickAddResponse
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Zero(t, response.Todo.ID)
+		assert.Equal(t, "Call the bank", response.Parsed.Title)
+		assert.Equal(t, models.PriorityLow, response.Todo.Priority)
+		if assert.Len(t, response.Todo.Tags, 1) {
+			assert.Equal(t, "errands", response.Todo.Tags[0].Name)
+			assert.Zero(t, response.Todo.Tags[0].ID)
+		}
+		if assert.NotNil(t, response.Todo.DueDate) {
+			tomorrow := time.Now().In(tokyo).AddDate(0, 0, 1)
+			due := response.Todo.DueDate.In(tokyo)
+			assert.Equal(t, tomorrow.Day(), due.Day())
+			assert.Equal(t, 9, due.Hour())
+		}
+		// Without a project reference the todo goes to the inbox
+		assert.NotNil(t, response.Todo.ProjectID)
+
+		var todosAfter, tagsAfter int64
+		db.Model(&models.Todo{}).Count(&todosAfter)
+		db.Model(&models.Tag{}).Count(&tagsAfter)
+		assert.Equal(t, todosBefore, todosAfter)
+		assert.Equal(t, tagsBefore, tagsAfter)
+	})
+
+	errorCases := []struct {
+		name string
+		text string
+	}{
+		{name: "Unknown project", text: "Plant tomatoes +garden"},
+		{name: "Nothing left for the title", text: "tomorrow #finance !high"},
+		{name: "Empty text", text: ""},
+	}
+	for _, tt := range errorCases {
+		t.Run(tt.name, func(t *testing.T) {
+			w := post("/todos/quick-add", tt.text)
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+		})
+	}
+}
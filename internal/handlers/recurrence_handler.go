@@ -0,0 +1,262 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"todo-api/internal/database"
+	"todo-api/internal/models"
+	"todo-api/internal/recurrence"
+)
+
+// @Summary Skip an occurrence of a recurring todo
+// @Description Move a recurring todo on to its next occurrence without completing this one
+// @Tags todos
+// @Produce json
+// @Security Bearer
+// @Param id path int true "Todo ID"
+// @Success 200 {object} models.Todo
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/todos/{id}/skip [post]
+func SkipOccurrence(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	id := c.Param("id")
+	var todo models.Todo
+
+	if err := accessibleTodos(database.GetDB(), userID.(uint)).Where("todos.id = ?", id).First(&todo).Error; err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Todo not found"})
+		return
+	}
+
+	if todo.Recurrence == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Todo is not recurring"})
+		return
+	}
+	if todo.Completed {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Completed occurrences cannot be skipped"})
+		return
+	}
+
+	rule, err := recurrence.Parse(todo.Recurrence)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	loc, err := userLocation(database.GetDB(), todo.UserID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	next, ok := nextOccurrenceDue(&todo, rule, time.Now(), loc)
+	if !ok {
+		c.JSON(http.StatusConflict, ErrorResponse{Error: "The series has no more occurrences"})
+		return
+	}
+
+	before := snapshotTodo(&todo)
+	err = database.GetDB().Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&todo).Updates(map[string]interface{}{
+			"due_date":   next,
+			"occurrence": occurrenceNumber(&todo) + 1,
+		}).Error
+		if err != nil {
+			return err
+		}
+		todo.DueDate = &next
+		if err := rescheduleReminders(tx, &todo); err != nil {
+			return err
+		}
+		if err := recordRevision(tx, before, todo.ID, userID.(uint)); err != nil {
+			return err
+		}
+		return runRules(tx, models.TriggerUpdated, &todo)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	database.GetDB().Preload("User").Preload("Assignees").Preload("Tags").First(&todo, todo.ID)
+
+	c.JSON(http.StatusOK, todo)
+}
+
+// @Summary Get the occurrences of a recurring todo
+// @Description Get every occurrence in a recurring todo's series, including completed ones
+// @Tags todos
+// @Produce json
+// @Security Bearer
+// @Param id path int true "Todo ID"
+// @Success 200 {array} models.Todo
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/todos/{id}/occurrences [get]
+func GetOccurrences(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	id := c.Param("id")
+	var todo models.Todo
+
+	if err := accessibleTodos(database.GetDB(), userID.(uint)).Where("todos.id = ?", id).First(&todo).Error; err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Todo not found"})
+		return
+	}
+
+	if todo.SeriesID == nil {
+		c.JSON(http.StatusOK, []models.Todo{todo})
+		return
+	}
+
+	var todos []models.Todo
+	result := accessibleTodos(database.GetDB(), userID.(uint)).Preload("Tags").
+		Where("todos.series_id = ?", *todo.SeriesID).
+		Order("occurrence").
+		Find(&todos)
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: result.Error.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, todos)
+}
+
+// validateRecurrence checks that an optional recurrence rule can be parsed.
+func validateRecurrence(rule string) error {
+	if rule == "" {
+		return nil
+	}
+	_, err := recurrence.Parse(rule)
+	return err
+}
+
+// startSeries makes a todo with a recurrence rule the first occurrence of
+// its own series, unless it already belongs to one.
+func startSeries(tx *gorm.DB, todo *models.Todo) error {
+	if todo.Recurrence == "" || todo.SeriesID != nil {
+		return nil
+	}
+
+	todo.SeriesID = &todo.ID
+	todo.Occurrence = occurrenceNumber(todo)
+	return tx.Model(todo).Updates(map[string]interface{}{
+		"series_id":  todo.SeriesID,
+		"occurrence": todo.Occurrence,
+	}).Error
+}
+
+// spawnNextOccurrence creates the occurrence that follows a completed
+// recurring todo. It returns nil when the series has ended or the next
+// occurrence already exists.
+func spawnNextOccurrence(tx *gorm.DB, todo *models.Todo) (*models.Todo, error) {
+	rule, err := recurrence.Parse(todo.Recurrence)
+	if err != nil {
+		return nil, err
+	}
+	if err := startSeries(tx, todo); err != nil {
+		return nil, err
+	}
+
+	var existing int64
+	err = tx.Model(&models.Todo{}).
+		Where("series_id = ? AND occurrence > ?", *todo.SeriesID, todo.Occurrence).
+		Count(&existing).Error
+	if err != nil || existing > 0 {
+		return nil, err
+	}
+
+	completedAt := time.Now()
+	if todo.CompletedAt != nil {
+		completedAt = *todo.CompletedAt
+	}
+	loc, err := userLocation(tx, todo.UserID)
+	if err != nil {
+		return nil, err
+	}
+	due, ok := nextOccurrenceDue(todo, rule, completedAt, loc)
+	if !ok {
+		return nil, nil
+	}
+
+	next := models.Todo{
+		Title:               todo.Title,
+		Description:         todo.Description,
+		Priority:            todo.Priority,
+		UserID:              todo.UserID,
+		ProjectID:           todo.ProjectID,
+		ParentID:            todo.ParentID,
+		AutoComplete:        todo.AutoComplete,
+		DueDate:             &due,
+		Recurrence:          todo.Recurrence,
+		RecurFromCompletion: todo.RecurFromCompletion,
+		SeriesID:            todo.SeriesID,
+		Occurrence:          todo.Occurrence + 1,
+	}
+	if err := tx.Omit(clause.Associations).Create(&next).Error; err != nil {
+		return nil, err
+	}
+
+	err = tx.Exec("INSERT INTO todo_tags (todo_id, tag_id) SELECT ?, tag_id FROM todo_tags WHERE todo_id = ?", next.ID, todo.ID).Error
+	if err != nil {
+		return nil, err
+	}
+	err = tx.Exec("INSERT INTO todo_assignees (todo_id, user_id) SELECT ?, user_id FROM todo_assignees WHERE todo_id = ?", next.ID, todo.ID).Error
+	if err != nil {
+		return nil, err
+	}
+	if err := copyCustomFields(tx, todo.ID, next.ID); err != nil {
+		return nil, err
+	}
+	if err := copyReminders(tx, todo, &next); err != nil {
+		return nil, err
+	}
+	if err := syncStatus(tx, &next); err != nil {
+		return nil, err
+	}
+
+	return &next, nil
+}
+
+// nextOccurrenceDue works out when the occurrence after todo falls due. Fixed
+// schedules continue from the current due date, never landing in the past;
+// "after completion" schedules count from the day the todo was done, keeping
+// the time of day it was due at. Dates are evaluated in loc.
+func nextOccurrenceDue(todo *models.Todo, rule *recurrence.Rule, now time.Time, loc *time.Location) (time.Time, bool) {
+	if rule.Count > 0 && occurrenceNumber(todo) >= rule.Count {
+		return time.Time{}, false
+	}
+
+	now = now.In(loc)
+	if todo.DueDate == nil {
+		return rule.Next(now, now)
+	}
+
+	due := todo.DueDate.In(loc)
+	if todo.RecurFromCompletion {
+		anchor := time.Date(now.Year(), now.Month(), now.Day(), due.Hour(), due.Minute(), due.Second(), 0, loc)
+		return rule.Next(anchor, anchor)
+	}
+
+	after := due
+	if now.After(after) {
+		after = now
+	}
+	return rule.Next(due, after)
+}
+
+// occurrenceNumber returns the todo's position in its series, treating todos
+// that predate recurrence support as the first occurrence.
+func occurrenceNumber(todo *models.Todo) int {
+	if todo.Occurrence < 1 {
+		return 1
+	}
+	return todo.Occurrence
+}
@@ -0,0 +1,204 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"todo-api/internal/models"
+	"todo-api/internal/test"
+)
+
+func TestCompleteRecurringTodo(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db, _ := test.SetupTestDB()
+
+	future := time.Now().UTC().Add(48 * time.Hour).Truncate(time.Second)
+
+	tests := []struct {
+		name      string
+		rule      string
+		fromDone  bool
+		wantSpawn bool
+		wantDue   func(completedAt time.Time) time.Time
+	}{
+		{
+			name:      "Weekly rule shifts the due date by a week",
+			rule:      "FREQ=WEEKLY",
+			wantSpawn: true,
+			wantDue:   func(time.Time) time.Time { return future.AddDate(0, 0, 7) },
+		},
+		{
+			name:      "Repeat three days after completion",
+			rule:      "FREQ=DAILY;INTERVAL=3",
+			fromDone:  true,
+			wantSpawn: true,
+			wantDue: func(completedAt time.Time) time.Time {
+				return time.Date(completedAt.Year(), completedAt.Month(), completedAt.Day(),
+					future.Hour(), future.Minute(), future.Second(), 0, time.UTC).AddDate(0, 0, 3)
+			},
+		},
+		{
+			name:      "Series with a single occurrence ends",
+			rule:      "FREQ=DAILY;COUNT=1",
+			wantSpawn: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+
+			test.ClearTestData(db)
+			testUser, err := test.CreateTestUser(db)
+			assert.NoError(t, err)
+			tag := &models.Tag{Name: "chores", UserID: testUser.ID}
+			db.Create(tag)
+			todo := &models.Todo{
+				Title:               "Water plants",
+				UserID:              testUser.ID,
+				DueDate:             &future,
+				Recurrence:          tt.rule,
+				RecurFromCompletion: tt.fromDone,
+				Tags:                []models.Tag{*tag},
+			}
+			db.Create(todo)
+			db.Model(todo).Updates(map[string]interface{}{"series_id": todo.ID, "occurrence": 1})
+
+			router.PUT("/todos/:id", func(c *gin.Context) {
+				c.Set("userID", testUser.ID)
+				UpdateTodo(c)
+			})
+
+			jsonBody, _ := json.Marshal(map[string]interface{}{
+				"title":                 todo.Title,
+				"completed":             true,
+				"due_date":              future,
+				"recurrence":            tt.rule,
+				"recur_from_completion": tt.fromDone,
+			})
+			req, _ := http.NewRequest("PUT", fmt.Sprintf("/todos/%d", todo.ID), bytes.NewBuffer(jsonBody))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusOK, w.Code)
+
+			var completed models.Todo
+			db.First(&completed, todo.ID)
+			assert.True(t, completed.Completed)
+			assert.NotNil(t, completed.CompletedAt)
+
+			var next []models.Todo
+			db.Preload("Tags").Where("series_id = ? AND occurrence = ?", todo.ID, 2).Find(&next)
+			if !tt.wantSpawn {
+				assert.Empty(t, next)
+				return
+			}
+
+			assert.Len(t, next, 1)
+			assert.False(t, next[0].Completed)
+			assert.True(t, tt.wantDue(completed.CompletedAt.UTC()).Equal(*next[0].DueDate), "due %v", next[0].DueDate)
+			assert.Len(t, next[0].Tags, 1)
+		})
+	}
+}
+
+func TestSkipOccurrence(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db, _ := test.SetupTestDB()
+
+	due := time.Date(2030, time.January, 15, 9, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		rule       string
+		wantStatus int
+	}{
+		{
+			name:       "Skip to the next month",
+			rule:       "FREQ=MONTHLY",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "Not recurring",
+			rule:       "",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Last occurrence cannot be skipped",
+			rule:       "FREQ=MONTHLY;COUNT=1",
+			wantStatus: http.StatusConflict,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+
+			test.ClearTestData(db)
+			testUser, err := test.CreateTestUser(db)
+			assert.NoError(t, err)
+			todo := &models.Todo{Title: "Pay rent", UserID: testUser.ID, DueDate: &due, Recurrence: tt.rule, Occurrence: 1}
+			db.Create(todo)
+
+			router.POST("/todos/:id/skip", func(c *gin.Context) {
+				c.Set("userID", testUser.ID)
+				SkipOccurrence(c)
+			})
+
+			req, _ := http.NewRequest("POST", fmt.Sprintf("/todos/%d/skip", todo.ID), nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+
+			if w.Code == http.StatusOK {
+				var response models.Todo
+				err := json.Unmarshal(w.Body.Bytes(), &response)
+				assert.NoError(t, err)
+				assert.Equal(t, 2, response.Occurrence)
+				assert.True(t, due.AddDate(0, 1, 0).Equal(*response.DueDate))
+				assert.False(t, response.Completed)
+			}
+		})
+	}
+}
+
+func TestSkipOccurrenceInUserTimezone(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db, _ := test.SetupTestDB()
+	test.ClearTestData(db)
+	testUser, err := test.CreateTestUser(db)
+	assert.NoError(t, err)
+	db.Model(testUser).Update("timezone", "Europe/Berlin")
+
+	// Monday evening in UTC is already Tuesday in Berlin
+	due := time.Date(2030, time.January, 14, 23, 30, 0, 0, time.UTC)
+	todo := &models.Todo{Title: "Team sync", UserID: testUser.ID, DueDate: &due, Recurrence: "FREQ=WEEKLY;BYDAY=TU", Occurrence: 1}
+	db.Create(todo)
+
+	router := gin.New()
+	router.POST("/todos/:id/skip", func(c *gin.Context) {
+		c.Set("userID", testUser.ID)
+		SkipOccurrence(c)
+	})
+
+	req, _ := http.NewRequest("POST", fmt.Sprintf("/todos/%d/skip", todo.ID), nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response models.Todo
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.True(t, due.AddDate(0, 0, 7).Equal(*response.DueDate), "got %s", response.DueDate)
+}
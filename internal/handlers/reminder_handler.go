@@ -0,0 +1,194 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"todo-api/internal/database"
+	"todo-api/internal/models"
+	"todo-api/internal/notify"
+)
+
+type ReminderRequest struct {
+	RemindAt      *time.Time `json:"remind_at" example:"2026-01-31T08:00:00Z"`
+	OffsetMinutes *int       `json:"offset_minutes" binding:"omitempty,min=0" example:"30"`
+	Channel       string     `json:"channel" binding:"omitempty,oneof=email webhook in_app" example:"in_app"`
+}
+
+// @Summary Add a reminder to a todo
+// @Description Remind the user at a fixed time, or a number of minutes before the todo is due
+// @Tags reminders
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "Todo ID"
+// @Param request body ReminderRequest true "Reminder"
+// @Success 201 {object} models.Reminder
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/todos/{id}/reminders [post]
+func CreateReminder(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	id := c.Param("id")
+	var todo models.Todo
+
+	if err := accessibleTodos(database.GetDB(), userID.(uint)).Where("todos.id = ?", id).First(&todo).Error; err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Todo not found"})
+		return
+	}
+
+	var req ReminderRequest
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	if (req.RemindAt == nil) == (req.OffsetMinutes == nil) {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Exactly one of remind_at or offset_minutes is required"})
+		return
+	}
+	if req.OffsetMinutes != nil && todo.DueDate == nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Relative reminders need a todo with a due date"})
+		return
+	}
+
+	reminder := models.Reminder{
+		TodoID:        todo.ID,
+		UserID:        userID.(uint),
+		RemindAt:      req.RemindAt,
+		OffsetMinutes: req.OffsetMinutes,
+		Channel:       req.Channel,
+		Event:         models.EventReminder,
+		Status:        models.ReminderPending,
+	}
+	if reminder.Channel == "" {
+		reminder.Channel = notify.ChannelInApp
+	}
+	reminder.Schedule(todo.DueDate)
+
+	result := database.GetDB().Create(&reminder)
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: result.Error.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, reminder)
+}
+
+// @Summary Get a todo's reminders
+// @Description Get the reminders the user set on a todo
+// @Tags reminders
+// @Produce json
+// @Security Bearer
+// @Param id path int true "Todo ID"
+// @Success 200 {array} models.Reminder
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/todos/{id}/reminders [get]
+func GetReminders(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	id := c.Param("id")
+	var todo models.Todo
+
+	if err := accessibleTodos(database.GetDB(), userID.(uint)).Where("todos.id = ?", id).First(&todo).Error; err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Todo not found"})
+		return
+	}
+
+	var reminders []models.Reminder
+	result := database.GetDB().Where("todo_id = ? AND user_id = ?", todo.ID, userID).Order("fire_at").Find(&reminders)
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: result.Error.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, reminders)
+}
+
+// @Summary Delete a reminder
+// @Description Delete a reminder so that it no longer fires
+// @Tags reminders
+// @Security Bearer
+// @Param id path int true "Reminder ID"
+// @Success 204 "No Content"
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/reminders/{id} [delete]
+func DeleteReminder(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	id := c.Param("id")
+	var reminder models.Reminder
+
+	if err := database.GetDB().Where("id = ? AND user_id = ?", id, userID).First(&reminder).Error; err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Reminder not found"})
+		return
+	}
+
+	result := database.GetDB().Delete(&reminder)
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: result.Error.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// rescheduleReminders moves the relative reminders of a todo whose due date
+// changed. Reminders that already fired are re-armed if they now lie ahead;
+// one that a dispatcher is sending is left to finish, so it is not sent twice.
+func rescheduleReminders(tx *gorm.DB, todo *models.Todo) error {
+	var reminders []models.Reminder
+	if err := tx.Where("todo_id = ? AND offset_minutes IS NOT NULL", todo.ID).Find(&reminders).Error; err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for _, reminder := range reminders {
+		reminder.Schedule(todo.DueDate)
+		updates := map[string]interface{}{"fire_at": reminder.FireAt}
+		rearm := reminder.Status != models.ReminderPending && reminder.Status != models.ReminderSending
+		if rearm && reminder.FireAt != nil && reminder.FireAt.After(now) {
+			updates["status"] = models.ReminderPending
+			updates["attempts"] = 0
+			updates["sent_at"] = nil
+			updates["last_error"] = ""
+		}
+		if err := tx.Model(&reminder).Updates(updates).Error; err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// copyReminders gives a new occurrence of a recurring todo the same relative
+// reminders as the occurrence before it.
+func copyReminders(tx *gorm.DB, from *models.Todo, to *models.Todo) error {
+	var reminders []models.Reminder
+	if err := tx.Where("todo_id = ? AND offset_minutes IS NOT NULL", from.ID).Find(&reminders).Error; err != nil {
+		return err
+	}
+
+	for _, reminder := range reminders {
+		copied := models.Reminder{
+			TodoID:        to.ID,
+			UserID:        reminder.UserID,
+			OffsetMinutes: reminder.OffsetMinutes,
+			Channel:       reminder.Channel,
+			Event:         models.EventReminder,
+			Status:        models.ReminderPending,
+		}
+		copied.Schedule(to.DueDate)
+		if err := tx.Create(&copied).Error; err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
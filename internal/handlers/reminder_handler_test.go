@@ -0,0 +1,154 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"todo-api/internal/models"
+	"todo-api/internal/test"
+)
+
+func TestCreateReminder(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db, _ := test.SetupTestDB()
+	testUser, err := test.CreateTestUser(db)
+	assert.NoError(t, err)
+
+	due := time.Now().UTC().Add(24 * time.Hour).Truncate(time.Second)
+	at := due.Add(-2 * time.Hour)
+
+	tests := []struct {
+		name         string
+		withDue      bool
+		payload      map[string]interface{}
+		expectedCode int
+		wantFireAt   *time.Time
+	}{
+		{
+			name:         "Fixed time reminder",
+			payload:      map[string]interface{}{"remind_at": at},
+			expectedCode: http.StatusCreated,
+			wantFireAt:   &at,
+		},
+		{
+			name:         "Relative reminder fires before the due date",
+			withDue:      true,
+			payload:      map[string]interface{}{"offset_minutes": 30, "channel": "email"},
+			expectedCode: http.StatusCreated,
+			wantFireAt:   func() *time.Time { t := due.Add(-30 * time.Minute); return &t }(),
+		},
+		{
+			name:         "Relative reminder without a due date",
+			payload:      map[string]interface{}{"offset_minutes": 30},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "Both time and offset",
+			withDue:      true,
+			payload:      map[string]interface{}{"remind_at": at, "offset_minutes": 30},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "Unknown channel",
+			payload:      map[string]interface{}{"remind_at": at, "channel": "pigeon"},
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+
+			test.ClearTestData(db)
+			todo := &models.Todo{Title: "Renew passport", UserID: testUser.ID}
+			if tt.withDue {
+				todo.DueDate = &due
+			}
+			db.Create(todo)
+
+			router.POST("/todos/:id/reminders", func(c *gin.Context) {
+				c.Set("userID", testUser.ID)
+				CreateReminder(c)
+			})
+
+			jsonBody, _ := json.Marshal(tt.payload)
+			req, _ := http.NewRequest("POST", fmt.Sprintf("/todos/%d/reminders", todo.ID), bytes.NewBuffer(jsonBody))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+			if tt.expectedCode == http.StatusCreated {
+				var response models.Reminder
+				err := json.Unmarshal(w.Body.Bytes(), &response)
+				assert.NoError(t, err)
+				assert.Equal(t, models.ReminderPending, response.Status)
+				assert.NotNil(t, response.FireAt)
+				assert.True(t, tt.wantFireAt.Equal(*response.FireAt))
+			}
+		})
+	}
+}
+
+func TestRescheduleRemindersOnDueDateChange(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db, _ := test.SetupTestDB()
+	testUser, err := test.CreateTestUser(db)
+	assert.NoError(t, err)
+	test.ClearTestData(db)
+
+	due := time.Now().UTC().Add(-time.Hour).Truncate(time.Second)
+	todo := &models.Todo{Title: "Call dentist", UserID: testUser.ID, DueDate: &due}
+	db.Create(todo)
+
+	offset := 15
+	fixed := due.Add(-3 * time.Hour)
+	relative := &models.Reminder{TodoID: todo.ID, UserID: testUser.ID, OffsetMinutes: &offset, Channel: "in_app", Status: models.ReminderSent}
+	relative.Schedule(todo.DueDate)
+	absolute := &models.Reminder{TodoID: todo.ID, UserID: testUser.ID, RemindAt: &fixed, Channel: "in_app", Status: models.ReminderSent}
+	absolute.Schedule(todo.DueDate)
+	claimedUntil := time.Now().UTC().Add(time.Minute)
+	sending := &models.Reminder{TodoID: todo.ID, UserID: testUser.ID, OffsetMinutes: &offset, Channel: "in_app", Status: models.ReminderSending, ClaimedUntil: &claimedUntil, Attempts: 1}
+	sending.Schedule(todo.DueDate)
+	db.Create(relative)
+	db.Create(absolute)
+	db.Create(sending)
+
+	router := gin.New()
+	router.PUT("/todos/:id", func(c *gin.Context) {
+		c.Set("userID", testUser.ID)
+		UpdateTodo(c)
+	})
+
+	newDue := time.Now().UTC().Add(48 * time.Hour).Truncate(time.Second)
+	jsonBody, _ := json.Marshal(map[string]interface{}{"title": "Call dentist", "due_date": newDue})
+	req, _ := http.NewRequest("PUT", fmt.Sprintf("/todos/%d", todo.ID), bytes.NewBuffer(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var rearmed, unchanged models.Reminder
+	db.First(&rearmed, relative.ID)
+	assert.Equal(t, models.ReminderPending, rearmed.Status)
+	assert.True(t, newDue.Add(-15*time.Minute).Equal(*rearmed.FireAt))
+
+	db.First(&unchanged, absolute.ID)
+	assert.Equal(t, models.ReminderSent, unchanged.Status)
+	assert.True(t, fixed.Equal(*unchanged.FireAt))
+
+	// A reminder being sent is left to its dispatcher
+	var inFlight models.Reminder
+	db.First(&inFlight, sending.ID)
+	assert.Equal(t, models.ReminderSending, inFlight.Status)
+	assert.Equal(t, 1, inFlight.Attempts)
+}
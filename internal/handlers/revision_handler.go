@@ -0,0 +1,316 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"todo-api/internal/database"
+	"todo-api/internal/models"
+)
+
+// todoFields are the parts of a todo whose changes are kept in its history.
+type todoFields struct {
+	Title               string     `json:"title"`
+	Description         string     `json:"description"`
+	Completed           bool       `json:"completed"`
+	Priority            int        `json:"priority"`
+	StatusID            *uint      `json:"status_id"`
+	ProjectID           *uint      `json:"project_id"`
+	ParentID            *uint      `json:"parent_id"`
+	AutoComplete        bool       `json:"auto_complete"`
+	DueDate             *time.Time `json:"due_date"`
+	Archived            bool       `json:"archived"`
+	Recurrence          string     `json:"recurrence"`
+	RecurFromCompletion bool       `json:"recur_from_completion"`
+}
+
+// @Summary Get a todo's history
+// @Description Get every recorded change to a todo, oldest first, with who made it and the fields it changed
+// @Tags todos
+// @Produce json
+// @Security Bearer
+// @Param id path int true "Todo ID"
+// @Success 200 {array} models.TodoRevision
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/todos/{id}/history [get]
+func GetTodoHistory(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	db := database.GetDB()
+
+	todo, err := findAccessibleTodo(db, userID.(uint), c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Todo not found"})
+		return
+	}
+
+	var revisions []models.TodoRevision
+	result := db.Where("todo_id = ?", todo.ID).Order("number").Find(&revisions)
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: result.Error.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, revisions)
+}
+
+// @Summary Revert a todo to an earlier revision
+// @Description Put a todo's fields back to how they were right after the given revision. The revert is recorded as a new revision. Only the todo's creator can revert it, since a revert can move or archive it.
+// @Tags todos
+// @Produce json
+// @Security Bearer
+// @Param id path int true "Todo ID"
+// @Param revision path int true "Revision number"
+// @Param force query bool false "Complete the todo even if it is blocked by open todos"
+// @Success 200 {object} models.Todo
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/todos/{id}/revert/{revision} [post]
+func RevertTodo(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	id := c.Param("id")
+	db := database.GetDB()
+	var todo models.Todo
+
+	if err := db.Where("id = ? AND user_id = ?", id, userID).First(&todo).Error; err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Todo not found"})
+		return
+	}
+
+	number, err := strconv.Atoi(c.Param("revision"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Revision must be a number"})
+		return
+	}
+	var count int64
+	if err := db.Model(&models.TodoRevision{}).Where("todo_id = ? AND number = ?", todo.ID, number).Count(&count).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+	if count == 0 {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Revision not found"})
+		return
+	}
+
+	target, err := fieldsAt(db, &todo, number)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	moveTo := todo.ProjectID
+	if target.ProjectID != nil && (todo.ProjectID == nil || *target.ProjectID != *todo.ProjectID) {
+		project, err := findActiveProject(db, todo.UserID, *target.ProjectID)
+		if err != nil {
+			c.JSON(http.StatusConflict, ErrorResponse{Error: "Cannot revert: " + err.Error()})
+			return
+		}
+		moveTo = &project.ID
+	}
+	if err := validateParent(db, todo.UserID, todo.ID, moveTo, target.ParentID); err != nil {
+		c.JSON(http.StatusConflict, ErrorResponse{Error: "Cannot revert: " + err.Error()})
+		return
+	}
+
+	if target.Completed && !todo.Completed && c.Query("force") != "true" {
+		blockers, err := openBlockers(db, todo.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+			return
+		}
+		if len(blockers) > 0 {
+			c.JSON(http.StatusConflict, ErrorResponse{Error: blockedError(blockers)})
+			return
+		}
+	}
+
+	before := snapshotTodo(&todo)
+	previousParentID := todo.ParentID
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if moveTo != nil && (todo.ProjectID == nil || *moveTo != *todo.ProjectID) {
+			if err := moveTodo(tx, &todo, *moveTo); err != nil {
+				return err
+			}
+		}
+
+		archivedAt := todo.ArchivedAt
+		if target.Archived != todo.Archived {
+			archivedAt = nil
+			if target.Archived {
+				now := time.Now()
+				archivedAt = &now
+			}
+		}
+		err := tx.Model(&todo).Updates(map[string]interface{}{
+			"title":                 target.Title,
+			"description":           target.Description,
+			"priority":              target.Priority,
+			"status_id":             target.StatusID,
+			"parent_id":             target.ParentID,
+			"auto_complete":         target.AutoComplete,
+			"due_date":              target.DueDate,
+			"archived":              target.Archived,
+			"archived_at":           archivedAt,
+			"recurrence":            target.Recurrence,
+			"recur_from_completion": target.RecurFromCompletion,
+		}).Error
+		if err != nil {
+			return err
+		}
+
+		todo.StatusID = target.StatusID
+		todo.ParentID = target.ParentID
+		todo.DueDate = target.DueDate
+		todo.Recurrence = target.Recurrence
+		todo.RecurFromCompletion = target.RecurFromCompletion
+		if err := startSeries(tx, &todo); err != nil {
+			return err
+		}
+		if err := rescheduleReminders(tx, &todo); err != nil {
+			return err
+		}
+		if todo.Completed != target.Completed {
+			err = setCompletion(tx, &todo, target.Completed)
+		} else {
+			// The old status may no longer fit the todo's workflow
+			err = syncStatus(tx, &todo)
+		}
+		if err != nil {
+			return err
+		}
+		if previousParentID != nil && (todo.ParentID == nil || *todo.ParentID != *previousParentID) {
+			if err := rollUpCompletion(tx, &models.Todo{ParentID: previousParentID}); err != nil {
+				return err
+			}
+			if err := rollUpCompletion(tx, &todo); err != nil {
+				return err
+			}
+		}
+		if err := recordRevision(tx, before, todo.ID, userID.(uint)); err != nil {
+			return err
+		}
+		return runRules(tx, models.TriggerUpdated, &todo)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	db.Preload("User").Preload("Assignees").Preload("Tags").First(&todo, todo.ID)
+
+	c.JSON(http.StatusOK, todo)
+}
+
+func snapshotTodo(todo *models.Todo) todoFields {
+	return todoFields{
+		Title:               todo.Title,
+		Description:         todo.Description,
+		Completed:           todo.Completed,
+		Priority:            todo.Priority,
+		StatusID:            todo.StatusID,
+		ProjectID:           todo.ProjectID,
+		ParentID:            todo.ParentID,
+		AutoComplete:        todo.AutoComplete,
+		DueDate:             todo.DueDate,
+		Archived:            todo.Archived,
+		Recurrence:          todo.Recurrence,
+		RecurFromCompletion: todo.RecurFromCompletion,
+	}
+}
+
+// recordRevision stores the changes a todo went through since the before
+// snapshot was taken. Nothing is recorded when no tracked field changed.
+func recordRevision(tx *gorm.DB, before todoFields, todoID uint, userID uint) error {
+	var todo models.Todo
+	if err := tx.First(&todo, todoID).Error; err != nil {
+		return err
+	}
+
+	changes, err := diffFields(before, snapshotTodo(&todo))
+	if err != nil || len(changes) == 0 {
+		return err
+	}
+
+	var last int
+	err = tx.Model(&models.TodoRevision{}).
+		Select("COALESCE(MAX(number), 0)").
+		Where("todo_id = ?", todoID).
+		Scan(&last).Error
+	if err != nil {
+		return err
+	}
+
+	return tx.Create(&models.TodoRevision{
+		TodoID:  todoID,
+		Number:  last + 1,
+		UserID:  userID,
+		Changes: changes,
+	}).Error
+}
+
+// diffFields returns the fields that differ between two snapshots, sorted
+// by name.
+func diffFields(before, after todoFields) (models.RevisionChanges, error) {
+	old, err := fieldValues(before)
+	if err != nil {
+		return nil, err
+	}
+	current, err := fieldValues(after)
+	if err != nil {
+		return nil, err
+	}
+
+	var changes models.RevisionChanges
+	for field, value := range current {
+		if string(old[field]) != string(value) {
+			changes = append(changes, models.FieldChange{Field: field, Old: old[field], New: value})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Field < changes[j].Field })
+	return changes, nil
+}
+
+func fieldValues(fields todoFields) (map[string]json.RawMessage, error) {
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return nil, err
+	}
+	values := map[string]json.RawMessage{}
+	return values, json.Unmarshal(data, &values)
+}
+
+// fieldsAt rebuilds a todo's fields as they were right after the given
+// revision by undoing every later revision, newest first.
+func fieldsAt(db *gorm.DB, todo *models.Todo, number int) (todoFields, error) {
+	var later []models.TodoRevision
+	err := db.Where("todo_id = ? AND number > ?", todo.ID, number).Order("number DESC").Find(&later).Error
+	if err != nil {
+		return todoFields{}, err
+	}
+
+	values, err := fieldValues(snapshotTodo(todo))
+	if err != nil {
+		return todoFields{}, err
+	}
+	for _, revision := range later {
+		for _, change := range revision.Changes {
+			values[change.Field] = change.Old
+		}
+	}
+
+	data, err := json.Marshal(values)
+	if err != nil {
+		return todoFields{}, err
+	}
+	var fields todoFields
+	return fields, json.Unmarshal(data, &fields)
+}
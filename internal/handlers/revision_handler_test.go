@@ -0,0 +1,134 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"todo-api/internal/models"
+	"todo-api/internal/test"
+)
+
+func TestTodoHistoryAndRevert(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db, _ := test.SetupTestDB()
+	test.ClearTestData(db)
+	testUser, err := test.CreateTestUser(db)
+	assert.NoError(t, err)
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set("userID", testUser.ID)
+	})
+	router.POST("/todos", CreateTodo)
+	router.PUT("/todos/:id", UpdateTodo)
+	router.DELETE("/todos/:id", DeleteTodo)
+	router.GET("/todos/:id/history", GetTodoHistory)
+	router.POST("/todos/:id/revert/:revision", RevertTodo)
+
+	send := func(method, path string, body interface{}) *httptest.ResponseRecorder {
+		data, _ := json.Marshal(body)
+		req, _ := http.NewRequest(method, path, bytes.NewBuffer(data))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	history := func(id uint) []models.TodoRevision {
+		w := send("GET", fmt.Sprintf("/todos/%d/history", id), nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		var revisions []models.TodoRevision
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &revisions))
+		return revisions
+	}
+	fields := func(revision models.TodoRevision) []string {
+		var names []string
+		for _, change := range revision.Changes {
+			names = append(names, change.Field)
+		}
+		return names
+	}
+
+	w := send("POST", "/todos", map[string]interface{}{"title": "Write report"})
+	assert.Equal(t, http.StatusCreated, w.Code)
+	var todo models.Todo
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &todo))
+
+	w = send("PUT", fmt.Sprintf("/todos/%d", todo.ID), map[string]interface{}{"title": "Write quarterly report", "description": "Q3"})
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = send("PUT", fmt.Sprintf("/todos/%d", todo.ID), map[string]interface{}{"title": "Write quarterly report", "description": "Q3", "completed": true})
+	assert.Equal(t, http.StatusOK, w.Code)
+	// Saving the same values again changes nothing and is not recorded
+	w = send("PUT", fmt.Sprintf("/todos/%d", todo.ID), map[string]interface{}{"title": "Write quarterly report", "description": "Q3", "completed": true})
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	revisions := history(todo.ID)
+	if assert.Len(t, revisions, 3) {
+		assert.Equal(t, 1, revisions[0].Number)
+		assert.Equal(t, testUser.ID, revisions[0].UserID)
+		assert.Contains(t, fields(revisions[0]), "title")
+		assert.Contains(t, fields(revisions[0]), "project_id")
+
+		assert.Equal(t, []string{"description", "title"}, fields(revisions[1]))
+		assert.JSONEq(t, `"Write report"`, string(revisions[1].Changes[1].Old))
+		assert.JSONEq(t, `"Write quarterly report"`, string(revisions[1].Changes[1].New))
+
+		assert.Equal(t, []string{"completed"}, fields(revisions[2]))
+	}
+
+	t.Run("revert to an earlier revision", func(t *testing.T) {
+		w := send("POST", fmt.Sprintf("/todos/%d/revert/1", todo.ID), nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var reverted models.Todo
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &reverted))
+		assert.Equal(t, "Write report", reverted.Title)
+		assert.Equal(t, "", reverted.Description)
+		assert.False(t, reverted.Completed)
+		assert.Nil(t, reverted.CompletedAt)
+
+		revisions := history(todo.ID)
+		if assert.Len(t, revisions, 4) {
+			assert.Equal(t, 4, revisions[3].Number)
+			assert.Equal(t, []string{"completed", "description", "title"}, fields(revisions[3]))
+		}
+	})
+
+	t.Run("unknown revision", func(t *testing.T) {
+		w := send("POST", fmt.Sprintf("/todos/%d/revert/99", todo.ID), nil)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+
+		w = send("POST", fmt.Sprintf("/todos/%d/revert/latest", todo.ID), nil)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("parent no longer exists", func(t *testing.T) {
+		parent := &models.Todo{Title: "Release", UserID: testUser.ID, ProjectID: todo.ProjectID}
+		db.Create(parent)
+		w := send("PUT", fmt.Sprintf("/todos/%d", todo.ID), map[string]interface{}{"title": "Write report", "parent_id": parent.ID})
+		assert.Equal(t, http.StatusOK, w.Code)
+		w = send("PUT", fmt.Sprintf("/todos/%d", todo.ID), map[string]interface{}{"title": "Write report"})
+		assert.Equal(t, http.StatusOK, w.Code)
+		db.Delete(parent)
+
+		revisions := history(todo.ID)
+		w = send("POST", fmt.Sprintf("/todos/%d/revert/%d", todo.ID, revisions[len(revisions)-2].Number), nil)
+		assert.Equal(t, http.StatusConflict, w.Code)
+	})
+
+	t.Run("other users cannot see the history", func(t *testing.T) {
+		other := &models.Todo{Title: "Someone else's", UserID: testUser.ID + 1}
+		db.Create(other)
+
+		w := send("GET", fmt.Sprintf("/todos/%d/history", other.ID), nil)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		w = send("POST", fmt.Sprintf("/todos/%d/revert/1", other.ID), nil)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
@@ -0,0 +1,306 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"todo-api/internal/models"
+)
+
+// maxRuleDepth is how many rules may set each other off in a row, counting
+// the one the original change triggered.
+const maxRuleDepth = 5
+
+// defaultLeadMinutes is how long before the due date a due_soon rule fires
+// when it does not say.
+const defaultLeadMinutes = 24 * 60
+
+// ruleRun is one cascade of rules, started by a change made through the API.
+// Changes made by rules can set off further rules, but a rule runs at most
+// once for each todo in a cascade and cascades end after maxRuleDepth steps.
+type ruleRun struct {
+	ran map[[2]uint]bool
+}
+
+// runRules applies the rules of the todo's owner that trigger sets off.
+func runRules(tx *gorm.DB, trigger string, todo *models.Todo) error {
+	run := &ruleRun{ran: make(map[[2]uint]bool)}
+	return run.fire(tx, trigger, todo.ID, 1)
+}
+
+// fire runs every active rule with the trigger against a todo.
+func (r *ruleRun) fire(tx *gorm.DB, trigger string, todoID uint, depth int) error {
+	var todo models.Todo
+	if err := tx.First(&todo, todoID).Error; err != nil {
+		return err
+	}
+
+	var rules []models.Rule
+	err := tx.Where(map[string]interface{}{"user_id": todo.UserID, "trigger": trigger, "paused": false}).
+		Order("id").Find(&rules).Error
+	if err != nil {
+		return err
+	}
+
+	for i := range rules {
+		if err := r.execute(tx, &rules[i], &todo, trigger, depth, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// execute runs one rule against a todo if the todo matches its condition,
+// and logs the outcome. The rule's changes are undone when one of its
+// actions fails, without failing the change that set it off. A rule that
+// already ran for the todo in this cascade is passed over without a log
+// entry, since rules that change their own todo set themselves off again on
+// every update. claimed is the execution row a due_soon run inserted up
+// front, or nil.
+func (r *ruleRun) execute(tx *gorm.DB, rule *models.Rule, todo *models.Todo, trigger string, depth int, claimed *models.RuleExecution) error {
+	execution := models.RuleExecution{
+		RuleID:  rule.ID,
+		UserID:  rule.UserID,
+		TodoID:  todo.ID,
+		Trigger: trigger,
+		Status:  models.ExecutionSucceeded,
+	}
+	if claimed != nil {
+		execution = *claimed
+	}
+
+	key := [2]uint{rule.ID, todo.ID}
+	// A savepoint keeps a failed query from aborting the whole transaction,
+	// and with it the change that set the rule off
+	var matched bool
+	err := tx.Transaction(func(tx *gorm.DB) error {
+		var err error
+		matched, err = ruleMatches(tx, rule, todo.ID)
+		return err
+	})
+	switch {
+	case err != nil:
+		execution.Status = models.ExecutionFailed
+		execution.Error = err.Error()
+	case !matched || r.ran[key]:
+		if claimed != nil {
+			return tx.Delete(claimed).Error
+		}
+		return nil
+	case depth > maxRuleDepth:
+		execution.Status = models.ExecutionSkipped
+		execution.Error = fmt.Sprintf("more than %d rules set each other off", maxRuleDepth)
+	default:
+		r.ran[key] = true
+		err := tx.Transaction(func(tx *gorm.DB) error {
+			return r.apply(tx, rule, todo.ID, depth)
+		})
+		if err != nil {
+			execution.Status = models.ExecutionFailed
+			execution.Error = err.Error()
+		}
+	}
+
+	if claimed != nil {
+		return tx.Save(&execution).Error
+	}
+	return tx.Create(&execution).Error
+}
+
+// ruleMatches reports whether a todo matches a rule's condition.
+func ruleMatches(tx *gorm.DB, rule *models.Rule, todoID uint) (bool, error) {
+	_, scope, err := parseTodoQuery(tx, rule.UserID, rule.Condition)
+	if err != nil {
+		return false, fmt.Errorf("invalid condition: %w", err)
+	}
+
+	var count int64
+	err = tx.Session(&gorm.Session{NewDB: true}).Model(&models.Todo{}).
+		Where("todos.id = ?", todoID).Scopes(scope).Count(&count).Error
+	return count > 0, err
+}
+
+// apply carries out a rule's actions on a todo. Todos the rule creates or
+// changes are passed on to the rules they trigger in turn.
+func (r *ruleRun) apply(tx *gorm.DB, rule *models.Rule, todoID uint, depth int) error {
+	var todo models.Todo
+	if err := tx.First(&todo, todoID).Error; err != nil {
+		return err
+	}
+	loc, err := userLocation(tx, todo.UserID)
+	if err != nil {
+		return err
+	}
+	today := startOfDay(time.Now().In(loc))
+
+	before := snapshotTodo(&todo)
+	changed := false
+	var created []uint
+
+	for _, action := range rule.Actions {
+		switch action.Type {
+		case models.ActionAddTag:
+			tagIDs, err := findOrCreateTags(tx, todo.UserID, []string{action.Tag})
+			if err != nil {
+				return err
+			}
+			for _, tagID := range tagIDs {
+				var tag models.Tag
+				if err := tx.First(&tag, tagID).Error; err != nil {
+					return err
+				}
+				if err := tx.Model(&todo).Association("Tags").Append(&tag); err != nil {
+					return err
+				}
+			}
+
+		case models.ActionSetPriority:
+			if err := tx.Model(&todo).Update("priority", *action.Priority).Error; err != nil {
+				return err
+			}
+
+		case models.ActionSetDue:
+			due, err := models.AddOffset(today, action.DueOffset)
+			if err != nil {
+				return err
+			}
+			due = due.UTC()
+			if err := tx.Model(&todo).Update("due_date", due).Error; err != nil {
+				return err
+			}
+			todo.DueDate = &due
+			if err := rescheduleReminders(tx, &todo); err != nil {
+				return err
+			}
+
+		case models.ActionMove:
+			project, err := findActiveProject(tx, todo.UserID, *action.ProjectID)
+			if err != nil {
+				return err
+			}
+			if err := moveTodo(tx, &todo, project.ID); err != nil {
+				return err
+			}
+
+		case models.ActionCreateTodo:
+			item, err := expandTemplate(*action.Todo, map[string]string{
+				"title": todo.Title,
+				"date":  today.Format(reportDateLayout),
+			})
+			if err != nil {
+				return err
+			}
+			base := models.Todo{UserID: todo.UserID, ProjectID: action.ProjectID}
+			if base.ProjectID == nil {
+				base.ProjectID = todo.ProjectID
+			}
+			if err := assignProject(tx, &base); err != nil {
+				return err
+			}
+			tagIDs, err := findOrCreateTags(tx, todo.UserID, templateTags(&item, nil))
+			if err != nil {
+				return err
+			}
+			followUp, err := createFromTemplate(tx, &item, base, today, tagIDs)
+			if err != nil {
+				return err
+			}
+			created = append(created, followUp.ID)
+			continue
+		}
+		changed = true
+	}
+
+	if changed {
+		if err := recordRevision(tx, before, todo.ID, todo.UserID); err != nil {
+			return err
+		}
+		if err := r.fire(tx, models.TriggerUpdated, todo.ID, depth+1); err != nil {
+			return err
+		}
+	}
+	for _, id := range created {
+		if err := r.fire(tx, models.TriggerCreated, id, depth+1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DueSoonRules runs due_soon rules for open todos coming due within each
+// rule's lead time, once per rule, todo and due date.
+type DueSoonRules struct {
+	DB  *gorm.DB
+	Now func() time.Time
+}
+
+// Run fires the due_soon rules that are due. It is meant to be registered
+// as a scheduler job.
+func (d *DueSoonRules) Run(ctx context.Context) error {
+	db := d.DB.WithContext(ctx)
+	now := d.now().UTC()
+
+	var rules []models.Rule
+	err := db.Where(map[string]interface{}{"trigger": models.TriggerDueSoon, "paused": false}).Order("id").Find(&rules).Error
+	if err != nil {
+		return err
+	}
+
+	for i := range rules {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		rule := &rules[i]
+		lead := time.Duration(rule.LeadMinutes) * time.Minute
+		if rule.LeadMinutes == 0 {
+			lead = defaultLeadMinutes * time.Minute
+		}
+
+		var todos []models.Todo
+		err := db.Where("user_id = ? AND completed = ? AND archived = ? AND due_date > ? AND due_date <= ?", rule.UserID, false, false, now, now.Add(lead)).
+			Where("NOT EXISTS (SELECT 1 FROM rule_executions WHERE rule_executions.rule_id = ? AND rule_executions.todo_id = todos.id AND rule_executions.due_at = todos.due_date)", rule.ID).
+			Order("id").Find(&todos).Error
+		if err != nil {
+			return err
+		}
+
+		for j := range todos {
+			todo := &todos[j]
+			err := db.Transaction(func(tx *gorm.DB) error {
+				// The execution row is inserted first as a claim: its unique
+				// index lets only one replica fire the rule for this due date
+				claim := models.RuleExecution{
+					RuleID:  rule.ID,
+					UserID:  rule.UserID,
+					TodoID:  todo.ID,
+					Trigger: models.TriggerDueSoon,
+					Status:  models.ExecutionSucceeded,
+					DueAt:   todo.DueDate,
+				}
+				result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&claim)
+				if result.Error != nil || result.RowsAffected == 0 {
+					return result.Error
+				}
+				run := &ruleRun{ran: make(map[[2]uint]bool)}
+				return run.execute(tx, rule, todo, models.TriggerDueSoon, 1, &claim)
+			})
+			if err != nil {
+				// One todo failing should not hold up the rest
+				log.Printf("Rule %d failed for todo %d: %v", rule.ID, todo.ID, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (d *DueSoonRules) now() time.Time {
+	if d.Now != nil {
+		return d.Now()
+	}
+	return time.Now()
+}
@@ -0,0 +1,285 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"todo-api/internal/database"
+	"todo-api/internal/models"
+)
+
+// RuleExecutionPage is one page of a rule's execution log
+type RuleExecutionPage struct {
+	Executions []models.RuleExecution `json:"executions"`
+	Page       int                    `json:"page" example:"1"`
+	PageSize   int                    `json:"page_size" example:"20"`
+	Total      int64                  `json:"total" example:"42"`
+}
+
+// @Summary Create a rule
+// @Description Create an automation rule. When its trigger fires for one of your todos that matches its condition, its actions are applied to that todo.
+// @Tags rules
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param rule body models.Rule true "Rule"
+// @Success 201 {object} models.Rule
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/rules [post]
+func CreateRule(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	var rule models.Rule
+	if err := c.BindJSON(&rule); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	rule.UserID = userID.(uint)
+	if err := validateRule(database.GetDB(), &rule); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	if err := database.GetDB().Create(&rule).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, rule)
+}
+
+// @Summary Get all rules
+// @Description Get the authenticated user's automation rules
+// @Tags rules
+// @Produce json
+// @Security Bearer
+// @Success 200 {array} models.Rule
+// @Failure 401 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/rules [get]
+func GetRules(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	var rules []models.Rule
+	if err := database.GetDB().Where("user_id = ?", userID).Order("id").Find(&rules).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, rules)
+}
+
+// @Summary Get a rule
+// @Description Get a specific automation rule by ID
+// @Tags rules
+// @Produce json
+// @Security Bearer
+// @Param id path int true "Rule ID"
+// @Success 200 {object} models.Rule
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/rules/{id} [get]
+func GetRule(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	var rule models.Rule
+
+	if err := database.GetDB().Where("id = ? AND user_id = ?", c.Param("id"), userID).First(&rule).Error; err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Rule not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, rule)
+}
+
+// @Summary Update a rule
+// @Description Replace an automation rule's name, trigger, condition and actions, or pause it
+// @Tags rules
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "Rule ID"
+// @Param rule body models.Rule true "Rule"
+// @Success 200 {object} models.Rule
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/rules/{id} [put]
+func UpdateRule(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	var rule models.Rule
+
+	if err := database.GetDB().Where("id = ? AND user_id = ?", c.Param("id"), userID).First(&rule).Error; err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Rule not found"})
+		return
+	}
+
+	var updateData models.Rule
+	if err := c.BindJSON(&updateData); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+	updateData.UserID = rule.UserID
+	if err := validateRule(database.GetDB(), &updateData); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	result := database.GetDB().Model(&rule).Updates(map[string]interface{}{
+		"name":         updateData.Name,
+		"trigger":      updateData.Trigger,
+		"condition":    updateData.Condition,
+		"lead_minutes": updateData.LeadMinutes,
+		"actions":      updateData.Actions,
+		"paused":       updateData.Paused,
+	})
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: result.Error.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, rule)
+}
+
+// @Summary Delete a rule
+// @Description Delete an automation rule together with its execution log
+// @Tags rules
+// @Security Bearer
+// @Param id path int true "Rule ID"
+// @Success 204 "No Content"
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/rules/{id} [delete]
+func DeleteRule(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	var rule models.Rule
+
+	if err := database.GetDB().Where("id = ? AND user_id = ?", c.Param("id"), userID).First(&rule).Error; err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Rule not found"})
+		return
+	}
+
+	err := database.GetDB().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("rule_id = ?", rule.ID).Delete(&models.RuleExecution{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&rule).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// @Summary Get a rule's execution log
+// @Description Get a page of the times a rule ran, newest first, with whether it succeeded, failed or was skipped to stop a loop
+// @Tags rules
+// @Produce json
+// @Security Bearer
+// @Param id path int true "Rule ID"
+// @Param page query int false "Page number, starting at 1"
+// @Param page_size query int false "Executions per page (at most 100)"
+// @Success 200 {object} RuleExecutionPage
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/rules/{id}/executions [get]
+func GetRuleExecutions(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	db := database.GetDB()
+	var rule models.Rule
+
+	if err := db.Where("id = ? AND user_id = ?", c.Param("id"), userID).First(&rule).Error; err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Rule not found"})
+		return
+	}
+
+	page, pageSize, err := pageParams(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	result := RuleExecutionPage{Executions: []models.RuleExecution{}, Page: page, PageSize: pageSize}
+	log := db.Model(&models.RuleExecution{}).Where("rule_id = ?", rule.ID)
+	if err := log.Session(&gorm.Session{}).Count(&result.Total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+	err = log.Order("created_at DESC, id DESC").
+		Offset((page - 1) * pageSize).
+		Limit(pageSize).
+		Find(&result.Executions).Error
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// validateRule checks that a rule's condition parses and that each action
+// has what it needs, tidying the rule in place.
+func validateRule(db *gorm.DB, rule *models.Rule) error {
+	rule.Name = strings.TrimSpace(rule.Name)
+	if rule.Name == "" {
+		return errors.New("name must not be empty")
+	}
+	if _, _, err := parseTodoQuery(db, rule.UserID, rule.Condition); err != nil {
+		return fmt.Errorf("invalid condition: %w", err)
+	}
+
+	if rule.Trigger != models.TriggerDueSoon {
+		rule.LeadMinutes = 0
+	} else if rule.LeadMinutes == 0 {
+		rule.LeadMinutes = defaultLeadMinutes
+	}
+
+	for i := range rule.Actions {
+		action := &rule.Actions[i]
+		switch action.Type {
+		case models.ActionAddTag:
+			action.Tag = strings.TrimSpace(action.Tag)
+			if action.Tag == "" {
+				return errors.New("add_tag needs a tag")
+			}
+		case models.ActionSetPriority:
+			if action.Priority == nil {
+				return errors.New("set_priority needs a priority")
+			}
+		case models.ActionSetDue:
+			if _, err := models.AddOffset(time.Now(), action.DueOffset); err != nil {
+				return fmt.Errorf("set_due needs a due_offset: %w", err)
+			}
+		case models.ActionMove:
+			if action.ProjectID == nil {
+				return errors.New("move needs a project_id")
+			}
+		case models.ActionCreateTodo:
+			if action.Todo == nil {
+				return errors.New("create_todo needs a todo")
+			}
+			if err := validateTemplate(action.Todo); err != nil {
+				return err
+			}
+		}
+		if action.ProjectID != nil {
+			if _, err := findActiveProject(db, rule.UserID, *action.ProjectID); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,481 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"todo-api/internal/models"
+	"todo-api/internal/test"
+)
+
+func TestCreateRule(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db, _ := test.SetupTestDB()
+	test.ClearTestData(db)
+	testUser, err := test.CreateTestUser(db)
+	assert.NoError(t, err)
+
+	archived := &models.Project{Name: "Old", UserID: testUser.ID, Archived: true}
+	db.Create(archived)
+
+	router := gin.New()
+	router.POST("/rules", func(c *gin.Context) {
+		c.Set("userID", testUser.ID)
+		CreateRule(c)
+	})
+
+	addTag := []map[string]interface{}{{"type": "add_tag", "tag": "finance"}}
+	tests := []struct {
+		name         string
+		payload      map[string]interface{}
+		expectedCode int
+		wantLead     int
+	}{
+		{
+			name: "Valid rule",
+			payload: map[string]interface{}{
+				"name":      "File invoices",
+				"trigger":   "created",
+				"condition": "title:invoice",
+				"actions": []map[string]interface{}{
+					{"type": "add_tag", "tag": "finance"},
+					{"type": "set_due", "due_offset": "3d"},
+				},
+			},
+			expectedCode: http.StatusCreated,
+		},
+		{
+			name:         "Due soon rules default to a day's notice",
+			payload:      map[string]interface{}{"name": "Flag", "trigger": "due_soon", "actions": []map[string]interface{}{{"type": "set_priority", "priority": 3}}},
+			expectedCode: http.StatusCreated,
+			wantLead:     24 * 60,
+		},
+		{
+			name:         "Unknown trigger",
+			payload:      map[string]interface{}{"name": "Rule", "trigger": "deleted", "actions": addTag},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "No actions",
+			payload:      map[string]interface{}{"name": "Rule", "trigger": "created", "actions": []map[string]interface{}{}},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "Unknown action",
+			payload:      map[string]interface{}{"name": "Rule", "trigger": "created", "actions": []map[string]interface{}{{"type": "explode"}}},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "Invalid condition",
+			payload:      map[string]interface{}{"name": "Rule", "trigger": "created", "condition": "due<someday", "actions": addTag},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "Tag action without a tag",
+			payload:      map[string]interface{}{"name": "Rule", "trigger": "created", "actions": []map[string]interface{}{{"type": "add_tag"}}},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "Due action with an invalid offset",
+			payload:      map[string]interface{}{"name": "Rule", "trigger": "created", "actions": []map[string]interface{}{{"type": "set_due", "due_offset": "soon"}}},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "Move to an archived project",
+			payload:      map[string]interface{}{"name": "Rule", "trigger": "created", "actions": []map[string]interface{}{{"type": "move", "project_id": archived.ID}}},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "Follow-up without a todo",
+			payload:      map[string]interface{}{"name": "Rule", "trigger": "completed", "actions": []map[string]interface{}{{"type": "create_todo"}}},
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, _ := json.Marshal(tt.payload)
+			req, _ := http.NewRequest("POST", "/rules", bytes.NewBuffer(body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+			if w.Code == http.StatusCreated {
+				var response models.Rule
+				assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+				assert.Equal(t, testUser.ID, response.UserID)
+				assert.Equal(t, tt.wantLead, response.LeadMinutes)
+				assert.NotEmpty(t, response.Actions)
+			}
+		})
+	}
+}
+
+func TestUpdateRule(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db, _ := test.SetupTestDB()
+	test.ClearTestData(db)
+	testUser, err := test.CreateTestUser(db)
+	assert.NoError(t, err)
+
+	rule := &models.Rule{UserID: testUser.ID, Name: "Flag", Trigger: models.TriggerCreated, Actions: models.RuleActions{{Type: models.ActionAddTag, Tag: "flagged"}}}
+	db.Create(rule)
+
+	router := gin.New()
+	router.PUT("/rules/:id", func(c *gin.Context) {
+		c.Set("userID", testUser.ID)
+		UpdateRule(c)
+	})
+
+	body, _ := json.Marshal(map[string]interface{}{
+		"name":    "Flag urgent",
+		"trigger": "updated",
+		"paused":  true,
+		"actions": []map[string]interface{}{{"type": "set_priority", "priority": 3}},
+	})
+	req, _ := http.NewRequest("PUT", fmt.Sprintf("/rules/%d", rule.ID), bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var response models.Rule
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, "Flag urgent", response.Name)
+	assert.Len(t, response.Actions, 1)
+
+	var updated models.Rule
+	db.First(&updated, rule.ID)
+	assert.Equal(t, "Flag urgent", updated.Name)
+	assert.Equal(t, models.TriggerUpdated, updated.Trigger)
+	assert.True(t, updated.Paused)
+	if assert.Len(t, updated.Actions, 1) {
+		assert.Equal(t, models.ActionSetPriority, updated.Actions[0].Type)
+	}
+}
+
+func TestRulesOnCreate(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db, _ := test.SetupTestDB()
+	test.ClearTestData(db)
+	testUser, err := test.CreateTestUser(db)
+	assert.NoError(t, err)
+
+	closing := &models.Project{Name: "Closing", UserID: testUser.ID}
+	db.Create(closing)
+	invoices := &models.Rule{
+		UserID:    testUser.ID,
+		Name:      "File invoices",
+		Trigger:   models.TriggerCreated,
+		Condition: "title:invoice",
+		Actions: models.RuleActions{
+			{Type: models.ActionAddTag, Tag: "finance"},
+			{Type: models.ActionSetDue, DueOffset: "3d"},
+		},
+	}
+	db.Create(invoices)
+	// Fails once the project is archived, which must not stop todos being created
+	broken := &models.Rule{
+		UserID:  testUser.ID,
+		Name:    "Move to closing",
+		Trigger: models.TriggerCreated,
+		Actions: models.RuleActions{{Type: models.ActionMove, ProjectID: &closing.ID}},
+	}
+	db.Create(broken)
+	db.Model(closing).Update("archived", true)
+	paused := &models.Rule{UserID: testUser.ID, Name: "Paused", Trigger: models.TriggerCreated, Paused: true, Actions: models.RuleActions{{Type: models.ActionAddTag, Tag: "never"}}}
+	db.Create(paused)
+
+	router := gin.New()
+	router.POST("/todos", func(c *gin.Context) {
+		c.Set("userID", testUser.ID)
+		CreateTodo(c)
+	})
+	router.GET("/rules/:id/executions", func(c *gin.Context) {
+		c.Set("userID", testUser.ID)
+		GetRuleExecutions(c)
+	})
+
+	create := func(title string) models.Todo {
+		body, _ := json.Marshal(map[string]interface{}{"title": title})
+		req, _ := http.NewRequest("POST", "/todos", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusCreated, w.Code)
+
+		var todo models.Todo
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &todo))
+		return todo
+	}
+
+	invoice := create("Send Invoice to ACME")
+	if assert.Len(t, invoice.Tags, 1) {
+		assert.Equal(t, "finance", invoice.Tags[0].Name)
+	}
+	now := time.Now().UTC()
+	wantDue := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC).AddDate(0, 0, 3)
+	if assert.NotNil(t, invoice.DueDate) {
+		assert.True(t, wantDue.Equal(*invoice.DueDate), "due %s, want %s", invoice.DueDate, wantDue)
+	}
+
+	other := create("Buy milk")
+	assert.Empty(t, other.Tags)
+	assert.Nil(t, other.DueDate)
+
+	// The rule's changes show up in the todo's history
+	var revisions int64
+	db.Model(&models.TodoRevision{}).Where("todo_id = ?", invoice.ID).Count(&revisions)
+	assert.Equal(t, int64(2), revisions)
+
+	req, _ := http.NewRequest("GET", fmt.Sprintf("/rules/%d/executions", invoices.ID), nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var page RuleExecutionPage
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &page))
+	assert.Equal(t, int64(1), page.Total)
+	if assert.Len(t, page.Executions, 1) {
+		assert.Equal(t, invoice.ID, page.Executions[0].TodoID)
+		assert.Equal(t, models.ExecutionSucceeded, page.Executions[0].Status)
+	}
+
+	var failures []models.RuleExecution
+	db.Where("rule_id = ?", broken.ID).Find(&failures)
+	if assert.Len(t, failures, 2) {
+		assert.Equal(t, models.ExecutionFailed, failures[0].Status)
+		assert.Equal(t, "project is archived", failures[0].Error)
+	}
+	var pausedRuns int64
+	db.Model(&models.RuleExecution{}).Where("rule_id = ?", paused.ID).Count(&pausedRuns)
+	assert.Zero(t, pausedRuns)
+}
+
+func TestRulesOnComplete(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db, _ := test.SetupTestDB()
+	test.ClearTestData(db)
+	testUser, err := test.CreateTestUser(db)
+	assert.NoError(t, err)
+
+	sales := &models.Project{Name: "Sales", UserID: testUser.ID}
+	db.Create(sales)
+	support := &models.Project{Name: "Support", UserID: testUser.ID}
+	db.Create(support)
+	db.Create(&models.Rule{
+		UserID:    testUser.ID,
+		Name:      "Hand over to support",
+		Trigger:   models.TriggerCompleted,
+		Condition: fmt.Sprintf("project:%d", sales.ID),
+		Actions: models.RuleActions{{
+			Type:      models.ActionCreateTodo,
+			ProjectID: &support.ID,
+			Todo:      &models.TemplateItem{Title: "Onboard: {{title}}", Tags: []string{"onboarding"}, DueOffset: "2d"},
+		}},
+	})
+
+	deal := &models.Todo{Title: "Close ACME", UserID: testUser.ID, ProjectID: &sales.ID}
+	db.Create(deal)
+	elsewhere := &models.Todo{Title: "Close the window", UserID: testUser.ID, ProjectID: &support.ID}
+	db.Create(elsewhere)
+
+	router := gin.New()
+	router.PUT("/todos/:id", func(c *gin.Context) {
+		c.Set("userID", testUser.ID)
+		UpdateTodo(c)
+	})
+	complete := func(todo *models.Todo) {
+		body, _ := json.Marshal(map[string]interface{}{"title": todo.Title, "completed": true})
+		req, _ := http.NewRequest("PUT", fmt.Sprintf("/todos/%d", todo.ID), bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+	}
+
+	complete(deal)
+	complete(elsewhere)
+
+	var followUps []models.Todo
+	db.Preload("Tags").Where("title LIKE ?", "Onboard:%").Find(&followUps)
+	if assert.Len(t, followUps, 1) {
+		assert.Equal(t, "Onboard: Close ACME", followUps[0].Title)
+		assert.Equal(t, support.ID, *followUps[0].ProjectID)
+		assert.NotNil(t, followUps[0].DueDate)
+		if assert.Len(t, followUps[0].Tags, 1) {
+			assert.Equal(t, "onboarding", followUps[0].Tags[0].Name)
+		}
+	}
+}
+
+func TestRulesOnOtherChanges(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db, _ := test.SetupTestDB()
+	test.ClearTestData(db)
+	testUser, err := test.CreateTestUser(db)
+	assert.NoError(t, err)
+
+	work := &models.Project{Name: "Work", UserID: testUser.ID}
+	db.Create(work)
+	db.Create(&models.Rule{
+		UserID:    testUser.ID,
+		Name:      "Work comes first",
+		Trigger:   models.TriggerUpdated,
+		Condition: fmt.Sprintf("project:%d", work.ID),
+		Actions:   models.RuleActions{{Type: models.ActionSetPriority, Priority: intPtr(models.PriorityHigh)}},
+	})
+	db.Create(&models.Rule{
+		UserID:  testUser.ID,
+		Name:    "Tag copies",
+		Trigger: models.TriggerCreated,
+		Actions: models.RuleActions{{Type: models.ActionAddTag, Tag: "copy"}},
+	})
+
+	router := gin.New()
+	router.POST("/todos/bulk", func(c *gin.Context) {
+		c.Set("userID", testUser.ID)
+		BulkUpdateTodos(c)
+	})
+	router.POST("/todos/:id/move", func(c *gin.Context) {
+		c.Set("userID", testUser.ID)
+		MoveTodo(c)
+	})
+	router.POST("/todos/:id/duplicate", func(c *gin.Context) {
+		c.Set("userID", testUser.ID)
+		DuplicateTodo(c)
+	})
+	post := func(path string, body interface{}) *httptest.ResponseRecorder {
+		data, _ := json.Marshal(body)
+		req, _ := http.NewRequest("POST", path, bytes.NewBuffer(data))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	bulkMoved := &models.Todo{Title: "Bulk moved", UserID: testUser.ID}
+	db.Create(bulkMoved)
+	moved := &models.Todo{Title: "Moved", UserID: testUser.ID}
+	db.Create(moved)
+
+	w := post("/todos/bulk", map[string]interface{}{"action": "move", "ids": []uint{bulkMoved.ID}, "project_id": work.ID})
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = post(fmt.Sprintf("/todos/%d/move", moved.ID), map[string]interface{}{"project_id": work.ID})
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	for _, todo := range []*models.Todo{bulkMoved, moved} {
+		var reloaded models.Todo
+		db.First(&reloaded, todo.ID)
+		assert.Equal(t, models.PriorityHigh, reloaded.Priority, todo.Title)
+	}
+
+	w = post(fmt.Sprintf("/todos/%d/duplicate", moved.ID), map[string]interface{}{})
+	assert.Equal(t, http.StatusCreated, w.Code)
+	var copied models.Todo
+	json.Unmarshal(w.Body.Bytes(), &copied)
+	db.Preload("Tags").First(&copied, copied.ID)
+	if assert.Len(t, copied.Tags, 1) {
+		assert.Equal(t, "copy", copied.Tags[0].Name)
+	}
+}
+
+func TestRuleLoopProtection(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db, _ := test.SetupTestDB()
+	test.ClearTestData(db)
+	testUser, err := test.CreateTestUser(db)
+	assert.NoError(t, err)
+
+	// Changes its own todo, which would set it off again
+	bump := &models.Rule{UserID: testUser.ID, Name: "Bump", Trigger: models.TriggerUpdated, Actions: models.RuleActions{{Type: models.ActionSetPriority, Priority: intPtr(models.PriorityHigh)}}}
+	db.Create(bump)
+	// Creates a todo that sets it off again, and so on
+	echo := &models.Rule{UserID: testUser.ID, Name: "Echo", Trigger: models.TriggerCreated, Condition: "title:echo", Actions: models.RuleActions{{Type: models.ActionCreateTodo, Todo: &models.TemplateItem{Title: "{{title}}"}}}}
+	db.Create(echo)
+
+	todo := &models.Todo{Title: "Echo", UserID: testUser.ID}
+	db.Create(todo)
+	assert.NoError(t, runRules(db, models.TriggerUpdated, todo))
+
+	// Setting itself off again is passed over without a log entry
+	var runs []models.RuleExecution
+	db.Where("rule_id = ?", bump.ID).Find(&runs)
+	if assert.Len(t, runs, 1) {
+		assert.Equal(t, models.ExecutionSucceeded, runs[0].Status)
+	}
+
+	assert.NoError(t, runRules(db, models.TriggerCreated, todo))
+	var echoes int64
+	db.Model(&models.Todo{}).Where("title = ?", "Echo").Count(&echoes)
+	assert.Equal(t, int64(1+maxRuleDepth), echoes)
+	var skipped int64
+	db.Model(&models.RuleExecution{}).Where("rule_id = ? AND status = ?", echo.ID, models.ExecutionSkipped).Count(&skipped)
+	assert.Equal(t, int64(1), skipped)
+}
+
+func TestDueSoonRules(t *testing.T) {
+	db, _ := test.SetupTestDB()
+	test.ClearTestData(db)
+	testUser, err := test.CreateTestUser(db)
+	assert.NoError(t, err)
+
+	now := time.Date(2026, 5, 4, 8, 0, 0, 0, time.UTC)
+	soon := now.Add(2 * time.Hour)
+	later := now.Add(10 * time.Hour)
+	past := now.Add(-time.Hour)
+
+	rule := &models.Rule{UserID: testUser.ID, Name: "Escalate", Trigger: models.TriggerDueSoon, LeadMinutes: 180, Actions: models.RuleActions{{Type: models.ActionSetPriority, Priority: intPtr(models.PriorityHigh)}}}
+	db.Create(rule)
+	dueSoon := &models.Todo{Title: "Due soon", UserID: testUser.ID, DueDate: &soon}
+	dueLater := &models.Todo{Title: "Due later", UserID: testUser.ID, DueDate: &later}
+	overdue := &models.Todo{Title: "Overdue", UserID: testUser.ID, DueDate: &past}
+	done := &models.Todo{Title: "Done", UserID: testUser.ID, DueDate: &soon, Completed: true}
+	for _, todo := range []*models.Todo{dueSoon, dueLater, overdue, done} {
+		db.Create(todo)
+	}
+
+	job := &DueSoonRules{DB: db, Now: func() time.Time { return now }}
+	assert.NoError(t, job.Run(context.Background()))
+	// Running again does not fire twice for the same due date
+	assert.NoError(t, job.Run(context.Background()))
+
+	var runs []models.RuleExecution
+	db.Where("rule_id = ?", rule.ID).Find(&runs)
+	if assert.Len(t, runs, 1) {
+		assert.Equal(t, dueSoon.ID, runs[0].TodoID)
+		assert.Equal(t, models.TriggerDueSoon, runs[0].Trigger)
+		// Another replica cannot claim the same due date
+		claim := models.RuleExecution{RuleID: rule.ID, UserID: testUser.ID, TodoID: dueSoon.ID, Trigger: models.TriggerDueSoon, DueAt: runs[0].DueAt}
+		assert.Error(t, db.Create(&claim).Error)
+	}
+	var reloaded models.Todo
+	db.First(&reloaded, dueSoon.ID)
+	assert.Equal(t, models.PriorityHigh, reloaded.Priority)
+
+	// Moving the due date into the window again fires once more
+	rescheduled := soon.Add(30 * time.Minute)
+	db.Model(dueSoon).Update("due_date", rescheduled)
+	assert.NoError(t, job.Run(context.Background()))
+	var count int64
+	db.Model(&models.RuleExecution{}).Where("rule_id = ?", rule.ID).Count(&count)
+	assert.Equal(t, int64(2), count)
+}
+
+func intPtr(n int) *int {
+	return &n
+}
@@ -0,0 +1,187 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"todo-api/internal/database"
+	"todo-api/internal/models"
+	"todo-api/internal/notify"
+)
+
+// Snooze presets, resolved in the user's time zone
+const (
+	snoozeLaterToday = "later_today"
+	snoozeTomorrow   = "tomorrow"
+	snoozeNextWeek   = "next_week"
+	snoozeCustom     = "custom"
+)
+
+// snoozeMorningHour is when todos snoozed to another day come back.
+const snoozeMorningHour = 9
+
+type SnoozeRequest struct {
+	Preset  string     `json:"preset" binding:"required,oneof=later_today tomorrow next_week custom" example:"tomorrow"`
+	Until   *time.Time `json:"until" example:"2026-02-02T09:00:00Z"`
+	Channel string     `json:"channel" binding:"omitempty,oneof=email webhook in_app" example:"in_app"`
+}
+
+// @Summary Snooze a todo
+// @Description Hide a todo from lists until later: in three hours (later_today), tomorrow morning, next Monday morning (next_week) or at a custom time. When it comes back an "unsnoozed" notification is sent on the chosen channel.
+// @Tags todos
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "Todo ID"
+// @Param request body SnoozeRequest true "How long to snooze for"
+// @Success 200 {object} models.Todo
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/todos/{id}/snooze [post]
+func SnoozeTodo(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	db := database.GetDB()
+
+	var todo models.Todo
+	if err := accessibleTodos(db, userID.(uint)).Where("todos.id = ?", c.Param("id")).First(&todo).Error; err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Todo not found"})
+		return
+	}
+	if todo.Completed {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Completed todos cannot be snoozed"})
+		return
+	}
+
+	var req SnoozeRequest
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+	if req.Channel == "" {
+		req.Channel = notify.ChannelInApp
+	}
+
+	loc, err := userLocation(db, userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+	until, err := snoozeUntil(req.Preset, req.Until, time.Now().In(loc))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+	until = until.UTC()
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := cancelUnsnooze(tx, todo.ID); err != nil {
+			return err
+		}
+		if err := tx.Model(&todo).Update("hidden_until", until).Error; err != nil {
+			return err
+		}
+		wake := models.Reminder{
+			TodoID:   todo.ID,
+			UserID:   userID.(uint),
+			RemindAt: &until,
+			Channel:  req.Channel,
+			Event:    models.EventUnsnoozed,
+			Status:   models.ReminderPending,
+		}
+		wake.Schedule(nil)
+		if err := tx.Create(&wake).Error; err != nil {
+			return err
+		}
+		return runRules(tx, models.TriggerUpdated, &todo)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	db.Preload("User").Preload("Assignees").Preload("Tags").Preload("CustomFields").First(&todo, todo.ID)
+
+	c.JSON(http.StatusOK, todo)
+}
+
+// @Summary Wake a snoozed todo
+// @Description Bring a snoozed todo back into lists now, without an "unsnoozed" notification
+// @Tags todos
+// @Produce json
+// @Security Bearer
+// @Param id path int true "Todo ID"
+// @Success 200 {object} models.Todo
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/todos/{id}/snooze [delete]
+func UnsnoozeTodo(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	db := database.GetDB()
+
+	var todo models.Todo
+	if err := accessibleTodos(db, userID.(uint)).Where("todos.id = ?", c.Param("id")).First(&todo).Error; err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Todo not found"})
+		return
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := cancelUnsnooze(tx, todo.ID); err != nil {
+			return err
+		}
+		if err := tx.Model(&todo).Update("hidden_until", nil).Error; err != nil {
+			return err
+		}
+		return runRules(tx, models.TriggerUpdated, &todo)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	db.Preload("User").Preload("Assignees").Preload("Tags").Preload("CustomFields").First(&todo, todo.ID)
+
+	c.JSON(http.StatusOK, todo)
+}
+
+// snoozeUntil works out when a todo snoozed with a preset comes back. now
+// must be in the user's time zone.
+func snoozeUntil(preset string, until *time.Time, now time.Time) (time.Time, error) {
+	loc := now.Location()
+	y, m, d := now.Date()
+
+	switch preset {
+	case snoozeLaterToday:
+		// Three hours from now, on the hour
+		later := now.Add(3 * time.Hour)
+		return time.Date(later.Year(), later.Month(), later.Day(), later.Hour()+1, 0, 0, 0, loc), nil
+	case snoozeTomorrow:
+		return time.Date(y, m, d+1, snoozeMorningHour, 0, 0, 0, loc), nil
+	case snoozeNextWeek:
+		days := (8 - int(now.Weekday())) % 7
+		if days == 0 {
+			days = 7
+		}
+		return time.Date(y, m, d+days, snoozeMorningHour, 0, 0, 0, loc), nil
+	case snoozeCustom:
+		if until == nil {
+			return time.Time{}, errors.New("until is required for a custom snooze")
+		}
+		if !until.After(now) {
+			return time.Time{}, errors.New("until must be in the future")
+		}
+		return *until, nil
+	}
+	return time.Time{}, errors.New("preset must be 'later_today', 'tomorrow', 'next_week' or 'custom'")
+}
+
+// cancelUnsnooze drops the pending "unsnoozed" notification of a todo.
+func cancelUnsnooze(tx *gorm.DB, todoID uint) error {
+	return tx.Where("todo_id = ? AND event = ? AND status = ?", todoID, models.EventUnsnoozed, models.ReminderPending).
+		Delete(&models.Reminder{}).Error
+}
@@ -0,0 +1,281 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"todo-api/internal/models"
+	"todo-api/internal/test"
+)
+
+func TestSnoozeUntil(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	assert.NoError(t, err)
+	// A Saturday, the night before clocks go back
+	now := time.Date(2026, 10, 24, 22, 15, 0, 0, berlin)
+	custom := time.Date(2026, 11, 2, 8, 0, 0, 0, time.UTC)
+	past := now.Add(-time.Minute)
+
+	tests := []struct {
+		name    string
+		preset  string
+		until   *time.Time
+		want    time.Time
+		wantErr bool
+	}{
+		{
+			name:   "Later today rounds up to the hour",
+			preset: "later_today",
+			want:   time.Date(2026, 10, 25, 2, 0, 0, 0, berlin),
+		},
+		{
+			name:   "Tomorrow morning",
+			preset: "tomorrow",
+			want:   time.Date(2026, 10, 25, 9, 0, 0, 0, berlin),
+		},
+		{
+			name:   "Next Monday morning",
+			preset: "next_week",
+			want:   time.Date(2026, 10, 26, 9, 0, 0, 0, berlin),
+		},
+		{
+			name:   "Custom time",
+			preset: "custom",
+			until:  &custom,
+			want:   custom,
+		},
+		{
+			name:    "Custom time in the past",
+			preset:  "custom",
+			until:   &past,
+			wantErr: true,
+		},
+		{
+			name:    "Custom without a time",
+			preset:  "custom",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := snoozeUntil(tt.preset, tt.until, now)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.True(t, tt.want.Equal(got), "got %s, want %s", got, tt.want)
+		})
+	}
+
+	// On a Monday, next week is a week away
+	monday := time.Date(2026, 10, 26, 8, 0, 0, 0, berlin)
+	got, err := snoozeUntil("next_week", nil, monday)
+	assert.NoError(t, err)
+	assert.True(t, time.Date(2026, 11, 2, 9, 0, 0, 0, berlin).Equal(got))
+}
+
+func TestSnoozeTodo(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db, _ := test.SetupTestDB()
+	test.ClearTestData(db)
+	testUser, err := test.CreateTestUser(db)
+	assert.NoError(t, err)
+
+	todo := &models.Todo{Title: "Call the bank", UserID: testUser.ID}
+	db.Create(todo)
+	done := &models.Todo{Title: "Done already", UserID: testUser.ID, Completed: true}
+	db.Create(done)
+	other := &models.Todo{Title: "Not mine", UserID: testUser.ID + 1}
+	db.Create(other)
+
+	router := gin.New()
+	router.POST("/todos/:id/snooze", func(c *gin.Context) {
+		c.Set("userID", testUser.ID)
+		SnoozeTodo(c)
+	})
+
+	until := time.Now().UTC().Add(48 * time.Hour).Truncate(time.Second)
+	tests := []struct {
+		name         string
+		todoID       uint
+		payload      map[string]interface{}
+		expectedCode int
+	}{
+		{
+			name:         "Snooze until tomorrow",
+			todoID:       todo.ID,
+			payload:      map[string]interface{}{"preset": "tomorrow"},
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "Snoozing again replaces the snooze",
+			todoID:       todo.ID,
+			payload:      map[string]interface{}{"preset": "custom", "until": until, "channel": "webhook"},
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "Unknown preset",
+			todoID:       todo.ID,
+			payload:      map[string]interface{}{"preset": "someday"},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "Unknown channel",
+			todoID:       todo.ID,
+			payload:      map[string]interface{}{"preset": "tomorrow", "channel": "pager"},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "Completed todo",
+			todoID:       done.ID,
+			payload:      map[string]interface{}{"preset": "tomorrow"},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "Another user's todo",
+			todoID:       other.ID,
+			payload:      map[string]interface{}{"preset": "tomorrow"},
+			expectedCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, _ := json.Marshal(tt.payload)
+			req, _ := http.NewRequest("POST", fmt.Sprintf("/todos/%d/snooze", tt.todoID), bytes.NewBuffer(body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+			if w.Code == http.StatusOK {
+				var response models.Todo
+				assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+				if assert.NotNil(t, response.HiddenUntil) {
+					assert.True(t, response.HiddenUntil.After(time.Now()))
+				}
+			}
+		})
+	}
+
+	// Only the latest snooze will notify
+	var wakes []models.Reminder
+	db.Where("todo_id = ? AND event = ?", todo.ID, models.EventUnsnoozed).Find(&wakes)
+	if assert.Len(t, wakes, 1) {
+		assert.Equal(t, "webhook", wakes[0].Channel)
+		assert.True(t, until.Equal(*wakes[0].FireAt))
+	}
+}
+
+func TestUnsnoozeTodo(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db, _ := test.SetupTestDB()
+	test.ClearTestData(db)
+	testUser, err := test.CreateTestUser(db)
+	assert.NoError(t, err)
+
+	until := time.Now().UTC().Add(time.Hour)
+	todo := &models.Todo{Title: "Call the bank", UserID: testUser.ID, HiddenUntil: &until}
+	db.Create(todo)
+	db.Create(&models.Reminder{TodoID: todo.ID, UserID: testUser.ID, RemindAt: &until, FireAt: &until, Channel: "in_app", Event: models.EventUnsnoozed, Status: models.ReminderPending})
+
+	router := gin.New()
+	router.DELETE("/todos/:id/snooze", func(c *gin.Context) {
+		c.Set("userID", testUser.ID)
+		UnsnoozeTodo(c)
+	})
+
+	req, _ := http.NewRequest("DELETE", fmt.Sprintf("/todos/%d/snooze", todo.ID), nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var response models.Todo
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Nil(t, response.HiddenUntil)
+
+	var pending int64
+	db.Model(&models.Reminder{}).Where("todo_id = ?", todo.ID).Count(&pending)
+	assert.Equal(t, int64(0), pending)
+}
+
+func TestGetTodosHidesSnoozed(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db, _ := test.SetupTestDB()
+	test.ClearTestData(db)
+	testUser, err := test.CreateTestUser(db)
+	assert.NoError(t, err)
+
+	later := time.Now().UTC().Add(time.Hour)
+	earlier := time.Now().UTC().Add(-time.Hour)
+	db.Create(&models.Todo{Title: "Visible", UserID: testUser.ID})
+	db.Create(&models.Todo{Title: "Snoozed", UserID: testUser.ID, HiddenUntil: &later})
+	db.Create(&models.Todo{Title: "Back from snooze", UserID: testUser.ID, HiddenUntil: &earlier})
+
+	router := gin.New()
+	router.GET("/todos", func(c *gin.Context) {
+		c.Set("userID", testUser.ID)
+		GetTodos(c)
+	})
+
+	tests := []struct {
+		name           string
+		query          string
+		expectedCode   int
+		expectedTitles []string
+	}{
+		{
+			name:           "Snoozed todos are hidden",
+			expectedCode:   http.StatusOK,
+			expectedTitles: []string{"Visible", "Back from snooze"},
+		},
+		{
+			name:           "Include snoozed todos",
+			query:          "?snoozed=true",
+			expectedCode:   http.StatusOK,
+			expectedTitles: []string{"Visible", "Snoozed", "Back from snooze"},
+		},
+		{
+			name:           "Only snoozed todos",
+			query:          "?snoozed=only",
+			expectedCode:   http.StatusOK,
+			expectedTitles: []string{"Snoozed"},
+		},
+		{
+			name:         "Invalid value",
+			query:        "?snoozed=maybe",
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", "/todos"+tt.query, nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+			if w.Code == http.StatusOK {
+				var response []models.Todo
+				assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+				var titles []string
+				for _, todo := range response {
+					titles = append(titles, todo.Title)
+				}
+				assert.ElementsMatch(t, tt.expectedTitles, titles)
+			}
+		})
+	}
+}
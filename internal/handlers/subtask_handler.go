@@ -0,0 +1,196 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"todo-api/internal/database"
+	"todo-api/internal/models"
+)
+
+var (
+	errInvalidParent = errors.New("parent todo not found")
+	errParentCycle   = errors.New("a todo cannot be nested under itself or one of its subtasks")
+	errParentProject = errors.New("a subtask must be in the same project as its parent")
+)
+
+// @Summary Get a todo's subtree
+// @Description Get a todo with all of its subtasks nested to any depth, each with a completion roll-up
+// @Tags todos
+// @Produce json
+// @Security Bearer
+// @Param id path int true "Todo ID"
+// @Success 200 {object} models.Todo
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/todos/{id}/subtree [get]
+func GetSubtree(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	id := c.Param("id")
+	var todo models.Todo
+
+	if err := accessibleTodos(database.GetDB(), userID.(uint)).Preload("Tags").Where("todos.id = ?", id).First(&todo).Error; err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Todo not found"})
+		return
+	}
+
+	descendants, err := loadDescendants(database.GetDB().Preload("Tags"), todo.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	buildTree(&todo, descendants)
+
+	c.JSON(http.StatusOK, todo)
+}
+
+// loadDescendants returns every subtask below the given todo, level by level.
+func loadDescendants(db *gorm.DB, rootID uint) ([]models.Todo, error) {
+	var all []models.Todo
+	frontier := []uint{rootID}
+	db = db.Session(&gorm.Session{})
+
+	for len(frontier) > 0 {
+		var level []models.Todo
+		if err := db.Where("parent_id IN ?", frontier).Order("id").Find(&level).Error; err != nil {
+			return nil, err
+		}
+
+		frontier = frontier[:0]
+		for _, todo := range level {
+			frontier = append(frontier, todo.ID)
+		}
+		all = append(all, level...)
+	}
+
+	return all, nil
+}
+
+// descendantIDs returns the IDs of every subtask below the given todo.
+func descendantIDs(db *gorm.DB, rootID uint) ([]uint, error) {
+	descendants, err := loadDescendants(db.Select("id", "parent_id"), rootID)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]uint, len(descendants))
+	for i, todo := range descendants {
+		ids[i] = todo.ID
+	}
+	return ids, nil
+}
+
+// buildTree nests the descendants under root and fills in the progress of
+// every node that has subtasks. It returns the roll-up for root.
+func buildTree(root *models.Todo, descendants []models.Todo) models.SubtaskProgress {
+	children := make(map[uint][]models.Todo)
+	for _, todo := range descendants {
+		children[*todo.ParentID] = append(children[*todo.ParentID], todo)
+	}
+
+	var attach func(node *models.Todo) models.SubtaskProgress
+	attach = func(node *models.Todo) models.SubtaskProgress {
+		var progress models.SubtaskProgress
+		node.Subtasks = children[node.ID]
+		for i := range node.Subtasks {
+			child := &node.Subtasks[i]
+			below := attach(child)
+			progress.Total += below.Total + 1
+			progress.Completed += below.Completed
+			if child.Completed {
+				progress.Completed++
+			}
+		}
+		if progress.Total > 0 {
+			p := progress
+			node.Progress = &p
+		}
+		return progress
+	}
+
+	return attach(root)
+}
+
+// subtaskProgress computes the completion roll-up for a todo without
+// nesting its subtasks in the response.
+func subtaskProgress(db *gorm.DB, todo *models.Todo) error {
+	descendants, err := loadDescendants(db.Select("id", "parent_id", "completed"), todo.ID)
+	if err != nil {
+		return err
+	}
+
+	progress := buildTree(todo, descendants)
+	todo.Subtasks = nil
+	if progress.Total > 0 {
+		todo.Progress = &progress
+	}
+	return nil
+}
+
+// validateParent checks that parentID names one of the user's todos in
+// projectID, and that attaching todoID beneath it would not create a cycle.
+// A nil projectID means the todo will take its parent's project.
+func validateParent(db *gorm.DB, userID uint, todoID uint, projectID *uint, parentID *uint) error {
+	if parentID == nil {
+		return nil
+	}
+
+	var parent models.Todo
+	if err := db.Where("id = ? AND user_id = ?", *parentID, userID).First(&parent).Error; err != nil {
+		return errInvalidParent
+	}
+	if projectID != nil && (parent.ProjectID == nil || *parent.ProjectID != *projectID) {
+		return errParentProject
+	}
+
+	if todoID == 0 {
+		return nil
+	}
+	if *parentID == todoID {
+		return errParentCycle
+	}
+
+	ids, err := descendantIDs(db, todoID)
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		if id == *parentID {
+			return errParentCycle
+		}
+	}
+
+	return nil
+}
+
+// rollUpCompletion completes or reopens the parent of a todo whose
+// completion changed, if the parent opted into auto-completion. Changes
+// propagate further up through setCompletion.
+func rollUpCompletion(tx *gorm.DB, todo *models.Todo) error {
+	if todo.ParentID == nil {
+		return nil
+	}
+
+	var parent models.Todo
+	if err := tx.First(&parent, *todo.ParentID).Error; err != nil || !parent.AutoComplete {
+		return nil
+	}
+
+	var total, open int64
+	children := tx.Model(&models.Todo{}).Where("parent_id = ?", parent.ID)
+	if err := children.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return err
+	}
+	if total == 0 {
+		return nil
+	}
+	if err := children.Session(&gorm.Session{}).Where("completed = ?", false).Count(&open).Error; err != nil {
+		return err
+	}
+
+	return setCompletion(tx, &parent, open == 0)
+}
@@ -0,0 +1,248 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"todo-api/internal/models"
+	"todo-api/internal/test"
+)
+
+func TestGetSubtree(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db, _ := test.SetupTestDB()
+	testUser, err := test.CreateTestUser(db)
+	assert.NoError(t, err)
+
+	root := &models.Todo{Title: "Release", UserID: testUser.ID}
+	db.Create(root)
+	child := &models.Todo{Title: "Write notes", UserID: testUser.ID, ParentID: &root.ID}
+	db.Create(child)
+	db.Create(&models.Todo{Title: "Tag build", UserID: testUser.ID, ParentID: &root.ID, Completed: true})
+	db.Create(&models.Todo{Title: "Draft", UserID: testUser.ID, ParentID: &child.ID, Completed: true})
+
+	router := gin.New()
+	router.GET("/todos/:id/subtree", func(c *gin.Context) {
+		c.Set("userID", testUser.ID)
+		GetSubtree(c)
+	})
+
+	req, _ := http.NewRequest("GET", fmt.Sprintf("/todos/%d/subtree", root.ID), nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response models.Todo
+	err = json.Unmarshal(w.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	assert.Len(t, response.Subtasks, 2)
+	assert.Equal(t, &models.SubtaskProgress{Completed: 2, Total: 3}, response.Progress)
+	assert.Len(t, response.Subtasks[0].Subtasks, 1)
+	assert.Equal(t, &models.SubtaskProgress{Completed: 1, Total: 1}, response.Subtasks[0].Progress)
+}
+
+func TestUpdateTodoSubtasks(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db, _ := test.SetupTestDB()
+	testUser, err := test.CreateTestUser(db)
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name           string
+		target         string
+		reqBody        func(parent, first, second *models.Todo) map[string]interface{}
+		wantStatus     int
+		wantParentDone bool
+	}{
+		{
+			name:   "Completing the last subtask completes the parent",
+			target: "second",
+			reqBody: func(parent, first, second *models.Todo) map[string]interface{} {
+				return map[string]interface{}{"title": second.Title, "completed": true, "parent_id": parent.ID}
+			},
+			wantStatus:     http.StatusOK,
+			wantParentDone: true,
+		},
+		{
+			name:   "Moving the open subtask away completes the parent",
+			target: "second",
+			reqBody: func(parent, first, second *models.Todo) map[string]interface{} {
+				return map[string]interface{}{"title": second.Title}
+			},
+			wantStatus:     http.StatusOK,
+			wantParentDone: true,
+		},
+		{
+			name:   "Nesting a todo under its own subtask is rejected",
+			target: "parent",
+			reqBody: func(parent, first, second *models.Todo) map[string]interface{} {
+				return map[string]interface{}{"title": parent.Title, "auto_complete": true, "parent_id": first.ID}
+			},
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+
+			test.ClearTestData(db)
+			parent := &models.Todo{Title: "Parent", UserID: testUser.ID, AutoComplete: true}
+			db.Create(parent)
+			first := &models.Todo{Title: "First", UserID: testUser.ID, ParentID: &parent.ID, Completed: true}
+			second := &models.Todo{Title: "Second", UserID: testUser.ID, ParentID: &parent.ID}
+			db.Create(first)
+			db.Create(second)
+
+			targets := map[string]*models.Todo{"parent": parent, "second": second}
+
+			router.PUT("/todos/:id", func(c *gin.Context) {
+				c.Set("userID", testUser.ID)
+				UpdateTodo(c)
+			})
+
+			jsonBody, _ := json.Marshal(tt.reqBody(parent, first, second))
+			req, _ := http.NewRequest("PUT", fmt.Sprintf("/todos/%d", targets[tt.target].ID), bytes.NewBuffer(jsonBody))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+
+			var reloaded models.Todo
+			db.First(&reloaded, parent.ID)
+			assert.Equal(t, tt.wantParentDone, reloaded.Completed)
+		})
+	}
+}
+
+func TestSubtasksStayInTheirParentsProject(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db, _ := test.SetupTestDB()
+	testUser, err := test.CreateTestUser(db)
+	assert.NoError(t, err)
+	test.ClearTestData(db)
+
+	work := &models.Project{Name: "Work", UserID: testUser.ID}
+	db.Create(work)
+	home := &models.Project{Name: "Home", UserID: testUser.ID}
+	db.Create(home)
+	parent := &models.Todo{Title: "Release", UserID: testUser.ID, ProjectID: &work.ID}
+	db.Create(parent)
+	stray := &models.Todo{Title: "Water the plants", UserID: testUser.ID, ProjectID: &home.ID}
+	db.Create(stray)
+
+	router := gin.New()
+	router.POST("/todos", func(c *gin.Context) {
+		c.Set("userID", testUser.ID)
+		CreateTodo(c)
+	})
+	router.PUT("/todos/:id", func(c *gin.Context) {
+		c.Set("userID", testUser.ID)
+		UpdateTodo(c)
+	})
+	send := func(method string, url string, payload interface{}) (int, []byte) {
+		jsonBody, _ := json.Marshal(payload)
+		req, _ := http.NewRequest(method, url, bytes.NewBuffer(jsonBody))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code, w.Body.Bytes()
+	}
+
+	code, _ := send("PUT", fmt.Sprintf("/todos/%d", stray.ID), map[string]interface{}{"title": stray.Title, "parent_id": parent.ID})
+	assert.Equal(t, http.StatusBadRequest, code)
+	var reloaded models.Todo
+	db.First(&reloaded, stray.ID)
+	assert.Nil(t, reloaded.ParentID)
+
+	code, _ = send("POST", "/todos", map[string]interface{}{"title": "Plant tomatoes", "parent_id": parent.ID, "project_id": home.ID})
+	assert.Equal(t, http.StatusBadRequest, code)
+
+	// Without a project the subtask joins its parent's
+	code, body := send("POST", "/todos", map[string]interface{}{"title": "Write notes", "parent_id": parent.ID})
+	assert.Equal(t, http.StatusCreated, code)
+	var created models.Todo
+	assert.NoError(t, json.Unmarshal(body, &created))
+	assert.Equal(t, work.ID, *created.ProjectID)
+}
+
+func TestDeleteTodoCascade(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db, _ := test.SetupTestDB()
+	testUser, err := test.CreateTestUser(db)
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name          string
+		query         string
+		wantStatus    int
+		wantRemaining int64
+	}{
+		{
+			name:          "Delete the whole subtree",
+			query:         "",
+			wantStatus:    http.StatusNoContent,
+			wantRemaining: 1,
+		},
+		{
+			name:          "Promote subtasks to top-level",
+			query:         "?cascade=promote",
+			wantStatus:    http.StatusNoContent,
+			wantRemaining: 3,
+		},
+		{
+			name:          "Unknown cascade mode",
+			query:         "?cascade=keep",
+			wantStatus:    http.StatusBadRequest,
+			wantRemaining: 4,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+
+			test.ClearTestData(db)
+			grandparent := &models.Todo{Title: "Grandparent", UserID: testUser.ID}
+			db.Create(grandparent)
+			parent := &models.Todo{Title: "Parent", UserID: testUser.ID, ParentID: &grandparent.ID}
+			db.Create(parent)
+			child := &models.Todo{Title: "Child", UserID: testUser.ID, ParentID: &parent.ID}
+			db.Create(child)
+			db.Create(&models.Todo{Title: "Grandchild", UserID: testUser.ID, ParentID: &child.ID})
+
+			router.DELETE("/todos/:id", func(c *gin.Context) {
+				c.Set("userID", testUser.ID)
+				DeleteTodo(c)
+			})
+
+			req, _ := http.NewRequest("DELETE", fmt.Sprintf("/todos/%d%s", parent.ID, tt.query), nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+
+			var remaining int64
+			db.Model(&models.Todo{}).Where("user_id = ?", testUser.ID).Count(&remaining)
+			assert.Equal(t, tt.wantRemaining, remaining)
+
+			if tt.query == "?cascade=promote" {
+				var reloaded models.Todo
+				db.First(&reloaded, child.ID)
+				assert.Nil(t, reloaded.ParentID)
+			}
+		})
+	}
+}
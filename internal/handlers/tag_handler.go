@@ -0,0 +1,432 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"todo-api/internal/database"
+	"todo-api/internal/models"
+)
+
+type MergeTagRequest struct {
+	TargetID uint `json:"target_id" binding:"required" example:"2"`
+}
+
+// @Summary Create a new tag
+// @Description Create a new tag for the authenticated user
+// @Tags tags
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param tag body models.Tag true "Tag object"
+// @Success 201 {object} models.Tag
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/tags [post]
+func CreateTag(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	var tag models.Tag
+	if err := c.BindJSON(&tag); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	tag.Name = strings.TrimSpace(tag.Name)
+	tag.UserID = userID.(uint)
+	if tag.Name == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "name must not be empty"})
+		return
+	}
+
+	if tagNameTaken(tag.UserID, tag.Name, 0) {
+		c.JSON(http.StatusConflict, ErrorResponse{Error: "Tag already exists"})
+		return
+	}
+
+	result := database.GetDB().Omit("Todos").Create(&tag)
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: result.Error.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, tag)
+}
+
+// @Summary Get all tags
+// @Description Get all tags for the authenticated user with the number of todos carrying each
+// @Tags tags
+// @Produce json
+// @Security Bearer
+// @Success 200 {array} models.Tag
+// @Failure 401 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/tags [get]
+func GetTags(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	var tags []models.Tag
+	result := database.GetDB().Where("user_id = ?", userID).Order("name").Find(&tags)
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: result.Error.Error()})
+		return
+	}
+
+	if err := loadTagCounts(tags); err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, tags)
+}
+
+// @Summary Get a tag
+// @Description Get a specific tag by ID
+// @Tags tags
+// @Produce json
+// @Security Bearer
+// @Param id path int true "Tag ID"
+// @Success 200 {object} models.Tag
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/tags/{id} [get]
+func GetTag(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	id := c.Param("id")
+	var tag models.Tag
+
+	if err := database.GetDB().Where("id = ? AND user_id = ?", id, userID).First(&tag).Error; err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Tag not found"})
+		return
+	}
+
+	tags := []models.Tag{tag}
+	if err := loadTagCounts(tags); err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, tags[0])
+}
+
+// @Summary Update a tag
+// @Description Rename or recolor a tag; every todo carrying it reflects the change
+// @Tags tags
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "Tag ID"
+// @Param tag body models.Tag true "Tag object"
+// @Success 200 {object} models.Tag
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/tags/{id} [put]
+func UpdateTag(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	id := c.Param("id")
+	var tag models.Tag
+
+	if err := database.GetDB().Where("id = ? AND user_id = ?", id, userID).First(&tag).Error; err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Tag not found"})
+		return
+	}
+
+	var updateData models.Tag
+	if err := c.BindJSON(&updateData); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	name := strings.TrimSpace(updateData.Name)
+	if name == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "name must not be empty"})
+		return
+	}
+	if tagNameTaken(tag.UserID, name, tag.ID) {
+		c.JSON(http.StatusConflict, ErrorResponse{Error: "Another tag already has this name, merge the tags instead"})
+		return
+	}
+
+	result := database.GetDB().Model(&tag).Updates(map[string]interface{}{
+		"name":  name,
+		"color": updateData.Color,
+	})
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: result.Error.Error()})
+		return
+	}
+
+	database.GetDB().First(&tag, tag.ID)
+	tags := []models.Tag{tag}
+	if err := loadTagCounts(tags); err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, tags[0])
+}
+
+// @Summary Delete a tag
+// @Description Delete a tag and detach it from every todo
+// @Tags tags
+// @Security Bearer
+// @Param id path int true "Tag ID"
+// @Success 204 "No Content"
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/tags/{id} [delete]
+func DeleteTag(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	id := c.Param("id")
+	var tag models.Tag
+
+	if err := database.GetDB().Where("id = ? AND user_id = ?", id, userID).First(&tag).Error; err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Tag not found"})
+		return
+	}
+
+	err := database.GetDB().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("DELETE FROM todo_tags WHERE tag_id = ?", tag.ID).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&tag).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// @Summary Merge a tag into another
+// @Description Move every todo carrying the tag onto the target tag, then delete the tag
+// @Tags tags
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "Tag ID to merge away"
+// @Param request body MergeTagRequest true "Tag to merge into"
+// @Success 200 {object} models.Tag
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/tags/{id}/merge [post]
+func MergeTag(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	id := c.Param("id")
+
+	var req MergeTagRequest
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	var source, target models.Tag
+	if err := database.GetDB().Where("id = ? AND user_id = ?", id, userID).First(&source).Error; err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Tag not found"})
+		return
+	}
+	if err := database.GetDB().Where("id = ? AND user_id = ?", req.TargetID, userID).First(&target).Error; err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Target tag not found"})
+		return
+	}
+	if source.ID == target.ID {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Cannot merge a tag into itself"})
+		return
+	}
+
+	err := database.GetDB().Transaction(func(tx *gorm.DB) error {
+		err := tx.Exec(`INSERT INTO todo_tags (todo_id, tag_id)
+			SELECT todo_id, ? FROM todo_tags
+			WHERE tag_id = ? AND todo_id NOT IN (SELECT todo_id FROM todo_tags WHERE tag_id = ?)`,
+			target.ID, source.ID, target.ID).Error
+		if err != nil {
+			return err
+		}
+		if err := tx.Exec("DELETE FROM todo_tags WHERE tag_id = ?", source.ID).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&source).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	tags := []models.Tag{target}
+	if err := loadTagCounts(tags); err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, tags[0])
+}
+
+// @Summary Attach a tag to a todo
+// @Description Attach one of the user's tags to a todo
+// @Tags todos
+// @Produce json
+// @Security Bearer
+// @Param id path int true "Todo ID"
+// @Param tagId path int true "Tag ID"
+// @Success 200 {object} models.Todo
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/todos/{id}/tags/{tagId} [post]
+func AttachTag(c *gin.Context) {
+	todo, tag, ok := findTodoAndTag(c)
+	if !ok {
+		return
+	}
+
+	err := database.GetDB().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&todo).Association("Tags").Append(&tag); err != nil {
+			return err
+		}
+		return runRules(tx, models.TriggerUpdated, &todo)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	database.GetDB().Preload("User").Preload("Assignees").Preload("Tags").First(&todo, todo.ID)
+
+	c.JSON(http.StatusOK, todo)
+}
+
+// @Summary Detach a tag from a todo
+// @Description Remove a tag from a todo without deleting the tag
+// @Tags todos
+// @Produce json
+// @Security Bearer
+// @Param id path int true "Todo ID"
+// @Param tagId path int true "Tag ID"
+// @Success 200 {object} models.Todo
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/todos/{id}/tags/{tagId} [delete]
+func DetachTag(c *gin.Context) {
+	todo, tag, ok := findTodoAndTag(c)
+	if !ok {
+		return
+	}
+
+	err := database.GetDB().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&todo).Association("Tags").Delete(&tag); err != nil {
+			return err
+		}
+		return runRules(tx, models.TriggerUpdated, &todo)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	database.GetDB().Preload("User").Preload("Assignees").Preload("Tags").First(&todo, todo.ID)
+
+	c.JSON(http.StatusOK, todo)
+}
+
+// findTodoAndTag loads the todo and tag named in the route, writing a 404
+// response when either does not belong to the user.
+func findTodoAndTag(c *gin.Context) (models.Todo, models.Tag, bool) {
+	userID, _ := c.Get("userID")
+	var todo models.Todo
+	var tag models.Tag
+
+	if err := database.GetDB().Where("id = ? AND user_id = ?", c.Param("id"), userID).First(&todo).Error; err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Todo not found"})
+		return todo, tag, false
+	}
+	if err := database.GetDB().Where("id = ? AND user_id = ?", c.Param("tagId"), userID).First(&tag).Error; err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Tag not found"})
+		return todo, tag, false
+	}
+
+	return todo, tag, true
+}
+
+// tagNameTaken reports whether the user already has a tag with this name,
+// ignoring case and the tag being renamed.
+func tagNameTaken(userID uint, name string, exceptID uint) bool {
+	var count int64
+	database.GetDB().Model(&models.Tag{}).
+		Where("user_id = ? AND LOWER(name) = LOWER(?) AND id <> ?", userID, name, exceptID).
+		Count(&count)
+	return count > 0
+}
+
+// findOrCreateTags returns the IDs of the user's tags with the given names,
+// keyed by lowercased name, creating the tags that do not exist yet.
+func findOrCreateTags(tx *gorm.DB, userID uint, names []string) (map[string]uint, error) {
+	ids := make(map[string]uint)
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		key := strings.ToLower(name)
+		if _, ok := ids[key]; ok || name == "" {
+			continue
+		}
+
+		var tags []models.Tag
+		if err := tx.Where("user_id = ? AND LOWER(name) = ?", userID, key).Limit(1).Find(&tags).Error; err != nil {
+			return nil, err
+		}
+		if len(tags) == 0 {
+			tags = append(tags, models.Tag{Name: name, UserID: userID})
+			if err := tx.Omit("Todos").Create(&tags[0]).Error; err != nil {
+				return nil, err
+			}
+		}
+		ids[key] = tags[0].ID
+	}
+	return ids, nil
+}
+
+// loadTagCounts fills in TodoCount for each tag.
+func loadTagCounts(tags []models.Tag) error {
+	if len(tags) == 0 {
+		return nil
+	}
+
+	ids := make([]uint, len(tags))
+	for i, tag := range tags {
+		ids[i] = tag.ID
+	}
+
+	var rows []struct {
+		TagID uint
+		Count int64
+	}
+	err := database.GetDB().Table("todo_tags").
+		Select("todo_tags.tag_id, COUNT(*) AS count").
+		Joins("JOIN todos ON todos.id = todo_tags.todo_id AND todos.deleted_at IS NULL").
+		Where("todo_tags.tag_id IN ?", ids).
+		Group("todo_tags.tag_id").
+		Scan(&rows).Error
+	if err != nil {
+		return err
+	}
+
+	counts := make(map[uint]int64, len(rows))
+	for _, row := range rows {
+		counts[row.TagID] = row.Count
+	}
+	for i := range tags {
+		tags[i].TodoCount = counts[tags[i].ID]
+	}
+
+	return nil
+}
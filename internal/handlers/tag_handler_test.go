@@ -0,0 +1,242 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"todo-api/internal/models"
+	"todo-api/internal/test"
+)
+
+func TestCreateTag(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db, _ := test.SetupTestDB()
+	testUser, err := test.CreateTestUser(db)
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name       string
+		existing   string
+		reqBody    map[string]interface{}
+		wantStatus int
+	}{
+		{
+			name: "Valid tag creation",
+			reqBody: map[string]interface{}{
+				"name":  "work",
+				"color": "#ff8800",
+			},
+			wantStatus: http.StatusCreated,
+		},
+		{
+			name: "Missing name",
+			reqBody: map[string]interface{}{
+				"color": "#ff8800",
+			},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "Blank name",
+			reqBody: map[string]interface{}{
+				"name": "   ",
+			},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "Invalid color",
+			reqBody: map[string]interface{}{
+				"name":  "work",
+				"color": "orange",
+			},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:     "Duplicate name ignoring case",
+			existing: "Work",
+			reqBody: map[string]interface{}{
+				"name": "work",
+			},
+			wantStatus: http.StatusConflict,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+
+			test.ClearTestData(db)
+			if tt.existing != "" {
+				db.Create(&models.Tag{Name: tt.existing, UserID: testUser.ID})
+			}
+
+			router.POST("/tags", func(c *gin.Context) {
+				c.Set("userID", testUser.ID)
+				CreateTag(c)
+			})
+
+			jsonBody, _ := json.Marshal(tt.reqBody)
+			req, _ := http.NewRequest("POST", "/tags", bytes.NewBuffer(jsonBody))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+
+			if w.Code == http.StatusCreated {
+				var response models.Tag
+				err := json.Unmarshal(w.Body.Bytes(), &response)
+				assert.NoError(t, err)
+				assert.Equal(t, tt.reqBody["name"], response.Name)
+				assert.Equal(t, testUser.ID, response.UserID)
+			}
+		})
+	}
+}
+
+func TestUpdateTag(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db, _ := test.SetupTestDB()
+	test.ClearTestData(db)
+	testUser, err := test.CreateTestUser(db)
+	assert.NoError(t, err)
+
+	tag := &models.Tag{Name: "work", UserID: testUser.ID}
+	db.Create(tag)
+	db.Create(&models.Tag{Name: "home", UserID: testUser.ID})
+
+	router := gin.New()
+	router.PUT("/tags/:id", func(c *gin.Context) {
+		c.Set("userID", testUser.ID)
+		UpdateTag(c)
+	})
+
+	tests := []struct {
+		name       string
+		reqBody    map[string]interface{}
+		wantStatus int
+		wantName   string
+	}{
+		{
+			name:       "Rename",
+			reqBody:    map[string]interface{}{"name": " office "},
+			wantStatus: http.StatusOK,
+			wantName:   "office",
+		},
+		{
+			name:       "Blank name",
+			reqBody:    map[string]interface{}{"name": "   "},
+			wantStatus: http.StatusBadRequest,
+			wantName:   "office",
+		},
+		{
+			name:       "Name of another tag",
+			reqBody:    map[string]interface{}{"name": "Home"},
+			wantStatus: http.StatusConflict,
+			wantName:   "office",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			jsonBody, _ := json.Marshal(tt.reqBody)
+			req, _ := http.NewRequest("PUT", fmt.Sprintf("/tags/%d", tag.ID), bytes.NewBuffer(jsonBody))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			var stored models.Tag
+			db.First(&stored, tag.ID)
+			assert.Equal(t, tt.wantName, stored.Name)
+		})
+	}
+}
+
+func TestGetTagsCounts(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db, _ := test.SetupTestDB()
+	testUser, err := test.CreateTestUser(db)
+	assert.NoError(t, err)
+
+	work := &models.Tag{Name: "work", UserID: testUser.ID}
+	home := &models.Tag{Name: "home", UserID: testUser.ID}
+	db.Create(work)
+	db.Create(home)
+	db.Create(&models.Todo{Title: "One", UserID: testUser.ID, Tags: []models.Tag{*work}})
+	db.Create(&models.Todo{Title: "Two", UserID: testUser.ID, Tags: []models.Tag{*work, *home}})
+
+	router := gin.New()
+	router.GET("/tags", func(c *gin.Context) {
+		c.Set("userID", testUser.ID)
+		GetTags(c)
+	})
+
+	req, _ := http.NewRequest("GET", "/tags", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response []models.Tag
+	err = json.Unmarshal(w.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	counts := map[string]int64{}
+	for _, tag := range response {
+		counts[tag.Name] = tag.TodoCount
+	}
+	assert.Equal(t, map[string]int64{"work": 2, "home": 1}, counts)
+}
+
+func TestMergeTag(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db, _ := test.SetupTestDB()
+	testUser, err := test.CreateTestUser(db)
+	assert.NoError(t, err)
+
+	source := &models.Tag{Name: "job", UserID: testUser.ID}
+	target := &models.Tag{Name: "work", UserID: testUser.ID}
+	db.Create(source)
+	db.Create(target)
+	onlySource := &models.Todo{Title: "One", UserID: testUser.ID, Tags: []models.Tag{*source}}
+	both := &models.Todo{Title: "Two", UserID: testUser.ID, Tags: []models.Tag{*source, *target}}
+	db.Create(onlySource)
+	db.Create(both)
+
+	router := gin.New()
+	router.POST("/tags/:id/merge", func(c *gin.Context) {
+		c.Set("userID", testUser.ID)
+		MergeTag(c)
+	})
+
+	jsonBody, _ := json.Marshal(map[string]interface{}{"target_id": target.ID})
+	req, _ := http.NewRequest("POST", fmt.Sprintf("/tags/%d/merge", source.ID), bytes.NewBuffer(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response models.Tag
+	err = json.Unmarshal(w.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), response.TodoCount)
+
+	var sourceCount int64
+	db.Model(&models.Tag{}).Where("id = ?", source.ID).Count(&sourceCount)
+	assert.Equal(t, int64(0), sourceCount)
+
+	var loaded models.Todo
+	db.Preload("Tags").First(&loaded, onlySource.ID)
+	assert.Len(t, loaded.Tags, 1)
+	assert.Equal(t, "work", loaded.Tags[0].Name)
+}
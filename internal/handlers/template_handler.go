@@ -0,0 +1,515 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"todo-api/internal/database"
+	"todo-api/internal/models"
+)
+
+const (
+	maxTemplateItems = 200
+	maxTemplateDepth = 10
+)
+
+var templateVariable = regexp.MustCompile(`\{\{\s*([A-Za-z_][A-Za-z0-9_]*)\s*\}\}`)
+
+type SaveTemplateRequest struct {
+	Name string `json:"name" binding:"required" example:"Release checklist"`
+}
+
+type InstantiateTemplateRequest struct {
+	Start     string            `json:"start" example:"2026-02-01"` // Date due offsets count from; today when left out
+	Variables map[string]string `json:"variables"`
+	ProjectID *uint             `json:"project_id" example:"1"`
+	ParentID  *uint             `json:"parent_id" example:"1"`
+}
+
+// @Summary Create a template
+// @Description Create a template for a todo tree. Due offsets such as 2d17h are counted from the start date given when it is instantiated.
+// @Tags templates
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param template body models.Template true "Template"
+// @Success 201 {object} models.Template
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/templates [post]
+func CreateTemplate(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	var template models.Template
+	if err := c.BindJSON(&template); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	template.UserID = userID.(uint)
+	template.Name = strings.TrimSpace(template.Name)
+	if err := validateTemplate(&template.Todo); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	if err := database.GetDB().Create(&template).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, template)
+}
+
+// @Summary Get all templates
+// @Description Get the authenticated user's templates
+// @Tags templates
+// @Produce json
+// @Security Bearer
+// @Success 200 {array} models.Template
+// @Failure 401 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/templates [get]
+func GetTemplates(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	var templates []models.Template
+	result := database.GetDB().Where("user_id = ?", userID).Order("name").Find(&templates)
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: result.Error.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, templates)
+}
+
+// @Summary Get a template
+// @Description Get a specific template by ID
+// @Tags templates
+// @Produce json
+// @Security Bearer
+// @Param id path int true "Template ID"
+// @Success 200 {object} models.Template
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/templates/{id} [get]
+func GetTemplate(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	var template models.Template
+
+	if err := database.GetDB().Where("id = ? AND user_id = ?", c.Param("id"), userID).First(&template).Error; err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Template not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, template)
+}
+
+// @Summary Update a template
+// @Description Rename a template and replace its todo tree
+// @Tags templates
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "Template ID"
+// @Param template body models.Template true "Template"
+// @Success 200 {object} models.Template
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/templates/{id} [put]
+func UpdateTemplate(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	var template models.Template
+
+	if err := database.GetDB().Where("id = ? AND user_id = ?", c.Param("id"), userID).First(&template).Error; err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Template not found"})
+		return
+	}
+
+	var updateData models.Template
+	if err := c.BindJSON(&updateData); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+	if err := validateTemplate(&updateData.Todo); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	result := database.GetDB().Model(&template).Updates(map[string]interface{}{
+		"name": strings.TrimSpace(updateData.Name),
+		"todo": updateData.Todo,
+	})
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: result.Error.Error()})
+		return
+	}
+
+	database.GetDB().First(&template, template.ID)
+	c.JSON(http.StatusOK, template)
+}
+
+// @Summary Delete a template
+// @Description Delete a template. Todos created from it are kept.
+// @Tags templates
+// @Security Bearer
+// @Param id path int true "Template ID"
+// @Success 204 "No Content"
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/templates/{id} [delete]
+func DeleteTemplate(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	result := database.GetDB().Where("id = ? AND user_id = ?", c.Param("id"), userID).Delete(&models.Template{})
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: result.Error.Error()})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Template not found"})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// @Summary Save a todo as a template
+// @Description Capture a todo with its description, tags and subtasks as a template. Due dates become offsets from the day the todo is due, or the day it was created. Only the todo's creator can capture it.
+// @Tags templates
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "Todo ID"
+// @Param request body SaveTemplateRequest true "Template name"
+// @Success 201 {object} models.Template
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/todos/{id}/template [post]
+func SaveAsTemplate(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	db := database.GetDB()
+	var todo models.Todo
+
+	if err := db.Preload("Tags").Where("id = ? AND user_id = ?", c.Param("id"), userID).First(&todo).Error; err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Todo not found"})
+		return
+	}
+
+	var req SaveTemplateRequest
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	descendants, err := loadDescendants(db.Preload("Tags"), todo.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+	buildTree(&todo, descendants)
+
+	loc, err := userLocation(db, todo.UserID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+	start := todo.CreatedAt
+	if todo.DueDate != nil {
+		start = *todo.DueDate
+	}
+
+	template := models.Template{
+		UserID: todo.UserID,
+		Name:   strings.TrimSpace(req.Name),
+		Todo:   templateItemFor(&todo, startOfDay(start.In(loc))),
+	}
+	if err := validateTemplate(&template.Todo); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	if err := db.Create(&template).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, template)
+}
+
+// @Summary Instantiate a template
+// @Description Create the template's whole todo tree in one go. {{date}} in titles and descriptions becomes the start date, and other {{variables}} are taken from the request.
+// @Tags templates
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "Template ID"
+// @Param request body InstantiateTemplateRequest false "Start date, variables and placement"
+// @Success 201 {object} models.Todo
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/templates/{id}/instantiate [post]
+func InstantiateTemplate(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	db := database.GetDB()
+	var template models.Template
+
+	if err := db.Where("id = ? AND user_id = ?", c.Param("id"), userID).First(&template).Error; err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Template not found"})
+		return
+	}
+
+	var req InstantiateTemplateRequest
+	if err := bindOptionalJSON(c, &req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	loc, err := userLocation(db, template.UserID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+	start := startOfDay(time.Now().In(loc))
+	if req.Start != "" {
+		if start, err = time.ParseInLocation(reportDateLayout, req.Start, loc); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "start must be a date like 2026-01-31"})
+			return
+		}
+	}
+
+	variables := map[string]string{"date": start.Format(reportDateLayout)}
+	for name, value := range req.Variables {
+		variables[name] = value
+	}
+	item, err := expandTemplate(template.Todo, variables)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	root := models.Todo{UserID: template.UserID, ProjectID: req.ProjectID, ParentID: req.ParentID}
+	if err := validateParent(db, root.UserID, 0, root.ProjectID, root.ParentID); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+	if err := assignProject(db, &root); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	var created *models.Todo
+	err = db.Transaction(func(tx *gorm.DB) error {
+		tagIDs, err := findOrCreateTags(tx, root.UserID, templateTags(&item, nil))
+		if err != nil {
+			return err
+		}
+		created, err = createFromTemplate(tx, &item, root, start, tagIDs)
+		if err != nil {
+			return err
+		}
+		if err := rollUpCompletion(tx, created); err != nil {
+			return err
+		}
+		descendants, err := loadDescendants(tx, created.ID)
+		if err != nil {
+			return err
+		}
+		if err := runRules(tx, models.TriggerCreated, created); err != nil {
+			return err
+		}
+		for i := range descendants {
+			if err := runRules(tx, models.TriggerCreated, &descendants[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	db.Preload("Tags").First(created, created.ID)
+	descendants, err := loadDescendants(db.Preload("Tags"), created.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+	buildTree(created, descendants)
+
+	c.JSON(http.StatusCreated, created)
+}
+
+// validateTemplate checks a template's todo tree, tidying titles and tags
+// in place.
+func validateTemplate(root *models.TemplateItem) error {
+	count := 0
+	var check func(item *models.TemplateItem, depth int) error
+	check = func(item *models.TemplateItem, depth int) error {
+		count++
+		if count > maxTemplateItems {
+			return fmt.Errorf("templates can hold at most %d todos", maxTemplateItems)
+		}
+		if depth > maxTemplateDepth {
+			return fmt.Errorf("templates can be nested at most %d levels deep", maxTemplateDepth)
+		}
+
+		item.Title = strings.TrimSpace(item.Title)
+		if item.Title == "" {
+			return errors.New("every todo in a template needs a title")
+		}
+		if _, err := item.DueDate(time.Now()); err != nil {
+			return err
+		}
+
+		var tags []string
+		seen := make(map[string]bool)
+		for _, tag := range item.Tags {
+			tag = strings.TrimSpace(tag)
+			if tag != "" && !seen[strings.ToLower(tag)] {
+				seen[strings.ToLower(tag)] = true
+				tags = append(tags, tag)
+			}
+		}
+		item.Tags = tags
+
+		for i := range item.Subtasks {
+			if err := check(&item.Subtasks[i], depth+1); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return check(root, 1)
+}
+
+// templateItemFor turns a todo tree, as built by buildTree, into a template
+// whose due offsets count from start.
+func templateItemFor(todo *models.Todo, start time.Time) models.TemplateItem {
+	item := models.TemplateItem{
+		Title:        todo.Title,
+		Description:  todo.Description,
+		AutoComplete: todo.AutoComplete,
+	}
+	for _, tag := range todo.Tags {
+		item.Tags = append(item.Tags, tag.Name)
+	}
+	if todo.DueDate != nil {
+		item.DueOffset = models.FormatOffset(start, todo.DueDate.In(start.Location()))
+	}
+	for i := range todo.Subtasks {
+		item.Subtasks = append(item.Subtasks, templateItemFor(&todo.Subtasks[i], start))
+	}
+	return item
+}
+
+// expandTemplate returns a copy of the tree with the variables in titles
+// and descriptions filled in. It fails if any variable has no value.
+func expandTemplate(item models.TemplateItem, variables map[string]string) (models.TemplateItem, error) {
+	missing := make(map[string]bool)
+	untitled := false
+	expand := func(text string) string {
+		return templateVariable.ReplaceAllStringFunc(text, func(match string) string {
+			name := templateVariable.FindStringSubmatch(match)[1]
+			value, ok := variables[name]
+			if !ok {
+				missing[name] = true
+			}
+			return value
+		})
+	}
+
+	var walk func(item models.TemplateItem) models.TemplateItem
+	walk = func(item models.TemplateItem) models.TemplateItem {
+		item.Title = strings.TrimSpace(expand(item.Title))
+		untitled = untitled || item.Title == ""
+		item.Description = expand(item.Description)
+		subtasks := make([]models.TemplateItem, len(item.Subtasks))
+		for i := range item.Subtasks {
+			subtasks[i] = walk(item.Subtasks[i])
+		}
+		item.Subtasks = subtasks
+		return item
+	}
+	expanded := walk(item)
+
+	if len(missing) > 0 {
+		names := make([]string, 0, len(missing))
+		for name := range missing {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return expanded, fmt.Errorf("missing template variables: %s", strings.Join(names, ", "))
+	}
+	if untitled {
+		return expanded, errors.New("the template variables leave a todo without a title")
+	}
+	return expanded, nil
+}
+
+// templateTags collects the tag names used anywhere in the tree.
+func templateTags(item *models.TemplateItem, names []string) []string {
+	names = append(names, item.Tags...)
+	for i := range item.Subtasks {
+		names = templateTags(&item.Subtasks[i], names)
+	}
+	return names
+}
+
+// createFromTemplate creates the todo for an item and, below it, its
+// subtasks. base carries the owner, project and parent of the new todo.
+func createFromTemplate(tx *gorm.DB, item *models.TemplateItem, base models.Todo, start time.Time, tagIDs map[string]uint) (*models.Todo, error) {
+	due, err := item.DueDate(start)
+	if err != nil {
+		return nil, err
+	}
+
+	todo := models.Todo{
+		Title:        item.Title,
+		Description:  item.Description,
+		UserID:       base.UserID,
+		ProjectID:    base.ProjectID,
+		ParentID:     base.ParentID,
+		AutoComplete: item.AutoComplete,
+		DueDate:      due,
+	}
+	if err := tx.Omit(clause.Associations).Create(&todo).Error; err != nil {
+		return nil, err
+	}
+	for _, name := range item.Tags {
+		if err := tx.Exec("INSERT INTO todo_tags (todo_id, tag_id) VALUES (?, ?)", todo.ID, tagIDs[strings.ToLower(name)]).Error; err != nil {
+			return nil, err
+		}
+	}
+	if err := syncStatus(tx, &todo); err != nil {
+		return nil, err
+	}
+	if err := recordRevision(tx, todoFields{}, todo.ID, todo.UserID); err != nil {
+		return nil, err
+	}
+
+	for i := range item.Subtasks {
+		child := models.Todo{UserID: todo.UserID, ProjectID: todo.ProjectID, ParentID: &todo.ID}
+		if _, err := createFromTemplate(tx, &item.Subtasks[i], child, start, tagIDs); err != nil {
+			return nil, err
+		}
+	}
+	return &todo, nil
+}
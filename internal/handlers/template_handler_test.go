@@ -0,0 +1,267 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"todo-api/internal/models"
+	"todo-api/internal/test"
+)
+
+func TestCreateTemplate(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db, _ := test.SetupTestDB()
+	test.ClearTestData(db)
+	testUser, err := test.CreateTestUser(db)
+	assert.NoError(t, err)
+
+	deep := map[string]interface{}{"title": "Level 11"}
+	for level := 10; level >= 1; level-- {
+		deep = map[string]interface{}{"title": fmt.Sprintf("Level %d", level), "subtasks": []interface{}{deep}}
+	}
+
+	tests := []struct {
+		name         string
+		payload      map[string]interface{}
+		expectedCode int
+	}{
+		{
+			name: "Checklist with subtasks",
+			payload: map[string]interface{}{"name": "Onboarding", "todo": map[string]interface{}{
+				"title":    "Onboard {{name}}",
+				"tags":     []string{"hr", " HR ", ""},
+				"subtasks": []interface{}{map[string]interface{}{"title": "Order laptop", "due_offset": "-1w"}},
+			}},
+			expectedCode: http.StatusCreated,
+		},
+		{
+			name:         "Missing name",
+			payload:      map[string]interface{}{"todo": map[string]interface{}{"title": "Onboard"}},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name: "Subtask without a title",
+			payload: map[string]interface{}{"name": "Onboarding", "todo": map[string]interface{}{
+				"title":    "Onboard",
+				"subtasks": []interface{}{map[string]interface{}{"title": " "}},
+			}},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "Invalid due offset",
+			payload:      map[string]interface{}{"name": "Onboarding", "todo": map[string]interface{}{"title": "Onboard", "due_offset": "next week"}},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "Nested too deep",
+			payload:      map[string]interface{}{"name": "Deep", "todo": deep},
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+
+	router := gin.New()
+	router.POST("/templates", func(c *gin.Context) {
+		c.Set("userID", testUser.ID)
+		CreateTemplate(c)
+	})
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			jsonBody, _ := json.Marshal(tt.payload)
+			req, _ := http.NewRequest("POST", "/templates", bytes.NewBuffer(jsonBody))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+			if tt.expectedCode == http.StatusCreated {
+				var response models.Template
+				assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+				assert.Equal(t, []string{"hr"}, response.Todo.Tags)
+				assert.Equal(t, "Order laptop", response.Todo.Subtasks[0].Title)
+
+				var stored models.Template
+				db.First(&stored, response.ID)
+				assert.Equal(t, response.Todo, stored.Todo)
+			}
+		})
+	}
+}
+
+func TestInstantiateTemplate(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db, _ := test.SetupTestDB()
+	test.ClearTestData(db)
+	testUser, err := test.CreateTestUser(db)
+	assert.NoError(t, err)
+	db.Model(testUser).Update("timezone", "Europe/Berlin")
+
+	existing := &models.Tag{Name: "Release", UserID: testUser.ID}
+	db.Create(existing)
+	project := &models.Project{Name: "Platform", UserID: testUser.ID}
+	db.Create(project)
+
+	template := &models.Template{Name: "Release", UserID: testUser.ID, Todo: models.TemplateItem{
+		Title:        "Release {{ version }}",
+		Description:  "Ship on {{date}}",
+		Tags:         []string{"release"},
+		DueOffset:    "2d17h",
+		AutoComplete: true,
+		Subtasks: []models.TemplateItem{
+			{Title: "Freeze {{version}}", DueOffset: "1d", Tags: []string{"release", "qa"}},
+			{Title: "Announce", Subtasks: []models.TemplateItem{{Title: "Write notes", DueOffset: "-9h"}}},
+		},
+	}}
+	db.Create(template)
+
+	router := gin.New()
+	router.POST("/templates/:id/instantiate", func(c *gin.Context) {
+		c.Set("userID", testUser.ID)
+		InstantiateTemplate(c)
+	})
+	instantiate := func(payload map[string]interface{}) *httptest.ResponseRecorder {
+		jsonBody, _ := json.Marshal(payload)
+		req, _ := http.NewRequest("POST", fmt.Sprintf("/templates/%d/instantiate", template.ID), bytes.NewBuffer(jsonBody))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("missing variable", func(t *testing.T) {
+		w := instantiate(map[string]interface{}{"start": "2026-03-28"})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "version")
+
+		var count int64
+		db.Model(&models.Todo{}).Count(&count)
+		assert.Equal(t, int64(0), count)
+	})
+
+	t.Run("invalid start", func(t *testing.T) {
+		w := instantiate(map[string]interface{}{"start": "tomorrow", "variables": map[string]string{"version": "2.0"}})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("creates the whole tree", func(t *testing.T) {
+		w := instantiate(map[string]interface{}{
+			"start":      "2026-03-28",
+			"variables":  map[string]string{"version": "2.0"},
+			"project_id": project.ID,
+		})
+		assert.Equal(t, http.StatusCreated, w.Code)
+
+		var root models.Todo
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &root))
+		assert.Equal(t, "Release 2.0", root.Title)
+		assert.Equal(t, "Ship on 2026-03-28", root.Description)
+		assert.True(t, root.AutoComplete)
+		assert.Equal(t, project.ID, *root.ProjectID)
+		// Two days and 17 hours after midnight in Berlin, after the switch to summer time
+		assert.True(t, time.Date(2026, 3, 30, 15, 0, 0, 0, time.UTC).Equal(*root.DueDate), "got %s", root.DueDate)
+		if assert.Len(t, root.Tags, 1) {
+			assert.Equal(t, existing.ID, root.Tags[0].ID)
+		}
+
+		if assert.Len(t, root.Subtasks, 2) {
+			freeze := root.Subtasks[0]
+			assert.Equal(t, "Freeze 2.0", freeze.Title)
+			assert.Equal(t, project.ID, *freeze.ProjectID)
+			assert.True(t, time.Date(2026, 3, 28, 23, 0, 0, 0, time.UTC).Equal(*freeze.DueDate), "got %s", freeze.DueDate)
+			assert.Len(t, freeze.Tags, 2)
+
+			announce := root.Subtasks[1]
+			assert.Nil(t, announce.DueDate)
+			if assert.Len(t, announce.Subtasks, 1) {
+				assert.True(t, time.Date(2026, 3, 27, 14, 0, 0, 0, time.UTC).Equal(*announce.Subtasks[0].DueDate))
+			}
+		}
+
+		var tags int64
+		db.Model(&models.Tag{}).Where("user_id = ?", testUser.ID).Count(&tags)
+		assert.Equal(t, int64(2), tags)
+
+		var revisions int64
+		db.Model(&models.TodoRevision{}).Count(&revisions)
+		assert.Equal(t, int64(4), revisions)
+	})
+
+	t.Run("nested under another todo", func(t *testing.T) {
+		parent := &models.Todo{Title: "Q2 releases", UserID: testUser.ID, ProjectID: &project.ID, AutoComplete: true, Completed: true}
+		db.Create(parent)
+
+		w := instantiate(map[string]interface{}{"variables": map[string]string{"version": "2.1"}, "parent_id": parent.ID})
+		assert.Equal(t, http.StatusCreated, w.Code)
+
+		var root models.Todo
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &root))
+		assert.Equal(t, parent.ID, *root.ParentID)
+		assert.Equal(t, project.ID, *root.ProjectID)
+
+		// The new open subtask reopens the auto-completing parent
+		db.First(parent, parent.ID)
+		assert.False(t, parent.Completed)
+	})
+}
+
+func TestSaveAsTemplate(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db, _ := test.SetupTestDB()
+	test.ClearTestData(db)
+	testUser, err := test.CreateTestUser(db)
+	assert.NoError(t, err)
+
+	due := time.Date(2026, 5, 4, 17, 0, 0, 0, time.UTC)
+	before := time.Date(2026, 5, 1, 9, 30, 0, 0, time.UTC)
+	tag := &models.Tag{Name: "launch", UserID: testUser.ID}
+	db.Create(tag)
+	root := &models.Todo{Title: "Launch", Description: "Go live", UserID: testUser.ID, DueDate: &due, Tags: []models.Tag{*tag}}
+	db.Create(root)
+	child := &models.Todo{Title: "Prepare slides", UserID: testUser.ID, ParentID: &root.ID, DueDate: &before}
+	db.Create(child)
+	db.Create(&models.Todo{Title: "Book room", UserID: testUser.ID, ParentID: &child.ID})
+
+	router := gin.New()
+	router.POST("/todos/:id/template", func(c *gin.Context) {
+		c.Set("userID", testUser.ID)
+		SaveAsTemplate(c)
+	})
+
+	jsonBody, _ := json.Marshal(map[string]string{"name": "Launch plan"})
+	req, _ := http.NewRequest("POST", fmt.Sprintf("/todos/%d/template", root.ID), bytes.NewBuffer(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	var response models.Template
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, "Launch plan", response.Name)
+	assert.Equal(t, models.TemplateItem{
+		Title:       "Launch",
+		Description: "Go live",
+		Tags:        []string{"launch"},
+		DueOffset:   "17h",
+		Subtasks: []models.TemplateItem{{
+			Title:     "Prepare slides",
+			DueOffset: "-2d14h30m",
+			Subtasks:  []models.TemplateItem{{Title: "Book room"}},
+		}},
+	}, response.Todo)
+
+	// Instantiating the captured template recreates the same spacing
+	start := time.Date(2026, 5, 4, 0, 0, 0, 0, time.UTC)
+	slides, err := response.Todo.Subtasks[0].DueDate(start)
+	assert.NoError(t, err)
+	assert.True(t, before.Equal(*slides))
+}
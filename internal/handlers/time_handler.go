@@ -0,0 +1,517 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"todo-api/internal/database"
+	"todo-api/internal/models"
+)
+
+const (
+	reportDateLayout = "2006-01-02"
+	maxReportDays    = 366
+)
+
+type StartTimerRequest struct {
+	Note string `json:"note" example:"Client call"`
+}
+
+type TimeEntryRequest struct {
+	StartedAt time.Time `json:"started_at" binding:"required" example:"2026-01-31T09:00:00Z"`
+	EndedAt   time.Time `json:"ended_at" binding:"required" example:"2026-01-31T10:30:00Z"`
+	Note      string    `json:"note" example:"Client call"`
+}
+
+type TimeEntryList struct {
+	Entries      []models.TimeEntry `json:"entries"`
+	TotalSeconds int64              `json:"total_seconds" example:"5400"`
+}
+
+type TimeBucket struct {
+	Start   string `json:"start" example:"2026-01-26"`
+	Seconds int64  `json:"seconds" example:"5400"`
+}
+
+type TimeReport struct {
+	Timezone     string       `json:"timezone" example:"Europe/Berlin"`
+	Group        string       `json:"group" example:"day"`
+	From         string       `json:"from" example:"2026-01-26"`
+	To           string       `json:"to" example:"2026-02-01"`
+	Buckets      []TimeBucket `json:"buckets"`
+	TotalSeconds int64        `json:"total_seconds" example:"27000"`
+}
+
+// @Summary Start a timer
+// @Description Start tracking time on a todo. A user can only have one running timer.
+// @Tags time
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "Todo ID"
+// @Param request body StartTimerRequest false "Timer note"
+// @Success 201 {object} models.TimeEntry
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/todos/{id}/timer/start [post]
+func StartTimer(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	db := database.GetDB()
+
+	todo, err := findAccessibleTodo(db, userID.(uint), c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Todo not found"})
+		return
+	}
+
+	var req StartTimerRequest
+	if err := bindOptionalJSON(c, &req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	if running, err := runningTimer(db, userID.(uint)); err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	} else if running != nil {
+		c.JSON(http.StatusConflict, ErrorResponse{Error: fmt.Sprintf("A timer is already running on todo %d", running.TodoID)})
+		return
+	}
+
+	entry := models.TimeEntry{
+		UserID:    userID.(uint),
+		TodoID:    todo.ID,
+		StartedAt: time.Now(),
+		Note:      req.Note,
+	}
+	if err := db.Create(&entry).Error; err != nil {
+		// Another request may have started a timer in the meantime
+		if running, _ := runningTimer(db, userID.(uint)); running != nil {
+			c.JSON(http.StatusConflict, ErrorResponse{Error: fmt.Sprintf("A timer is already running on todo %d", running.TodoID)})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, entry)
+}
+
+// @Summary Stop the running timer
+// @Description Stop the authenticated user's running timer, whichever todo it is on
+// @Tags time
+// @Produce json
+// @Security Bearer
+// @Success 200 {object} models.TimeEntry
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/timer/stop [post]
+func StopTimer(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	db := database.GetDB()
+
+	entry, err := runningTimer(db, userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+	if entry == nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "No timer is running"})
+		return
+	}
+
+	now := time.Now()
+	duration := int64(entry.Elapsed(now) / time.Second)
+	result := db.Model(entry).Where("ended_at IS NULL").Updates(map[string]interface{}{
+		"ended_at": now,
+		"duration": duration,
+	})
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: result.Error.Error()})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "No timer is running"})
+		return
+	}
+	entry.EndedAt = &now
+	entry.Duration = duration
+
+	c.JSON(http.StatusOK, entry)
+}
+
+// @Summary Get the running timer
+// @Description Get the authenticated user's running timer, with the time tracked so far
+// @Tags time
+// @Produce json
+// @Security Bearer
+// @Success 200 {object} models.TimeEntry
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/timer [get]
+func GetTimer(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	entry, err := runningTimer(database.GetDB(), userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+	if entry == nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "No timer is running"})
+		return
+	}
+
+	entry.Duration = int64(entry.Elapsed(time.Now()) / time.Second)
+	c.JSON(http.StatusOK, entry)
+}
+
+// @Summary Log time on a todo
+// @Description Add a time entry for a span of time that was not tracked with a timer
+// @Tags time
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "Todo ID"
+// @Param request body TimeEntryRequest true "Time entry"
+// @Success 201 {object} models.TimeEntry
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/todos/{id}/time-entries [post]
+func CreateTimeEntry(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	db := database.GetDB()
+
+	todo, err := findAccessibleTodo(db, userID.(uint), c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Todo not found"})
+		return
+	}
+
+	var req TimeEntryRequest
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+	if !req.EndedAt.After(req.StartedAt) {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "ended_at must be after started_at"})
+		return
+	}
+
+	entry := models.TimeEntry{
+		UserID:    userID.(uint),
+		TodoID:    todo.ID,
+		StartedAt: req.StartedAt,
+		EndedAt:   &req.EndedAt,
+		Duration:  int64(req.EndedAt.Sub(req.StartedAt) / time.Second),
+		Note:      req.Note,
+	}
+	if err := db.Create(&entry).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, entry)
+}
+
+// @Summary Get the time tracked on a todo
+// @Description Get a todo's time entries, newest first, and the total time tracked on it
+// @Tags time
+// @Produce json
+// @Security Bearer
+// @Param id path int true "Todo ID"
+// @Success 200 {object} TimeEntryList
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/todos/{id}/time-entries [get]
+func GetTimeEntries(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	db := database.GetDB()
+
+	todo, err := findAccessibleTodo(db, userID.(uint), c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Todo not found"})
+		return
+	}
+
+	list := TimeEntryList{Entries: []models.TimeEntry{}}
+	if err := db.Where("todo_id = ?", todo.ID).Order("started_at DESC, id DESC").Find(&list.Entries).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	now := time.Now()
+	for i := range list.Entries {
+		if list.Entries[i].Running() {
+			list.Entries[i].Duration = int64(list.Entries[i].Elapsed(now) / time.Second)
+		}
+		list.TotalSeconds += list.Entries[i].Duration
+	}
+
+	c.JSON(http.StatusOK, list)
+}
+
+// @Summary Update a time entry
+// @Description Correct the span or note of one of your stopped time entries
+// @Tags time
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "Time entry ID"
+// @Param request body TimeEntryRequest true "Time entry"
+// @Success 200 {object} models.TimeEntry
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/time-entries/{id} [put]
+func UpdateTimeEntry(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	db := database.GetDB()
+	var entry models.TimeEntry
+
+	if err := db.Where("id = ? AND user_id = ?", c.Param("id"), userID).First(&entry).Error; err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Time entry not found"})
+		return
+	}
+	if entry.Running() {
+		c.JSON(http.StatusConflict, ErrorResponse{Error: "Stop the timer before editing its entry"})
+		return
+	}
+
+	var req TimeEntryRequest
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+	if !req.EndedAt.After(req.StartedAt) {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "ended_at must be after started_at"})
+		return
+	}
+
+	err := db.Model(&entry).Updates(map[string]interface{}{
+		"started_at": req.StartedAt,
+		"ended_at":   req.EndedAt,
+		"duration":   int64(req.EndedAt.Sub(req.StartedAt) / time.Second),
+		"note":       req.Note,
+	}).Error
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	db.First(&entry, entry.ID)
+	c.JSON(http.StatusOK, entry)
+}
+
+// @Summary Delete a time entry
+// @Description Delete one of your time entries. Deleting a running timer discards it.
+// @Tags time
+// @Security Bearer
+// @Param id path int true "Time entry ID"
+// @Success 204 "No Content"
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/time-entries/{id} [delete]
+func DeleteTimeEntry(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	result := database.GetDB().Where("id = ? AND user_id = ?", c.Param("id"), userID).Delete(&models.TimeEntry{})
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: result.Error.Error()})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Time entry not found"})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// @Summary Get a time report
+// @Description Get the time you tracked per day or week between two dates, counted in your time zone. Entries that cross midnight are split between the days.
+// @Tags time
+// @Produce json
+// @Security Bearer
+// @Param from query string false "First day, as YYYY-MM-DD (default six days before to)"
+// @Param to query string false "Last day, as YYYY-MM-DD (default today)"
+// @Param group query string false "Bucket size; weeks start on Monday" Enums(day, week)
+// @Param project_id query int false "Only time tracked on todos in this project"
+// @Success 200 {object} TimeReport
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/time/report [get]
+func GetTimeReport(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	db := database.GetDB()
+
+	loc, err := userLocation(db, userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	group := c.DefaultQuery("group", "day")
+	if group != "day" && group != "week" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "group must be 'day' or 'week'"})
+		return
+	}
+
+	now := time.Now()
+	to := startOfDay(now.In(loc))
+	if value := c.Query("to"); value != "" {
+		if to, err = time.ParseInLocation(reportDateLayout, value, loc); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "to must be a date like 2026-01-31"})
+			return
+		}
+	}
+	from := to.AddDate(0, 0, -6)
+	if value := c.Query("from"); value != "" {
+		if from, err = time.ParseInLocation(reportDateLayout, value, loc); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "from must be a date like 2026-01-31"})
+			return
+		}
+	}
+	if to.Before(from) {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "from must not be after to"})
+		return
+	}
+	if to.Sub(from) > maxReportDays*24*time.Hour {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: fmt.Sprintf("Reports can span at most %d days", maxReportDays)})
+		return
+	}
+	end := to.AddDate(0, 0, 1)
+
+	query := db.Model(&models.TimeEntry{}).
+		Joins("JOIN todos ON todos.id = time_entries.todo_id AND todos.deleted_at IS NULL").
+		Where("time_entries.user_id = ? AND time_entries.started_at < ?", userID, end.UTC()).
+		Where("time_entries.ended_at IS NULL OR time_entries.ended_at > ?", from.UTC())
+	if value := c.Query("project_id"); value != "" {
+		projectID, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "project_id must be a number"})
+			return
+		}
+		query = query.Where("todos.project_id = ?", projectID)
+	}
+
+	var entries []models.TimeEntry
+	if err := query.Find(&entries).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	report := TimeReport{
+		Timezone: loc.String(),
+		Group:    group,
+		From:     from.Format(reportDateLayout),
+		To:       to.Format(reportDateLayout),
+		Buckets:  bucketTime(entries, from, end, group, now),
+	}
+	for _, bucket := range report.Buckets {
+		report.TotalSeconds += bucket.Seconds
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// runningTimer returns the user's running timer, or nil when none is running.
+func runningTimer(db *gorm.DB, userID uint) (*models.TimeEntry, error) {
+	var entries []models.TimeEntry
+	if err := db.Where("user_id = ? AND ended_at IS NULL", userID).Limit(1).Find(&entries).Error; err != nil {
+		return nil, err
+	}
+	if len(entries) == 0 {
+		return nil, nil
+	}
+	return &entries[0], nil
+}
+
+// trackedSeconds sums the time of the entries matched by query, counting
+// running timers up to now.
+func trackedSeconds(query *gorm.DB, now time.Time) (int64, error) {
+	var total int64
+	err := query.Session(&gorm.Session{}).Where("ended_at IS NOT NULL").Select("COALESCE(SUM(duration), 0)").Scan(&total).Error
+	if err != nil {
+		return 0, err
+	}
+
+	var running []models.TimeEntry
+	if err := query.Session(&gorm.Session{}).Where("ended_at IS NULL").Find(&running).Error; err != nil {
+		return 0, err
+	}
+	for i := range running {
+		total += int64(running[i].Elapsed(now) / time.Second)
+	}
+	return total, nil
+}
+
+// bucketTime spreads entries over the days or weeks between from and end,
+// which must be midnights in the time zone to count in. Every bucket in the
+// range is returned, including empty ones.
+func bucketTime(entries []models.TimeEntry, from, end time.Time, group string, now time.Time) []TimeBucket {
+	var buckets []TimeBucket
+	index := map[string]int{}
+	for day := from; day.Before(end); day = day.AddDate(0, 0, 1) {
+		key := bucketStart(day, group).Format(reportDateLayout)
+		if _, ok := index[key]; !ok {
+			index[key] = len(buckets)
+			buckets = append(buckets, TimeBucket{Start: key})
+		}
+	}
+
+	for i := range entries {
+		start := entries[i].StartedAt.In(from.Location())
+		stop := now
+		if entries[i].EndedAt != nil {
+			stop = *entries[i].EndedAt
+		}
+		if start.Before(from) {
+			start = from
+		}
+		if stop.After(end) {
+			stop = end
+		}
+
+		for start.Before(stop) {
+			next := startOfDay(start).AddDate(0, 0, 1)
+			if next.After(stop) {
+				next = stop
+			}
+			key := bucketStart(start, group).Format(reportDateLayout)
+			buckets[index[key]].Seconds += int64(next.Sub(start) / time.Second)
+			start = next
+		}
+	}
+	return buckets
+}
+
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+// bucketStart returns the first day of the bucket t falls in.
+func bucketStart(t time.Time, group string) time.Time {
+	day := startOfDay(t)
+	if group == "week" {
+		// Weeks start on Monday
+		return day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+	}
+	return day
+}
@@ -0,0 +1,282 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"todo-api/internal/models"
+	"todo-api/internal/test"
+)
+
+func TestTimers(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db, _ := test.SetupTestDB()
+	test.ClearTestData(db)
+	testUser, err := test.CreateTestUser(db)
+	assert.NoError(t, err)
+
+	first := &models.Todo{Title: "Draft contract", UserID: testUser.ID}
+	db.Create(first)
+	second := &models.Todo{Title: "Review contract", UserID: testUser.ID}
+	db.Create(second)
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set("userID", testUser.ID)
+	})
+	router.POST("/todos/:id/timer/start", StartTimer)
+	router.POST("/timer/stop", StopTimer)
+	router.GET("/timer", GetTimer)
+	router.GET("/todos/:id/time-entries", GetTimeEntries)
+
+	send := func(method, path string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, path, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	assert.Equal(t, http.StatusNotFound, send("GET", "/timer").Code)
+	assert.Equal(t, http.StatusNotFound, send("POST", "/timer/stop").Code)
+
+	w := send("POST", fmt.Sprintf("/todos/%d/timer/start", first.ID))
+	assert.Equal(t, http.StatusCreated, w.Code)
+	var started models.TimeEntry
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &started))
+	assert.True(t, started.Running())
+
+	// Only one timer can run at a time, even on another todo
+	w = send("POST", fmt.Sprintf("/todos/%d/timer/start", second.ID))
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Contains(t, w.Body.String(), fmt.Sprintf("todo %d", first.ID))
+
+	// Pretend the timer has been running for an hour
+	db.Model(&started).Update("started_at", time.Now().Add(-time.Hour))
+
+	w = send("GET", "/timer")
+	assert.Equal(t, http.StatusOK, w.Code)
+	var running models.TimeEntry
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &running))
+	assert.InDelta(t, 3600, running.Duration, 5)
+
+	w = send("POST", "/timer/stop")
+	assert.Equal(t, http.StatusOK, w.Code)
+	var stopped models.TimeEntry
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &stopped))
+	assert.NotNil(t, stopped.EndedAt)
+	assert.InDelta(t, 3600, stopped.Duration, 5)
+
+	assert.Equal(t, http.StatusNotFound, send("GET", "/timer").Code)
+	assert.Equal(t, http.StatusCreated, send("POST", fmt.Sprintf("/todos/%d/timer/start", second.ID)).Code)
+
+	w = send("GET", fmt.Sprintf("/todos/%d/time-entries", first.ID))
+	assert.Equal(t, http.StatusOK, w.Code)
+	var list TimeEntryList
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &list))
+	assert.Len(t, list.Entries, 1)
+	assert.InDelta(t, 3600, list.TotalSeconds, 5)
+
+	other := &models.Todo{Title: "Someone else's", UserID: testUser.ID + 1}
+	db.Create(other)
+	assert.Equal(t, http.StatusNotFound, send("POST", fmt.Sprintf("/todos/%d/timer/start", other.ID)).Code)
+}
+
+func TestTimeEntries(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db, _ := test.SetupTestDB()
+	test.ClearTestData(db)
+	testUser, err := test.CreateTestUser(db)
+	assert.NoError(t, err)
+
+	todo := &models.Todo{Title: "Audit books", UserID: testUser.ID}
+	db.Create(todo)
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set("userID", testUser.ID)
+	})
+	router.POST("/todos/:id/time-entries", CreateTimeEntry)
+	router.PUT("/time-entries/:id", UpdateTimeEntry)
+	router.DELETE("/time-entries/:id", DeleteTimeEntry)
+
+	send := func(method, path string, body interface{}) *httptest.ResponseRecorder {
+		data, _ := json.Marshal(body)
+		req, _ := http.NewRequest(method, path, bytes.NewBuffer(data))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	tests := []struct {
+		name         string
+		payload      map[string]interface{}
+		expectedCode int
+		wantDuration int64
+	}{
+		{
+			name:         "Log an hour and a half",
+			payload:      map[string]interface{}{"started_at": "2026-01-05T09:00:00Z", "ended_at": "2026-01-05T10:30:00Z", "note": "Ledger"},
+			expectedCode: http.StatusCreated,
+			wantDuration: 5400,
+		},
+		{
+			name:         "End before start",
+			payload:      map[string]interface{}{"started_at": "2026-01-05T10:00:00Z", "ended_at": "2026-01-05T09:00:00Z"},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "Missing end",
+			payload:      map[string]interface{}{"started_at": "2026-01-05T10:00:00Z"},
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := send("POST", fmt.Sprintf("/todos/%d/time-entries", todo.ID), tt.payload)
+			assert.Equal(t, tt.expectedCode, w.Code)
+			if tt.expectedCode == http.StatusCreated {
+				var entry models.TimeEntry
+				assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &entry))
+				assert.Equal(t, tt.wantDuration, entry.Duration)
+				assert.Equal(t, todo.ID, entry.TodoID)
+			}
+		})
+	}
+
+	var entry models.TimeEntry
+	db.Where("todo_id = ?", todo.ID).First(&entry)
+
+	w := send("PUT", fmt.Sprintf("/time-entries/%d", entry.ID), map[string]interface{}{"started_at": "2026-01-05T09:00:00Z", "ended_at": "2026-01-05T09:45:00Z"})
+	assert.Equal(t, http.StatusOK, w.Code)
+	var updated models.TimeEntry
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &updated))
+	assert.Equal(t, int64(2700), updated.Duration)
+	assert.Equal(t, "", updated.Note)
+
+	running := &models.TimeEntry{UserID: testUser.ID, TodoID: todo.ID, StartedAt: time.Now()}
+	db.Create(running)
+	w = send("PUT", fmt.Sprintf("/time-entries/%d", running.ID), map[string]interface{}{"started_at": "2026-01-05T09:00:00Z", "ended_at": "2026-01-05T09:45:00Z"})
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	assert.Equal(t, http.StatusNoContent, send("DELETE", fmt.Sprintf("/time-entries/%d", running.ID), nil).Code)
+	assert.Equal(t, http.StatusNotFound, send("DELETE", fmt.Sprintf("/time-entries/%d", running.ID), nil).Code)
+}
+
+func TestTimeReport(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db, _ := test.SetupTestDB()
+	test.ClearTestData(db)
+	testUser, err := test.CreateTestUser(db)
+	assert.NoError(t, err)
+	db.Model(testUser).Update("timezone", "America/New_York")
+
+	client := &models.Project{Name: "Client", UserID: testUser.ID}
+	db.Create(client)
+	billable := &models.Todo{Title: "Billable work", UserID: testUser.ID, ProjectID: &client.ID}
+	db.Create(billable)
+	internal := &models.Todo{Title: "Internal work", UserID: testUser.ID}
+	db.Create(internal)
+
+	at := func(value string) time.Time {
+		parsed, _ := time.Parse(time.RFC3339, value)
+		return parsed
+	}
+	entry := func(todo *models.Todo, start, end string) {
+		ended := at(end)
+		db.Create(&models.TimeEntry{
+			UserID:    testUser.ID,
+			TodoID:    todo.ID,
+			StartedAt: at(start),
+			EndedAt:   &ended,
+			Duration:  int64(ended.Sub(at(start)) / time.Second),
+		})
+	}
+	// 22:00 to 01:30 in New York, crossing midnight
+	entry(billable, "2026-01-06T03:00:00Z", "2026-01-06T06:30:00Z")
+	// Sunday evening in New York, though Monday in UTC
+	entry(internal, "2026-01-12T02:00:00Z", "2026-01-12T03:00:00Z")
+	// Outside the range
+	entry(internal, "2026-01-13T15:00:00Z", "2026-01-13T16:00:00Z")
+
+	router := gin.New()
+	router.GET("/time/report", func(c *gin.Context) {
+		c.Set("userID", testUser.ID)
+		GetTimeReport(c)
+	})
+	router.GET("/projects/:id/stats", func(c *gin.Context) {
+		c.Set("userID", testUser.ID)
+		GetProjectStats(c)
+	})
+
+	report := func(query string) (int, TimeReport) {
+		req, _ := http.NewRequest("GET", "/time/report"+query, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		var response TimeReport
+		if w.Code == http.StatusOK {
+			assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		}
+		return w.Code, response
+	}
+
+	t.Run("by day", func(t *testing.T) {
+		code, response := report("?from=2026-01-05&to=2026-01-11")
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, "America/New_York", response.Timezone)
+		if assert.Len(t, response.Buckets, 7) {
+			assert.Equal(t, TimeBucket{Start: "2026-01-05", Seconds: 7200}, response.Buckets[0])
+			assert.Equal(t, TimeBucket{Start: "2026-01-06", Seconds: 5400}, response.Buckets[1])
+			assert.Equal(t, TimeBucket{Start: "2026-01-11", Seconds: 3600}, response.Buckets[6])
+		}
+		assert.Equal(t, int64(16200), response.TotalSeconds)
+	})
+
+	t.Run("by week", func(t *testing.T) {
+		code, response := report("?from=2026-01-07&to=2026-01-14&group=week")
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, []TimeBucket{
+			{Start: "2026-01-05", Seconds: 3600},
+			{Start: "2026-01-12", Seconds: 3600},
+		}, response.Buckets)
+	})
+
+	t.Run("by project", func(t *testing.T) {
+		code, response := report(fmt.Sprintf("?from=2026-01-05&to=2026-01-11&project_id=%d", client.ID))
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, int64(12600), response.TotalSeconds)
+	})
+
+	t.Run("invalid ranges", func(t *testing.T) {
+		code, _ := report("?from=2026-01-11&to=2026-01-05")
+		assert.Equal(t, http.StatusBadRequest, code)
+		code, _ = report("?from=2024-01-01&to=2026-01-05")
+		assert.Equal(t, http.StatusBadRequest, code)
+		code, _ = report("?from=yesterday")
+		assert.Equal(t, http.StatusBadRequest, code)
+		code, _ = report("?group=month")
+		assert.Equal(t, http.StatusBadRequest, code)
+	})
+
+	t.Run("project totals", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", fmt.Sprintf("/projects/%d/stats", client.ID), nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var stats ProjectStats
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &stats))
+		assert.Equal(t, int64(12600), stats.TrackedSeconds)
+	})
+}
@@ -0,0 +1,32 @@
+package handlers
+
+import (
+	"gorm.io/gorm"
+	"todo-api/internal/models"
+)
+
+// findAccessibleTodo loads a todo the user is allowed to see.
+func findAccessibleTodo(db *gorm.DB, userID uint, todoID interface{}) (*models.Todo, error) {
+	var todo models.Todo
+	if err := accessibleTodos(db, userID).Where("todos.id = ?", todoID).First(&todo).Error; err != nil {
+		return nil, err
+	}
+	return &todo, nil
+}
+
+// accessibleTodos limits a todo query to the todos the user created and
+// those in projects shared with them.
+func accessibleTodos(db *gorm.DB, userID uint) *gorm.DB {
+	return db.Where("(todos.user_id = ? OR todos.project_id IN (?))", userID, sharedProjectIDs(db, userID))
+}
+
+// accessibleProjects limits a project query to the user's own projects and
+// those shared with them.
+func accessibleProjects(db *gorm.DB, userID uint) *gorm.DB {
+	return db.Where("(projects.user_id = ? OR projects.id IN (?))", userID, sharedProjectIDs(db, userID))
+}
+
+// sharedProjectIDs selects the IDs of the projects the user is a member of.
+func sharedProjectIDs(db *gorm.DB, userID uint) *gorm.DB {
+	return db.Session(&gorm.Session{NewDB: true}).Model(&models.ProjectMember{}).Select("project_id").Where("user_id = ?", userID)
+}
@@ -0,0 +1,236 @@
+package handlers
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"todo-api/internal/models"
+)
+
+const customFieldParam = "cf_"
+
+// applyTodoFilters narrows a todo query using the query-string filters
+// accepted by GetTodos.
+func applyTodoFilters(db *gorm.DB, userID uint, params url.Values) (*gorm.DB, error) {
+	// Archived and snoozed todos are left out unless asked for
+	archived, snoozed := params.Get("archived"), params.Get("snoozed")
+	if q := params.Get("q"); q != "" {
+		compiled, scope, err := parseTodoQuery(db, userID, q)
+		if err != nil {
+			return nil, fmt.Errorf("invalid q: %w", err)
+		}
+		db = db.Scopes(scope)
+		if compiled.archived && archived == "" {
+			archived = "true"
+		}
+		if compiled.snoozed && snoozed == "" {
+			snoozed = "true"
+		}
+	}
+
+	if tags := splitList(params.Get("tags")); len(tags) > 0 {
+		match := params.Get("tag_match")
+		if match == "" {
+			match = "any"
+		}
+		if match != "any" && match != "all" {
+			return nil, fmt.Errorf("tag_match must be 'any' or 'all'")
+		}
+		db = filterByTags(db, userID, tags, match == "all")
+	}
+
+	switch parent := params.Get("parent_id"); parent {
+	case "":
+	case "none":
+		db = db.Where("todos.parent_id IS NULL")
+	default:
+		parentID, err := strconv.ParseUint(parent, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("parent_id must be a todo ID or 'none'")
+		}
+		db = db.Where("todos.parent_id = ?", parentID)
+	}
+
+	if project := params.Get("project_id"); project != "" {
+		projectID, err := strconv.ParseUint(project, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("project_id must be a project ID")
+		}
+		db = db.Where("todos.project_id = ?", projectID)
+	}
+
+	switch assignee := params.Get("assignee"); assignee {
+	case "":
+	case "none":
+		db = db.Where("todos.id NOT IN (?)", db.Session(&gorm.Session{NewDB: true}).Table("todo_assignees").Select("todo_id"))
+	default:
+		assigneeID := uint64(userID)
+		if assignee != "me" {
+			var err error
+			if assigneeID, err = strconv.ParseUint(assignee, 10, 64); err != nil {
+				return nil, fmt.Errorf("assignee must be a user ID, 'me' or 'none'")
+			}
+		}
+		assigned := db.Session(&gorm.Session{NewDB: true}).Table("todo_assignees").Select("todo_id").Where("user_id = ?", assigneeID)
+		db = db.Where("todos.id IN (?)", assigned)
+	}
+
+	if priority := params.Get("priority"); priority != "" {
+		level, err := strconv.Atoi(priority)
+		if err != nil || level < models.PriorityNone || level > models.PriorityHigh {
+			return nil, fmt.Errorf("priority must be a number from 0 to 3")
+		}
+		db = db.Where("todos.priority = ?", level)
+	}
+
+	if params.Get("actionable") == "true" {
+		blocked := db.Session(&gorm.Session{NewDB: true}).
+			Table("todo_dependencies").
+			Select("todo_dependencies.todo_id").
+			Joins("JOIN todos blockers ON blockers.id = todo_dependencies.blocked_by_id AND blockers.deleted_at IS NULL").
+			Where("blockers.completed = ?", false)
+		db = db.Where("todos.completed = ? AND todos.id NOT IN (?)", false, blocked)
+	}
+
+	switch archived {
+	case "", "false":
+		db = db.Where("todos.archived = ?", false)
+	case "true":
+	case "only":
+		db = db.Where("todos.archived = ?", true)
+	default:
+		return nil, fmt.Errorf("archived must be 'false', 'true' or 'only'")
+	}
+
+	switch snoozed {
+	case "", "false":
+		db = db.Where("(todos.hidden_until IS NULL OR todos.hidden_until <= ?)", time.Now().UTC())
+	case "true":
+	case "only":
+		db = db.Where("todos.hidden_until > ?", time.Now().UTC())
+	default:
+		return nil, fmt.Errorf("snoozed must be 'false', 'true' or 'only'")
+	}
+
+	for key := range params {
+		if !strings.HasPrefix(key, customFieldParam) || params.Get(key) == "" {
+			continue
+		}
+		field, err := findCustomField(db, userID, strings.TrimPrefix(key, customFieldParam))
+		if err != nil {
+			return nil, err
+		}
+		value, number, err := field.Normalize(params.Get(key))
+		if err != nil {
+			return nil, err
+		}
+
+		matching := db.Session(&gorm.Session{NewDB: true}).
+			Model(&models.CustomFieldValue{}).
+			Select("todo_id").
+			Where("field_id = ?", field.ID)
+		switch {
+		case number != nil:
+			matching = matching.Where("number = ?", *number)
+		case field.Type == models.FieldText:
+			matching = matching.Where("LOWER(value) = LOWER(?)", value)
+		default:
+			matching = matching.Where("value = ?", value)
+		}
+		db = db.Where("todos.id IN (?)", matching)
+	}
+
+	return db, nil
+}
+
+// applyTodoSort orders a todo query by the sort parameter: a todo column or
+// cf_<id> for a custom field, prefixed with '-' for descending order. Todos
+// without a value come last either way.
+func applyTodoSort(db *gorm.DB, userID uint, sort string) (*gorm.DB, error) {
+	if sort == "" {
+		return db, nil
+	}
+
+	direction := "ASC"
+	if strings.HasPrefix(sort, "-") {
+		direction = "DESC"
+		sort = sort[1:]
+	}
+
+	var column string
+	switch sort {
+	case "title", "priority", "created_at", "updated_at", "due_date", "completed_at":
+		column = "todos." + sort
+	default:
+		if !strings.HasPrefix(sort, customFieldParam) {
+			return nil, fmt.Errorf("sort must be title, priority, created_at, updated_at, due_date, completed_at or cf_<field id>")
+		}
+		field, err := findCustomField(db, userID, strings.TrimPrefix(sort, customFieldParam))
+		if err != nil {
+			return nil, err
+		}
+		db = db.Joins("LEFT JOIN custom_field_values sort_values ON sort_values.todo_id = todos.id AND sort_values.field_id = ?", field.ID)
+		column = "sort_values.value"
+		if field.Type == models.FieldNumber {
+			column = "sort_values.number"
+		}
+	}
+
+	return db.Order(fmt.Sprintf("%s IS NULL, %s %s, todos.id", column, column, direction)), nil
+}
+
+// findCustomField loads one of the user's custom fields by the ID given in
+// a query parameter.
+func findCustomField(db *gorm.DB, userID uint, id string) (*models.CustomField, error) {
+	fieldID, err := strconv.ParseUint(id, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("%s%s is not a custom field", customFieldParam, id)
+	}
+
+	var field models.CustomField
+	err = db.Session(&gorm.Session{NewDB: true}).Where("id = ? AND user_id = ?", fieldID, userID).First(&field).Error
+	if err != nil {
+		return nil, fmt.Errorf("custom field %d not found", fieldID)
+	}
+	return &field, nil
+}
+
+// filterByTags keeps todos carrying any (or all) of the named tags.
+func filterByTags(db *gorm.DB, userID uint, names []string, matchAll bool) *gorm.DB {
+	seen := make(map[string]bool)
+	var lowered []string
+	for _, name := range names {
+		name = strings.ToLower(name)
+		if !seen[name] {
+			seen[name] = true
+			lowered = append(lowered, name)
+		}
+	}
+
+	sub := db.Session(&gorm.Session{NewDB: true}).
+		Table("todo_tags").
+		Select("todo_tags.todo_id").
+		Joins("JOIN tags ON tags.id = todo_tags.tag_id AND tags.deleted_at IS NULL").
+		Where("tags.user_id = ? AND LOWER(tags.name) IN ?", userID, lowered)
+
+	if matchAll {
+		sub = sub.Group("todo_tags.todo_id").Having("COUNT(DISTINCT tags.id) = ?", len(lowered))
+	}
+
+	return db.Where("todos.id IN (?)", sub)
+}
+
+// splitList splits a comma separated query value, dropping empty items.
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
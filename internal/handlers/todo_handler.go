@@ -1,9 +1,12 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 	"todo-api/internal/database"
 	"todo-api/internal/models"
 )
@@ -13,11 +16,12 @@ type ErrorResponse struct {
 }
 
 // @Summary Create a new todo
-// @Description Create a new todo for the authenticated user
+// @Description Create a new todo for the authenticated user. Without a project_id it goes to the parent's project or the inbox.
 // @Tags todos
 // @Accept json
 // @Produce json
 // @Security Bearer
+// @Param render query string false "Also return the description rendered as sanitized HTML" Enums(html)
 // @Param todo body models.Todo true "Todo object"
 // @Success 201 {object} models.Todo
 // @Failure 400 {object} ErrorResponse
@@ -27,6 +31,12 @@ type ErrorResponse struct {
 func CreateTodo(c *gin.Context) {
 	userID, _ := c.Get("userID")
 
+	render, err := renderOption(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
 	var todo models.Todo
 	if err := c.BindJSON(&todo); err != nil {
 		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
@@ -34,36 +44,118 @@ func CreateTodo(c *gin.Context) {
 	}
 
 	todo.UserID = userID.(uint)
+	todo.SeriesID = nil
+	todo.Occurrence = 0
+	todo.CompletedAt = completionTime(&models.Todo{}, todo.Completed)
+	todo.Archived = false
+	todo.ArchivedAt = nil
+	todo.HiddenUntil = nil
+	todo.StatusID = nil
 
-	result := database.GetDB().Create(&todo)
-	if result.Error != nil {
-		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: result.Error.Error()})
+	if err := validateRecurrence(todo.Recurrence); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
 		return
 	}
 
-	database.GetDB().Preload("User").First(&todo, todo.ID)
+	if err := validateParent(database.GetDB(), todo.UserID, 0, todo.ProjectID, todo.ParentID); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	if err := assignProject(database.GetDB(), &todo); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	customFields, err := normalizeCustomFields(database.GetDB(), todo.UserID, todo.CustomFields)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+	todo.CustomFields = nil
+
+	err = database.GetDB().Transaction(func(tx *gorm.DB) error {
+		// Associations such as tags are managed through their own endpoints
+		if err := tx.Omit(clause.Associations).Create(&todo).Error; err != nil {
+			return err
+		}
+		if err := replaceCustomFields(tx, todo.ID, customFields); err != nil {
+			return err
+		}
+		if err := startSeries(tx, &todo); err != nil {
+			return err
+		}
+		if err := afterCompletionChange(tx, &todo, false); err != nil {
+			return err
+		}
+		if err := recordRevision(tx, todoFields{}, todo.ID, todo.UserID); err != nil {
+			return err
+		}
+		return runRules(tx, models.TriggerCreated, &todo)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	database.GetDB().Preload("User").Preload("Assignees").Preload("Tags").Preload("CustomFields").First(&todo, todo.ID)
+	describeTodo(&todo, render)
 
 	c.JSON(http.StatusCreated, todo)
 }
 
 // @Summary Get all todos
-// @Description Get all todos for the authenticated user
+// @Description Get the todos the authenticated user created and those in projects shared with them
 // @Tags todos
 // @Produce json
 // @Security Bearer
+// @Param q query string false "Filter query, such as status:open tag:work due<7d (priority:high OR assignee:me) -tag:someday"
+// @Param tags query string false "Comma separated tag names to filter by"
+// @Param tag_match query string false "Whether todos must carry any or all of the tags" Enums(any, all)
+// @Param parent_id query string false "Only subtasks of this todo, or 'none' for top-level todos"
+// @Param project_id query int false "Only todos in this project"
+// @Param assignee query string false "Only todos assigned to this user ID, to 'me', or to nobody with 'none'"
+// @Param archived query string false "Include archived todos, or list only archived ones" Enums(false, true, only)
+// @Param snoozed query string false "Include snoozed todos, or list only snoozed ones" Enums(false, true, only)
+// @Param priority query int false "Only todos with this priority (0 none to 3 high)"
+// @Param actionable query bool false "Only open todos that no open todo blocks"
+// @Param cf_{id} query string false "Only todos whose custom field {id} has this value"
+// @Param sort query string false "Sort by title, priority, created_at, updated_at, due_date, completed_at or cf_{id}; prefix with - for descending"
+// @Param render query string false "Also return descriptions rendered as sanitized HTML" Enums(html)
 // @Success 200 {array} models.Todo
+// @Failure 400 {object} ErrorResponse
 // @Failure 401 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
 // @Router /api/todos [get]
 func GetTodos(c *gin.Context) {
 	userID, _ := c.Get("userID")
 
+	render, err := renderOption(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	query, err := applyTodoFilters(accessibleTodos(database.GetDB(), userID.(uint)), userID.(uint), c.Request.URL.Query())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+	query, err = applyTodoSort(query, userID.(uint), c.Query("sort"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
 	var todos []models.Todo
-	result := database.GetDB().Preload("User").Where("user_id = ?", userID).Find(&todos)
+	result := query.Preload("User").Preload("Assignees").Preload("Tags").Preload("CustomFields").Find(&todos)
 	if result.Error != nil {
 		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: result.Error.Error()})
 		return
 	}
+	for i := range todos {
+		describeTodo(&todos[i], render)
+	}
 
 	c.JSON(http.StatusOK, todos)
 }
@@ -74,7 +166,9 @@ func GetTodos(c *gin.Context) {
 // @Produce json
 // @Security Bearer
 // @Param id path int true "Todo ID"
+// @Param render query string false "Also return the description rendered as sanitized HTML" Enums(html)
 // @Success 200 {object} models.Todo
+// @Failure 400 {object} ErrorResponse
 // @Failure 401 {object} ErrorResponse
 // @Failure 404 {object} ErrorResponse
 // @Router /api/todos/{id} [get]
@@ -83,27 +177,42 @@ func GetTodo(c *gin.Context) {
 	id := c.Param("id")
 	var todo models.Todo
 
-	result := database.GetDB().Preload("User").Where("id = ? AND user_id = ?", id, userID).First(&todo)
+	render, err := renderOption(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	result := accessibleTodos(database.GetDB(), userID.(uint)).Preload("User").Preload("Assignees").Preload("Tags").Preload("CustomFields").Where("todos.id = ?", id).First(&todo)
 	if result.Error != nil {
 		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Todo not found"})
 		return
 	}
 
+	if err := subtaskProgress(database.GetDB(), &todo); err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+	describeTodo(&todo, render)
+
 	c.JSON(http.StatusOK, todo)
 }
 
 // @Summary Update a todo
-// @Description Update a specific todo by ID
+// @Description Update a specific todo by ID. Members of a shared project can update its todos. Completing a recurring todo creates its next occurrence. Completing a todo with open blockers fails unless force is set. In a project with a workflow, completing or reopening a todo must be an allowed transition to the first done or todo status.
 // @Tags todos
 // @Accept json
 // @Produce json
 // @Security Bearer
 // @Param id path int true "Todo ID"
+// @Param force query bool false "Complete the todo even if it is blocked by open todos"
+// @Param render query string false "Also return the description rendered as sanitized HTML" Enums(html)
 // @Param todo body models.Todo true "Todo object"
 // @Success 200 {object} models.Todo
 // @Failure 400 {object} ErrorResponse
 // @Failure 401 {object} ErrorResponse
 // @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
 // @Router /api/todos/{id} [put]
 func UpdateTodo(c *gin.Context) {
@@ -111,39 +220,131 @@ func UpdateTodo(c *gin.Context) {
 	id := c.Param("id")
 	var todo models.Todo
 
-	if err := database.GetDB().Where("id = ? AND user_id = ?", id, userID).First(&todo).Error; err != nil {
+	if err := accessibleTodos(database.GetDB(), userID.(uint)).Where("todos.id = ?", id).First(&todo).Error; err != nil {
 		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Todo not found"})
 		return
 	}
 
+	render, err := renderOption(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
 	var updateData models.Todo
 	if err := c.BindJSON(&updateData); err != nil {
 		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
 		return
 	}
 
-	result := database.GetDB().Model(&todo).Updates(map[string]interface{}{
-		"title":       updateData.Title,
-		"description": updateData.Description,
-		"completed":   updateData.Completed,
+	if err := validateRecurrence(updateData.Recurrence); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	if err := validateParent(database.GetDB(), todo.UserID, todo.ID, todo.ProjectID, updateData.ParentID); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	// Custom fields left out of the request keep their values
+	customFields, err := normalizeCustomFields(database.GetDB(), todo.UserID, updateData.CustomFields)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	if updateData.Completed && !todo.Completed && c.Query("force") != "true" {
+		blockers, err := openBlockers(database.GetDB(), todo.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+			return
+		}
+		if len(blockers) > 0 {
+			c.JSON(http.StatusConflict, ErrorResponse{Error: blockedError(blockers)})
+			return
+		}
+	}
+
+	if err := checkCompletion(database.GetDB(), &todo, updateData.Completed); err != nil {
+		if errors.Is(err, errTransition) {
+			c.JSON(http.StatusConflict, ErrorResponse{Error: err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	before := snapshotTodo(&todo)
+	previousParentID := todo.ParentID
+	wasCompleted := todo.Completed
+	err = database.GetDB().Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&todo).Updates(map[string]interface{}{
+			"title":                 updateData.Title,
+			"description":           updateData.Description,
+			"completed":             updateData.Completed,
+			"completed_at":          completionTime(&todo, updateData.Completed),
+			"priority":              updateData.Priority,
+			"parent_id":             updateData.ParentID,
+			"auto_complete":         updateData.AutoComplete,
+			"due_date":              updateData.DueDate,
+			"recurrence":            updateData.Recurrence,
+			"recur_from_completion": updateData.RecurFromCompletion,
+		}).Error
+		if err != nil {
+			return err
+		}
+		if updateData.CustomFields != nil {
+			if err := replaceCustomFields(tx, todo.ID, customFields); err != nil {
+				return err
+			}
+		}
+
+		todo.Completed = updateData.Completed
+		todo.ParentID = updateData.ParentID
+		todo.DueDate = updateData.DueDate
+		todo.Recurrence = updateData.Recurrence
+		todo.RecurFromCompletion = updateData.RecurFromCompletion
+		if err := startSeries(tx, &todo); err != nil {
+			return err
+		}
+		if err := rescheduleReminders(tx, &todo); err != nil {
+			return err
+		}
+		if err := afterCompletionChange(tx, &todo, wasCompleted); err != nil {
+			return err
+		}
+		// A subtask moved away may have been the last open child of its old parent
+		if previousParentID != nil && (todo.ParentID == nil || *todo.ParentID != *previousParentID) {
+			if err := rollUpCompletion(tx, &models.Todo{ParentID: previousParentID}); err != nil {
+				return err
+			}
+		}
+		if err := recordRevision(tx, before, todo.ID, userID.(uint)); err != nil {
+			return err
+		}
+		return runRules(tx, models.TriggerUpdated, &todo)
 	})
 
-	if result.Error != nil {
-		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: result.Error.Error()})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
 		return
 	}
 
-	database.GetDB().Preload("User").First(&todo, id)
+	database.GetDB().Preload("User").Preload("Assignees").Preload("Tags").Preload("CustomFields").First(&todo, id)
+	describeTodo(&todo, render)
 
 	c.JSON(http.StatusOK, todo)
 }
 
 // @Summary Delete a todo
-// @Description Delete a specific todo by ID
+// @Description Move a specific todo to the trash. Subtasks are trashed with it unless cascade asks to keep them. Only the todo's creator can delete it.
 // @Tags todos
 // @Security Bearer
 // @Param id path int true "Todo ID"
+// @Param cascade query string false "What happens to subtasks: delete them, promote them to top-level, or reparent them onto the deleted todo's parent" Enums(delete, promote, reparent)
 // @Success 204 "No Content"
+// @Failure 400 {object} ErrorResponse
 // @Failure 401 {object} ErrorResponse
 // @Failure 404 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
@@ -158,11 +359,49 @@ func DeleteTodo(c *gin.Context) {
 		return
 	}
 
-	result := database.GetDB().Delete(&todo)
-	if result.Error != nil {
-		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: result.Error.Error()})
+	cascade := c.DefaultQuery("cascade", "delete")
+	if cascade != "delete" && cascade != "promote" && cascade != "reparent" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "cascade must be 'delete', 'promote' or 'reparent'"})
+		return
+	}
+
+	err := database.GetDB().Transaction(func(tx *gorm.DB) error {
+		_, err := trashTodo(tx, &todo, cascade)
+		return err
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
 		return
 	}
 
 	c.Status(http.StatusNoContent)
 }
+
+// trashTodo moves a todo to the trash, handling its subtasks as cascade
+// says, and returns the IDs of the todos trashed.
+func trashTodo(tx *gorm.DB, todo *models.Todo, cascade string) ([]uint, error) {
+	trashed := []uint{todo.ID}
+	switch cascade {
+	case "delete":
+		ids, err := descendantIDs(tx, todo.ID)
+		if err != nil {
+			return nil, err
+		}
+		trashed = append(trashed, ids...)
+	case "promote":
+		if err := tx.Model(&models.Todo{}).Where("parent_id = ?", todo.ID).Update("parent_id", nil).Error; err != nil {
+			return nil, err
+		}
+	case "reparent":
+		if err := tx.Model(&models.Todo{}).Where("parent_id = ?", todo.ID).Update("parent_id", todo.ParentID).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	// Trash the whole subtree in one statement so that it shares a
+	// deletion time and can be restored together
+	if err := tx.Where("id IN ?", trashed).Delete(&models.Todo{}).Error; err != nil {
+		return nil, err
+	}
+	return trashed, rollUpCompletion(tx, todo)
+}
@@ -42,6 +42,14 @@ func TestCreateTodo(t *testing.T) {
 			},
 			wantStatus: http.StatusBadRequest,
 		},
+		{
+			name: "Priority out of range",
+			reqBody: map[string]interface{}{
+				"title":    "Test Todo",
+				"priority": 4,
+			},
+			wantStatus: http.StatusBadRequest,
+		},
 	}
 
 	for _, tt := range tests {
@@ -311,3 +319,136 @@ func TestDeleteTodo(t *testing.T) {
 		})
 	}
 }
+
+func TestGetTodosTagFilter(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db, _ := test.SetupTestDB()
+	testUser, err := test.CreateTestUser(db)
+	assert.NoError(t, err)
+
+	work := &models.Tag{Name: "work", UserID: testUser.ID}
+	urgent := &models.Tag{Name: "urgent", UserID: testUser.ID}
+	db.Create(work)
+	db.Create(urgent)
+	db.Create(&models.Todo{Title: "Work only", UserID: testUser.ID, Tags: []models.Tag{*work}})
+	db.Create(&models.Todo{Title: "Work and urgent", UserID: testUser.ID, Tags: []models.Tag{*work, *urgent}})
+	db.Create(&models.Todo{Title: "Untagged", UserID: testUser.ID})
+
+	tests := []struct {
+		name       string
+		query      string
+		wantStatus int
+		wantCount  int
+	}{
+		{
+			name:       "No filter",
+			query:      "",
+			wantStatus: http.StatusOK,
+			wantCount:  3,
+		},
+		{
+			name:       "Any of the tags",
+			query:      "?tags=work,urgent",
+			wantStatus: http.StatusOK,
+			wantCount:  2,
+		},
+		{
+			name:       "All of the tags",
+			query:      "?tags=WORK,urgent&tag_match=all",
+			wantStatus: http.StatusOK,
+			wantCount:  1,
+		},
+		{
+			name:       "Invalid match mode",
+			query:      "?tags=work&tag_match=some",
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			router.GET("/todos", func(c *gin.Context) {
+				c.Set("userID", testUser.ID)
+				GetTodos(c)
+			})
+
+			req, _ := http.NewRequest("GET", "/todos"+tt.query, nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+
+			if w.Code == http.StatusOK {
+				var response []models.Todo
+				err := json.Unmarshal(w.Body.Bytes(), &response)
+				assert.NoError(t, err)
+				assert.Equal(t, tt.wantCount, len(response))
+			}
+		})
+	}
+}
+
+func TestGetTodosByPriority(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db, _ := test.SetupTestDB()
+	test.ClearTestData(db)
+	testUser, err := test.CreateTestUser(db)
+	assert.NoError(t, err)
+
+	db.Create(&models.Todo{Title: "Someday", UserID: testUser.ID})
+	db.Create(&models.Todo{Title: "Urgent", UserID: testUser.ID, Priority: models.PriorityHigh})
+	db.Create(&models.Todo{Title: "Soon", UserID: testUser.ID, Priority: models.PriorityMedium})
+
+	tests := []struct {
+		name       string
+		query      string
+		wantStatus int
+		wantTitles []string
+	}{
+		{
+			name:       "Highest priority first",
+			query:      "?sort=-priority",
+			wantStatus: http.StatusOK,
+			wantTitles: []string{"Urgent", "Soon", "Someday"},
+		},
+		{
+			name:       "Only one priority",
+			query:      "?priority=2",
+			wantStatus: http.StatusOK,
+			wantTitles: []string{"Soon"},
+		},
+		{
+			name:       "Invalid priority",
+			query:      "?priority=urgent",
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	router := gin.New()
+	router.GET("/todos", func(c *gin.Context) {
+		c.Set("userID", testUser.ID)
+		GetTodos(c)
+	})
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", "/todos"+tt.query, nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			if w.Code == http.StatusOK {
+				var response []models.Todo
+				assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+				titles := make([]string, len(response))
+				for i, todo := range response {
+					titles[i] = todo.Title
+				}
+				assert.Equal(t, tt.wantTitles, titles)
+			}
+		})
+	}
+}
@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+	"todo-api/internal/models"
+)
+
+// setCompletion completes or reopens a todo and runs the side effects that
+// follow from it.
+func setCompletion(tx *gorm.DB, todo *models.Todo, completed bool) error {
+	if todo.Completed == completed {
+		return nil
+	}
+
+	completedAt := completionTime(todo, completed)
+	err := tx.Model(todo).Updates(map[string]interface{}{
+		"completed":    completed,
+		"completed_at": completedAt,
+	}).Error
+	if err != nil {
+		return err
+	}
+
+	todo.Completed = completed
+	todo.CompletedAt = completedAt
+	return afterCompletionChange(tx, todo, !completed)
+}
+
+// completionTime returns the completed_at value for moving a todo into the
+// given completion state, keeping the original time if it was already done.
+func completionTime(todo *models.Todo, completed bool) *time.Time {
+	if !completed {
+		return nil
+	}
+	if todo.Completed && todo.CompletedAt != nil {
+		return todo.CompletedAt
+	}
+	now := time.Now()
+	return &now
+}
+
+// afterCompletionChange runs the side effects of a todo whose completion
+// state may have changed: its workflow status follows, recurring todos spawn
+// their next occurrence, completion rules run and auto-completing parents
+// are rolled up.
+func afterCompletionChange(tx *gorm.DB, todo *models.Todo, wasCompleted bool) error {
+	if err := syncStatus(tx, todo); err != nil {
+		return err
+	}
+
+	if todo.Completed && !wasCompleted {
+		if todo.Recurrence != "" {
+			if _, err := spawnNextOccurrence(tx, todo); err != nil {
+				return err
+			}
+		}
+		if err := runRules(tx, models.TriggerCompleted, todo); err != nil {
+			return err
+		}
+	}
+
+	return rollUpCompletion(tx, todo)
+}
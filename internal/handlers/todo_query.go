@@ -0,0 +1,259 @@
+package handlers
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"todo-api/internal/models"
+	"todo-api/internal/query"
+)
+
+var relativeDayPattern = regexp.MustCompile(`^([+-]?\d+)([dw])$`)
+
+// dateColumns are the query fields that compare dates.
+var dateColumns = map[string]string{
+	"due":       "todos.due_date",
+	"created":   "todos.created_at",
+	"updated":   "todos.updated_at",
+	"completed": "todos.completed_at",
+}
+
+var priorityNames = map[string]int{
+	"none":   models.PriorityNone,
+	"low":    models.PriorityLow,
+	"medium": models.PriorityMedium,
+	"high":   models.PriorityHigh,
+}
+
+// todoQuery compiles a parsed q parameter into SQL conditions. Values only
+// ever reach the database as bind variables.
+type todoQuery struct {
+	db     *gorm.DB
+	userID uint
+	now    time.Time // in the user's time zone
+
+	// Whether the query asks for todos that lists leave out by default
+	archived bool
+	snoozed  bool
+}
+
+// parseTodoQuery parses a q parameter into a scope narrowing a todo query.
+func parseTodoQuery(db *gorm.DB, userID uint, q string) (*todoQuery, func(*gorm.DB) *gorm.DB, error) {
+	db = db.Session(&gorm.Session{NewDB: true})
+	loc, err := userLocation(db, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	compiler := &todoQuery{db: db, userID: userID, now: time.Now().In(loc)}
+
+	node, err := query.Parse(q)
+	if err != nil {
+		return nil, nil, err
+	}
+	if node == nil {
+		return compiler, func(db *gorm.DB) *gorm.DB { return db }, nil
+	}
+	sql, vars, err := compiler.compile(node)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return compiler, func(db *gorm.DB) *gorm.DB {
+		return db.Where(clause.Expr{SQL: "(" + sql + ")", Vars: vars})
+	}, nil
+}
+
+func (q *todoQuery) compile(node query.Node) (string, []interface{}, error) {
+	switch n := node.(type) {
+	case query.And:
+		return q.join(n.Nodes, " AND ")
+	case query.Or:
+		return q.join(n.Nodes, " OR ")
+	case query.Not:
+		sql, vars, err := q.compile(n.Node)
+		if err != nil {
+			return "", nil, err
+		}
+		return "NOT (" + sql + ")", vars, nil
+	case query.Term:
+		return q.term(n)
+	}
+	return "", nil, nil
+}
+
+func (q *todoQuery) join(nodes []query.Node, sep string) (string, []interface{}, error) {
+	parts := make([]string, 0, len(nodes))
+	var vars []interface{}
+	for _, node := range nodes {
+		sql, nodeVars, err := q.compile(node)
+		if err != nil {
+			return "", nil, err
+		}
+		parts = append(parts, "("+sql+")")
+		vars = append(vars, nodeVars...)
+	}
+	return strings.Join(parts, sep), vars, nil
+}
+
+func (q *todoQuery) term(t query.Term) (string, []interface{}, error) {
+	if column, ok := dateColumns[t.Field]; ok {
+		return q.date(t, column)
+	}
+	if t.Field == "priority" {
+		return q.priority(t)
+	}
+
+	if t.Op != query.OpEq {
+		if t.Field == "" {
+			return "", nil, query.Errorf(t.Pos, "unexpected %q", string(t.Op))
+		}
+		return "", nil, query.Errorf(t.Pos, "%s can only be compared with ':'", t.Field)
+	}
+	value := strings.ToLower(t.Value)
+
+	switch t.Field {
+	case "":
+		pattern := "%" + escapeLike(value) + "%"
+		return `LOWER(todos.title) LIKE ? ESCAPE '\' OR LOWER(todos.description) LIKE ? ESCAPE '\'`, []interface{}{pattern, pattern}, nil
+
+	case "title":
+		pattern := "%" + escapeLike(value) + "%"
+		return `LOWER(todos.title) LIKE ? ESCAPE '\'`, []interface{}{pattern}, nil
+
+	case "status":
+		switch value {
+		case "open":
+			return "todos.completed = ?", []interface{}{false}, nil
+		case "done", "completed":
+			return "todos.completed = ?", []interface{}{true}, nil
+		case "archived":
+			q.archived = true
+			return "todos.archived = ?", []interface{}{true}, nil
+		case "snoozed":
+			q.snoozed = true
+			return "todos.hidden_until IS NOT NULL AND todos.hidden_until > ?", []interface{}{q.now.UTC()}, nil
+		}
+		return "", nil, query.Errorf(t.ValuePos, "unknown status %q, use open, done, archived or snoozed", t.Value)
+
+	case "tag":
+		tagged := q.db.Table("todo_tags").
+			Select("todo_tags.todo_id").
+			Joins("JOIN tags ON tags.id = todo_tags.tag_id AND tags.deleted_at IS NULL").
+			Where("tags.user_id = ? AND LOWER(tags.name) = ?", q.userID, value)
+		return "todos.id IN (?)", []interface{}{tagged}, nil
+
+	case "project":
+		// Like dates, todos without a project must not match, even negated
+		if id, err := strconv.ParseUint(value, 10, 64); err == nil && !t.Quoted {
+			return "todos.project_id IS NOT NULL AND todos.project_id = ?", []interface{}{id}, nil
+		}
+		shared := q.db.Model(&models.ProjectMember{}).Select("project_id").Where("user_id = ?", q.userID)
+		projects := q.db.Model(&models.Project{}).Select("id").
+			Where("LOWER(name) = ? AND (user_id = ? OR id IN (?))", value, q.userID, shared)
+		return "todos.project_id IS NOT NULL AND todos.project_id IN (?)", []interface{}{projects}, nil
+
+	case "assignee":
+		assignees := q.db.Table("todo_assignees").Select("todo_id")
+		switch value {
+		case "none":
+			return "todos.id NOT IN (?)", []interface{}{assignees}, nil
+		case "me":
+			return "todos.id IN (?)", []interface{}{assignees.Where("user_id = ?", q.userID)}, nil
+		}
+		id, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			return "", nil, query.Errorf(t.ValuePos, "assignee must be a user ID, me or none")
+		}
+		return "todos.id IN (?)", []interface{}{assignees.Where("user_id = ?", id)}, nil
+	}
+
+	return "", nil, query.Errorf(t.Pos, "unknown field %q", t.Field)
+}
+
+func (q *todoQuery) priority(t query.Term) (string, []interface{}, error) {
+	level, ok := priorityNames[strings.ToLower(t.Value)]
+	if !ok {
+		n, err := strconv.Atoi(t.Value)
+		if err != nil || n < models.PriorityNone || n > models.PriorityHigh {
+			return "", nil, query.Errorf(t.ValuePos, "priority must be none, low, medium, high or 0 to 3")
+		}
+		level = n
+	}
+
+	op := string(t.Op)
+	if t.Op == query.OpEq {
+		op = "="
+	}
+	return "todos.priority " + op + " ?", []interface{}{level}, nil
+}
+
+// date compares a date column with a day. Equality matches the whole day
+// and the other operators compare with its start or end.
+func (q *todoQuery) date(t query.Term, column string) (string, []interface{}, error) {
+	if strings.EqualFold(t.Value, "none") {
+		if t.Op != query.OpEq {
+			return "", nil, query.Errorf(t.Pos, "none can only be compared with ':'")
+		}
+		return column + " IS NULL", nil, nil
+	}
+
+	start, ok := q.day(strings.ToLower(t.Value))
+	if !ok {
+		return "", nil, query.Errorf(t.ValuePos, "%s must be a date such as today, tomorrow, 7d, -2w, 2026-01-31 or none", t.Field)
+	}
+	end := start.AddDate(0, 0, 1)
+	start, end = start.UTC(), end.UTC()
+
+	// Comparing with a missing date is false rather than unknown, so that
+	// negating it matches todos without one
+	present := column + " IS NOT NULL AND "
+	switch t.Op {
+	case query.OpLt:
+		return present + column + " < ?", []interface{}{start}, nil
+	case query.OpLe:
+		return present + column + " < ?", []interface{}{end}, nil
+	case query.OpGt:
+		return present + column + " >= ?", []interface{}{end}, nil
+	case query.OpGe:
+		return present + column + " >= ?", []interface{}{start}, nil
+	}
+	return present + column + " >= ? AND " + column + " < ?", []interface{}{start, end}, nil
+}
+
+// day resolves a date value to the midnight that starts it.
+func (q *todoQuery) day(value string) (time.Time, bool) {
+	y, m, d := q.now.Date()
+	today := time.Date(y, m, d, 0, 0, 0, 0, q.now.Location())
+
+	switch value {
+	case "today":
+		return today, true
+	case "tomorrow":
+		return today.AddDate(0, 0, 1), true
+	case "yesterday":
+		return today.AddDate(0, 0, -1), true
+	}
+
+	if match := relativeDayPattern.FindStringSubmatch(value); match != nil {
+		n, err := strconv.Atoi(match[1])
+		if err != nil {
+			return time.Time{}, false
+		}
+		if match[2] == "w" {
+			n *= 7
+		}
+		return today.AddDate(0, 0, n), true
+	}
+
+	date, err := time.ParseInLocation("2006-01-02", value, q.now.Location())
+	return date, err == nil
+}
+
+// escapeLike makes LIKE wildcards in s match literally.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
@@ -0,0 +1,219 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"todo-api/internal/models"
+	"todo-api/internal/test"
+)
+
+func TestGetTodosQuery(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db, _ := test.SetupTestDB()
+	test.ClearTestData(db)
+	testUser, err := test.CreateTestUser(db)
+	assert.NoError(t, err)
+	teammate := &models.User{Email: "teammate@example.com"}
+	db.Create(teammate)
+
+	now := time.Now().UTC()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 12, 0, 0, 0, time.UTC)
+	overdue := today.AddDate(0, 0, -2)
+	soon := today.AddDate(0, 0, 3)
+	later := today.AddDate(0, 0, 30)
+	hidden := now.Add(time.Hour)
+
+	work := &models.Project{Name: "Work", UserID: testUser.ID}
+	db.Create(work)
+	workTag := &models.Tag{Name: "work", UserID: testUser.ID}
+	db.Create(workTag)
+	somedayTag := &models.Tag{Name: "Some Day", UserID: testUser.ID}
+	db.Create(somedayTag)
+
+	report := &models.Todo{Title: "Write report", UserID: testUser.ID, ProjectID: &work.ID, DueDate: &soon, Priority: models.PriorityHigh}
+	db.Create(report)
+	slides := &models.Todo{Title: "Slides", Description: "For the 100% review", UserID: testUser.ID, ProjectID: &work.ID, DueDate: &overdue}
+	db.Create(slides)
+	hobby := &models.Todo{Title: "Learn piano", UserID: testUser.ID, DueDate: &later, Priority: models.PriorityLow}
+	db.Create(hobby)
+	done := &models.Todo{Title: "Filed taxes", UserID: testUser.ID, Completed: true, CompletedAt: &now}
+	db.Create(done)
+	archived := &models.Todo{Title: "Old plan", UserID: testUser.ID, Archived: true}
+	db.Create(archived)
+	snoozed := &models.Todo{Title: "Call back", UserID: testUser.ID, HiddenUntil: &hidden}
+	db.Create(snoozed)
+
+	db.Exec("INSERT INTO todo_tags (todo_id, tag_id) VALUES (?, ?), (?, ?), (?, ?)", report.ID, workTag.ID, slides.ID, workTag.ID, hobby.ID, somedayTag.ID)
+	db.Exec("INSERT INTO todo_assignees (todo_id, user_id) VALUES (?, ?), (?, ?)", slides.ID, testUser.ID, hobby.ID, teammate.ID)
+
+	router := gin.New()
+	router.GET("/todos", func(c *gin.Context) {
+		c.Set("userID", testUser.ID)
+		GetTodos(c)
+	})
+
+	tests := []struct {
+		name           string
+		q              string
+		expectedCode   int
+		expectedTitles []string
+		expectedError  string
+	}{
+		{
+			name:           "Example from the docs",
+			q:              `status:open tag:work due<7d (priority:high OR assignee:me) -tag:"some day"`,
+			expectedCode:   http.StatusOK,
+			expectedTitles: []string{"Write report", "Slides"},
+		},
+		{
+			name:           "Overdue",
+			q:              "status:open due<today",
+			expectedCode:   http.StatusOK,
+			expectedTitles: []string{"Slides"},
+		},
+		{
+			name:           "Negated dates match todos without one",
+			q:              "-due<today",
+			expectedCode:   http.StatusOK,
+			expectedTitles: []string{"Write report", "Learn piano", "Filed taxes"},
+		},
+		{
+			name:           "Due on a day",
+			q:              "due:" + soon.Format("2006-01-02"),
+			expectedCode:   http.StatusOK,
+			expectedTitles: []string{"Write report"},
+		},
+		{
+			name:           "Priority comparison",
+			q:              "priority>=low priority<high",
+			expectedCode:   http.StatusOK,
+			expectedTitles: []string{"Learn piano"},
+		},
+		{
+			name:           "Project by name and unassigned",
+			q:              "project:WORK assignee:none",
+			expectedCode:   http.StatusOK,
+			expectedTitles: []string{"Write report"},
+		},
+		{
+			name:           "Completed today",
+			q:              "status:done completed:today",
+			expectedCode:   http.StatusOK,
+			expectedTitles: []string{"Filed taxes"},
+		},
+		{
+			name:           "Text search treats wildcards literally",
+			q:              `"100%" OR PIANO`,
+			expectedCode:   http.StatusOK,
+			expectedTitles: []string{"Slides", "Learn piano"},
+		},
+		{
+			name:           "Title only searches titles",
+			q:              "title:review OR title:SLIDES",
+			expectedCode:   http.StatusOK,
+			expectedTitles: []string{"Slides"},
+		},
+		{
+			name:           "Asking for archived todos includes them",
+			q:              "status:archived",
+			expectedCode:   http.StatusOK,
+			expectedTitles: []string{"Old plan"},
+		},
+		{
+			name:           "Asking for snoozed todos includes them",
+			q:              "status:snoozed",
+			expectedCode:   http.StatusOK,
+			expectedTitles: []string{"Call back"},
+		},
+		{
+			name:           "Negating snoozed matches todos that were never snoozed",
+			q:              "-status:snoozed",
+			expectedCode:   http.StatusOK,
+			expectedTitles: []string{"Write report", "Slides", "Learn piano", "Filed taxes"},
+		},
+		{
+			name:           "NOT works like a leading minus",
+			q:              "NOT status:snoozed status:open",
+			expectedCode:   http.StatusOK,
+			expectedTitles: []string{"Write report", "Slides", "Learn piano"},
+		},
+		{
+			name:           "Negating a project by name matches todos without one",
+			q:              "-project:work",
+			expectedCode:   http.StatusOK,
+			expectedTitles: []string{"Learn piano", "Filed taxes"},
+		},
+		{
+			name:           "Negating a project by ID matches todos without one",
+			q:              fmt.Sprintf("NOT project:%d", work.ID),
+			expectedCode:   http.StatusOK,
+			expectedTitles: []string{"Learn piano", "Filed taxes"},
+		},
+		{
+			name:           "Values never reach the SQL",
+			q:              `tag:"work' OR 1=1 --" OR "'); DROP TABLE todos; --"`,
+			expectedCode:   http.StatusOK,
+			expectedTitles: nil,
+		},
+		{
+			name:          "Unknown field",
+			q:             "status:open colour:red",
+			expectedCode:  http.StatusBadRequest,
+			expectedError: `invalid q: unknown field "colour" at column 13`,
+		},
+		{
+			name:          "Bad value",
+			q:             "due<soon",
+			expectedCode:  http.StatusBadRequest,
+			expectedError: "invalid q: due must be a date such as today, tomorrow, 7d, -2w, 2026-01-31 or none at column 5",
+		},
+		{
+			name:          "Syntax error",
+			q:             "(tag:work OR tag:home",
+			expectedCode:  http.StatusBadRequest,
+			expectedError: "invalid q: unclosed '(' at column 1",
+		},
+		{
+			name:          "Comparison on a field without order",
+			q:             "tag>work",
+			expectedCode:  http.StatusBadRequest,
+			expectedError: "invalid q: tag can only be compared with ':' at column 1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", "/todos?q="+url.QueryEscape(tt.q), nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+			if w.Code == http.StatusOK {
+				var response []models.Todo
+				assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+				var titles []string
+				for _, todo := range response {
+					titles = append(titles, todo.Title)
+				}
+				assert.ElementsMatch(t, tt.expectedTitles, titles)
+			} else {
+				var response ErrorResponse
+				assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+				assert.Equal(t, tt.expectedError, response.Error)
+			}
+		})
+	}
+
+	var count int64
+	db.Model(&models.Todo{}).Count(&count)
+	assert.Equal(t, int64(6), count)
+}
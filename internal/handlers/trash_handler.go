@@ -0,0 +1,171 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"todo-api/internal/database"
+	"todo-api/internal/models"
+	"todo-api/internal/storage"
+	"todo-api/internal/trash"
+)
+
+// @Summary Get trashed todos
+// @Description Get the authenticated user's deleted todos, most recently deleted first
+// @Tags trash
+// @Produce json
+// @Security Bearer
+// @Success 200 {array} models.Todo
+// @Failure 401 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/todos/trash [get]
+func GetTrash(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	var todos []models.Todo
+
+	result := database.GetDB().Unscoped().
+		Where("user_id = ? AND deleted_at IS NOT NULL", userID).
+		Order("deleted_at DESC").
+		Preload("Tags").
+		Find(&todos)
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: result.Error.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, todos)
+}
+
+// @Summary Restore a todo from the trash
+// @Description Restore a deleted todo together with the subtasks that were deleted with it. A todo whose parent is still in the trash becomes a top-level todo, and one whose project is gone moves to the inbox.
+// @Tags trash
+// @Produce json
+// @Security Bearer
+// @Param id path int true "Todo ID"
+// @Success 200 {object} models.Todo
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/todos/{id}/restore [post]
+func RestoreTodo(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	todo, ok := findTrashedTodo(c, userID)
+	if !ok {
+		return
+	}
+
+	before := snapshotTodo(todo)
+	err := database.GetDB().Transaction(func(tx *gorm.DB) error {
+		ids, err := trashedSubtree(tx, todo)
+		if err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Model(&models.Todo{}).Where("id IN ?", ids).Update("deleted_at", nil).Error; err != nil {
+			return err
+		}
+		todo.DeletedAt = gorm.DeletedAt{}
+
+		if todo.ParentID != nil && tx.Select("id").First(&models.Todo{}, *todo.ParentID).Error != nil {
+			if err := tx.Model(todo).Update("parent_id", nil).Error; err != nil {
+				return err
+			}
+			todo.ParentID = nil
+		}
+
+		if todo.ProjectID == nil || tx.Select("id").First(&models.Project{}, *todo.ProjectID).Error != nil {
+			inbox, err := inboxFor(tx, todo.UserID)
+			if err != nil {
+				return err
+			}
+			if err := moveTodo(tx, todo, inbox.ID); err != nil {
+				return err
+			}
+		}
+
+		if err := rollUpCompletion(tx, todo); err != nil {
+			return err
+		}
+		if err := recordRevision(tx, before, todo.ID, userID.(uint)); err != nil {
+			return err
+		}
+		return runRules(tx, models.TriggerUpdated, todo)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	database.GetDB().Preload("Tags").First(todo, todo.ID)
+	c.JSON(http.StatusOK, todo)
+}
+
+// @Summary Permanently delete a todo
+// @Description Permanently delete a todo in the trash, together with the subtasks that were deleted with it
+// @Tags trash
+// @Security Bearer
+// @Param id path int true "Todo ID"
+// @Success 204 "No Content"
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/todos/trash/{id} [delete]
+func PurgeTodo(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	todo, ok := findTrashedTodo(c, userID)
+	if !ok {
+		return
+	}
+
+	var checksums []string
+	err := database.GetDB().Transaction(func(tx *gorm.DB) error {
+		ids, err := trashedSubtree(tx, todo)
+		if err != nil {
+			return err
+		}
+		checksums, err = trash.Purge(tx, ids)
+		return err
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	if err := storage.RemoveOrphans(c.Request.Context(), database.GetDB(), storage.GetStore(), checksums); err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// findTrashedTodo loads one of the user's deleted todos, writing a 404 when
+// there is none.
+func findTrashedTodo(c *gin.Context, userID interface{}) (*models.Todo, bool) {
+	var todo models.Todo
+	err := database.GetDB().Unscoped().
+		Where("id = ? AND user_id = ? AND deleted_at IS NOT NULL", c.Param("id"), userID).
+		First(&todo).Error
+	if err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Todo not found in trash"})
+		return nil, false
+	}
+	return &todo, true
+}
+
+// trashedSubtree returns the IDs of a trashed todo and of the subtasks that
+// were trashed with it. Subtasks deleted on their own earlier are left out.
+func trashedSubtree(tx *gorm.DB, todo *models.Todo) ([]uint, error) {
+	descendants, err := loadDescendants(tx.Unscoped().Select("id", "parent_id", "deleted_at"), todo.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := []uint{todo.ID}
+	for _, descendant := range descendants {
+		if descendant.DeletedAt.Valid && !descendant.DeletedAt.Time.Before(todo.DeletedAt.Time) {
+			ids = append(ids, descendant.ID)
+		}
+	}
+	return ids, nil
+}
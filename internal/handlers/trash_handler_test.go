@@ -0,0 +1,183 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"todo-api/internal/models"
+	"todo-api/internal/test"
+)
+
+func TestRestoreTodo(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db, _ := test.SetupTestDB()
+	testUser, err := test.CreateTestUser(db)
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name           string
+		deleteProject  bool
+		deleteParent   bool
+		restoreDeleted bool
+		expectedCode   int
+	}{
+		{
+			name:           "Restore a todo with its subtasks",
+			restoreDeleted: true,
+			expectedCode:   http.StatusOK,
+		},
+		{
+			name:           "Restore into the inbox when the project is gone",
+			restoreDeleted: true,
+			deleteProject:  true,
+			expectedCode:   http.StatusOK,
+		},
+		{
+			name:           "Restore as a top-level todo when the parent is trashed",
+			restoreDeleted: true,
+			deleteParent:   true,
+			expectedCode:   http.StatusOK,
+		},
+		{
+			name:         "Todo not in the trash",
+			expectedCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+
+			test.ClearTestData(db)
+			project := &models.Project{Name: "Garden", UserID: testUser.ID}
+			db.Create(project)
+			root := &models.Todo{Title: "Garden chores", UserID: testUser.ID, ProjectID: &project.ID}
+			db.Create(root)
+			todo := &models.Todo{Title: "Plant tulips", UserID: testUser.ID, ProjectID: &project.ID, ParentID: &root.ID}
+			db.Create(todo)
+			subtask := &models.Todo{Title: "Buy bulbs", UserID: testUser.ID, ProjectID: &project.ID, ParentID: &todo.ID}
+			db.Create(subtask)
+			removed := &models.Todo{Title: "Buy a spade", UserID: testUser.ID, ProjectID: &project.ID, ParentID: &todo.ID}
+			db.Create(removed)
+
+			// Deleted on its own before its parent, so it stays in the trash
+			db.Delete(removed)
+			if tt.restoreDeleted {
+				db.Where("id IN ?", []uint{todo.ID, subtask.ID}).Delete(&models.Todo{})
+			}
+			if tt.deleteParent {
+				db.Delete(root)
+			}
+			if tt.deleteProject {
+				db.Delete(project)
+			}
+
+			router.POST("/todos/:id/restore", func(c *gin.Context) {
+				c.Set("userID", testUser.ID)
+				RestoreTodo(c)
+			})
+
+			req, _ := http.NewRequest("POST", fmt.Sprintf("/todos/%d/restore", todo.ID), nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+			if tt.expectedCode != http.StatusOK {
+				return
+			}
+
+			var response models.Todo
+			err := json.Unmarshal(w.Body.Bytes(), &response)
+			assert.NoError(t, err)
+
+			var restored models.Todo
+			assert.NoError(t, db.First(&restored, subtask.ID).Error)
+			assert.Error(t, db.First(&models.Todo{}, removed.ID).Error)
+
+			// A parent left behind in another project no longer holds the todo
+			if tt.deleteParent || tt.deleteProject {
+				assert.Nil(t, response.ParentID)
+			} else {
+				assert.Equal(t, root.ID, *response.ParentID)
+			}
+			if tt.deleteProject {
+				var inbox models.Project
+				db.Where("user_id = ? AND inbox = ?", testUser.ID, true).First(&inbox)
+				assert.Equal(t, inbox.ID, *response.ProjectID)
+				assert.Equal(t, inbox.ID, *restored.ProjectID)
+			} else {
+				assert.Equal(t, project.ID, *response.ProjectID)
+			}
+		})
+	}
+}
+
+func TestTrashAndPurge(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db, _ := test.SetupTestDB()
+	setupTestStore(t)
+	testUser, err := test.CreateTestUser(db)
+	assert.NoError(t, err)
+	test.ClearTestData(db)
+
+	tag := &models.Tag{Name: "errands", UserID: testUser.ID}
+	db.Create(tag)
+	todo := &models.Todo{Title: "Return library books", UserID: testUser.ID, Tags: []models.Tag{*tag}}
+	db.Create(todo)
+	subtask := &models.Todo{Title: "Find the receipt", UserID: testUser.ID, ParentID: &todo.ID}
+	db.Create(subtask)
+	kept := &models.Todo{Title: "Renew card", UserID: testUser.ID}
+	db.Create(kept)
+
+	router := gin.New()
+	router.DELETE("/todos/:id", func(c *gin.Context) {
+		c.Set("userID", testUser.ID)
+		DeleteTodo(c)
+	})
+	router.GET("/todos/trash", func(c *gin.Context) {
+		c.Set("userID", testUser.ID)
+		GetTrash(c)
+	})
+	router.DELETE("/todos/trash/:id", func(c *gin.Context) {
+		c.Set("userID", testUser.ID)
+		PurgeTodo(c)
+	})
+
+	req, _ := http.NewRequest("DELETE", fmt.Sprintf("/todos/%d", todo.ID), nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+
+	req, _ = http.NewRequest("GET", "/todos/trash", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var trashed []models.Todo
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &trashed))
+	assert.Len(t, trashed, 2)
+
+	// Only trashed todos can be purged
+	req, _ = http.NewRequest("DELETE", fmt.Sprintf("/todos/trash/%d", kept.ID), nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	req, _ = http.NewRequest("DELETE", fmt.Sprintf("/todos/trash/%d", todo.ID), nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+
+	var remaining int64
+	db.Unscoped().Model(&models.Todo{}).Where("id IN ?", []uint{todo.ID, subtask.ID}).Count(&remaining)
+	assert.Equal(t, int64(0), remaining)
+	db.Table("todo_tags").Where("todo_id = ?", todo.ID).Count(&remaining)
+	assert.Equal(t, int64(0), remaining)
+	assert.NoError(t, db.First(&models.Todo{}, kept.ID).Error)
+}
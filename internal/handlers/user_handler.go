@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"todo-api/internal/database"
+	"todo-api/internal/models"
+)
+
+type UserSettingsRequest struct {
+	AutoArchiveDays *int    `json:"auto_archive_days" binding:"omitempty,min=0" example:"30"`
+	Timezone        *string `json:"timezone" example:"Europe/Berlin"`
+}
+
+// @Summary Get the current user
+// @Description Get the authenticated user's account and settings
+// @Tags users
+// @Produce json
+// @Security Bearer
+// @Success 200 {object} models.User
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/me [get]
+func GetMe(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	var user models.User
+
+	if err := database.GetDB().First(&user, userID).Error; err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "User not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
+// @Summary Update the current user's settings
+// @Description Change the authenticated user's settings. Settings left out of the request keep their value.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body UserSettingsRequest true "Settings"
+// @Success 200 {object} models.User
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/me/settings [put]
+func UpdateSettings(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	var user models.User
+
+	if err := database.GetDB().First(&user, userID).Error; err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "User not found"})
+		return
+	}
+
+	var req UserSettingsRequest
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	updates := map[string]interface{}{}
+	if req.AutoArchiveDays != nil {
+		updates["auto_archive_days"] = *req.AutoArchiveDays
+	}
+	if req.Timezone != nil {
+		// LoadLocation treats "Local" as the server's zone, which users cannot mean
+		if _, err := time.LoadLocation(*req.Timezone); err != nil || *req.Timezone == "Local" {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Unknown time zone: " + *req.Timezone})
+			return
+		}
+		updates["timezone"] = *req.Timezone
+	}
+
+	if len(updates) > 0 {
+		result := database.GetDB().Model(&user).Updates(updates)
+		if result.Error != nil {
+			c.JSON(http.StatusInternalServerError, ErrorResponse{Error: result.Error.Error()})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
+// userLocation returns the time zone a user's dates are counted in.
+func userLocation(db *gorm.DB, userID uint) (*time.Location, error) {
+	var user models.User
+	if err := db.Select("id", "timezone").First(&user, userID).Error; err != nil {
+		return nil, err
+	}
+	return user.Location(), nil
+}
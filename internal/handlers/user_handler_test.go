@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"todo-api/internal/models"
+	"todo-api/internal/test"
+)
+
+func TestUpdateSettings(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db, _ := test.SetupTestDB()
+
+	tests := []struct {
+		name         string
+		payload      map[string]interface{}
+		expectedCode int
+		wantDays     int
+		wantTimezone string
+	}{
+		{
+			name:         "Turn on auto-archive",
+			payload:      map[string]interface{}{"auto_archive_days": 14},
+			expectedCode: http.StatusOK,
+			wantDays:     14,
+		},
+		{
+			name:         "Missing settings keep their value",
+			payload:      map[string]interface{}{},
+			expectedCode: http.StatusOK,
+			wantDays:     7,
+		},
+		{
+			name:         "Negative days",
+			payload:      map[string]interface{}{"auto_archive_days": -1},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "Set the time zone",
+			payload:      map[string]interface{}{"timezone": "Europe/Berlin"},
+			expectedCode: http.StatusOK,
+			wantDays:     7,
+			wantTimezone: "Europe/Berlin",
+		},
+		{
+			name:         "Unknown time zone",
+			payload:      map[string]interface{}{"timezone": "Mars/Olympus_Mons"},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "Server time zone",
+			payload:      map[string]interface{}{"timezone": "Local"},
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+
+			test.ClearTestData(db)
+			testUser, err := test.CreateTestUser(db)
+			assert.NoError(t, err)
+			db.Model(testUser).Update("auto_archive_days", 7)
+
+			router.PUT("/me/settings", func(c *gin.Context) {
+				c.Set("userID", testUser.ID)
+				UpdateSettings(c)
+			})
+
+			jsonBody, _ := json.Marshal(tt.payload)
+			req, _ := http.NewRequest("PUT", "/me/settings", bytes.NewBuffer(jsonBody))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+			if tt.expectedCode == http.StatusOK {
+				var response models.User
+				err := json.Unmarshal(w.Body.Bytes(), &response)
+				assert.NoError(t, err)
+				assert.Equal(t, tt.wantDays, response.AutoArchiveDays)
+				assert.Equal(t, tt.wantTimezone, response.Timezone)
+
+				var stored models.User
+				db.First(&stored, testUser.ID)
+				assert.Equal(t, tt.wantDays, stored.AutoArchiveDays)
+				assert.Equal(t, tt.wantTimezone, stored.Timezone)
+			}
+		})
+	}
+}
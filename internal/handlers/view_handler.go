@@ -0,0 +1,458 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"todo-api/internal/database"
+	"todo-api/internal/models"
+)
+
+// defaultViews are the smart lists every new user starts with.
+var defaultViews = []models.SavedView{
+	{Name: "Today", Query: "status:open due<=today", Sort: "due_date", Pinned: true},
+	{Name: "Upcoming", Query: "status:open due>today due<=7d", Sort: "due_date", GroupBy: models.GroupByDue, SortOrder: 1},
+	{Name: "Overdue", Query: "status:open due<today", Sort: "due_date", SortOrder: 2},
+	{Name: "Recently completed", Query: "status:done completed>=-7d", Sort: "-completed_at", SortOrder: 3},
+}
+
+// ViewGroup lists the todos of a view result that share a project,
+// priority, status or due date bucket
+type ViewGroup struct {
+	Key     string `json:"key" example:"today"`
+	Label   string `json:"label" example:"Today"`
+	Count   int    `json:"count" example:"3"`
+	TodoIDs []uint `json:"todo_ids"`
+}
+
+// ViewResult is one page of the todos a saved view matches
+type ViewResult struct {
+	View     models.SavedView `json:"view"`
+	Todos    []models.Todo    `json:"todos"`
+	Groups   []ViewGroup      `json:"groups,omitempty"`
+	Count    int64            `json:"count" example:"42"`
+	Page     int              `json:"page" example:"1"`
+	PageSize int              `json:"page_size" example:"20"`
+}
+
+// @Summary Create a saved view
+// @Description Save a filter query, sort and grouping to come back to
+// @Tags views
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param view body models.SavedView true "Saved view"
+// @Success 201 {object} models.SavedView
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/views [post]
+func CreateView(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	var view models.SavedView
+	if err := c.BindJSON(&view); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	view.UserID = userID.(uint)
+	view.Name = strings.TrimSpace(view.Name)
+	if err := validateView(database.GetDB(), &view); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	if err := database.GetDB().Create(&view).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, view)
+}
+
+// @Summary Get all saved views
+// @Description Get the authenticated user's saved views, pinned ones first
+// @Tags views
+// @Produce json
+// @Security Bearer
+// @Success 200 {array} models.SavedView
+// @Failure 401 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/views [get]
+func GetViews(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	var views []models.SavedView
+	result := database.GetDB().Where("user_id = ?", userID).Order("pinned DESC, sort_order, id").Find(&views)
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: result.Error.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, views)
+}
+
+// @Summary Get a saved view
+// @Description Get a specific saved view by ID
+// @Tags views
+// @Produce json
+// @Security Bearer
+// @Param id path int true "View ID"
+// @Success 200 {object} models.SavedView
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/views/{id} [get]
+func GetView(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	var view models.SavedView
+
+	if err := database.GetDB().Where("id = ? AND user_id = ?", c.Param("id"), userID).First(&view).Error; err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "View not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, view)
+}
+
+// @Summary Update a saved view
+// @Description Rename a saved view or change its query, sort, grouping, pinning or position
+// @Tags views
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "View ID"
+// @Param view body models.SavedView true "Saved view"
+// @Success 200 {object} models.SavedView
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/views/{id} [put]
+func UpdateView(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	var view models.SavedView
+
+	if err := database.GetDB().Where("id = ? AND user_id = ?", c.Param("id"), userID).First(&view).Error; err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "View not found"})
+		return
+	}
+
+	var updateData models.SavedView
+	if err := c.BindJSON(&updateData); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+	updateData.UserID = view.UserID
+	updateData.Name = strings.TrimSpace(updateData.Name)
+	if err := validateView(database.GetDB(), &updateData); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	result := database.GetDB().Model(&view).Updates(map[string]interface{}{
+		"name":       updateData.Name,
+		"query":      updateData.Query,
+		"sort":       updateData.Sort,
+		"group_by":   updateData.GroupBy,
+		"pinned":     updateData.Pinned,
+		"sort_order": updateData.SortOrder,
+	})
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: result.Error.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, view)
+}
+
+// @Summary Delete a saved view
+// @Description Delete a saved view. The todos it lists are not touched.
+// @Tags views
+// @Security Bearer
+// @Param id path int true "View ID"
+// @Success 204 "No Content"
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/views/{id} [delete]
+func DeleteView(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	var view models.SavedView
+
+	if err := database.GetDB().Where("id = ? AND user_id = ?", c.Param("id"), userID).First(&view).Error; err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "View not found"})
+		return
+	}
+
+	if err := database.GetDB().Delete(&view).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// @Summary Pin a saved view
+// @Description Pin a saved view so that it is listed first
+// @Tags views
+// @Produce json
+// @Security Bearer
+// @Param id path int true "View ID"
+// @Success 200 {object} models.SavedView
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/views/{id}/pin [post]
+func PinView(c *gin.Context) {
+	setViewPinned(c, true)
+}
+
+// @Summary Unpin a saved view
+// @Description Unpin a saved view
+// @Tags views
+// @Produce json
+// @Security Bearer
+// @Param id path int true "View ID"
+// @Success 200 {object} models.SavedView
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/views/{id}/pin [delete]
+func UnpinView(c *gin.Context) {
+	setViewPinned(c, false)
+}
+
+func setViewPinned(c *gin.Context, pinned bool) {
+	userID, _ := c.Get("userID")
+	var view models.SavedView
+
+	if err := database.GetDB().Where("id = ? AND user_id = ?", c.Param("id"), userID).First(&view).Error; err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "View not found"})
+		return
+	}
+
+	if err := database.GetDB().Model(&view).Update("pinned", pinned).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, view)
+}
+
+// @Summary Run a saved view
+// @Description Get a page of the todos a saved view matches, in its sort order, with the total count and, for grouped views, the groups on the page
+// @Tags views
+// @Produce json
+// @Security Bearer
+// @Param id path int true "View ID"
+// @Param page query int false "Page number, starting at 1"
+// @Param page_size query int false "Todos per page (at most 100)"
+// @Param render query string false "Also return descriptions rendered as sanitized HTML" Enums(html)
+// @Success 200 {object} ViewResult
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/views/{id}/todos [get]
+func RunView(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	db := database.GetDB()
+	var view models.SavedView
+
+	if err := db.Where("id = ? AND user_id = ?", c.Param("id"), userID).First(&view).Error; err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "View not found"})
+		return
+	}
+
+	page, pageSize, err := pageParams(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+	render, err := renderOption(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	query, err := applyTodoFilters(accessibleTodos(db, view.UserID), view.UserID, url.Values{"q": {view.Query}})
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	var total int64
+	if err := query.Session(&gorm.Session{}).Model(&models.Todo{}).Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	query, err = applyTodoSort(query, view.UserID, view.Sort)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+	todos := []models.Todo{}
+	result := query.Order("todos.id").Offset((page - 1) * pageSize).Limit(pageSize).
+		Preload("User").Preload("Assignees").Preload("Tags").Preload("CustomFields").Find(&todos)
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: result.Error.Error()})
+		return
+	}
+	for i := range todos {
+		describeTodo(&todos[i], render)
+	}
+
+	groups, err := groupTodos(db, view.UserID, view.GroupBy, todos)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, ViewResult{
+		View:     view,
+		Todos:    todos,
+		Groups:   groups,
+		Count:    total,
+		Page:     page,
+		PageSize: pageSize,
+	})
+}
+
+// validateView checks that a view's query and sort can be run.
+func validateView(db *gorm.DB, view *models.SavedView) error {
+	if view.Name == "" {
+		return fmt.Errorf("name must not be empty")
+	}
+	if _, _, err := parseTodoQuery(db, view.UserID, view.Query); err != nil {
+		return fmt.Errorf("invalid query: %w", err)
+	}
+	if _, err := applyTodoSort(db.Session(&gorm.Session{NewDB: true}), view.UserID, view.Sort); err != nil {
+		return err
+	}
+	return nil
+}
+
+// seedViews gives a new user the default smart lists.
+func seedViews(db *gorm.DB, userID uint) error {
+	views := make([]models.SavedView, len(defaultViews))
+	for i, view := range defaultViews {
+		view.UserID = userID
+		views[i] = view
+	}
+	return db.Create(&views).Error
+}
+
+// groupTodos splits a page of todos into groups. Priorities, statuses and
+// due dates come in a fixed order, projects in the order they first appear.
+func groupTodos(db *gorm.DB, userID uint, groupBy string, todos []models.Todo) ([]ViewGroup, error) {
+	if groupBy == "" {
+		return nil, nil
+	}
+
+	var keyOf func(todo *models.Todo) (string, string)
+	var order []string
+	switch groupBy {
+	case models.GroupByPriority:
+		order = []string{"high", "medium", "low", "none"}
+		keyOf = func(todo *models.Todo) (string, string) {
+			for name, level := range priorityNames {
+				if level == todo.Priority {
+					return name, strings.ToUpper(name[:1]) + name[1:]
+				}
+			}
+			return "none", "None"
+		}
+
+	case models.GroupByStatus:
+		order = []string{"open", "done"}
+		keyOf = func(todo *models.Todo) (string, string) {
+			if todo.Completed {
+				return "done", "Done"
+			}
+			return "open", "Open"
+		}
+
+	case models.GroupByDue:
+		loc, err := userLocation(db, userID)
+		if err != nil {
+			return nil, err
+		}
+		now := time.Now().In(loc)
+		today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+		order = []string{"overdue", "today", "tomorrow", "next_7_days", "later", "none"}
+		keyOf = func(todo *models.Todo) (string, string) {
+			switch {
+			case todo.DueDate == nil:
+				return "none", "No due date"
+			case todo.DueDate.Before(today):
+				return "overdue", "Overdue"
+			case todo.DueDate.Before(today.AddDate(0, 0, 1)):
+				return "today", "Today"
+			case todo.DueDate.Before(today.AddDate(0, 0, 2)):
+				return "tomorrow", "Tomorrow"
+			case todo.DueDate.Before(today.AddDate(0, 0, 8)):
+				return "next_7_days", "Next 7 days"
+			}
+			return "later", "Later"
+		}
+
+	case models.GroupByProject:
+		var projects []models.Project
+		if err := db.Where("id IN (?)", projectIDs(todos)).Find(&projects).Error; err != nil {
+			return nil, err
+		}
+		names := make(map[uint]string, len(projects))
+		for _, project := range projects {
+			names[project.ID] = project.Name
+		}
+		keyOf = func(todo *models.Todo) (string, string) {
+			if todo.ProjectID == nil {
+				return "none", "No project"
+			}
+			return strconv.FormatUint(uint64(*todo.ProjectID), 10), names[*todo.ProjectID]
+		}
+	}
+
+	byKey := make(map[string]*ViewGroup)
+	var appeared []string
+	for i := range todos {
+		key, label := keyOf(&todos[i])
+		group, ok := byKey[key]
+		if !ok {
+			group = &ViewGroup{Key: key, Label: label, TodoIDs: []uint{}}
+			byKey[key] = group
+			appeared = append(appeared, key)
+		}
+		group.Count++
+		group.TodoIDs = append(group.TodoIDs, todos[i].ID)
+	}
+
+	if order == nil {
+		order = appeared
+	}
+	groups := []ViewGroup{}
+	for _, key := range order {
+		if group, ok := byKey[key]; ok {
+			groups = append(groups, *group)
+		}
+	}
+	return groups, nil
+}
+
+func projectIDs(todos []models.Todo) []uint {
+	ids := []uint{}
+	for _, todo := range todos {
+		if todo.ProjectID != nil {
+			ids = append(ids, *todo.ProjectID)
+		}
+	}
+	return ids
+}
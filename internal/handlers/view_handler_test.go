@@ -0,0 +1,372 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"todo-api/internal/models"
+	"todo-api/internal/test"
+)
+
+func TestCreateView(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db, _ := test.SetupTestDB()
+	test.ClearTestData(db)
+	testUser, err := test.CreateTestUser(db)
+	assert.NoError(t, err)
+
+	router := gin.New()
+	router.POST("/views", func(c *gin.Context) {
+		c.Set("userID", testUser.ID)
+		CreateView(c)
+	})
+
+	tests := []struct {
+		name         string
+		payload      map[string]interface{}
+		expectedCode int
+	}{
+		{
+			name: "Valid view",
+			payload: map[string]interface{}{
+				"name":     "Work this week",
+				"query":    "status:open tag:work due<7d",
+				"sort":     "-priority",
+				"group_by": "project",
+			},
+			expectedCode: http.StatusCreated,
+		},
+		{
+			name:         "View without a query lists everything",
+			payload:      map[string]interface{}{"name": "Everything"},
+			expectedCode: http.StatusCreated,
+		},
+		{
+			name:         "Missing name",
+			payload:      map[string]interface{}{"query": "status:open"},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "Blank name",
+			payload:      map[string]interface{}{"name": "  "},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "Invalid query",
+			payload:      map[string]interface{}{"name": "Broken", "query": "due<someday"},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "Invalid sort",
+			payload:      map[string]interface{}{"name": "Broken", "sort": "colour"},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "Invalid grouping",
+			payload:      map[string]interface{}{"name": "Broken", "group_by": "colour"},
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, _ := json.Marshal(tt.payload)
+			req, _ := http.NewRequest("POST", "/views", bytes.NewBuffer(body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+			if w.Code == http.StatusCreated {
+				var response models.SavedView
+				assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+				assert.Equal(t, tt.payload["name"], response.Name)
+				assert.Equal(t, testUser.ID, response.UserID)
+			}
+		})
+	}
+}
+
+func TestGetViews(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db, _ := test.SetupTestDB()
+	test.ClearTestData(db)
+	testUser, err := test.CreateTestUser(db)
+	assert.NoError(t, err)
+
+	assert.NoError(t, seedViews(db, testUser.ID))
+	db.Create(&models.SavedView{UserID: testUser.ID, Name: "Pinned later", Pinned: true, SortOrder: 5})
+	db.Create(&models.SavedView{UserID: testUser.ID + 1, Name: "Not mine"})
+
+	router := gin.New()
+	router.GET("/views", func(c *gin.Context) {
+		c.Set("userID", testUser.ID)
+		GetViews(c)
+	})
+
+	req, _ := http.NewRequest("GET", "/views", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var views []models.SavedView
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &views))
+	var names []string
+	for _, view := range views {
+		names = append(names, view.Name)
+	}
+	assert.Equal(t, []string{"Today", "Pinned later", "Upcoming", "Overdue", "Recently completed"}, names)
+}
+
+func TestUpdateView(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db, _ := test.SetupTestDB()
+	test.ClearTestData(db)
+	testUser, err := test.CreateTestUser(db)
+	assert.NoError(t, err)
+
+	view := &models.SavedView{UserID: testUser.ID, Name: "Work", Query: "tag:work", Pinned: true}
+	db.Create(view)
+	other := &models.SavedView{UserID: testUser.ID + 1, Name: "Not mine"}
+	db.Create(other)
+
+	router := gin.New()
+	router.PUT("/views/:id", func(c *gin.Context) {
+		c.Set("userID", testUser.ID)
+		UpdateView(c)
+	})
+
+	tests := []struct {
+		name         string
+		viewID       uint
+		payload      map[string]interface{}
+		expectedCode int
+	}{
+		{
+			name:         "Valid update",
+			viewID:       view.ID,
+			payload:      map[string]interface{}{"name": "Urgent work", "query": "tag:work priority:high", "group_by": "due"},
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "Invalid query",
+			viewID:       view.ID,
+			payload:      map[string]interface{}{"name": "Work", "query": "(tag:work"},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "Another user's view",
+			viewID:       other.ID,
+			payload:      map[string]interface{}{"name": "Mine now"},
+			expectedCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, _ := json.Marshal(tt.payload)
+			req, _ := http.NewRequest("PUT", fmt.Sprintf("/views/%d", tt.viewID), bytes.NewBuffer(body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+		})
+	}
+
+	var updated models.SavedView
+	db.First(&updated, view.ID)
+	assert.Equal(t, "Urgent work", updated.Name)
+	assert.Equal(t, "tag:work priority:high", updated.Query)
+	assert.Equal(t, models.GroupByDue, updated.GroupBy)
+	assert.False(t, updated.Pinned)
+}
+
+func TestPinView(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db, _ := test.SetupTestDB()
+	test.ClearTestData(db)
+	testUser, err := test.CreateTestUser(db)
+	assert.NoError(t, err)
+
+	view := &models.SavedView{UserID: testUser.ID, Name: "Work"}
+	db.Create(view)
+
+	router := gin.New()
+	router.POST("/views/:id/pin", func(c *gin.Context) {
+		c.Set("userID", testUser.ID)
+		PinView(c)
+	})
+	router.DELETE("/views/:id/pin", func(c *gin.Context) {
+		c.Set("userID", testUser.ID)
+		UnpinView(c)
+	})
+
+	tests := []struct {
+		method       string
+		viewID       uint
+		expectedCode int
+		wantPinned   bool
+	}{
+		{method: "POST", viewID: view.ID, expectedCode: http.StatusOK, wantPinned: true},
+		{method: "DELETE", viewID: view.ID, expectedCode: http.StatusOK, wantPinned: false},
+		{method: "POST", viewID: view.ID + 100, expectedCode: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%s %d", tt.method, tt.viewID), func(t *testing.T) {
+			req, _ := http.NewRequest(tt.method, fmt.Sprintf("/views/%d/pin", tt.viewID), nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+			if w.Code == http.StatusOK {
+				var response models.SavedView
+				assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+				assert.Equal(t, tt.wantPinned, response.Pinned)
+			}
+		})
+	}
+}
+
+func TestRunView(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db, _ := test.SetupTestDB()
+	test.ClearTestData(db)
+	testUser, err := test.CreateTestUser(db)
+	assert.NoError(t, err)
+
+	now := time.Now().UTC()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 12, 0, 0, 0, time.UTC)
+	yesterday := today.AddDate(0, 0, -1)
+	tomorrow := today.AddDate(0, 0, 1)
+	nextWeek := today.AddDate(0, 0, 5)
+	later := today.AddDate(0, 0, 30)
+	completedAt := now.Add(-time.Hour)
+
+	overdue := &models.Todo{Title: "Overdue", UserID: testUser.ID, DueDate: &yesterday}
+	dueToday := &models.Todo{Title: "Due today", UserID: testUser.ID, DueDate: &today, Priority: models.PriorityHigh}
+	dueTomorrow := &models.Todo{Title: "Due tomorrow", UserID: testUser.ID, DueDate: &tomorrow}
+	dueNextWeek := &models.Todo{Title: "Due next week", UserID: testUser.ID, DueDate: &nextWeek, Priority: models.PriorityHigh}
+	dueLater := &models.Todo{Title: "Due later", UserID: testUser.ID, DueDate: &later}
+	done := &models.Todo{Title: "Done", UserID: testUser.ID, DueDate: &yesterday, Completed: true, CompletedAt: &completedAt}
+	for _, todo := range []*models.Todo{overdue, dueToday, dueTomorrow, dueNextWeek, dueLater, done} {
+		db.Create(todo)
+	}
+	db.Create(&models.Todo{Title: "Not mine", UserID: testUser.ID + 1, DueDate: &today})
+
+	assert.NoError(t, seedViews(db, testUser.ID))
+	viewID := func(name string) uint {
+		var view models.SavedView
+		db.Where("user_id = ? AND name = ?", testUser.ID, name).First(&view)
+		return view.ID
+	}
+	byPriority := &models.SavedView{UserID: testUser.ID, Name: "By priority", Query: "status:open", Sort: "due_date", GroupBy: models.GroupByPriority}
+	db.Create(byPriority)
+	other := &models.SavedView{UserID: testUser.ID + 1, Name: "Not mine"}
+	db.Create(other)
+
+	router := gin.New()
+	router.GET("/views/:id/todos", func(c *gin.Context) {
+		c.Set("userID", testUser.ID)
+		RunView(c)
+	})
+
+	tests := []struct {
+		name         string
+		viewID       uint
+		params       string
+		expectedCode int
+		wantTitles   []string
+		wantCount    int64
+		wantGroups   []string
+	}{
+		{
+			name:         "Today",
+			viewID:       viewID("Today"),
+			expectedCode: http.StatusOK,
+			wantTitles:   []string{"Overdue", "Due today"},
+			wantCount:    2,
+		},
+		{
+			name:         "Upcoming is grouped by due date",
+			viewID:       viewID("Upcoming"),
+			expectedCode: http.StatusOK,
+			wantTitles:   []string{"Due tomorrow", "Due next week"},
+			wantCount:    2,
+			wantGroups:   []string{"tomorrow", "next_7_days"},
+		},
+		{
+			name:         "Overdue",
+			viewID:       viewID("Overdue"),
+			expectedCode: http.StatusOK,
+			wantTitles:   []string{"Overdue"},
+			wantCount:    1,
+		},
+		{
+			name:         "Recently completed",
+			viewID:       viewID("Recently completed"),
+			expectedCode: http.StatusOK,
+			wantTitles:   []string{"Done"},
+			wantCount:    1,
+		},
+		{
+			name:         "Grouped by priority, paginated",
+			viewID:       byPriority.ID,
+			params:       "?page=1&page_size=3",
+			expectedCode: http.StatusOK,
+			wantTitles:   []string{"Overdue", "Due today", "Due tomorrow"},
+			wantCount:    5,
+			wantGroups:   []string{"high", "none"},
+		},
+		{
+			name:         "Invalid page size",
+			viewID:       byPriority.ID,
+			params:       "?page_size=0",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "Another user's view",
+			viewID:       other.ID,
+			expectedCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", fmt.Sprintf("/views/%d/todos%s", tt.viewID, tt.params), nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+			if w.Code != http.StatusOK {
+				return
+			}
+			var response ViewResult
+			assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+			var titles []string
+			for _, todo := range response.Todos {
+				titles = append(titles, todo.Title)
+			}
+			assert.Equal(t, tt.wantTitles, titles)
+			assert.Equal(t, tt.wantCount, response.Count)
+			var groups []string
+			for _, group := range response.Groups {
+				groups = append(groups, group.Key)
+			}
+			assert.Equal(t, tt.wantGroups, groups)
+		})
+	}
+}
@@ -0,0 +1,640 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"todo-api/internal/database"
+	"todo-api/internal/models"
+)
+
+var (
+	errNoWorkflow    = errors.New("todo is not in a project with a workflow")
+	errUnknownStatus = errors.New("transition refers to a status that is not listed")
+	errTransition    = errors.New("cannot move a todo")
+)
+
+type WorkflowRequest struct {
+	Name        string              `json:"name" binding:"required" example:"Kanban"`
+	ProjectID   *uint               `json:"project_id" example:"1"`
+	Statuses    []StatusRequest     `json:"statuses" binding:"required,min=2,dive"`
+	Transitions []TransitionRequest `json:"transitions" binding:"dive"`
+}
+
+type StatusRequest struct {
+	Name     string `json:"name" binding:"required" example:"In review"`
+	Category string `json:"category" binding:"required,oneof=todo doing done" example:"doing"`
+}
+
+type TransitionRequest struct {
+	From string `json:"from" binding:"required" example:"In progress"`
+	To   string `json:"to" binding:"required" example:"In review"`
+}
+
+type StatusChangeRequest struct {
+	StatusID uint `json:"status_id" binding:"required" example:"2"`
+}
+
+// BoardColumn holds the todos in one status of a workflow
+type BoardColumn struct {
+	Status models.WorkflowStatus `json:"status"`
+	Todos  []models.Todo         `json:"todos"`
+}
+
+// Board is a workflow's todos grouped by status, in workflow order
+type Board struct {
+	Workflow models.Workflow `json:"workflow"`
+	Columns  []BoardColumn   `json:"columns"`
+}
+
+// @Summary Create a workflow
+// @Description Create a workflow for a project, or the user's default workflow when no project is given. Statuses are ordered as listed; leaving out transitions allows every move.
+// @Tags workflows
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body WorkflowRequest true "Workflow"
+// @Success 201 {object} models.Workflow
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/workflows [post]
+func CreateWorkflow(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	var req WorkflowRequest
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+	if err := validateStatuses(req.Statuses); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	db := database.GetDB()
+	if req.ProjectID != nil {
+		var project models.Project
+		if err := db.Where("id = ? AND user_id = ?", *req.ProjectID, userID).First(&project).Error; err != nil {
+			c.JSON(http.StatusNotFound, ErrorResponse{Error: "Project not found"})
+			return
+		}
+	}
+
+	existing := db.Model(&models.Workflow{}).Where("user_id = ?", userID)
+	if req.ProjectID != nil {
+		existing = existing.Where("project_id = ?", *req.ProjectID)
+	} else {
+		existing = existing.Where("project_id IS NULL")
+	}
+	var count int64
+	if err := existing.Count(&count).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+	if count > 0 {
+		c.JSON(http.StatusConflict, ErrorResponse{Error: "A workflow already exists for this project"})
+		return
+	}
+
+	workflow := models.Workflow{Name: req.Name, UserID: userID.(uint), ProjectID: req.ProjectID}
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&workflow).Error; err != nil {
+			return err
+		}
+		return saveStatuses(tx, &workflow, req.Statuses, req.Transitions)
+	})
+	if err != nil {
+		if errors.Is(err, errUnknownStatus) {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, workflow)
+}
+
+// @Summary Get all workflows
+// @Description Get the authenticated user's workflows
+// @Tags workflows
+// @Produce json
+// @Security Bearer
+// @Success 200 {array} models.Workflow
+// @Failure 401 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/workflows [get]
+func GetWorkflows(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	var workflows []models.Workflow
+
+	result := preloadWorkflow(database.GetDB()).Where("user_id = ?", userID).Order("id").Find(&workflows)
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: result.Error.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, workflows)
+}
+
+// @Summary Get a workflow
+// @Description Get a specific workflow with its statuses and transitions
+// @Tags workflows
+// @Produce json
+// @Security Bearer
+// @Param id path int true "Workflow ID"
+// @Success 200 {object} models.Workflow
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/workflows/{id} [get]
+func GetWorkflow(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	id := c.Param("id")
+	var workflow models.Workflow
+
+	if err := preloadWorkflow(database.GetDB()).Where("id = ? AND user_id = ?", id, userID).First(&workflow).Error; err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Workflow not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, workflow)
+}
+
+// @Summary Update a workflow
+// @Description Rename a workflow and replace its statuses and transitions. Statuses are matched by name, so todos keep statuses that are still listed; todos in removed statuses fall back to the first status of their completion state. Todos in a status that moves into or out of the done category are completed or reopened with it.
+// @Tags workflows
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "Workflow ID"
+// @Param request body WorkflowRequest true "Workflow"
+// @Success 200 {object} models.Workflow
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/workflows/{id} [put]
+func UpdateWorkflow(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	id := c.Param("id")
+	var workflow models.Workflow
+
+	if err := preloadWorkflow(database.GetDB()).Where("id = ? AND user_id = ?", id, userID).First(&workflow).Error; err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Workflow not found"})
+		return
+	}
+
+	var req WorkflowRequest
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+	if err := validateStatuses(req.Statuses); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	err := database.GetDB().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&workflow).Update("name", req.Name).Error; err != nil {
+			return err
+		}
+		workflow.Name = req.Name
+		return saveStatuses(tx, &workflow, req.Statuses, req.Transitions)
+	})
+	if err != nil {
+		if errors.Is(err, errUnknownStatus) {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, workflow)
+}
+
+// @Summary Delete a workflow
+// @Description Delete a workflow. Its todos keep their completion state.
+// @Tags workflows
+// @Security Bearer
+// @Param id path int true "Workflow ID"
+// @Success 204 "No Content"
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/workflows/{id} [delete]
+func DeleteWorkflow(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	id := c.Param("id")
+	var workflow models.Workflow
+
+	if err := database.GetDB().Where("id = ? AND user_id = ?", id, userID).First(&workflow).Error; err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Workflow not found"})
+		return
+	}
+
+	err := database.GetDB().Transaction(func(tx *gorm.DB) error {
+		statuses := tx.Model(&models.WorkflowStatus{}).Select("id").Where("workflow_id = ?", workflow.ID)
+		if err := tx.Model(&models.Todo{}).Where("status_id IN (?)", statuses).Update("status_id", nil).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("workflow_id = ?", workflow.ID).Delete(&models.WorkflowTransition{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("workflow_id = ?", workflow.ID).Delete(&models.WorkflowStatus{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&workflow).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// @Summary Get a workflow board
+// @Description Get the unarchived todos that follow a workflow, grouped by status in workflow order. Members of a shared project can see the board of its workflow.
+// @Tags workflows
+// @Produce json
+// @Security Bearer
+// @Param id path int true "Workflow ID"
+// @Success 200 {object} Board
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/workflows/{id}/board [get]
+func GetBoard(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	id := c.Param("id")
+	db := database.GetDB()
+	var workflow models.Workflow
+
+	// Members of a shared project see its board too
+	err := preloadWorkflow(db).Where("id = ? AND (user_id = ? OR project_id IN (?))", id, userID, sharedProjectIDs(db, userID.(uint))).
+		First(&workflow).Error
+	if err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Workflow not found"})
+		return
+	}
+
+	query := db.Where("archived = ?", false)
+	if workflow.ProjectID != nil {
+		query = query.Where("project_id = ?", *workflow.ProjectID)
+	} else {
+		// The default workflow covers the user's todos in every project
+		// without its own
+		own := db.Model(&models.Workflow{}).Select("project_id").Where("project_id IS NOT NULL")
+		query = query.Where("user_id = ?", userID).Where("project_id IS NULL OR project_id NOT IN (?)", own)
+	}
+
+	var todos []models.Todo
+	if err := query.Preload("Tags").Order("id").Find(&todos).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	board := Board{Workflow: workflow, Columns: make([]BoardColumn, len(workflow.Statuses))}
+	column := make(map[uint]int)
+	for i, status := range workflow.Statuses {
+		board.Columns[i] = BoardColumn{Status: status, Todos: []models.Todo{}}
+		column[status.ID] = i
+	}
+	for _, todo := range todos {
+		status := effectiveStatus(&workflow, &todo)
+		i := column[status.ID]
+		board.Columns[i].Todos = append(board.Columns[i].Todos, todo)
+	}
+
+	c.JSON(http.StatusOK, board)
+}
+
+// @Summary Change a todo's status
+// @Description Move a todo to another status of its workflow. The move must be an allowed transition; moving into a done status completes the todo, and moving out of one reopens it.
+// @Tags workflows
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "Todo ID"
+// @Param force query bool false "Complete the todo even if it is blocked by open todos"
+// @Param request body StatusChangeRequest true "Target status"
+// @Success 200 {object} models.Todo
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/todos/{id}/status [post]
+func ChangeStatus(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	id := c.Param("id")
+	db := database.GetDB()
+	var todo models.Todo
+
+	if err := accessibleTodos(db, userID.(uint)).Where("todos.id = ?", id).First(&todo).Error; err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Todo not found"})
+		return
+	}
+
+	var req StatusChangeRequest
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	workflow, err := workflowFor(db, todo.UserID, todo.ProjectID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+	if workflow == nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: errNoWorkflow.Error()})
+		return
+	}
+
+	target := workflow.Status(req.StatusID)
+	if target == nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Status is not part of the todo's workflow"})
+		return
+	}
+	current := effectiveStatus(workflow, &todo)
+	if !workflow.Allows(current.ID, target.ID) {
+		c.JSON(http.StatusConflict, ErrorResponse{Error: transitionError(current, target).Error()})
+		return
+	}
+
+	completed := target.Category == models.CategoryDone
+	if completed && !todo.Completed && c.Query("force") != "true" {
+		blockers, err := openBlockers(db, todo.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+			return
+		}
+		if len(blockers) > 0 {
+			c.JSON(http.StatusConflict, ErrorResponse{Error: blockedError(blockers)})
+			return
+		}
+	}
+
+	before := snapshotTodo(&todo)
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&todo).Update("status_id", target.ID).Error; err != nil {
+			return err
+		}
+		todo.StatusID = &target.ID
+		if err := setCompletion(tx, &todo, completed); err != nil {
+			return err
+		}
+		if err := recordRevision(tx, before, todo.ID, userID.(uint)); err != nil {
+			return err
+		}
+		return runRules(tx, models.TriggerUpdated, &todo)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	db.Preload("Tags").First(&todo, todo.ID)
+	c.JSON(http.StatusOK, todo)
+}
+
+// validateStatuses checks that status names are unique and that completed
+// and open todos both have a status to map to.
+func validateStatuses(statuses []StatusRequest) error {
+	seen := make(map[string]bool)
+	categories := make(map[string]bool)
+	for _, status := range statuses {
+		name := strings.ToLower(status.Name)
+		if seen[name] {
+			return fmt.Errorf("status %q is listed twice", status.Name)
+		}
+		seen[name] = true
+		categories[status.Category] = true
+	}
+
+	if !categories[models.CategoryTodo] || !categories[models.CategoryDone] {
+		return errors.New("a workflow needs at least one 'todo' and one 'done' status")
+	}
+	return nil
+}
+
+// saveStatuses brings a workflow's statuses and transitions in line with a
+// request, keeping the IDs of statuses whose names are unchanged.
+func saveStatuses(tx *gorm.DB, workflow *models.Workflow, statuses []StatusRequest, transitions []TransitionRequest) error {
+	existing := make(map[string]models.WorkflowStatus)
+	for _, status := range workflow.Statuses {
+		existing[strings.ToLower(status.Name)] = status
+	}
+
+	// Todos in a status that is removed or moves into or out of done no
+	// longer agree with their status once it is saved
+	var changed []uint
+	for _, status := range workflow.Statuses {
+		req := findStatusRequest(statuses, status.Name)
+		if req == nil || (req.Category == models.CategoryDone) != (status.Category == models.CategoryDone) {
+			changed = append(changed, status.ID)
+		}
+	}
+	var affected []uint
+	if len(changed) > 0 {
+		if err := tx.Model(&models.Todo{}).Where("status_id IN ?", changed).Order("id").Pluck("id", &affected).Error; err != nil {
+			return err
+		}
+	}
+
+	byName := make(map[string]uint)
+	kept := make([]models.WorkflowStatus, len(statuses))
+	for i, req := range statuses {
+		name := strings.ToLower(req.Name)
+		status, ok := existing[name]
+		if !ok {
+			status = models.WorkflowStatus{WorkflowID: workflow.ID}
+		}
+		delete(existing, name)
+
+		status.Name = req.Name
+		status.Category = req.Category
+		status.Position = i
+		if err := tx.Save(&status).Error; err != nil {
+			return err
+		}
+		kept[i] = status
+		byName[name] = status.ID
+	}
+
+	for _, removed := range existing {
+		if err := tx.Model(&models.Todo{}).Where("status_id = ?", removed.ID).Update("status_id", nil).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&removed).Error; err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Where("workflow_id = ?", workflow.ID).Delete(&models.WorkflowTransition{}).Error; err != nil {
+		return err
+	}
+	allowed := make([]models.WorkflowTransition, 0, len(transitions))
+	for _, req := range transitions {
+		from, okFrom := byName[strings.ToLower(req.From)]
+		to, okTo := byName[strings.ToLower(req.To)]
+		if !okFrom || !okTo {
+			return fmt.Errorf("%w: %s -> %s", errUnknownStatus, req.From, req.To)
+		}
+		allowed = append(allowed, models.WorkflowTransition{WorkflowID: workflow.ID, FromStatusID: from, ToStatusID: to})
+	}
+	if len(allowed) > 0 {
+		if err := tx.Create(&allowed).Error; err != nil {
+			return err
+		}
+	}
+
+	workflow.Statuses = kept
+	workflow.Transitions = allowed
+	return reconcileStatuses(tx, workflow, affected)
+}
+
+func findStatusRequest(statuses []StatusRequest, name string) *StatusRequest {
+	for i := range statuses {
+		if strings.EqualFold(statuses[i].Name, name) {
+			return &statuses[i]
+		}
+	}
+	return nil
+}
+
+// reconcileStatuses brings todos whose status changed category or was
+// removed back in line with their workflow. Todos in a status that is now
+// done are completed and those in one that no longer is are reopened, with
+// the usual side effects; todos in a removed status move to the first
+// status matching their completion.
+func reconcileStatuses(tx *gorm.DB, workflow *models.Workflow, todoIDs []uint) error {
+	for _, id := range todoIDs {
+		var todo models.Todo
+		if err := tx.First(&todo, id).Error; err != nil {
+			return err
+		}
+
+		var status *models.WorkflowStatus
+		if todo.StatusID != nil {
+			status = workflow.Status(*todo.StatusID)
+		}
+		if status == nil {
+			if err := syncStatus(tx, &todo); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := setCompletion(tx, &todo, status.Category == models.CategoryDone); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkCompletion reports errTransition when completing or reopening a todo
+// would move it between statuses its workflow does not allow. Changes the
+// API makes on its own behalf, such as rolling up subtasks, reverting to a
+// revision or editing a workflow, are not checked.
+func checkCompletion(db *gorm.DB, todo *models.Todo, completed bool) error {
+	if todo.Completed == completed {
+		return nil
+	}
+	workflow, err := workflowFor(db, todo.UserID, todo.ProjectID)
+	if err != nil || workflow == nil {
+		return err
+	}
+
+	current := effectiveStatus(workflow, todo)
+	category := models.CategoryTodo
+	if completed {
+		category = models.CategoryDone
+	}
+	target := workflow.FirstIn(category)
+	if current == nil || target == nil || workflow.Allows(current.ID, target.ID) {
+		return nil
+	}
+	return transitionError(current, target)
+}
+
+func transitionError(from *models.WorkflowStatus, to *models.WorkflowStatus) error {
+	return fmt.Errorf("%w from %q to %q", errTransition, from.Name, to.Name)
+}
+
+func preloadWorkflow(db *gorm.DB) *gorm.DB {
+	return db.Preload("Statuses", func(db *gorm.DB) *gorm.DB {
+		return db.Order("position")
+	}).Preload("Transitions")
+}
+
+// workflowFor returns the workflow a todo in the given project follows: the
+// project's own workflow, which covers the todos of all its members, else
+// the user's default one. It returns nil when there is neither.
+func workflowFor(db *gorm.DB, userID uint, projectID *uint) (*models.Workflow, error) {
+	query := preloadWorkflow(db)
+	if projectID != nil {
+		query = query.Where("project_id = ? OR (project_id IS NULL AND user_id = ?)", *projectID, userID)
+	} else {
+		query = query.Where("project_id IS NULL AND user_id = ?", userID)
+	}
+
+	var workflows []models.Workflow
+	if err := query.Find(&workflows).Error; err != nil {
+		return nil, err
+	}
+
+	var found *models.Workflow
+	for i := range workflows {
+		if found == nil || workflows[i].ProjectID != nil {
+			found = &workflows[i]
+		}
+	}
+	return found, nil
+}
+
+// effectiveStatus returns the status a todo is in. Todos without a status,
+// or whose status disagrees with their completion, are treated as being in
+// the first status matching their completion.
+func effectiveStatus(workflow *models.Workflow, todo *models.Todo) *models.WorkflowStatus {
+	if todo.StatusID != nil {
+		if status := workflow.Status(*todo.StatusID); status != nil && (status.Category == models.CategoryDone) == todo.Completed {
+			return status
+		}
+	}
+	if todo.Completed {
+		return workflow.FirstIn(models.CategoryDone)
+	}
+	return workflow.FirstIn(models.CategoryTodo)
+}
+
+// syncStatus stores the effective status of a todo whose completion or
+// project changed, so that status and completed never disagree.
+func syncStatus(tx *gorm.DB, todo *models.Todo) error {
+	workflow, err := workflowFor(tx, todo.UserID, todo.ProjectID)
+	if err != nil {
+		return err
+	}
+
+	var statusID *uint
+	if workflow != nil {
+		statusID = &effectiveStatus(workflow, todo).ID
+	}
+	if statusID == nil && todo.StatusID == nil || statusID != nil && todo.StatusID != nil && *statusID == *todo.StatusID {
+		return nil
+	}
+
+	if err := tx.Model(todo).Update("status_id", statusID).Error; err != nil {
+		return err
+	}
+	todo.StatusID = statusID
+	return nil
+}
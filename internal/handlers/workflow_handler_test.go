@@ -0,0 +1,312 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"todo-api/internal/models"
+	"todo-api/internal/test"
+)
+
+var kanbanStatuses = []map[string]interface{}{
+	{"name": "Backlog", "category": "todo"},
+	{"name": "In progress", "category": "doing"},
+	{"name": "In review", "category": "doing"},
+	{"name": "Done", "category": "done"},
+}
+
+var kanbanTransitions = []map[string]interface{}{
+	{"from": "Backlog", "to": "In progress"},
+	{"from": "In progress", "to": "In review"},
+	{"from": "In review", "to": "In progress"},
+	{"from": "In review", "to": "Done"},
+	{"from": "Done", "to": "Backlog"},
+}
+
+func TestCreateWorkflow(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db, _ := test.SetupTestDB()
+	testUser, err := test.CreateTestUser(db)
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name         string
+		payload      map[string]interface{}
+		existing     bool
+		expectedCode int
+	}{
+		{
+			name:         "Default workflow",
+			payload:      map[string]interface{}{"name": "Kanban", "statuses": kanbanStatuses, "transitions": kanbanTransitions},
+			expectedCode: http.StatusCreated,
+		},
+		{
+			name:         "Second default workflow",
+			payload:      map[string]interface{}{"name": "Kanban", "statuses": kanbanStatuses},
+			existing:     true,
+			expectedCode: http.StatusConflict,
+		},
+		{
+			name: "No done status",
+			payload: map[string]interface{}{"name": "Endless", "statuses": []map[string]interface{}{
+				{"name": "Backlog", "category": "todo"},
+				{"name": "Doing", "category": "doing"},
+			}},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name: "Duplicate status names",
+			payload: map[string]interface{}{"name": "Twice", "statuses": []map[string]interface{}{
+				{"name": "Open", "category": "todo"},
+				{"name": "open", "category": "doing"},
+				{"name": "Done", "category": "done"},
+			}},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "Unknown category",
+			payload:      map[string]interface{}{"name": "Odd", "statuses": []map[string]interface{}{{"name": "Open", "category": "todo"}, {"name": "Gone", "category": "lost"}}},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name: "Transition to an unlisted status",
+			payload: map[string]interface{}{"name": "Kanban", "statuses": kanbanStatuses, "transitions": []map[string]interface{}{
+				{"from": "Backlog", "to": "Blocked"},
+			}},
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+
+			test.ClearTestData(db)
+			if tt.existing {
+				db.Create(&models.Workflow{Name: "Existing", UserID: testUser.ID})
+			}
+
+			router.POST("/workflows", func(c *gin.Context) {
+				c.Set("userID", testUser.ID)
+				CreateWorkflow(c)
+			})
+
+			jsonBody, _ := json.Marshal(tt.payload)
+			req, _ := http.NewRequest("POST", "/workflows", bytes.NewBuffer(jsonBody))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+			if tt.expectedCode == http.StatusCreated {
+				var response models.Workflow
+				err := json.Unmarshal(w.Body.Bytes(), &response)
+				assert.NoError(t, err)
+				assert.Len(t, response.Statuses, 4)
+				assert.Len(t, response.Transitions, 5)
+				assert.Equal(t, "In review", response.Statuses[2].Name)
+				assert.Equal(t, 2, response.Statuses[2].Position)
+			}
+		})
+	}
+}
+
+func TestChangeStatus(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db, _ := test.SetupTestDB()
+	testUser, err := test.CreateTestUser(db)
+	assert.NoError(t, err)
+	test.ClearTestData(db)
+
+	router := gin.New()
+	router.POST("/workflows", func(c *gin.Context) {
+		c.Set("userID", testUser.ID)
+		CreateWorkflow(c)
+	})
+	router.POST("/todos", func(c *gin.Context) {
+		c.Set("userID", testUser.ID)
+		CreateTodo(c)
+	})
+	router.PUT("/todos/:id", func(c *gin.Context) {
+		c.Set("userID", testUser.ID)
+		UpdateTodo(c)
+	})
+	router.POST("/todos/:id/status", func(c *gin.Context) {
+		c.Set("userID", testUser.ID)
+		ChangeStatus(c)
+	})
+	router.GET("/workflows/:id/board", func(c *gin.Context) {
+		c.Set("userID", testUser.ID)
+		GetBoard(c)
+	})
+
+	send := func(method string, url string, payload interface{}) (int, []byte) {
+		jsonBody, _ := json.Marshal(payload)
+		req, _ := http.NewRequest(method, url, bytes.NewBuffer(jsonBody))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code, w.Body.Bytes()
+	}
+
+	code, body := send("POST", "/workflows", map[string]interface{}{"name": "Kanban", "statuses": kanbanStatuses, "transitions": kanbanTransitions})
+	assert.Equal(t, http.StatusCreated, code)
+	var workflow models.Workflow
+	assert.NoError(t, json.Unmarshal(body, &workflow))
+	status := make(map[string]uint)
+	for _, s := range workflow.Statuses {
+		status[s.Name] = s.ID
+	}
+
+	// New todos start in the first open status
+	code, body = send("POST", "/todos", map[string]interface{}{"title": "Write release notes"})
+	assert.Equal(t, http.StatusCreated, code)
+	var todo models.Todo
+	assert.NoError(t, json.Unmarshal(body, &todo))
+	assert.Equal(t, status["Backlog"], *todo.StatusID)
+
+	statusURL := fmt.Sprintf("/todos/%d/status", todo.ID)
+	tests := []struct {
+		name          string
+		to            string
+		expectedCode  int
+		wantCompleted bool
+	}{
+		{name: "Skipping ahead is not allowed", to: "Done", expectedCode: http.StatusConflict},
+		{name: "Start work", to: "In progress", expectedCode: http.StatusOK},
+		{name: "Ask for review", to: "In review", expectedCode: http.StatusOK},
+		{name: "Finish", to: "Done", expectedCode: http.StatusOK, wantCompleted: true},
+		{name: "Reopen", to: "Backlog", expectedCode: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, body := send("POST", statusURL, map[string]interface{}{"status_id": status[tt.to]})
+			assert.Equal(t, tt.expectedCode, code)
+			if tt.expectedCode == http.StatusOK {
+				var response models.Todo
+				assert.NoError(t, json.Unmarshal(body, &response))
+				assert.Equal(t, status[tt.to], *response.StatusID)
+				assert.Equal(t, tt.wantCompleted, response.Completed)
+			}
+		})
+	}
+
+	// Completing through the completed flag follows the same transitions
+	code, _ = send("PUT", fmt.Sprintf("/todos/%d", todo.ID), map[string]interface{}{"title": todo.Title, "completed": true})
+	assert.Equal(t, http.StatusConflict, code)
+	code, _ = send("POST", statusURL, map[string]interface{}{"status_id": status["In progress"]})
+	assert.Equal(t, http.StatusOK, code)
+	code, _ = send("POST", statusURL, map[string]interface{}{"status_id": status["In review"]})
+	assert.Equal(t, http.StatusOK, code)
+
+	// and moves the todo to a done status
+	code, body = send("PUT", fmt.Sprintf("/todos/%d", todo.ID), map[string]interface{}{"title": todo.Title, "completed": true})
+	assert.Equal(t, http.StatusOK, code)
+	assert.NoError(t, json.Unmarshal(body, &todo))
+	var stored models.Todo
+	db.First(&stored, todo.ID)
+	assert.Equal(t, status["Done"], *stored.StatusID)
+
+	code, _ = send("POST", "/todos", map[string]interface{}{"title": "Tag the release"})
+	assert.Equal(t, http.StatusCreated, code)
+
+	code, body = send("GET", fmt.Sprintf("/workflows/%d/board", workflow.ID), nil)
+	assert.Equal(t, http.StatusOK, code)
+	var board Board
+	assert.NoError(t, json.Unmarshal(body, &board))
+	assert.Len(t, board.Columns, 4)
+	assert.Len(t, board.Columns[0].Todos, 1)
+	assert.Equal(t, "Tag the release", board.Columns[0].Todos[0].Title)
+	assert.Len(t, board.Columns[3].Todos, 1)
+	assert.Equal(t, "Write release notes", board.Columns[3].Todos[0].Title)
+}
+
+func TestUpdateWorkflowKeepsTodosInLine(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db, _ := test.SetupTestDB()
+	test.ClearTestData(db)
+	testUser, err := test.CreateTestUser(db)
+	assert.NoError(t, err)
+
+	router := gin.New()
+	router.POST("/workflows", func(c *gin.Context) {
+		c.Set("userID", testUser.ID)
+		CreateWorkflow(c)
+	})
+	router.PUT("/workflows/:id", func(c *gin.Context) {
+		c.Set("userID", testUser.ID)
+		UpdateWorkflow(c)
+	})
+	send := func(method string, url string, payload interface{}) (int, []byte) {
+		jsonBody, _ := json.Marshal(payload)
+		req, _ := http.NewRequest(method, url, bytes.NewBuffer(jsonBody))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code, w.Body.Bytes()
+	}
+
+	code, body := send("POST", "/workflows", map[string]interface{}{"name": "Simple", "statuses": []map[string]interface{}{
+		{"name": "To do", "category": "todo"},
+		{"name": "Waiting", "category": "doing"},
+		{"name": "Done", "category": "done"},
+	}})
+	assert.Equal(t, http.StatusCreated, code)
+	var workflow models.Workflow
+	assert.NoError(t, json.Unmarshal(body, &workflow))
+	status := make(map[string]uint)
+	for _, s := range workflow.Statuses {
+		status[s.Name] = s.ID
+	}
+
+	waitingID, doneID := status["Waiting"], status["Done"]
+	waiting := &models.Todo{Title: "Hear back from legal", UserID: testUser.ID, StatusID: &waitingID}
+	db.Create(waiting)
+	done := &models.Todo{Title: "Send contract", UserID: testUser.ID, StatusID: &doneID, Completed: true}
+	db.Create(done)
+
+	load := func(id uint) models.Todo {
+		var todo models.Todo
+		db.First(&todo, id)
+		return todo
+	}
+
+	// Waiting now counts as done, and Done is dropped
+	code, _ = send("PUT", fmt.Sprintf("/workflows/%d", workflow.ID), map[string]interface{}{"name": "Simple", "statuses": []map[string]interface{}{
+		{"name": "To do", "category": "todo"},
+		{"name": "Waiting", "category": "done"},
+	}})
+	assert.Equal(t, http.StatusOK, code)
+	reloaded := load(waiting.ID)
+	assert.True(t, reloaded.Completed)
+	assert.NotNil(t, reloaded.CompletedAt)
+	assert.Equal(t, status["Waiting"], *reloaded.StatusID)
+	reloaded = load(done.ID)
+	assert.True(t, reloaded.Completed)
+	if assert.NotNil(t, reloaded.StatusID) {
+		assert.Equal(t, status["Waiting"], *reloaded.StatusID)
+	}
+
+	// Moving it back out of done reopens its todos
+	code, _ = send("PUT", fmt.Sprintf("/workflows/%d", workflow.ID), map[string]interface{}{"name": "Simple", "statuses": []map[string]interface{}{
+		{"name": "To do", "category": "todo"},
+		{"name": "Waiting", "category": "doing"},
+		{"name": "Done", "category": "done"},
+	}})
+	assert.Equal(t, http.StatusOK, code)
+	for _, id := range []uint{waiting.ID, done.ID} {
+		reloaded := load(id)
+		assert.False(t, reloaded.Completed)
+		assert.Nil(t, reloaded.CompletedAt)
+		assert.Equal(t, status["Waiting"], *reloaded.StatusID)
+	}
+}
@@ -0,0 +1,316 @@
+package markdown
+
+import (
+	"bytes"
+	"html"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+var (
+	autolinkPattern = regexp.MustCompile(`^<((?:https?|mailto):[^\s<>]+|[^\s<>@]+@[^\s<>@]+\.[^\s<>@]+)>`)
+	bareURLPattern  = regexp.MustCompile(`^https?://[^\s<]+`)
+)
+
+// renderInline converts the inline Markdown of a paragraph or heading to
+// HTML, escaping all text.
+func renderInline(s string) string {
+	var out bytes.Buffer
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == '\\' && i+1 < len(s) && s[i+1] == '\n':
+			out.WriteString("<br>\n")
+			i += 2
+		case c == '\\' && i+1 < len(s) && isPunct(s[i+1]):
+			out.WriteString(html.EscapeString(s[i+1 : i+2]))
+			i += 2
+		case c == '\n':
+			// Two trailing spaces make a hard line break
+			if bytes.HasSuffix(out.Bytes(), []byte("  ")) {
+				out.Truncate(len(bytes.TrimRight(out.Bytes(), " ")))
+				out.WriteString("<br>")
+			} else {
+				out.Truncate(len(bytes.TrimRight(out.Bytes(), " ")))
+			}
+			out.WriteString("\n")
+			i++
+		case c == '`':
+			i = codeSpan(&out, s, i)
+		case c == '!' && i+1 < len(s) && s[i+1] == '[':
+			if n := link(&out, s, i+1, true); n > 0 {
+				i = n
+			} else {
+				out.WriteString("!")
+				i++
+			}
+		case c == '[':
+			if n := link(&out, s, i, false); n > 0 {
+				i = n
+			} else {
+				out.WriteString("[")
+				i++
+			}
+		case c == '<':
+			if match := autolinkPattern.FindStringSubmatch(s[i:]); match != nil {
+				target := match[1]
+				if !strings.Contains(target, ":") {
+					target = "mailto:" + target
+				}
+				writeLink(&out, target, "", html.EscapeString(match[1]))
+				i += len(match[0])
+			} else {
+				out.WriteString("&lt;")
+				i++
+			}
+		case c == 'h' && (i == 0 || !isAlphanumeric(s[i-1])) && bareURLPattern.MatchString(s[i:]):
+			target := trimURL(bareURLPattern.FindString(s[i:]))
+			writeLink(&out, target, "", html.EscapeString(target))
+			i += len(target)
+		case c == '*' || c == '_' || c == '~':
+			i = emphasis(&out, s, i)
+		default:
+			j := i + 1
+			for j < len(s) && !isSpecial(s[j]) {
+				j++
+			}
+			out.WriteString(html.EscapeString(s[i:j]))
+			i = j
+		}
+	}
+	return out.String()
+}
+
+// codeSpan writes the code span opening at s[i], or the backticks as text if
+// it is never closed.
+func codeSpan(out *bytes.Buffer, s string, i int) int {
+	n := runLength(s, i)
+	fence := s[i : i+n]
+	for j := i + n; j < len(s); {
+		k := strings.Index(s[j:], fence)
+		if k < 0 {
+			break
+		}
+		k += j
+		if runLength(s, k) != n {
+			j = k + runLength(s, k)
+			continue
+		}
+		code := strings.ReplaceAll(s[i+n:k], "\n", " ")
+		if len(code) > 2 && code[0] == ' ' && code[len(code)-1] == ' ' && strings.Trim(code, " ") != "" {
+			code = code[1 : len(code)-1]
+		}
+		out.WriteString("<code>" + html.EscapeString(code) + "</code>")
+		return k + n
+	}
+	out.WriteString(fence)
+	return i + n
+}
+
+// emphasis writes the *em*, **strong** or ~~strikethrough~~ span opening at
+// s[i], or the delimiters as text if there is no matching closer.
+func emphasis(out *bytes.Buffer, s string, i int) int {
+	c := s[i]
+	n := runLength(s, i)
+	opensWord := i+n < len(s) && !isSpace(s[i+n])
+	// Underscores inside words, as in snake_case, are not emphasis
+	if c == '_' && i > 0 && isAlphanumeric(s[i-1]) {
+		opensWord = false
+	}
+	if c == '~' && n != 2 {
+		opensWord = false
+	}
+
+	if opensWord {
+		for _, size := range []int{2, 1} {
+			if size > n || (c == '~' && size != 2) {
+				continue
+			}
+			delim := s[i : i+size]
+			if end := closer(s, i+size, delim); end > 0 {
+				tag := "em"
+				switch {
+				case c == '~':
+					tag = "del"
+				case size == 2:
+					tag = "strong"
+				}
+				out.WriteString("<" + tag + ">" + renderInline(s[i+size:end]) + "</" + tag + ">")
+				return end + size
+			}
+		}
+	}
+
+	out.WriteString(s[i : i+n])
+	return i + n
+}
+
+// closer finds the delimiter that closes a span starting at from. It must
+// follow something other than whitespace and, for underscores, end a word.
+// A run of three closes a span of either size, as in ***both***.
+func closer(s string, from int, delim string) int {
+	for j := from + 1; j < len(s); j++ {
+		switch s[j] {
+		case '\\':
+			j++
+			continue
+		case '`':
+			// Delimiters inside code spans do not count
+			n := runLength(s, j)
+			if k := strings.Index(s[j+n:], s[j:j+n]); k >= 0 {
+				j += n + k + n - 1
+			}
+			continue
+		}
+		if s[j] != delim[0] {
+			continue
+		}
+
+		run := runLength(s, j)
+		endsWord := delim[0] != '_' || j+run == len(s) || !isAlphanumeric(s[j+run])
+		if (run == len(delim) || run >= 3) && !isSpace(s[j-1]) && endsWord {
+			return j + run - len(delim)
+		}
+		j += run - 1
+	}
+	return -1
+}
+
+// link writes the link or image whose text opens with the bracket at s[i]
+// and returns the index after it, or 0 if there is no link there.
+func link(out *bytes.Buffer, s string, i int, image bool) int {
+	depth := 0
+	end := -1
+	for j := i; j < len(s) && end < 0; j++ {
+		switch s[j] {
+		case '\\':
+			j++
+		case '[':
+			depth++
+		case ']':
+			depth--
+			if depth == 0 {
+				end = j
+			}
+		}
+	}
+	if end < 0 || end+1 >= len(s) || s[end+1] != '(' {
+		return 0
+	}
+
+	// The destination may contain balanced parentheses
+	paren, depth := -1, 1
+	for j := end + 2; j < len(s) && paren < 0; j++ {
+		switch s[j] {
+		case '\\':
+			j++
+		case '(':
+			depth++
+		case ')':
+			if depth--; depth == 0 {
+				paren = j
+			}
+		}
+	}
+	if paren < 0 {
+		return 0
+	}
+	dest, title := strings.TrimSpace(s[end+2:paren]), ""
+	if k := strings.IndexAny(dest, " \t\n"); k >= 0 {
+		title = strings.TrimSpace(dest[k:])
+		dest = dest[:k]
+		if len(title) < 2 || title[0] != '"' || title[len(title)-1] != '"' {
+			return 0
+		}
+		title = title[1 : len(title)-1]
+	}
+	dest = strings.TrimSuffix(strings.TrimPrefix(dest, "<"), ">")
+
+	text := s[i+1 : end]
+	if image {
+		if !safeURL(dest, true) {
+			out.WriteString(html.EscapeString(text))
+			return paren + 1
+		}
+		out.WriteString(`<img src="` + html.EscapeString(dest) + `" alt="` + html.EscapeString(text) + `"`)
+		if title != "" {
+			out.WriteString(` title="` + html.EscapeString(title) + `"`)
+		}
+		out.WriteString(">")
+		return paren + 1
+	}
+	writeLink(out, dest, title, renderInline(text))
+	return paren + 1
+}
+
+// writeLink writes an anchor around already rendered content, or just the
+// content if the target is not a safe URL.
+func writeLink(out *bytes.Buffer, target, title, content string) {
+	if !safeURL(target, false) {
+		out.WriteString(content)
+		return
+	}
+	out.WriteString(`<a href="` + html.EscapeString(target) + `"`)
+	if title != "" {
+		out.WriteString(` title="` + html.EscapeString(title) + `"`)
+	}
+	out.WriteString(` rel="nofollow noopener noreferrer">` + content + "</a>")
+}
+
+// safeURL allows http and https URLs, mailto links unless only images are
+// wanted, and URLs relative to the page.
+func safeURL(target string, image bool) bool {
+	u, err := url.Parse(target)
+	if err != nil {
+		return false
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "http", "https":
+		return true
+	case "mailto":
+		return !image
+	case "":
+		// Without a scheme, a colon before any slash would be read as one
+		colon := strings.IndexByte(target, ':')
+		return colon < 0 || strings.IndexAny(target, "/?#") >= 0 && strings.IndexAny(target, "/?#") < colon
+	}
+	return false
+}
+
+// trimURL drops punctuation that ends the sentence around a bare URL,
+// keeping a closing parenthesis that balances one in the URL.
+func trimURL(target string) string {
+	for target != "" && strings.IndexByte(".,:;!?\"')*_~", target[len(target)-1]) >= 0 {
+		if target[len(target)-1] == ')' && strings.Count(target, "(") >= strings.Count(target, ")") {
+			break
+		}
+		target = target[:len(target)-1]
+	}
+	return target
+}
+
+func runLength(s string, i int) int {
+	n := 0
+	for i+n < len(s) && s[i+n] == s[i] {
+		n++
+	}
+	return n
+}
+
+// isSpecial reports whether c may start inline markup.
+func isSpecial(c byte) bool {
+	return strings.IndexByte("\\\n`![<h*_~", c) >= 0
+}
+
+func isPunct(c byte) bool {
+	return strings.IndexByte("!\"#$%&'()*+,-./:;<=>?@[\\]^_`{|}~", c) >= 0
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n'
+}
+
+func isAlphanumeric(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
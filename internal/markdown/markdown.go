@@ -0,0 +1,401 @@
+// Package markdown renders the Markdown used in todo descriptions to HTML
+// and finds the task list checkboxes in it.
+//
+// Only the common subset of CommonMark and GitHub Flavored Markdown is
+// supported: headings, paragraphs, lists and task lists, block quotes,
+// fenced code, horizontal rules, emphasis, strikethrough, code spans, links,
+// images and autolinks. The output is safe to embed as is: all text is
+// escaped, raw HTML is shown as text rather than passed through, and links
+// may only use http, https, mailto or relative URLs.
+package markdown
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Task is a task list item such as "- [x] Book flights".
+type Task struct {
+	Line    int    // Line of the description it is on, counting from zero
+	Column  int    // Byte offset of the mark between the brackets on that line
+	Checked bool   // Whether the box is ticked
+	Text    string // Markdown source of the item's first line
+}
+
+// line is a line of the source with the block markers around it stripped.
+// no and col locate text within the original source.
+type line struct {
+	text string
+	no   int
+	col  int
+}
+
+type renderer struct {
+	out   bytes.Buffer
+	tasks []Task
+}
+
+// Render converts Markdown to sanitized HTML.
+func Render(src string) string {
+	return parse(src).out.String()
+}
+
+// Tasks returns the task list items of a Markdown document in the order
+// they appear.
+func Tasks(src string) []Task {
+	return parse(src).tasks
+}
+
+// SetTask ticks or clears the index-th task list item of a Markdown
+// document, leaving the rest of it untouched.
+func SetTask(src string, index int, checked bool) (string, error) {
+	tasks := Tasks(src)
+	if index < 0 || index >= len(tasks) {
+		return "", fmt.Errorf("task %d not found", index)
+	}
+
+	mark := byte(' ')
+	if checked {
+		mark = 'x'
+	}
+	lines := strings.Split(src, "\n")
+	task := tasks[index]
+	text := []byte(lines[task.Line])
+	text[task.Column] = mark
+	lines[task.Line] = string(text)
+	return strings.Join(lines, "\n"), nil
+}
+
+func parse(src string) *renderer {
+	raw := strings.Split(src, "\n")
+	lines := make([]line, len(raw))
+	for i, text := range raw {
+		lines[i] = line{text: strings.TrimSuffix(text, "\r"), no: i}
+	}
+
+	r := &renderer{}
+	r.blocks(lines, false)
+	return r
+}
+
+var (
+	headingPattern   = regexp.MustCompile(`^ {0,3}(#{1,6})(?:[ \t]+(.*?))?[ \t]*$`)
+	closingHashes    = regexp.MustCompile(`(?:^|[ \t]+)#+$`)
+	rulePattern      = regexp.MustCompile(`^ {0,3}(?:(?:\*[ \t]*){3,}|(?:-[ \t]*){3,}|(?:_[ \t]*){3,})$`)
+	setextPattern    = regexp.MustCompile(`^ {0,3}(=+|-+)[ \t]*$`)
+	quotePattern     = regexp.MustCompile(`^ {0,3}> ?`)
+	listItemPattern  = regexp.MustCompile(`^( {0,3})([-*+]|\d{1,9}[.)])([ \t]+|$)(.*)$`)
+	taskPattern      = regexp.MustCompile(`^\[([ xX])\](?:[ \t]+|$)`)
+	fenceInfoPattern = regexp.MustCompile(`^[A-Za-z0-9_+#.-]+`)
+	blankLinePattern = regexp.MustCompile(`^[ \t]*$`)
+)
+
+// fenceIndentLength is how far a code fence may be indented.
+const fenceIndentLength = 3
+
+// blocks renders a sequence of lines. In a tight list item paragraphs are
+// written without <p> tags.
+func (r *renderer) blocks(lines []line, tight bool) {
+	for i := 0; i < len(lines); {
+		text := lines[i].text
+		switch {
+		case isBlank(text):
+			i++
+		case fenceOf(text) != "":
+			i = r.fencedCode(lines, i)
+		case headingPattern.MatchString(text):
+			match := headingPattern.FindStringSubmatch(text)
+			content := closingHashes.ReplaceAllString(match[2], "")
+			fmt.Fprintf(&r.out, "<h%d>%s</h%d>\n", len(match[1]), renderInline(content), len(match[1]))
+			i++
+		case rulePattern.MatchString(text):
+			r.out.WriteString("<hr>\n")
+			i++
+		case quotePattern.MatchString(text):
+			i = r.blockquote(lines, i)
+		case listItemPattern.MatchString(text):
+			i = r.list(lines, i)
+		default:
+			i = r.paragraph(lines, i, tight)
+		}
+	}
+}
+
+func (r *renderer) paragraph(lines []line, i int, tight bool) int {
+	var texts []string
+	for ; i < len(lines); i++ {
+		text := lines[i].text
+		if len(texts) > 0 {
+			if match := setextPattern.FindStringSubmatch(text); match != nil {
+				level := 1
+				if match[1][0] == '-' {
+					level = 2
+				}
+				fmt.Fprintf(&r.out, "<h%d>%s</h%d>\n", level, renderInline(strings.Join(texts, "\n")), level)
+				return i + 1
+			}
+			if startsBlock(text) {
+				break
+			}
+		}
+		texts = append(texts, strings.TrimLeft(text, " \t"))
+	}
+
+	content := renderInline(strings.TrimRight(strings.Join(texts, "\n"), " \t"))
+	if tight {
+		r.out.WriteString(content + "\n")
+	} else {
+		r.out.WriteString("<p>" + content + "</p>\n")
+	}
+	return i
+}
+
+// startsBlock reports whether a line ends the paragraph before it. Only
+// ordered lists starting at 1 interrupt a paragraph, so that text such as
+// "2026. was a good year" stays part of it.
+func startsBlock(text string) bool {
+	if isBlank(text) || fenceOf(text) != "" || headingPattern.MatchString(text) ||
+		rulePattern.MatchString(text) || quotePattern.MatchString(text) {
+		return true
+	}
+	match := listItemPattern.FindStringSubmatch(text)
+	if match == nil || isBlank(match[4]) {
+		return false
+	}
+	return !isDigit(match[2][0]) || strings.TrimLeft(match[2], "0") == "1."
+}
+
+// fenceOf returns the opening ``` or ~~~ run of a fenced code block, or ""
+// if the line does not open one.
+func fenceOf(text string) string {
+	trimmed := strings.TrimLeft(text, " ")
+	if len(text)-len(trimmed) > fenceIndentLength || len(trimmed) < 3 {
+		return ""
+	}
+	char := trimmed[0]
+	if char != '`' && char != '~' {
+		return ""
+	}
+	n := 0
+	for n < len(trimmed) && trimmed[n] == char {
+		n++
+	}
+	if n < 3 || (char == '`' && strings.Contains(trimmed[n:], "`")) {
+		return ""
+	}
+	return trimmed[:n]
+}
+
+func (r *renderer) fencedCode(lines []line, i int) int {
+	open := lines[i].text
+	fence := fenceOf(open)
+	indent := len(open) - len(strings.TrimLeft(open, " "))
+	info := fenceInfoPattern.FindString(strings.TrimSpace(strings.TrimLeft(open, " ")[len(fence):]))
+
+	var code []string
+	for i++; i < len(lines); i++ {
+		text := lines[i].text
+		trimmed := strings.TrimLeft(text, " ")
+		if len(text)-len(trimmed) <= fenceIndentLength && strings.HasPrefix(trimmed, fence) &&
+			isBlank(strings.TrimLeft(trimmed, fence[:1])) {
+			i++
+			break
+		}
+		for n := 0; n < indent && strings.HasPrefix(text, " "); n++ {
+			text = text[1:]
+		}
+		code = append(code, text)
+	}
+
+	r.out.WriteString("<pre><code")
+	if info != "" {
+		r.out.WriteString(` class="language-` + html.EscapeString(info) + `"`)
+	}
+	r.out.WriteString(">")
+	for _, text := range code {
+		r.out.WriteString(html.EscapeString(text) + "\n")
+	}
+	r.out.WriteString("</code></pre>\n")
+	return i
+}
+
+func (r *renderer) blockquote(lines []line, i int) int {
+	var inner []line
+	for ; i < len(lines); i++ {
+		l := lines[i]
+		prefix := quotePattern.FindString(l.text)
+		if prefix == "" {
+			// Lazy continuation of a paragraph in the quote
+			if len(inner) == 0 || isBlank(inner[len(inner)-1].text) || startsBlock(l.text) {
+				break
+			}
+			inner = append(inner, l)
+			continue
+		}
+		inner = append(inner, line{text: l.text[len(prefix):], no: l.no, col: l.col + len(prefix)})
+	}
+
+	r.out.WriteString("<blockquote>\n")
+	r.blocks(inner, false)
+	r.out.WriteString("</blockquote>\n")
+	return i
+}
+
+type listItem struct {
+	ordered bool
+	delim   byte
+	number  int
+	content line
+	width   int // Indentation that continues the item on later lines
+}
+
+func listItemOf(l line) (listItem, bool) {
+	match := listItemPattern.FindStringSubmatchIndex(l.text)
+	if match == nil || rulePattern.MatchString(l.text) {
+		return listItem{}, false
+	}
+	marker := l.text[match[4]:match[5]]
+	item := listItem{
+		delim:   marker[len(marker)-1],
+		content: line{text: l.text[match[8]:], no: l.no, col: l.col + match[8]},
+		width:   match[7],
+	}
+	if match[6] == match[7] {
+		item.width++
+	}
+	if isDigit(marker[0]) {
+		item.ordered = true
+		item.number, _ = strconv.Atoi(marker[:len(marker)-1])
+	}
+	return item, true
+}
+
+func (r *renderer) list(lines []line, i int) int {
+	first, _ := listItemOf(lines[i])
+	sibling := func(l line) bool {
+		item, ok := listItemOf(l)
+		return ok && item.ordered == first.ordered && item.delim == first.delim
+	}
+
+	var items [][]line
+	loose := false
+	for i < len(lines) && sibling(lines[i]) {
+		item, _ := listItemOf(lines[i])
+		content := []line{item.content}
+		for i++; i < len(lines); i++ {
+			l := lines[i]
+			if isBlank(l.text) {
+				j := i
+				for j < len(lines) && isBlank(lines[j].text) {
+					j++
+				}
+				if j == len(lines) || indentOf(lines[j].text) < item.width {
+					break
+				}
+				loose = true
+				content = append(content, line{no: l.no})
+				continue
+			}
+			if indentOf(l.text) >= item.width {
+				content = append(content, trimIndent(l, item.width))
+				continue
+			}
+			if _, ok := listItemOf(l); ok || startsBlock(l.text) {
+				break
+			}
+			// Lazy continuation of the item's last paragraph
+			content = append(content, l)
+		}
+		items = append(items, content)
+
+		j := i
+		for j < len(lines) && isBlank(lines[j].text) {
+			j++
+		}
+		if j > i && j < len(lines) && sibling(lines[j]) {
+			loose = true
+			i = j
+		}
+	}
+
+	tag := "ul"
+	if first.ordered {
+		tag = "ol"
+	}
+	r.out.WriteString("<" + tag)
+	if first.ordered && first.number != 1 {
+		fmt.Fprintf(&r.out, ` start="%d"`, first.number)
+	}
+	r.out.WriteString(">\n")
+	for _, content := range items {
+		r.out.WriteString("<li")
+		if match := taskPattern.FindStringSubmatchIndex(content[0].text); match != nil {
+			head := content[0]
+			checked := head.text[match[2]] != ' '
+			r.tasks = append(r.tasks, Task{
+				Line:    head.no,
+				Column:  head.col + match[2],
+				Checked: checked,
+				Text:    strings.TrimSpace(head.text[match[1]:]),
+			})
+			fmt.Fprintf(&r.out, ` class="task-list-item"><input type="checkbox" disabled data-task="%d"`, len(r.tasks)-1)
+			if checked {
+				r.out.WriteString(" checked")
+			}
+			r.out.WriteString("> ")
+			content[0] = line{text: head.text[match[1]:], no: head.no, col: head.col + match[1]}
+		} else {
+			r.out.WriteString(">")
+		}
+		r.blocks(content, !loose)
+		r.out.WriteString("</li>\n")
+	}
+	r.out.WriteString("</" + tag + ">\n")
+	return i
+}
+
+// indentOf returns the width of a line's leading whitespace, with tabs
+// stopping at multiples of four.
+func indentOf(text string) int {
+	width := 0
+	for _, c := range text {
+		switch c {
+		case ' ':
+			width++
+		case '\t':
+			width += 4 - width%4
+		default:
+			return width
+		}
+	}
+	return width
+}
+
+// trimIndent removes up to width columns of leading whitespace.
+func trimIndent(l line, width int) line {
+	n, w := 0, 0
+	for n < len(l.text) && w < width {
+		switch l.text[n] {
+		case ' ':
+			w++
+		case '\t':
+			w += 4 - w%4
+		default:
+			return line{text: l.text[n:], no: l.no, col: l.col + n}
+		}
+		n++
+	}
+	return line{text: l.text[n:], no: l.no, col: l.col + n}
+}
+
+func isBlank(text string) bool {
+	return blankLinePattern.MatchString(text)
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
@@ -0,0 +1,128 @@
+package markdown
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRender(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{
+			name: "Headings and inline markup",
+			src:  "# Trip *plan*\n\nPack **light**, ~~skip~~ `a*b*` and ***both***",
+			want: "<h1>Trip <em>plan</em></h1>\n<p>Pack <strong>light</strong>, <del>skip</del> <code>a*b*</code> and <strong><em>both</em></strong></p>\n",
+		},
+		{
+			name: "Underscores inside words",
+			src:  "call snake_case_name but _this_",
+			want: "<p>call snake_case_name but <em>this</em></p>\n",
+		},
+		{
+			name: "Links",
+			src:  `See [the docs](https://go.dev/doc "Docs"), <me@example.com> or https://example.com/a_(b).`,
+			want: `<p>See <a href="https://go.dev/doc" title="Docs" rel="nofollow noopener noreferrer">the docs</a>, ` +
+				`<a href="mailto:me@example.com" rel="nofollow noopener noreferrer">me@example.com</a> or ` +
+				`<a href="https://example.com/a_(b)" rel="nofollow noopener noreferrer">https://example.com/a_(b)</a>.</p>` + "\n",
+		},
+		{
+			name: "Raw HTML is escaped",
+			src:  `<script>alert("hi")</script> <b onclick="x">bold</b>`,
+			want: "<p>&lt;script&gt;alert(&#34;hi&#34;)&lt;/script&gt; &lt;b onclick=&#34;x&#34;&gt;bold&lt;/b&gt;</p>\n",
+		},
+		{
+			name: "Unsafe URLs are dropped",
+			src:  "[click](javascript:alert(1)) [me](JaVaScRiPt:alert(1)) ![pixel](data:image/png;base64,AA) [ok](/todos/1)",
+			want: `<p>click me pixel <a href="/todos/1" rel="nofollow noopener noreferrer">ok</a></p>` + "\n",
+		},
+		{
+			name: "Attribute values are escaped",
+			src:  `![a"b](https://example.com/x.png?a=1&b="2")`,
+			want: `<p><img src="https://example.com/x.png?a=1&amp;b=&#34;2&#34;" alt="a&#34;b"></p>` + "\n",
+		},
+		{
+			name: "Task list",
+			src:  "- [ ] Book flights\n- [x] Renew passport\n  - [ ] Photos\n- Buy adapters",
+			want: "<ul>\n" +
+				"<li class=\"task-list-item\"><input type=\"checkbox\" disabled data-task=\"0\"> Book flights\n</li>\n" +
+				"<li class=\"task-list-item\"><input type=\"checkbox\" disabled data-task=\"1\" checked> Renew passport\n" +
+				"<ul>\n<li class=\"task-list-item\"><input type=\"checkbox\" disabled data-task=\"2\"> Photos\n</li>\n</ul>\n</li>\n" +
+				"<li>Buy adapters\n</li>\n</ul>\n",
+		},
+		{
+			name: "Loose ordered list",
+			src:  "3. three\n\n4. four",
+			want: "<ol start=\"3\">\n<li><p>three</p>\n</li>\n<li><p>four</p>\n</li>\n</ol>\n",
+		},
+		{
+			name: "Numbers do not interrupt a paragraph",
+			src:  "We shipped in\n2026. It went well",
+			want: "<p>We shipped in\n2026. It went well</p>\n",
+		},
+		{
+			name: "Fenced code",
+			src:  "```go\nfmt.Println(\"<b>\")\n- [ ] not a task\n```",
+			want: "<pre><code class=\"language-go\">fmt.Println(&#34;&lt;b&gt;&#34;)\n- [ ] not a task\n</code></pre>\n",
+		},
+		{
+			name: "Block quote, rule and line breaks",
+			src:  "> quoted  \n> text\n\n---\nSetext\n===",
+			want: "<blockquote>\n<p>quoted<br>\ntext</p>\n</blockquote>\n<hr>\n<h1>Setext</h1>\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Render(tt.src); got != tt.want {
+				t.Errorf("Render() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTasks(t *testing.T) {
+	src := "Packing:\r\n" +
+		"- [ ] Book flights\r\n" +
+		"* [X] Renew *passport*\r\n" +
+		"\t- [ ] Photos\r\n" +
+		"> 1. [x] Quoted\r\n" +
+		"```\r\n" +
+		"- [ ] Example\r\n" +
+		"```\r\n" +
+		"- [] Not a task"
+
+	want := []Task{
+		{Line: 1, Column: 3, Checked: false, Text: "Book flights"},
+		{Line: 2, Column: 3, Checked: true, Text: "Renew *passport*"},
+		{Line: 3, Column: 4, Checked: false, Text: "Photos"},
+		{Line: 4, Column: 6, Checked: true, Text: "Quoted"},
+	}
+	if got := Tasks(src); !reflect.DeepEqual(got, want) {
+		t.Errorf("Tasks() = %+v, want %+v", got, want)
+	}
+}
+
+func TestSetTask(t *testing.T) {
+	src := "Packing:\r\n- [ ] Book flights\r\n  - [X] Photos\r\n> - [ ] Quoted"
+
+	got, err := SetTask(src, 1, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err = SetTask(got, 2, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := strings.Replace(strings.Replace(src, "[X]", "[ ]", 1), "> - [ ]", "> - [x]", 1)
+	if got != want {
+		t.Errorf("SetTask() = %q, want %q", got, want)
+	}
+
+	if _, err := SetTask(src, 3, true); err == nil {
+		t.Error("SetTask() with an index past the last task succeeded")
+	}
+}
@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// Blob is a stored file, shared by every attachment with the same contents
+// @Description Stored file contents
+type Blob struct {
+	Checksum  string    `json:"checksum" example:"9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08" gorm:"primaryKey"`
+	Size      int64     `json:"size" example:"52431"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Attachment is a file attached to a todo
+// @Description Attachment information
+type Attachment struct {
+	ID        uint      `json:"id" example:"1" gorm:"primaryKey"`
+	CreatedAt time.Time `json:"created_at"`
+	TodoID    uint      `json:"todo_id" example:"1" gorm:"index"`
+	UserID    uint      `json:"user_id" example:"1" gorm:"index"`
+	Filename  string    `json:"filename" example:"screenshot.png"`
+	MimeType  string    `json:"mime_type" example:"image/png"`
+	Size      int64     `json:"size" example:"52431"`
+	Checksum  string    `json:"checksum" example:"9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08" gorm:"index"`
+}
@@ -0,0 +1,27 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Comment is a note left on a todo by one of the users who can see it
+// @Description Comment information
+type Comment struct {
+	gorm.Model
+	TodoID   uint       `json:"todo_id" example:"1" gorm:"index"`
+	UserID   uint       `json:"user_id" example:"1" gorm:"index"`
+	Author   User       `json:"author" gorm:"foreignKey:UserID"`
+	Body     string     `json:"body" example:"Waiting on the designs before starting"`
+	EditedAt *time.Time `json:"edited_at"`
+}
+
+// CommentRevision keeps the text a comment had before it was edited
+// @Description Previous version of a comment
+type CommentRevision struct {
+	ID        uint      `json:"id" example:"1" gorm:"primaryKey"`
+	CommentID uint      `json:"comment_id" example:"1" gorm:"index"`
+	Body      string    `json:"body" example:"Waiting on designs"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,136 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	FieldText     = "text"
+	FieldNumber   = "number"
+	FieldDate     = "date"
+	FieldSelect   = "select"
+	FieldCheckbox = "checkbox"
+)
+
+// FieldDateLayout is the format date field values are stored in.
+const FieldDateLayout = "2006-01-02"
+
+const maxFieldTextLength = 1000
+
+// CustomField is a typed piece of metadata a user can set on their todos
+// @Description Custom field definition
+type CustomField struct {
+	gorm.Model
+	UserID  uint       `json:"user_id" example:"1" gorm:"index"`
+	Name    string     `json:"name" example:"Story points" binding:"required"`
+	Type    string     `json:"type" example:"number" binding:"required,oneof=text number date select checkbox"`
+	Options StringList `json:"options" gorm:"type:text" swaggertype:"array,string" example:"small,medium,large"` // Choices of a select field
+}
+
+// CustomFieldValue is the value a todo has for one custom field. Values are
+// kept as text in a canonical form per type; numbers are also kept as a
+// number so that they sort numerically.
+// @Description Custom field value
+type CustomFieldValue struct {
+	TodoID  uint     `json:"-" gorm:"primaryKey;autoIncrement:false"`
+	FieldID uint     `json:"field_id" example:"1" gorm:"primaryKey;autoIncrement:false;index"`
+	Value   string   `json:"value" example:"5"`
+	Number  *float64 `json:"-"`
+}
+
+// UnmarshalJSON accepts numbers and booleans as values as well as strings,
+// so clients can send a number field as 5 rather than "5".
+func (v *CustomFieldValue) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		FieldID uint            `json:"field_id"`
+		Value   json.RawMessage `json:"value"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	v.FieldID = raw.FieldID
+	v.Value = ""
+	if len(raw.Value) == 0 || string(raw.Value) == "null" {
+		return nil
+	}
+	if raw.Value[0] == '"' {
+		return json.Unmarshal(raw.Value, &v.Value)
+	}
+	v.Value = string(raw.Value)
+	return nil
+}
+
+// Normalize validates a value for the field and returns it in canonical
+// form, along with its numeric value for number fields.
+func (f *CustomField) Normalize(value string) (string, *float64, error) {
+	switch f.Type {
+	case FieldText:
+		if len(value) > maxFieldTextLength {
+			return "", nil, fmt.Errorf("%s can be at most %d characters", f.Name, maxFieldTextLength)
+		}
+		return value, nil, nil
+	case FieldNumber:
+		number, err := strconv.ParseFloat(value, 64)
+		if err != nil || math.IsNaN(number) || math.IsInf(number, 0) {
+			return "", nil, fmt.Errorf("%s must be a number", f.Name)
+		}
+		return strconv.FormatFloat(number, 'f', -1, 64), &number, nil
+	case FieldDate:
+		date, err := time.Parse(FieldDateLayout, value)
+		if err != nil {
+			return "", nil, fmt.Errorf("%s must be a date like 2026-01-31", f.Name)
+		}
+		return date.Format(FieldDateLayout), nil, nil
+	case FieldSelect:
+		for _, option := range f.Options {
+			if option == value {
+				return value, nil, nil
+			}
+		}
+		return "", nil, fmt.Errorf("%s must be one of %v", f.Name, []string(f.Options))
+	case FieldCheckbox:
+		checked, err := strconv.ParseBool(value)
+		if err != nil {
+			return "", nil, fmt.Errorf("%s must be true or false", f.Name)
+		}
+		return strconv.FormatBool(checked), nil, nil
+	default:
+		return "", nil, fmt.Errorf("%s has unknown type %q", f.Name, f.Type)
+	}
+}
+
+// StringList is a list of strings stored as a JSON document.
+type StringList []string
+
+func (l StringList) Value() (driver.Value, error) {
+	if l == nil {
+		return "[]", nil
+	}
+	data, err := json.Marshal(l)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+func (l *StringList) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		*l = nil
+		return nil
+	case string:
+		return json.Unmarshal([]byte(v), l)
+	case []byte:
+		return json.Unmarshal(v, l)
+	default:
+		return fmt.Errorf("cannot scan %T into StringList", value)
+	}
+}
@@ -0,0 +1,11 @@
+package models
+
+import "time"
+
+// TodoDependency records that a todo cannot be done before another one
+// @Description Dependency between two todos
+type TodoDependency struct {
+	TodoID      uint      `json:"todo_id" example:"2" gorm:"primaryKey"`
+	BlockedByID uint      `json:"blocked_by_id" example:"1" gorm:"primaryKey;index"`
+	CreatedAt   time.Time `json:"created_at"`
+}
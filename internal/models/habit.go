@@ -0,0 +1,62 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// How often a habit is meant to be done
+const (
+	HabitDaily  = "daily"
+	HabitWeekly = "weekly"
+)
+
+// Habit is something a user means to do every day or week. Unlike a
+// recurring todo it is never completed; instead it is checked in on, and
+// consecutive days or weeks that reach the target make up a streak.
+// @Description Habit information
+type Habit struct {
+	gorm.Model
+	UserID       uint        `json:"user_id" example:"1" gorm:"index"`
+	Name         string      `json:"name" example:"Read" binding:"required"`
+	Description  string      `json:"description" example:"At least 20 pages"`
+	Frequency    string      `json:"frequency" example:"daily" binding:"required,oneof=daily weekly"`
+	Target       int         `json:"target" example:"1" binding:"min=0,max=100"`      // Check-ins needed each day or week; 0 means 1
+	GracePeriods int         `json:"grace_periods" example:"1" binding:"min=0,max=7"` // Missed days or weeks in a row that do not break a streak
+	GraceHours   int         `json:"grace_hours" example:"3" binding:"min=0,max=12"`  // Check-ins this long after midnight count for the day before
+	Archived     bool        `json:"archived" example:"false"`
+	Stats        *HabitStats `json:"stats,omitempty" gorm:"-"`
+}
+
+// HabitStats summarizes a habit's check-ins as of today
+// @Description Habit streaks and progress
+type HabitStats struct {
+	CurrentStreak int    `json:"current_streak" example:"4"` // Days or weeks, counting today or this week once the target is reached
+	LongestStreak int    `json:"longest_streak" example:"12"`
+	TotalCheckIns int    `json:"total_check_ins" example:"57"`
+	PeriodCount   int    `json:"period_count" example:"1"` // Check-ins today or this week
+	PeriodDone    bool   `json:"period_done" example:"true"`
+	LastCheckIn   string `json:"last_check_in,omitempty" example:"2026-01-31"`
+}
+
+// HabitCheckIn counts the times a habit was done on one day, in the user's
+// timezone
+// @Description Habit check-in
+type HabitCheckIn struct {
+	ID        uint      `json:"id" example:"1" gorm:"primaryKey"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	HabitID   uint      `json:"habit_id" example:"1" gorm:"uniqueIndex:idx_habit_day"`
+	UserID    uint      `json:"user_id" example:"1" gorm:"index"`
+	Day       string    `json:"day" example:"2026-01-31" gorm:"uniqueIndex:idx_habit_day"`
+	Count     int       `json:"count" example:"1"`
+}
+
+// Goal returns the number of check-ins a day or week needs.
+func (h *Habit) Goal() int {
+	if h.Target < 1 {
+		return 1
+	}
+	return h.Target
+}
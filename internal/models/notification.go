@@ -0,0 +1,19 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Notification is a message delivered to a user's in-app inbox
+// @Description In-app notification
+type Notification struct {
+	gorm.Model
+	UserID uint       `json:"user_id" example:"1" gorm:"index"`
+	TodoID *uint      `json:"todo_id" example:"1"`
+	Event  string     `json:"event" example:"reminder"`
+	Title  string     `json:"title" example:"Reminder: Pay rent"`
+	Body   string     `json:"body" example:"Due at 2026-01-31 09:00"`
+	ReadAt *time.Time `json:"read_at"`
+}
@@ -0,0 +1,28 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Project groups a user's todos into a list
+// @Description Project information
+type Project struct {
+	gorm.Model
+	Name      string `json:"name" example:"Home" binding:"required"`
+	Color     string `json:"color" example:"#3366ff" binding:"omitempty,hexcolor"`
+	Archived  bool   `json:"archived" example:"false"`
+	SortOrder int    `json:"sort_order" example:"0"`
+	Inbox     bool   `json:"inbox" example:"false"`
+	UserID    uint   `json:"user_id" example:"1" gorm:"index"`
+}
+
+// ProjectMember shares a project, and the todos in it, with another user
+// @Description Project membership
+type ProjectMember struct {
+	ProjectID uint      `json:"project_id" example:"1" gorm:"primaryKey"`
+	UserID    uint      `json:"user_id" example:"2" gorm:"primaryKey;index"`
+	User      User      `json:"user" gorm:"foreignKey:UserID"`
+	CreatedAt time.Time `json:"created_at"`
+}
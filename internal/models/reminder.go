@@ -0,0 +1,56 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	ReminderPending   = "pending"
+	ReminderSending   = "sending"
+	ReminderSent      = "sent"
+	ReminderFailed    = "failed"
+	ReminderCancelled = "cancelled"
+)
+
+// Events a reminder can notify about
+const (
+	EventReminder  = "reminder"
+	EventUnsnoozed = "unsnoozed"
+)
+
+// Reminder schedules a notification about a todo, either at a fixed time or
+// a number of minutes before the todo is due
+// @Description Reminder information
+type Reminder struct {
+	gorm.Model
+	TodoID        uint       `json:"todo_id" example:"1" gorm:"index"`
+	UserID        uint       `json:"user_id" example:"1" gorm:"index"`
+	RemindAt      *time.Time `json:"remind_at" example:"2026-01-31T08:00:00Z"`
+	OffsetMinutes *int       `json:"offset_minutes" example:"30"`
+	Channel       string     `json:"channel" example:"in_app"`
+	Event         string     `json:"event" example:"reminder"` // "unsnoozed" when a snoozed todo comes back
+	FireAt        *time.Time `json:"fire_at" gorm:"index"`
+	Status        string     `json:"status" example:"pending" gorm:"index"`
+	Attempts      int        `json:"attempts" example:"0"`
+	LastError     string     `json:"last_error,omitempty"`
+	SentAt        *time.Time `json:"sent_at"`
+	ClaimedBy     string     `json:"-"`
+	ClaimedUntil  *time.Time `json:"-"`
+}
+
+// Schedule works out when the reminder fires for a todo due at the given
+// time. Relative reminders on a todo without a due date never fire.
+func (r *Reminder) Schedule(due *time.Time) {
+	switch {
+	case r.RemindAt != nil:
+		fireAt := *r.RemindAt
+		r.FireAt = &fireAt
+	case r.OffsetMinutes != nil && due != nil:
+		fireAt := due.Add(-time.Duration(*r.OffsetMinutes) * time.Minute)
+		r.FireAt = &fireAt
+	default:
+		r.FireAt = nil
+	}
+}
@@ -0,0 +1,52 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// TodoRevision records one change to a todo. Revisions are never edited.
+// @Description Todo revision information
+type TodoRevision struct {
+	ID        uint            `json:"id" example:"1" gorm:"primaryKey"`
+	TodoID    uint            `json:"todo_id" example:"1" gorm:"uniqueIndex:idx_todo_revision"`
+	Number    int             `json:"number" example:"3" gorm:"uniqueIndex:idx_todo_revision"`
+	UserID    uint            `json:"user_id" example:"1"`
+	Changes   RevisionChanges `json:"changes" gorm:"type:text"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+// FieldChange is the value of one field before and after a revision
+// @Description Field-level change
+type FieldChange struct {
+	Field string          `json:"field" example:"title"`
+	Old   json.RawMessage `json:"old" swaggertype:"string" example:"\"Learn Go\""`
+	New   json.RawMessage `json:"new" swaggertype:"string" example:"\"Learn Go generics\""`
+}
+
+// RevisionChanges is stored as a JSON document.
+type RevisionChanges []FieldChange
+
+func (c RevisionChanges) Value() (driver.Value, error) {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+func (c *RevisionChanges) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		*c = nil
+		return nil
+	case string:
+		return json.Unmarshal([]byte(v), c)
+	case []byte:
+		return json.Unmarshal(v, c)
+	default:
+		return fmt.Errorf("cannot scan %T into RevisionChanges", value)
+	}
+}
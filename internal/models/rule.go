@@ -0,0 +1,98 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Events a rule can be triggered by
+const (
+	TriggerCreated   = "created"
+	TriggerUpdated   = "updated"
+	TriggerCompleted = "completed"
+	TriggerDueSoon   = "due_soon"
+)
+
+// Things a rule can do to the todo that triggered it
+const (
+	ActionAddTag      = "add_tag"
+	ActionSetPriority = "set_priority"
+	ActionSetDue      = "set_due"
+	ActionMove        = "move"
+	ActionCreateTodo  = "create_todo"
+)
+
+// Outcomes of running a rule
+const (
+	ExecutionSucceeded = "succeeded"
+	ExecutionFailed    = "failed"
+	ExecutionSkipped   = "skipped"
+)
+
+// Rule is a user-defined automation: when its trigger fires for a todo that
+// matches its condition, its actions are applied
+// @Description Automation rule
+type Rule struct {
+	gorm.Model
+	UserID      uint        `json:"user_id" example:"1" gorm:"index"`
+	Name        string      `json:"name" example:"File invoices" binding:"required"`
+	Trigger     string      `json:"trigger" example:"created" binding:"required,oneof=created updated completed due_soon" gorm:"index"`
+	Condition   string      `json:"condition" example:"title:invoice"`           // A filter query the todo must match; empty matches every todo
+	LeadMinutes int         `json:"lead_minutes" example:"1440" binding:"min=0"` // How long before the due date a due_soon rule fires
+	Actions     RuleActions `json:"actions" binding:"required,min=1,dive" gorm:"type:text"`
+	Paused      bool        `json:"paused" example:"false"`
+}
+
+// RuleAction is one step of a rule. Which fields are used depends on Type.
+// @Description Rule action
+type RuleAction struct {
+	Type      string        `json:"type" example:"add_tag" binding:"required,oneof=add_tag set_priority set_due move create_todo"`
+	Tag       string        `json:"tag,omitempty" example:"finance"`                                // add_tag
+	Priority  *int          `json:"priority,omitempty" example:"3" binding:"omitempty,min=0,max=3"` // set_priority
+	DueOffset string        `json:"due_offset,omitempty" example:"3d"`                              // set_due, counted from the start of today
+	ProjectID *uint         `json:"project_id,omitempty" example:"2"`                               // move and create_todo
+	Todo      *TemplateItem `json:"todo,omitempty"`                                                 // create_todo, started today; {{title}} is the triggering todo's title
+}
+
+// RuleActions is stored as a JSON column
+type RuleActions []RuleAction
+
+func (a RuleActions) Value() (driver.Value, error) {
+	data, err := json.Marshal(a)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+func (a *RuleActions) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		*a = nil
+		return nil
+	case string:
+		return json.Unmarshal([]byte(v), a)
+	case []byte:
+		return json.Unmarshal(v, a)
+	default:
+		return fmt.Errorf("cannot scan %T into RuleActions", value)
+	}
+}
+
+// RuleExecution records one run of a rule against a todo
+// @Description Rule execution log entry
+type RuleExecution struct {
+	ID        uint       `json:"id" example:"1" gorm:"primaryKey"`
+	CreatedAt time.Time  `json:"created_at"`
+	RuleID    uint       `json:"rule_id" example:"1" gorm:"index;uniqueIndex:idx_due_soon_run,where:due_at IS NOT NULL"`
+	UserID    uint       `json:"user_id" example:"1" gorm:"index"`
+	TodoID    uint       `json:"todo_id" example:"1" gorm:"index;uniqueIndex:idx_due_soon_run,where:due_at IS NOT NULL"`
+	Trigger   string     `json:"trigger" example:"created"`
+	Status    string     `json:"status" example:"succeeded"`
+	Error     string     `json:"error,omitempty" example:"project is archived"`
+	DueAt     *time.Time `json:"due_at,omitempty" gorm:"uniqueIndex:idx_due_soon_run,where:due_at IS NOT NULL"` // The due date a due_soon run was for
+}
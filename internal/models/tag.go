@@ -0,0 +1,14 @@
+package models
+
+import "gorm.io/gorm"
+
+// Tag represents a user-defined label that can be attached to many todos
+// @Description Tag information
+type Tag struct {
+	gorm.Model
+	Name      string `json:"name" example:"work" binding:"required"`
+	Color     string `json:"color" example:"#ff8800" binding:"omitempty,hexcolor"`
+	UserID    uint   `json:"user_id" example:"1" gorm:"index"`
+	Todos     []Todo `json:"-" gorm:"many2many:todo_tags;"`
+	TodoCount int64  `json:"todo_count" example:"3" gorm:"-"`
+}
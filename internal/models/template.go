@@ -0,0 +1,141 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Template is a reusable todo tree, such as an onboarding or release
+// checklist, that can be instantiated into real todos
+// @Description Template information
+type Template struct {
+	gorm.Model
+	UserID uint         `json:"user_id" example:"1" gorm:"index"`
+	Name   string       `json:"name" example:"Release checklist" binding:"required"`
+	Todo   TemplateItem `json:"todo" gorm:"type:text"`
+}
+
+// TemplateItem describes one todo of a template and its subtasks. Titles
+// and descriptions may contain variables such as {{date}}.
+// @Description Todo blueprint
+type TemplateItem struct {
+	Title        string         `json:"title" example:"Release {{version}}"`
+	Description  string         `json:"description" example:"Cut the release on {{date}}"`
+	Tags         []string       `json:"tags" example:"release"`
+	DueOffset    string         `json:"due_offset" example:"2d17h"` // When the todo is due, relative to the start date
+	AutoComplete bool           `json:"auto_complete" example:"true"`
+	Subtasks     []TemplateItem `json:"subtasks"`
+}
+
+func (t TemplateItem) Value() (driver.Value, error) {
+	data, err := json.Marshal(t)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+func (t *TemplateItem) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		*t = TemplateItem{}
+		return nil
+	case string:
+		return json.Unmarshal([]byte(v), t)
+	case []byte:
+		return json.Unmarshal(v, t)
+	default:
+		return fmt.Errorf("cannot scan %T into TemplateItem", value)
+	}
+}
+
+var offsetPattern = regexp.MustCompile(`^-?(\d+[wdhm])+$`)
+var offsetPart = regexp.MustCompile(`(\d+)([wdhm])`)
+
+// DueDate works out when the item is due for a template started at start.
+// Items without an offset have no due date.
+func (t *TemplateItem) DueDate(start time.Time) (*time.Time, error) {
+	if t.DueOffset == "" {
+		return nil, nil
+	}
+	due, err := AddOffset(start, t.DueOffset)
+	if err != nil {
+		return nil, fmt.Errorf("due %w", err)
+	}
+	return &due, nil
+}
+
+// AddOffset moves t by an offset such as 3d, 1w2d or -4h30m. Weeks and days
+// are calendar days, so an offset of 1d keeps the time of day across
+// daylight saving changes.
+func AddOffset(t time.Time, offset string) (time.Time, error) {
+	if !offsetPattern.MatchString(offset) {
+		return time.Time{}, fmt.Errorf("offset %q must look like 3d, 1w2d or 2d17h30m", offset)
+	}
+
+	sign := 1
+	if strings.HasPrefix(offset, "-") {
+		sign = -1
+	}
+	var days int
+	var rest time.Duration
+	for _, part := range offsetPart.FindAllStringSubmatch(offset, -1) {
+		n, err := strconv.Atoi(part[1])
+		if err != nil {
+			return time.Time{}, fmt.Errorf("offset %q is too large", offset)
+		}
+		switch part[2] {
+		case "w":
+			days += 7 * n
+		case "d":
+			days += n
+		case "h":
+			rest += time.Duration(n) * time.Hour
+		case "m":
+			rest += time.Duration(n) * time.Minute
+		}
+	}
+
+	return t.AddDate(0, 0, sign*days).Add(time.Duration(sign) * rest), nil
+}
+
+// FormatOffset returns the due offset that makes an item started at start
+// fall due at due.
+func FormatOffset(start, due time.Time) string {
+	sign := ""
+	if due.Before(start) {
+		sign = "-"
+		start, due = due, start
+	}
+
+	days := int(due.Sub(start).Hours() / 24)
+	for days > 0 && start.AddDate(0, 0, days).After(due) {
+		days--
+	}
+	for !start.AddDate(0, 0, days+1).After(due) {
+		days++
+	}
+	rest := due.Sub(start.AddDate(0, 0, days))
+
+	var b strings.Builder
+	if days > 0 {
+		fmt.Fprintf(&b, "%dd", days)
+	}
+	if hours := int(rest / time.Hour); hours > 0 {
+		fmt.Fprintf(&b, "%dh", hours)
+	}
+	if minutes := int(rest % time.Hour / time.Minute); minutes > 0 {
+		fmt.Fprintf(&b, "%dm", minutes)
+	}
+	if b.Len() == 0 {
+		return "0d"
+	}
+	return sign + b.String()
+}
@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+// TimeEntry is a span of time a user spent on a todo, either tracked with a
+// timer or logged by hand. A user has at most one running timer, which is
+// an entry without an end.
+// @Description Time entry information
+type TimeEntry struct {
+	ID        uint       `json:"id" example:"1" gorm:"primaryKey"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+	UserID    uint       `json:"user_id" example:"1" gorm:"index;uniqueIndex:idx_running_timer,where:ended_at IS NULL"`
+	TodoID    uint       `json:"todo_id" example:"1" gorm:"index"`
+	StartedAt time.Time  `json:"started_at" example:"2026-01-31T09:00:00Z" gorm:"index"`
+	EndedAt   *time.Time `json:"ended_at" example:"2026-01-31T10:30:00Z"`
+	Duration  int64      `json:"duration" example:"5400"` // Seconds; for a running timer, the time so far
+	Note      string     `json:"note" example:"Client call"`
+}
+
+// Running reports whether the entry is a timer that has not been stopped.
+func (e *TimeEntry) Running() bool {
+	return e.EndedAt == nil
+}
+
+// Elapsed returns the length of the entry, counting a running timer up to now.
+func (e *TimeEntry) Elapsed(now time.Time) time.Duration {
+	if e.EndedAt != nil {
+		return e.EndedAt.Sub(e.StartedAt)
+	}
+	return now.Sub(e.StartedAt)
+}
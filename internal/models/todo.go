@@ -1,14 +1,61 @@
 package models
 
-import "gorm.io/gorm"
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Priorities a todo can have, from none to highest
+const (
+	PriorityNone = iota
+	PriorityLow
+	PriorityMedium
+	PriorityHigh
+)
 
 // Todo represents a todo item in the system
 // @Description Todo information
 type Todo struct {
 	gorm.Model
-	Title       string `json:"title" example:"Learn Go" binding:"required"`
-	Description string `json:"description" example:"Study Go programming language"`
-	Completed   bool   `json:"completed" example:"false"`
-	UserID      uint   `json:"user_id" example:"1"`
-	User        User   `json:"user" gorm:"foreignKey:UserID"`
+	Title               string             `json:"title" example:"Learn Go" binding:"required"`
+	Description         string             `json:"description" example:"Study Go programming language"`
+	DescriptionHTML     string             `json:"description_html,omitempty" gorm:"-"`
+	Checklist           *ChecklistProgress `json:"checklist,omitempty" gorm:"-"`
+	Completed           bool               `json:"completed" example:"false"`
+	Priority            int                `json:"priority" example:"2" binding:"min=0,max=3"` // 0 none, 1 low, 2 medium, 3 high
+	StatusID            *uint              `json:"status_id" example:"1" gorm:"index"`
+	UserID              uint               `json:"user_id" example:"1"` // The todo's creator, who owns it
+	User                User               `json:"user" gorm:"foreignKey:UserID"`
+	Assignees           []User             `json:"assignees" gorm:"many2many:todo_assignees;"`
+	Tags                []Tag              `json:"tags" gorm:"many2many:todo_tags;"`
+	CustomFields        []CustomFieldValue `json:"custom_fields" gorm:"foreignKey:TodoID"`
+	ProjectID           *uint              `json:"project_id" example:"1" gorm:"index"`
+	ParentID            *uint              `json:"parent_id" example:"1" gorm:"index"`
+	Subtasks            []Todo             `json:"subtasks,omitempty" gorm:"foreignKey:ParentID"`
+	AutoComplete        bool               `json:"auto_complete" example:"false"`
+	Progress            *SubtaskProgress   `json:"progress,omitempty" gorm:"-"`
+	DueDate             *time.Time         `json:"due_date" example:"2026-01-31T09:00:00Z"`
+	CompletedAt         *time.Time         `json:"completed_at"`
+	Archived            bool               `json:"archived" example:"false" gorm:"index"`
+	ArchivedAt          *time.Time         `json:"archived_at"`
+	HiddenUntil         *time.Time         `json:"hidden_until" example:"2026-02-02T09:00:00Z" gorm:"index"` // Snoozed todos stay out of lists until then
+	Recurrence          string             `json:"recurrence" example:"FREQ=WEEKLY;BYDAY=MO"`
+	RecurFromCompletion bool               `json:"recur_from_completion" example:"false"`
+	SeriesID            *uint              `json:"series_id" example:"1" gorm:"index"`
+	Occurrence          int                `json:"occurrence" example:"1"`
+}
+
+// SubtaskProgress reports how many of a todo's subtasks are done
+// @Description Subtask completion roll-up
+type SubtaskProgress struct {
+	Completed int `json:"completed" example:"2"`
+	Total     int `json:"total" example:"5"`
+}
+
+// ChecklistProgress counts the Markdown checkboxes in a todo's description
+// @Description Description checklist progress
+type ChecklistProgress struct {
+	Checked int `json:"checked" example:"1"`
+	Total   int `json:"total" example:"3"`
 }
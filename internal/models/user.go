@@ -1,6 +1,8 @@
 package models
 
 import (
+	"time"
+
 	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
 )
@@ -9,8 +11,22 @@ import (
 // @Description User information
 type User struct {
 	gorm.Model
-	Email    string `json:"email" gorm:"uniqueIndex" example:"user@example.com"`
-	Password string `json:"-"` // The "-" tag prevents the password from being included in JSON responses
+	Email           string `json:"email" gorm:"uniqueIndex" example:"user@example.com"`
+	Password        string `json:"-"`                                // The "-" tag prevents the password from being included in JSON responses
+	AutoArchiveDays int    `json:"auto_archive_days" example:"30"`   // Days after completion at which todos are archived; zero turns it off
+	Timezone        string `json:"timezone" example:"Europe/Berlin"` // IANA time zone that dates are counted in; empty means UTC
+}
+
+// Location returns the user's time zone, falling back to UTC.
+func (u *User) Location() *time.Location {
+	if u.Timezone == "" {
+		return time.UTC
+	}
+	loc, err := time.LoadLocation(u.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
 }
 
 func (u *User) HashPassword() error {
@@ -0,0 +1,25 @@
+package models
+
+import "gorm.io/gorm"
+
+// Ways a saved view can group its todos
+const (
+	GroupByProject  = "project"
+	GroupByPriority = "priority"
+	GroupByStatus   = "status"
+	GroupByDue      = "due"
+)
+
+// SavedView is a named filter, sort and grouping over the user's todos, such
+// as the Today or Overdue smart lists
+// @Description Saved view information
+type SavedView struct {
+	gorm.Model
+	UserID    uint   `json:"user_id" example:"1" gorm:"index"`
+	Name      string `json:"name" example:"Work this week" binding:"required"`
+	Query     string `json:"query" example:"status:open tag:work due<7d"` // In the language of the todo list's q parameter
+	Sort      string `json:"sort" example:"due_date"`
+	GroupBy   string `json:"group_by" example:"project" binding:"omitempty,oneof=project priority status due"`
+	Pinned    bool   `json:"pinned" example:"false"`
+	SortOrder int    `json:"sort_order" example:"0"`
+}
@@ -0,0 +1,75 @@
+package models
+
+import "gorm.io/gorm"
+
+const (
+	CategoryTodo  = "todo"
+	CategoryDoing = "doing"
+	CategoryDone  = "done"
+)
+
+// Workflow is an ordered set of statuses todos move through. A workflow
+// belongs to a project, or is the user's default when it has no project.
+// @Description Workflow information
+type Workflow struct {
+	gorm.Model
+	Name        string               `json:"name" example:"Kanban"`
+	UserID      uint                 `json:"user_id" example:"1" gorm:"index"`
+	ProjectID   *uint                `json:"project_id" example:"1" gorm:"index"`
+	Statuses    []WorkflowStatus     `json:"statuses"`
+	Transitions []WorkflowTransition `json:"transitions"`
+}
+
+// WorkflowStatus is one column of a workflow. Its category decides whether
+// todos in it count as completed.
+// @Description Workflow status information
+type WorkflowStatus struct {
+	ID         uint   `json:"id" example:"1" gorm:"primaryKey"`
+	WorkflowID uint   `json:"workflow_id" example:"1" gorm:"index"`
+	Name       string `json:"name" example:"In review"`
+	Category   string `json:"category" example:"doing"`
+	Position   int    `json:"position" example:"2"`
+}
+
+// WorkflowTransition allows todos to move from one status to another
+// @Description Workflow transition information
+type WorkflowTransition struct {
+	ID           uint `json:"id" example:"1" gorm:"primaryKey"`
+	WorkflowID   uint `json:"workflow_id" example:"1" gorm:"index"`
+	FromStatusID uint `json:"from_status_id" example:"1"`
+	ToStatusID   uint `json:"to_status_id" example:"2"`
+}
+
+// Status returns the workflow's status with the given ID, or nil.
+func (w *Workflow) Status(id uint) *WorkflowStatus {
+	for i := range w.Statuses {
+		if w.Statuses[i].ID == id {
+			return &w.Statuses[i]
+		}
+	}
+	return nil
+}
+
+// FirstIn returns the first status of a category in workflow order, or nil.
+func (w *Workflow) FirstIn(category string) *WorkflowStatus {
+	for i := range w.Statuses {
+		if w.Statuses[i].Category == category {
+			return &w.Statuses[i]
+		}
+	}
+	return nil
+}
+
+// Allows reports whether todos may move between two statuses. A workflow
+// without transitions allows every move.
+func (w *Workflow) Allows(from uint, to uint) bool {
+	if from == to || len(w.Transitions) == 0 {
+		return true
+	}
+	for _, transition := range w.Transitions {
+		if transition.FromStatusID == from && transition.ToStatusID == to {
+			return true
+		}
+	}
+	return false
+}
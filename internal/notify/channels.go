@@ -0,0 +1,164 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"mime"
+	"net"
+	"net/http"
+	"net/smtp"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"todo-api/internal/models"
+)
+
+// Email sends messages through an SMTP server.
+type Email struct {
+	Addr string
+	Auth smtp.Auth
+	From string
+}
+
+func (e *Email) Notify(ctx context.Context, msg Message) error {
+	if msg.Email == "" {
+		return fmt.Errorf("user %d has no email address", msg.UserID)
+	}
+
+	var body strings.Builder
+	fmt.Fprintf(&body, "From: %s\r\n", e.From)
+	fmt.Fprintf(&body, "To: %s\r\n", msg.Email)
+	fmt.Fprintf(&body, "Subject: %s\r\n", encodeHeader(msg.Subject))
+	body.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	body.WriteString(msg.Body)
+
+	return e.send(ctx, msg.Email, []byte(body.String()))
+}
+
+// send delivers a message like smtp.SendMail does, but gives up when ctx is
+// done, so a server that stops answering cannot hold up the delivery.
+func (e *Email) send(ctx context.Context, to string, data []byte) error {
+	host, _, err := net.SplitHostPort(e.Addr)
+	if err != nil {
+		return err
+	}
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", e.Addr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		if err := conn.SetDeadline(deadline); err != nil {
+			return err
+		}
+	}
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	client, err := smtp.NewClient(conn, host)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: host}); err != nil {
+			return err
+		}
+	}
+	if e.Auth != nil {
+		if ok, _ := client.Extension("AUTH"); !ok {
+			return errors.New("smtp: server doesn't support AUTH")
+		}
+		if err := client.Auth(e.Auth); err != nil {
+			return err
+		}
+	}
+	if err := client.Mail(e.From); err != nil {
+		return err
+	}
+	if err := client.Rcpt(to); err != nil {
+		return err
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(data); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+// encodeHeader makes text safe for a mail header: line breaks, which would
+// start a new header, are dropped and non-ASCII text is encoded.
+func encodeHeader(text string) string {
+	text = strings.NewReplacer("\r", "", "\n", "").Replace(text)
+	return mime.QEncoding.Encode("utf-8", text)
+}
+
+// webhookClient is used by webhooks without a Client of their own. Its
+// timeout keeps a hanging endpoint from holding up other deliveries.
+var webhookClient = &http.Client{Timeout: 30 * time.Second}
+
+// Webhook posts messages as JSON to a URL.
+type Webhook struct {
+	URL    string
+	Client *http.Client
+}
+
+func (w *Webhook) Notify(ctx context.Context, msg Message) error {
+	payload, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.URL, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	client := w.Client
+	if client == nil {
+		client = webhookClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook responded with status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// InApp stores messages as notifications the user can list through the API.
+type InApp struct {
+	DB *gorm.DB
+}
+
+func (n *InApp) Notify(ctx context.Context, msg Message) error {
+	notification := models.Notification{
+		UserID: msg.UserID,
+		Event:  msg.Event,
+		Title:  msg.Subject,
+		Body:   msg.Body,
+	}
+	if msg.TodoID != 0 {
+		notification.TodoID = &msg.TodoID
+	}
+	return n.DB.WithContext(ctx).Create(&notification).Error
+}
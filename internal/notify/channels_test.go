@@ -0,0 +1,57 @@
+package notify
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncodeHeader(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		expected string
+	}{
+		{"Plain text is kept", "Reminder: Pay rent", "Reminder: Pay rent"},
+		{"Line breaks are dropped", "Pay rent\r\nBcc: victim@example.com", "Pay rentBcc: victim@example.com"},
+		{"Non-ASCII text is encoded", "Reminder: Café", "=?utf-8?q?Reminder:_Caf=C3=A9?="},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, encodeHeader(tt.text))
+		})
+	}
+}
+
+func TestEmailGivesUpOnHungServer(t *testing.T) {
+	// Accepts connections but never greets, like a stalled SMTP server
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	defer listener.Close()
+	accepted := make(chan net.Conn, 1)
+	go func() {
+		if conn, err := listener.Accept(); err == nil {
+			accepted <- conn
+		}
+	}()
+	defer func() {
+		select {
+		case conn := <-accepted:
+			conn.Close()
+		default:
+		}
+	}()
+
+	email := &Email{Addr: listener.Addr().String(), From: "todo@example.com"}
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	started := time.Now()
+	err = email.Notify(ctx, Message{Email: "user@example.com", Subject: "Reminder", Body: "Pay rent"})
+	assert.Error(t, err)
+	assert.Less(t, time.Since(started), 2*time.Second)
+}
@@ -0,0 +1,41 @@
+// Package notify delivers user notifications over pluggable channels.
+package notify
+
+import (
+	"context"
+	"fmt"
+)
+
+const (
+	ChannelEmail   = "email"
+	ChannelWebhook = "webhook"
+	ChannelInApp   = "in_app"
+)
+
+// Message is a notification addressed to a user.
+type Message struct {
+	Channel string `json:"channel"`
+	Event   string `json:"event"`
+	UserID  uint   `json:"user_id"`
+	Email   string `json:"email"`
+	TodoID  uint   `json:"todo_id"`
+	Subject string `json:"subject"`
+	Body    string `json:"body"`
+}
+
+// Notifier delivers a message. Implementations should return an error when
+// delivery failed and may be retried.
+type Notifier interface {
+	Notify(ctx context.Context, msg Message) error
+}
+
+// Router sends each message to the notifier registered for its channel.
+type Router map[string]Notifier
+
+func (r Router) Notify(ctx context.Context, msg Message) error {
+	notifier, ok := r[msg.Channel]
+	if !ok {
+		return fmt.Errorf("no notifier configured for channel %q", msg.Channel)
+	}
+	return notifier.Notify(ctx, msg)
+}
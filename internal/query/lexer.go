@@ -0,0 +1,148 @@
+// Package query parses the filter language accepted by the todo list, such
+// as `status:open tag:work due<7d (priority:high OR assignee:me) -tag:someday`.
+//
+// Terms separated by spaces must all match. OR between terms matches either
+// side and binds looser than the implicit AND; parentheses group terms and a
+// leading - or NOT negates one. A term is a field, an operator (:, =, <, <=,
+// > or >=) and a value, or a bare word that is searched for in the text.
+// Values and words with spaces can be double quoted, with \" and \\ inside.
+//
+// The package only builds the syntax tree. What fields exist and what their
+// values mean is up to the caller, which reports problems with Errorf so that
+// every error points at a column of the query.
+package query
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokWord
+	tokString
+	tokOp
+	tokLParen
+	tokRParen
+	tokMinus
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+// Error is a problem with a query at a position, counted in characters from 0.
+type Error struct {
+	Pos int
+	Msg string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s at column %d", e.Msg, e.Pos+1)
+}
+
+// Errorf reports a problem at a position in the query.
+func Errorf(pos int, format string, args ...interface{}) *Error {
+	return &Error{Pos: pos, Msg: fmt.Sprintf(format, args...)}
+}
+
+// lex splits a query into tokens. An operator right after a word makes the
+// word a field and everything up to the next space or closing parenthesis
+// its value.
+func lex(src string) ([]token, error) {
+	s := []rune(src)
+	var tokens []token
+	afterOp := false
+
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case unicode.IsSpace(c):
+			if afterOp {
+				return nil, Errorf(i, "expected a value")
+			}
+			i++
+		case c == '"':
+			text, end, err := quoted(s, i)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, token{kind: tokString, text: text, pos: i})
+			i = end
+			afterOp = false
+		case afterOp:
+			j := i
+			for j < len(s) && !unicode.IsSpace(s[j]) && s[j] != ')' {
+				j++
+			}
+			if j == i {
+				return nil, Errorf(i, "expected a value")
+			}
+			tokens = append(tokens, token{kind: tokWord, text: string(s[i:j]), pos: i})
+			i = j
+			afterOp = false
+		case c == '(':
+			tokens = append(tokens, token{kind: tokLParen, text: "(", pos: i})
+			i++
+		case c == ')':
+			tokens = append(tokens, token{kind: tokRParen, text: ")", pos: i})
+			i++
+		case c == '-':
+			tokens = append(tokens, token{kind: tokMinus, text: "-", pos: i})
+			i++
+		case isOp(c):
+			return nil, Errorf(i, "expected a field before %q", string(c))
+		default:
+			j := i
+			for j < len(s) && !unicode.IsSpace(s[j]) && !isOp(s[j]) && strings.IndexRune(`()"`, s[j]) < 0 {
+				j++
+			}
+			tokens = append(tokens, token{kind: tokWord, text: string(s[i:j]), pos: i})
+			i = j
+
+			if i < len(s) && isOp(s[i]) {
+				op := string(s[i])
+				if (s[i] == '<' || s[i] == '>') && i+1 < len(s) && s[i+1] == '=' {
+					op += "="
+				}
+				tokens = append(tokens, token{kind: tokOp, text: op, pos: i})
+				i += len(op)
+				afterOp = true
+			}
+		}
+	}
+	if afterOp {
+		return nil, Errorf(len(s), "expected a value")
+	}
+
+	return append(tokens, token{kind: tokEOF, pos: len(s)}), nil
+}
+
+// quoted reads the double quoted string opening at s[i] and returns its
+// text and the index after the closing quote.
+func quoted(s []rune, i int) (string, int, error) {
+	var b strings.Builder
+	for j := i + 1; j < len(s); j++ {
+		switch s[j] {
+		case '\\':
+			if j+1 < len(s) && (s[j+1] == '"' || s[j+1] == '\\') {
+				j++
+			}
+			b.WriteRune(s[j])
+		case '"':
+			return b.String(), j + 1, nil
+		default:
+			b.WriteRune(s[j])
+		}
+	}
+	return "", 0, Errorf(i, "unterminated quote")
+}
+
+func isOp(c rune) bool {
+	return c == ':' || c == '=' || c == '<' || c == '>'
+}
@@ -0,0 +1,209 @@
+package query
+
+import "strings"
+
+// Op compares a field with a value.
+type Op string
+
+const (
+	OpEq Op = ":" // = is read as :
+	OpLt Op = "<"
+	OpLe Op = "<="
+	OpGt Op = ">"
+	OpGe Op = ">="
+)
+
+// Node is a part of a parsed query: And, Or, Not or Term.
+type Node interface {
+	node()
+}
+
+// And matches when all of its nodes match.
+type And struct {
+	Nodes []Node
+}
+
+// Or matches when any of its nodes matches.
+type Or struct {
+	Nodes []Node
+}
+
+// Not matches when its node does not.
+type Not struct {
+	Node Node
+}
+
+// Term compares a field with a value. Text search terms have no field.
+type Term struct {
+	Field    string // lower case, empty for text search
+	Op       Op
+	Value    string
+	Quoted   bool
+	Pos      int // where the term starts
+	ValuePos int
+}
+
+func (And) node()  {}
+func (Or) node()   {}
+func (Not) node()  {}
+func (Term) node() {}
+
+// Parse reads a query. An empty query gives a nil node, which matches
+// everything.
+func Parse(src string) (Node, error) {
+	tokens, err := lex(src)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{tokens: tokens}
+	if p.peek().kind == tokEOF {
+		return nil, nil
+	}
+	node, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokEOF {
+		if t.kind == tokRParen {
+			return nil, Errorf(t.pos, "unexpected ')'")
+		}
+		return nil, Errorf(t.pos, "unexpected %q", t.text)
+	}
+	return node, nil
+}
+
+type parser struct {
+	tokens []token
+	i      int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.i]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.i]
+	if t.kind != tokEOF {
+		p.i++
+	}
+	return t
+}
+
+// keyword reports whether the next token is the given operator word. Only
+// the upper case spelling counts, so "or" can still be searched for.
+func (p *parser) keyword(word string) bool {
+	t := p.peek()
+	return t.kind == tokWord && t.text == word && (p.i+1 >= len(p.tokens) || p.tokens[p.i+1].kind != tokOp)
+}
+
+func (p *parser) or() (Node, error) {
+	first, err := p.and()
+	if err != nil {
+		return nil, err
+	}
+	nodes := []Node{first}
+	for p.keyword("OR") {
+		p.next()
+		node, err := p.and()
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, node)
+	}
+	if len(nodes) == 1 {
+		return first, nil
+	}
+	return Or{Nodes: nodes}, nil
+}
+
+func (p *parser) and() (Node, error) {
+	var nodes []Node
+	for {
+		t := p.peek()
+		if t.kind == tokEOF || t.kind == tokRParen || p.keyword("OR") {
+			break
+		}
+		if p.keyword("AND") {
+			p.next()
+			if len(nodes) == 0 {
+				return nil, Errorf(t.pos, `expected a term before "AND"`)
+			}
+		}
+		node, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, node)
+	}
+
+	switch len(nodes) {
+	case 0:
+		t := p.peek()
+		if t.kind == tokEOF {
+			return nil, Errorf(t.pos, "expected a term at the end")
+		}
+		return nil, Errorf(t.pos, "expected a term before %q", t.text)
+	case 1:
+		return nodes[0], nil
+	}
+	return And{Nodes: nodes}, nil
+}
+
+func (p *parser) unary() (Node, error) {
+	if t := p.peek(); t.kind == tokMinus || p.keyword("NOT") {
+		p.next()
+		if next := p.peek(); next.kind == tokEOF || next.kind == tokRParen || (t.kind == tokMinus && next.pos != t.pos+1) {
+			return nil, Errorf(t.pos, "expected a term after %q", t.text)
+		}
+		node, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return Not{Node: node}, nil
+	}
+	return p.primary()
+}
+
+func (p *parser) primary() (Node, error) {
+	t := p.next()
+	switch t.kind {
+	case tokLParen:
+		if p.peek().kind == tokRParen {
+			return nil, Errorf(t.pos, "empty parentheses")
+		}
+		node, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		if p.peek().kind != tokRParen {
+			return nil, Errorf(t.pos, "unclosed '('")
+		}
+		p.next()
+		return node, nil
+	case tokString:
+		return Term{Op: OpEq, Value: t.text, Quoted: true, Pos: t.pos, ValuePos: t.pos}, nil
+	case tokWord:
+		if p.peek().kind != tokOp {
+			return Term{Op: OpEq, Value: t.text, Pos: t.pos, ValuePos: t.pos}, nil
+		}
+		op := p.next()
+		value := p.next()
+		term := Term{
+			Field:    strings.ToLower(t.text),
+			Op:       Op(op.text),
+			Value:    value.text,
+			Quoted:   value.kind == tokString,
+			Pos:      t.pos,
+			ValuePos: value.pos,
+		}
+		if term.Op == "=" {
+			term.Op = OpEq
+		}
+		return term, nil
+	}
+	if t.kind == tokEOF {
+		return nil, Errorf(t.pos, "unexpected end of query")
+	}
+	return nil, Errorf(t.pos, "unexpected %q", t.text)
+}
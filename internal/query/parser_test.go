@@ -0,0 +1,121 @@
+package query
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want Node
+	}{
+		{
+			name: "Empty",
+			src:  "  ",
+			want: nil,
+		},
+		{
+			name: "Single term",
+			src:  "status:open",
+			want: Term{Field: "status", Op: OpEq, Value: "open", Pos: 0, ValuePos: 7},
+		},
+		{
+			name: "Implicit and with comparisons",
+			src:  "Tag=work due<7d priority>=2",
+			want: And{Nodes: []Node{
+				Term{Field: "tag", Op: OpEq, Value: "work", Pos: 0, ValuePos: 4},
+				Term{Field: "due", Op: OpLt, Value: "7d", Pos: 9, ValuePos: 13},
+				Term{Field: "priority", Op: OpGe, Value: "2", Pos: 16, ValuePos: 26},
+			}},
+		},
+		{
+			name: "OR binds looser than AND",
+			src:  "a b OR c AND d",
+			want: Or{Nodes: []Node{
+				And{Nodes: []Node{Term{Op: OpEq, Value: "a", Pos: 0, ValuePos: 0}, Term{Op: OpEq, Value: "b", Pos: 2, ValuePos: 2}}},
+				And{Nodes: []Node{Term{Op: OpEq, Value: "c", Pos: 7, ValuePos: 7}, Term{Op: OpEq, Value: "d", Pos: 13, ValuePos: 13}}},
+			}},
+		},
+		{
+			name: "Groups and negation",
+			src:  `(priority:high OR assignee:me) -tag:someday NOT due:none`,
+			want: And{Nodes: []Node{
+				Or{Nodes: []Node{
+					Term{Field: "priority", Op: OpEq, Value: "high", Pos: 1, ValuePos: 10},
+					Term{Field: "assignee", Op: OpEq, Value: "me", Pos: 18, ValuePos: 27},
+				}},
+				Not{Node: Term{Field: "tag", Op: OpEq, Value: "someday", Pos: 32, ValuePos: 36}},
+				Not{Node: Term{Field: "due", Op: OpEq, Value: "none", Pos: 48, ValuePos: 52}},
+			}},
+		},
+		{
+			name: "Quoted values and text",
+			src:  `tag:"long \"name\"" "pay rent" or`,
+			want: And{Nodes: []Node{
+				Term{Field: "tag", Op: OpEq, Value: `long "name"`, Quoted: true, Pos: 0, ValuePos: 4},
+				Term{Op: OpEq, Value: "pay rent", Quoted: true, Pos: 20, ValuePos: 20},
+				Term{Op: OpEq, Value: "or", Pos: 31, ValuePos: 31},
+			}},
+		},
+		{
+			name: "Values keep dashes and colons",
+			src:  "due>-3d title:a:b e-mail",
+			want: And{Nodes: []Node{
+				Term{Field: "due", Op: OpGt, Value: "-3d", Pos: 0, ValuePos: 4},
+				Term{Field: "title", Op: OpEq, Value: "a:b", Pos: 8, ValuePos: 14},
+				Term{Op: OpEq, Value: "e-mail", Pos: 18, ValuePos: 18},
+			}},
+		},
+		{
+			name: "Positions count characters",
+			src:  "ünïcode tag:x",
+			want: And{Nodes: []Node{
+				Term{Op: OpEq, Value: "ünïcode", Pos: 0, ValuePos: 0},
+				Term{Field: "tag", Op: OpEq, Value: "x", Pos: 8, ValuePos: 12},
+			}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.src)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parse() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		src  string
+		want string
+	}{
+		{src: "tag:", want: "expected a value at column 5"},
+		{src: "tag: work", want: "expected a value at column 5"},
+		{src: "(a OR b", want: "unclosed '(' at column 1"},
+		{src: "a b)", want: "unexpected ')' at column 4"},
+		{src: "()", want: "empty parentheses at column 1"},
+		{src: "a OR", want: "expected a term at the end at column 5"},
+		{src: "OR a", want: `expected a term before "OR" at column 1`},
+		{src: "a AND", want: "unexpected end of query at column 6"},
+		{src: "- a", want: `expected a term after "-" at column 1`},
+		{src: "a NOT", want: `expected a term after "NOT" at column 3`},
+		{src: `tag:"work`, want: "unterminated quote at column 5"},
+		{src: ":open", want: `expected a field before ":" at column 1`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.src, func(t *testing.T) {
+			_, err := Parse(tt.src)
+			if err == nil || err.Error() != tt.want {
+				t.Errorf("Parse(%q) error = %v, want %q", tt.src, err, tt.want)
+			}
+		})
+	}
+}
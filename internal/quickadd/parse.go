@@ -0,0 +1,767 @@
+// Package quickadd turns a single line such as
+// "Pay rent every month on the 1st #finance !high" into the parts of a todo.
+package quickadd
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"todo-api/internal/recurrence"
+)
+
+// Kinds of token recognized in quick-add text
+const (
+	KindDate       = "date"
+	KindTime       = "time"
+	KindRecurrence = "recurrence"
+	KindPriority   = "priority"
+	KindTag        = "tag"
+	KindProject    = "project"
+)
+
+// Result is what Parse found in a line of text. Anything that was not
+// recognized is left in the title.
+// @Description Quick-add parse result
+type Result struct {
+	Title      string     `json:"title" example:"Pay rent"`
+	DueDate    *time.Time `json:"due_date" example:"2026-11-01T00:00:00Z"`
+	Recurrence string     `json:"recurrence" example:"FREQ=MONTHLY;BYMONTHDAY=1"`
+	Priority   int        `json:"priority" example:"3"`
+	Tags       []string   `json:"tags" example:"finance"`
+	Project    string     `json:"project" example:"Home"`
+	Tokens     []Token    `json:"tokens"`
+}
+
+// Token is a recognized piece of the text. Start and End count characters,
+// so clients can highlight what was understood.
+// @Description Recognized part of quick-add text
+type Token struct {
+	Kind  string `json:"kind" example:"recurrence"`
+	Text  string `json:"text" example:"every month on the 1st"`
+	Start int    `json:"start" example:"9"`
+	End   int    `json:"end" example:"31"`
+}
+
+type word struct {
+	text       string
+	norm       string
+	start, end int
+}
+
+type parser struct {
+	now   time.Time
+	words []word
+
+	result       Result
+	date         *time.Time     // midnight of the day the todo is due
+	clock        *time.Duration // time of day the todo is due
+	defaultClock *time.Duration // time of day implied by words such as "tonight"
+	exact        *time.Time     // due time given relative to now, such as "in 2 hours"
+	rule         *recurrence.Rule
+}
+
+// Parse extracts the due date, recurrence, priority, tags and project from
+// text. Dates are worked out relative to now, in now's location. Only the
+// first date, time, recurrence, priority and project are used; repeats stay
+// in the title, as do words escaped with a backslash.
+func Parse(text string, now time.Time) Result {
+	p := &parser{now: now, words: splitWords(text)}
+
+	var title []string
+	for i := 0; i < len(p.words); {
+		// A leading backslash keeps a word such as \daily in the title
+		if text := p.words[i].text; strings.HasPrefix(text, `\`) && len(text) > 1 {
+			title = append(title, text[1:])
+			i++
+			continue
+		}
+
+		n := 0
+		for _, match := range []func(int) int{p.matchTag, p.matchProject, p.matchPriority, p.matchRecurrence, p.matchDate, p.matchClock} {
+			if n = match(i); n > 0 {
+				break
+			}
+		}
+		if n == 0 {
+			title = append(title, p.words[i].text)
+			n = 1
+		}
+		i += n
+	}
+
+	p.result.Title = strings.Join(title, " ")
+	p.result.DueDate = p.due()
+	if p.rule != nil {
+		p.result.Recurrence = p.rule.String()
+	}
+	return p.result
+}
+
+// due combines the date, time and recurrence found into a due date.
+func (p *parser) due() *time.Time {
+	if p.exact != nil {
+		return p.exact
+	}
+	if p.date == nil && p.clock == nil && p.defaultClock == nil && p.rule == nil {
+		return nil
+	}
+
+	day := startOfDay(p.now)
+	if p.date != nil {
+		day = *p.date
+	}
+	clock := p.clock
+	if clock == nil {
+		clock = p.defaultClock
+	}
+	due := day
+	if clock != nil {
+		due = time.Date(day.Year(), day.Month(), day.Day(), int(*clock/time.Hour), int(*clock%time.Hour/time.Minute), 0, 0, day.Location())
+	}
+
+	switch {
+	case p.date != nil:
+	case p.rule != nil:
+		// The first occurrence of the series that is still ahead
+		after := due.Add(-time.Nanosecond)
+		if clock != nil && after.Before(p.now) {
+			after = p.now
+		}
+		if next, ok := p.rule.Next(due, after); ok {
+			due = next
+		}
+	case due.Before(p.now):
+		// A time that has already passed today means tomorrow
+		due = due.AddDate(0, 0, 1)
+	}
+	return &due
+}
+
+func (p *parser) record(kind string, from, to int) {
+	p.result.Tokens = append(p.result.Tokens, Token{
+		Kind:  kind,
+		Text:  p.span(from, to),
+		Start: p.words[from].start,
+		End:   p.words[to-1].end,
+	})
+}
+
+// span returns the words from..to-1 joined with single spaces.
+func (p *parser) span(from, to int) string {
+	parts := make([]string, 0, to-from)
+	for _, w := range p.words[from:to] {
+		parts = append(parts, w.text)
+	}
+	return strings.Join(parts, " ")
+}
+
+// norm returns the normalized word at i, or "" past the end of the text.
+func (p *parser) norm(i int) string {
+	if i < 0 || i >= len(p.words) {
+		return ""
+	}
+	return p.words[i].norm
+}
+
+func (p *parser) matchTag(i int) int {
+	name, ok := reference(p.words[i].text, '#')
+	if !ok {
+		return 0
+	}
+	for _, tag := range p.result.Tags {
+		if strings.EqualFold(tag, name) {
+			p.record(KindTag, i, i+1)
+			return 1
+		}
+	}
+	p.result.Tags = append(p.result.Tags, name)
+	p.record(KindTag, i, i+1)
+	return 1
+}
+
+func (p *parser) matchProject(i int) int {
+	name, ok := reference(p.words[i].text, '+')
+	if !ok || p.result.Project != "" {
+		return 0
+	}
+	p.result.Project = name
+	p.record(KindProject, i, i+1)
+	return 1
+}
+
+var priorities = map[string]int{
+	"!low": 1, "!l": 1,
+	"!medium": 2, "!med": 2, "!m": 2, "!!": 2,
+	"!high": 3, "!h": 3, "!!!": 3,
+}
+
+func (p *parser) matchPriority(i int) int {
+	level, ok := priorities[p.norm(i)]
+	if !ok || p.result.Priority != 0 {
+		return 0
+	}
+	p.result.Priority = level
+	p.record(KindPriority, i, i+1)
+	return 1
+}
+
+var frequencies = map[string]recurrence.Frequency{
+	"daily":    recurrence.Daily,
+	"weekly":   recurrence.Weekly,
+	"monthly":  recurrence.Monthly,
+	"yearly":   recurrence.Yearly,
+	"annually": recurrence.Yearly,
+}
+
+var units = map[string]recurrence.Frequency{
+	"day":   recurrence.Daily,
+	"week":  recurrence.Weekly,
+	"month": recurrence.Monthly,
+	"year":  recurrence.Yearly,
+}
+
+// matchRecurrence recognizes "daily", "every 2 weeks", "every other day",
+// "every weekday", "every mon and thu", "every week on friday" and
+// "every month on the 1st and 15th".
+func (p *parser) matchRecurrence(i int) int {
+	if p.rule != nil {
+		return 0
+	}
+
+	var rule string
+	j := i + 1
+	if freq, ok := frequencies[p.norm(i)]; ok {
+		rule = "FREQ=" + string(freq)
+	} else if p.norm(i) == "every" {
+		rule, j = p.every(j)
+		if rule == "" {
+			return 0
+		}
+	} else {
+		return 0
+	}
+
+	parsed, err := recurrence.Parse(rule)
+	if err != nil {
+		return 0
+	}
+	p.rule = parsed
+	p.record(KindRecurrence, i, j)
+	return j - i
+}
+
+// every parses what follows "every" into an RRULE, returning the index of
+// the first word after it.
+func (p *parser) every(j int) (string, int) {
+	interval := 1
+	switch n, ok := number(p.norm(j)); {
+	case p.norm(j) == "other":
+		interval = 2
+		j++
+	case ok && n > 0:
+		interval = n
+		j++
+	}
+
+	switch p.norm(j) {
+	case "weekday", "weekdays":
+		if interval == 1 {
+			return "FREQ=WEEKLY;BYDAY=MO,TU,WE,TH,FR", j + 1
+		}
+	case "weekend", "weekends":
+		if interval == 1 {
+			return "FREQ=WEEKLY;BYDAY=SA,SU", j + 1
+		}
+	}
+
+	if days, n := p.weekdays(j, true); n > 0 {
+		return rruleOf(recurrence.Weekly, interval, "BYDAY="+days), j + n
+	}
+
+	freq, ok := units[strings.TrimSuffix(p.norm(j), "s")]
+	if !ok {
+		return "", j
+	}
+	j++
+
+	if p.norm(j) == "on" {
+		switch freq {
+		case recurrence.Weekly:
+			if days, n := p.weekdays(j+1, true); n > 0 {
+				return rruleOf(freq, interval, "BYDAY="+days), j + 1 + n
+			}
+		case recurrence.Monthly:
+			if days, n := p.monthDays(j + 1); n > 0 {
+				return rruleOf(freq, interval, "BYMONTHDAY="+days), j + 1 + n
+			}
+		}
+	}
+	return rruleOf(freq, interval, ""), j
+}
+
+func rruleOf(freq recurrence.Frequency, interval int, by string) string {
+	rule := "FREQ=" + string(freq)
+	if interval > 1 {
+		rule += ";INTERVAL=" + strconv.Itoa(interval)
+	}
+	if by != "" {
+		rule += ";" + by
+	}
+	return rule
+}
+
+// weekdays parses a list such as "mon, wed and fri" into BYDAY values.
+func (p *parser) weekdays(j int, abbreviated bool) (string, int) {
+	var days []string
+	n := 0
+	for {
+		day, ok := weekdayOf(strings.TrimSuffix(p.norm(j+n), "s"), abbreviated)
+		if !ok {
+			day, ok = weekdayOf(p.norm(j+n), abbreviated)
+		}
+		if !ok {
+			break
+		}
+		days = append(days, strings.ToUpper(day.String()[:2]))
+		n++
+		if p.norm(j+n) == "and" {
+			if _, ok := weekdayOf(strings.TrimSuffix(p.norm(j+n+1), "s"), abbreviated); ok {
+				n++
+			}
+		}
+	}
+	return strings.Join(days, ","), n
+}
+
+// monthDays parses "the 1st and the 15th" or "the last day" into
+// BYMONTHDAY values.
+func (p *parser) monthDays(j int) (string, int) {
+	var days []string
+	n := 0
+	for {
+		k := j + n
+		if p.norm(k) == "the" {
+			k++
+		}
+		if p.norm(k) == "last" && p.norm(k+1) == "day" {
+			days = append(days, "-1")
+			k += 2
+		} else if day, ok := ordinal(p.norm(k)); ok {
+			days = append(days, strconv.Itoa(day))
+			k++
+		} else {
+			break
+		}
+		n = k - j
+		if p.norm(j+n) != "and" {
+			break
+		}
+		n++
+	}
+	if len(days) == 0 {
+		return "", 0
+	}
+	if p.norm(j+n-1) == "and" {
+		n--
+	}
+	return strings.Join(days, ","), n
+}
+
+var connectors = map[string]bool{"on": true, "by": true, "due": true}
+
+// matchDate recognizes a due day, optionally introduced by "on", "by" or
+// "due".
+func (p *parser) matchDate(i int) int {
+	if p.date != nil || p.exact != nil {
+		return 0
+	}
+
+	j := i
+	for connectors[p.norm(j)] {
+		j++
+	}
+	n := p.dateAt(j, j > i)
+	if n == 0 {
+		return 0
+	}
+	p.record(KindDate, i, j+n)
+	return j + n - i
+}
+
+var relativeDays = map[string]int{"today": 0, "tod": 0, "tonight": 0, "tomorrow": 1, "tmr": 1, "tmrw": 1}
+
+var isoDate = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}$`)
+
+// dateAt parses the date starting at word j, returning how many words it
+// used. Abbreviated weekdays are only accepted after a connector, so that
+// words such as "sat" are not mistaken for dates.
+func (p *parser) dateAt(j int, connected bool) int {
+	today := startOfDay(p.now)
+	w := p.norm(j)
+
+	if days, ok := relativeDays[w]; ok {
+		p.setDate(today.AddDate(0, 0, days))
+		if w == "tonight" {
+			evening := 20 * time.Hour
+			p.defaultClock = &evening
+		}
+		return 1
+	}
+
+	if isoDate.MatchString(w) {
+		date, err := time.ParseInLocation("2006-01-02", w, p.now.Location())
+		if err != nil {
+			return 0
+		}
+		p.setDate(date)
+		return 1
+	}
+
+	switch w {
+	case "next", "this":
+		switch p.norm(j + 1) {
+		case "week":
+			if w == "this" {
+				return 0
+			}
+			days := (int(time.Monday) - int(today.Weekday()) + 7) % 7
+			if days == 0 {
+				days = 7
+			}
+			p.setDate(today.AddDate(0, 0, days))
+			return 2
+		case "month":
+			if w == "this" {
+				return 0
+			}
+			p.setDate(time.Date(today.Year(), today.Month()+1, 1, 0, 0, 0, 0, today.Location()))
+			return 2
+		case "year":
+			if w == "this" {
+				return 0
+			}
+			p.setDate(time.Date(today.Year()+1, time.January, 1, 0, 0, 0, 0, today.Location()))
+			return 2
+		}
+		day, ok := weekdayOf(p.norm(j+1), true)
+		if !ok {
+			return 0
+		}
+		days := (int(day) - int(today.Weekday()) + 7) % 7
+		if w == "next" && days == 0 {
+			days = 7
+		}
+		p.setDate(today.AddDate(0, 0, days))
+		return 2
+	case "in":
+		return p.relative(j + 1)
+	case "the":
+		day, ok := ordinal(p.norm(j + 1))
+		if !ok {
+			return 0
+		}
+		date, ok := nextMonthDay(today, day)
+		if !ok {
+			return 0
+		}
+		p.setDate(date)
+		return 2
+	}
+
+	if day, ok := weekdayOf(w, connected); ok {
+		p.setDate(today.AddDate(0, 0, (int(day)-int(today.Weekday())+7)%7))
+		return 1
+	}
+
+	return p.calendarDate(j)
+}
+
+// relative parses "2 days", "a week" or "an hour" after "in".
+func (p *parser) relative(j int) int {
+	n, ok := number(p.norm(j))
+	if !ok || n <= 0 {
+		return 0
+	}
+
+	today := startOfDay(p.now)
+	switch strings.TrimSuffix(p.norm(j+1), "s") {
+	case "minute", "min":
+		exact := p.now.Add(time.Duration(n) * time.Minute)
+		p.exact = &exact
+	case "hour", "hr", "h":
+		exact := p.now.Add(time.Duration(n) * time.Hour)
+		p.exact = &exact
+	case "day":
+		p.setDate(today.AddDate(0, 0, n))
+	case "week":
+		p.setDate(today.AddDate(0, 0, 7*n))
+	case "month":
+		p.setDate(today.AddDate(0, n, 0))
+	case "year":
+		p.setDate(today.AddDate(n, 0, 0))
+	default:
+		return 0
+	}
+	return 3
+}
+
+// calendarDate parses "jan 31", "31 jan", "january 31st" and the same with
+// a year, such as "jan 31 2027". Dates without a year are the next such day.
+func (p *parser) calendarDate(j int) int {
+	var month time.Month
+	var day int
+	if m, ok := monthOf(p.norm(j)); ok {
+		d, ok := dayOfMonth(p.norm(j + 1))
+		if !ok {
+			return 0
+		}
+		month, day = m, d
+	} else if d, ok := dayOfMonth(p.norm(j)); ok {
+		m, ok := monthOf(p.norm(j + 1))
+		if !ok {
+			return 0
+		}
+		month, day = m, d
+	} else {
+		return 0
+	}
+
+	today := startOfDay(p.now)
+	if year, err := strconv.Atoi(p.norm(j + 2)); err == nil && len(p.norm(j+2)) == 4 {
+		date := time.Date(year, month, day, 0, 0, 0, 0, today.Location())
+		if date.Day() != day {
+			return 0
+		}
+		p.setDate(date)
+		return 3
+	}
+
+	for year := today.Year(); year <= today.Year()+4; year++ {
+		date := time.Date(year, month, day, 0, 0, 0, 0, today.Location())
+		if date.Day() == day && !date.Before(today) {
+			p.setDate(date)
+			return 2
+		}
+	}
+	return 0
+}
+
+func (p *parser) setDate(date time.Time) {
+	p.date = &date
+}
+
+var clockPattern = regexp.MustCompile(`^(\d{1,2})(?::(\d{2}))?(am|pm|a|p)?$`)
+
+// matchClock recognizes a time of day such as "at 5pm", "9:30 am", "17:00"
+// or "noon". A bare hour is only a time after "at".
+func (p *parser) matchClock(i int) int {
+	if p.clock != nil || p.exact != nil {
+		return 0
+	}
+
+	j := i
+	if p.norm(j) == "at" || p.norm(j) == "@" {
+		j++
+	}
+	w := p.norm(j)
+
+	var clock time.Duration
+	n := 1
+	switch w {
+	case "noon", "midday":
+		clock = 12 * time.Hour
+	case "midnight":
+		clock = 0
+	default:
+		match := clockPattern.FindStringSubmatch(w)
+		if match == nil {
+			return 0
+		}
+		meridiem := match[3]
+		if meridiem == "" && (p.norm(j+1) == "am" || p.norm(j+1) == "pm") {
+			meridiem = p.norm(j + 1)
+			n++
+		}
+		if meridiem == "" && match[2] == "" && j == i {
+			return 0
+		}
+
+		hour, _ := strconv.Atoi(match[1])
+		minute := 0
+		if match[2] != "" {
+			minute, _ = strconv.Atoi(match[2])
+		}
+		if minute > 59 {
+			return 0
+		}
+		switch {
+		case meridiem == "":
+			if hour > 23 {
+				return 0
+			}
+		case hour < 1 || hour > 12:
+			return 0
+		case strings.HasPrefix(meridiem, "p"):
+			hour = hour%12 + 12
+		default:
+			hour %= 12
+		}
+		clock = time.Duration(hour)*time.Hour + time.Duration(minute)*time.Minute
+	}
+
+	p.clock = &clock
+	p.record(KindTime, i, j+n)
+	return j + n - i
+}
+
+// splitWords breaks text into whitespace separated words. A reference such
+// as #"Long tag" or +"Side project" stays one word up to its closing quote.
+func splitWords(text string) []word {
+	runes := []rune(text)
+	var words []word
+	for i := 0; i < len(runes); {
+		if unicode.IsSpace(runes[i]) {
+			i++
+			continue
+		}
+
+		start := i
+		if (runes[i] == '#' || runes[i] == '+') && i+1 < len(runes) && runes[i+1] == '"' {
+			if end := indexRune(runes, '"', i+2); end > 0 {
+				i = end + 1
+			}
+		}
+		for i < len(runes) && !unicode.IsSpace(runes[i]) {
+			i++
+		}
+
+		text := string(runes[start:i])
+		words = append(words, word{
+			text:  text,
+			norm:  strings.TrimRight(strings.ToLower(text), ",.;"),
+			start: start,
+			end:   i,
+		})
+	}
+	return words
+}
+
+func indexRune(runes []rune, r rune, from int) int {
+	for i := from; i < len(runes); i++ {
+		if runes[i] == r {
+			return i
+		}
+	}
+	return -1
+}
+
+// reference returns the name in a word such as #finance, +Home or
+// +"Side project". Names must start with a letter, so "#1" is left alone.
+func reference(text string, prefix byte) (string, bool) {
+	if len(text) < 2 || text[0] != prefix {
+		return "", false
+	}
+	name := text[1:]
+	if strings.HasPrefix(name, `"`) {
+		if len(name) < 3 || !strings.HasSuffix(name, `"`) {
+			return "", false
+		}
+		name = strings.TrimSpace(name[1 : len(name)-1])
+		return name, name != ""
+	}
+
+	name = strings.TrimRight(name, ",.;:!?")
+	for i, r := range name {
+		if i == 0 && !unicode.IsLetter(r) {
+			return "", false
+		}
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '-' && r != '_' && r != '/' {
+			return "", false
+		}
+	}
+	return name, name != ""
+}
+
+var weekdayNames = map[string]time.Weekday{
+	"sunday": time.Sunday, "monday": time.Monday, "tuesday": time.Tuesday, "wednesday": time.Wednesday,
+	"thursday": time.Thursday, "friday": time.Friday, "saturday": time.Saturday,
+}
+
+var weekdayAbbreviations = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "tues": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "thur": time.Thursday, "thurs": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+func weekdayOf(w string, abbreviated bool) (time.Weekday, bool) {
+	if day, ok := weekdayNames[w]; ok {
+		return day, true
+	}
+	day, ok := weekdayAbbreviations[w]
+	return day, ok && abbreviated
+}
+
+func monthOf(w string) (time.Month, bool) {
+	if w == "sept" {
+		return time.September, true
+	}
+	for m := time.January; m <= time.December; m++ {
+		name := strings.ToLower(m.String())
+		if w == name || w == name[:3] {
+			return m, true
+		}
+	}
+	return 0, false
+}
+
+var ordinalPattern = regexp.MustCompile(`^(\d{1,2})(st|nd|rd|th)$`)
+
+// ordinal parses a day of the month written as 1st, 2nd or 15th.
+func ordinal(w string) (int, bool) {
+	match := ordinalPattern.FindStringSubmatch(w)
+	if match == nil {
+		return 0, false
+	}
+	day, _ := strconv.Atoi(match[1])
+	return day, day >= 1 && day <= 31
+}
+
+// dayOfMonth parses a day of the month with or without an ordinal suffix.
+func dayOfMonth(w string) (int, bool) {
+	if day, ok := ordinal(w); ok {
+		return day, true
+	}
+	day, err := strconv.Atoi(w)
+	return day, err == nil && len(w) <= 2 && day >= 1 && day <= 31
+}
+
+var numberWords = map[string]int{
+	"a": 1, "an": 1, "one": 1, "two": 2, "three": 3, "four": 4, "five": 5, "six": 6,
+	"seven": 7, "eight": 8, "nine": 9, "ten": 10, "eleven": 11, "twelve": 12,
+}
+
+func number(w string) (int, bool) {
+	if n, ok := numberWords[w]; ok {
+		return n, true
+	}
+	n, err := strconv.Atoi(w)
+	return n, err == nil && n <= 1000
+}
+
+// nextMonthDay returns the next date on or after today that falls on the
+// given day of the month.
+func nextMonthDay(today time.Time, day int) (time.Time, bool) {
+	for months := 0; months < 12; months++ {
+		date := time.Date(today.Year(), today.Month()+time.Month(months), day, 0, 0, 0, 0, today.Location())
+		if date.Day() == day && !date.Before(today) {
+			return date, true
+		}
+	}
+	return time.Time{}, false
+}
+
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
@@ -0,0 +1,178 @@
+package quickadd
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	ny, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatal(err)
+	}
+	at := func(m time.Month, d, h, min int) *time.Time {
+		year := 2026
+		if m < time.October {
+			year = 2027
+		}
+		due := time.Date(year, m, d, h, min, 0, 0, ny)
+		return &due
+	}
+	// A Wednesday morning, shortly before daylight saving time ends
+	now := time.Date(2026, 10, 21, 10, 30, 0, 0, ny)
+
+	tests := []struct {
+		text       string
+		title      string
+		due        *time.Time
+		recurrence string
+		priority   int
+		tags       []string
+		project    string
+	}{
+		{
+			text:       "Pay rent every month on the 1st #finance !high",
+			title:      "Pay rent",
+			due:        at(time.November, 1, 0, 0),
+			recurrence: "FREQ=MONTHLY;BYMONTHDAY=1",
+			priority:   3,
+			tags:       []string{"finance"},
+		},
+		{
+			text:  "Call mom tomorrow at 5pm",
+			title: "Call mom",
+			due:   at(time.October, 22, 17, 0),
+		},
+		{
+			text:  "Submit report by Friday.",
+			title: "Submit report",
+			due:   at(time.October, 23, 0, 0),
+		},
+		{
+			text:    "Dentist next wed 9:30 am +Health",
+			title:   "Dentist",
+			due:     at(time.October, 28, 9, 30),
+			project: "Health",
+		},
+		{
+			text:       "Standup every weekday at 9am",
+			title:      "Standup",
+			due:        at(time.October, 22, 9, 0),
+			recurrence: "FREQ=WEEKLY;BYDAY=MO,TU,WE,TH,FR",
+		},
+		{
+			text:       "Gym every mon, wed and fri at 6pm",
+			title:      "Gym",
+			due:        at(time.October, 21, 18, 0),
+			recurrence: "FREQ=WEEKLY;BYDAY=MO,WE,FR",
+		},
+		{
+			text:       "Water plants every other day",
+			title:      "Water plants",
+			due:        at(time.October, 21, 0, 0),
+			recurrence: "FREQ=DAILY;INTERVAL=2",
+		},
+		{
+			text:       "Backup weekly on sunday",
+			title:      "Backup",
+			due:        at(time.October, 25, 0, 0),
+			recurrence: "FREQ=WEEKLY",
+		},
+		{
+			text:     "Renew passport jan 15 !!",
+			title:    "Renew passport",
+			due:      at(time.January, 15, 0, 0),
+			priority: 2,
+		},
+		{
+			text:  `File taxes due on 2027-04-15 #"Tax stuff" +"Side project"`,
+			title: "File taxes",
+			due:   at(time.April, 15, 0, 0),
+			tags:  []string{"Tax stuff"},
+		},
+		{
+			text:  "Review in 2 weeks #Work #work",
+			title: "Review",
+			due:   at(time.November, 4, 0, 0),
+			tags:  []string{"Work"},
+		},
+		{
+			text:  "Check the oven in 20 minutes",
+			title: "Check the oven",
+			due:   at(time.October, 21, 10, 50),
+		},
+		{
+			text:  "Lunch at noon",
+			title: "Lunch",
+			due:   at(time.October, 21, 12, 0),
+		},
+		{
+			text:  "Alarm at 7",
+			title: "Alarm",
+			due:   at(time.October, 22, 7, 0),
+		},
+		{
+			text:  "Movie tonight",
+			title: "Movie",
+			due:   at(time.October, 21, 20, 0),
+		},
+		{
+			text:  "Invoice on the 15th",
+			title: "Invoice",
+			due:   at(time.November, 15, 0, 0),
+		},
+		{
+			text:  "Buy 2 apples at the store sat",
+			title: "Buy 2 apples at the store sat",
+		},
+		{
+			text:  "Plan feb 30 party for issue #1 on +1",
+			title: "Plan feb 30 party for issue #1 on +1",
+		},
+		{
+			text:  `\daily standup notes every`,
+			title: "daily standup notes every",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			got := Parse(tt.text, now)
+			if got.Title != tt.title {
+				t.Errorf("Title = %q, want %q", got.Title, tt.title)
+			}
+			switch {
+			case tt.due == nil && got.DueDate != nil:
+				t.Errorf("DueDate = %v, want none", got.DueDate)
+			case tt.due != nil && (got.DueDate == nil || !got.DueDate.Equal(*tt.due)):
+				t.Errorf("DueDate = %v, want %v", got.DueDate, tt.due)
+			}
+			if got.Recurrence != tt.recurrence {
+				t.Errorf("Recurrence = %q, want %q", got.Recurrence, tt.recurrence)
+			}
+			if got.Priority != tt.priority {
+				t.Errorf("Priority = %d, want %d", got.Priority, tt.priority)
+			}
+			if !reflect.DeepEqual(got.Tags, tt.tags) {
+				t.Errorf("Tags = %v, want %v", got.Tags, tt.tags)
+			}
+			if tt.project != "" && got.Project != tt.project {
+				t.Errorf("Project = %q, want %q", got.Project, tt.project)
+			}
+		})
+	}
+}
+
+func TestParseTokens(t *testing.T) {
+	now := time.Date(2026, 10, 21, 10, 30, 0, 0, time.UTC)
+	got := Parse("Café with Zoë every month on the 1st !high", now)
+
+	want := []Token{
+		{Kind: KindRecurrence, Text: "every month on the 1st", Start: 14, End: 36},
+		{Kind: KindPriority, Text: "!high", Start: 37, End: 42},
+	}
+	if !reflect.DeepEqual(got.Tokens, want) {
+		t.Errorf("Tokens = %+v, want %+v", got.Tokens, want)
+	}
+}
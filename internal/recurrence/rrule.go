@@ -0,0 +1,438 @@
+// Package recurrence implements the subset of RFC 5545 recurrence rules
+// used by recurring todos.
+package recurrence
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Frequency string
+
+const (
+	Daily   Frequency = "DAILY"
+	Weekly  Frequency = "WEEKLY"
+	Monthly Frequency = "MONTHLY"
+	Yearly  Frequency = "YEARLY"
+)
+
+// maxPeriods bounds the search for the next occurrence so that rules which
+// can never match (such as February 30th) do not loop forever.
+const maxPeriods = 1000
+
+// WeekdayNum is a BYDAY entry such as MO, 1FR or -1SU. N is zero when the
+// entry applies to every matching weekday in the period.
+type WeekdayNum struct {
+	N   int
+	Day time.Weekday
+}
+
+// Rule is a parsed RRULE.
+type Rule struct {
+	Freq       Frequency
+	Interval   int
+	Count      int
+	Until      *time.Time
+	ByDay      []WeekdayNum
+	ByMonthDay []int
+	ByMonth    []time.Month
+	WeekStart  time.Weekday
+}
+
+var weekdays = map[string]time.Weekday{
+	"SU": time.Sunday,
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+}
+
+// Parse parses an RRULE value such as "FREQ=MONTHLY;BYMONTHDAY=1". A leading
+// "RRULE:" is accepted.
+func Parse(value string) (*Rule, error) {
+	value = strings.TrimPrefix(strings.TrimSpace(value), "RRULE:")
+	if value == "" {
+		return nil, fmt.Errorf("empty recurrence rule")
+	}
+
+	rule := &Rule{Interval: 1, WeekStart: time.Monday}
+	for _, part := range strings.Split(value, ";") {
+		key, val, ok := strings.Cut(part, "=")
+		if !ok || val == "" {
+			return nil, fmt.Errorf("invalid rule part %q", part)
+		}
+
+		var err error
+		switch strings.ToUpper(key) {
+		case "FREQ":
+			rule.Freq = Frequency(strings.ToUpper(val))
+			switch rule.Freq {
+			case Daily, Weekly, Monthly, Yearly:
+			default:
+				err = fmt.Errorf("unsupported FREQ %q", val)
+			}
+		case "INTERVAL":
+			rule.Interval, err = positiveInt(val)
+		case "COUNT":
+			rule.Count, err = positiveInt(val)
+		case "UNTIL":
+			var until time.Time
+			until, err = parseUntil(val)
+			rule.Until = &until
+		case "BYDAY":
+			rule.ByDay, err = parseByDay(val)
+		case "BYMONTHDAY":
+			rule.ByMonthDay, err = parseIntList(val, -31, 31)
+		case "BYMONTH":
+			var months []int
+			months, err = parseIntList(val, 1, 12)
+			for _, m := range months {
+				rule.ByMonth = append(rule.ByMonth, time.Month(m))
+			}
+		case "WKST":
+			day, ok := weekdays[strings.ToUpper(val)]
+			if !ok {
+				err = fmt.Errorf("invalid WKST %q", val)
+			}
+			rule.WeekStart = day
+		default:
+			err = fmt.Errorf("unsupported rule part %q", key)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if rule.Freq == "" {
+		return nil, fmt.Errorf("FREQ is required")
+	}
+	if rule.Count > 0 && rule.Until != nil {
+		return nil, fmt.Errorf("COUNT and UNTIL cannot both be set")
+	}
+	for _, day := range rule.ByDay {
+		if day.N != 0 && rule.Freq != Monthly && rule.Freq != Yearly {
+			return nil, fmt.Errorf("numbered BYDAY is only allowed with MONTHLY or YEARLY")
+		}
+	}
+	if len(rule.ByMonthDay) > 0 && rule.Freq == Weekly {
+		return nil, fmt.Errorf("BYMONTHDAY is not allowed with WEEKLY")
+	}
+
+	return rule, nil
+}
+
+// String formats the rule back into RRULE syntax.
+func (r *Rule) String() string {
+	parts := []string{"FREQ=" + string(r.Freq)}
+	if r.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Interval))
+	}
+	if r.Count > 0 {
+		parts = append(parts, "COUNT="+strconv.Itoa(r.Count))
+	}
+	if r.Until != nil {
+		parts = append(parts, "UNTIL="+r.Until.UTC().Format("20060102T150405Z"))
+	}
+	if len(r.ByDay) > 0 {
+		days := make([]string, len(r.ByDay))
+		for i, day := range r.ByDay {
+			days[i] = strings.ToUpper(day.Day.String()[:2])
+			if day.N != 0 {
+				days[i] = strconv.Itoa(day.N) + days[i]
+			}
+		}
+		parts = append(parts, "BYDAY="+strings.Join(days, ","))
+	}
+	if len(r.ByMonthDay) > 0 {
+		parts = append(parts, "BYMONTHDAY="+joinInts(r.ByMonthDay))
+	}
+	if len(r.ByMonth) > 0 {
+		months := make([]int, len(r.ByMonth))
+		for i, m := range r.ByMonth {
+			months[i] = int(m)
+		}
+		parts = append(parts, "BYMONTH="+joinInts(months))
+	}
+	if r.WeekStart != time.Monday {
+		parts = append(parts, "WKST="+strings.ToUpper(r.WeekStart.String()[:2]))
+	}
+	return strings.Join(parts, ";")
+}
+
+// Next returns the first occurrence of a series starting at start that falls
+// strictly after the given time. The second result is false once the series
+// has no more occurrences. COUNT is not applied here because only the caller
+// knows how many occurrences have already happened.
+func (r *Rule) Next(start, after time.Time) (time.Time, bool) {
+	if after.Before(start) {
+		after = start.Add(-time.Nanosecond)
+	}
+
+	first := r.skipPeriods(start, after)
+	for k := first; k < first+maxPeriods*r.Interval; k += r.Interval {
+		for _, candidate := range r.candidates(start, k) {
+			if candidate.Before(start) || !candidate.After(after) {
+				continue
+			}
+			if r.Until != nil && candidate.After(*r.Until) {
+				return time.Time{}, false
+			}
+			return candidate, true
+		}
+	}
+
+	return time.Time{}, false
+}
+
+// skipPeriods returns the first period worth searching, so that a series
+// started long ago does not have to be walked from the beginning.
+func (r *Rule) skipPeriods(start, after time.Time) int {
+	var periods int
+	switch r.Freq {
+	case Daily:
+		periods = int(after.Sub(start).Hours() / 24)
+	case Weekly:
+		periods = int(after.Sub(start).Hours() / (24 * 7))
+	case Monthly:
+		periods = (after.Year()-start.Year())*12 + int(after.Month()-start.Month())
+	case Yearly:
+		periods = after.Year() - start.Year()
+	}
+
+	// Step back one period to be safe around DST changes and month ends
+	periods -= r.Interval
+	if periods < 0 {
+		return 0
+	}
+	return periods - periods%r.Interval
+}
+
+// candidates returns the sorted occurrences within the k-th period after start.
+func (r *Rule) candidates(start time.Time, k int) []time.Time {
+	var days []time.Time
+
+	switch r.Freq {
+	case Daily:
+		days = []time.Time{dateOf(start).AddDate(0, 0, k)}
+	case Weekly:
+		offset := (int(start.Weekday()) - int(r.WeekStart) + 7) % 7
+		weekStart := dateOf(start).AddDate(0, 0, 7*k-offset)
+		if len(r.ByDay) == 0 {
+			days = []time.Time{weekStart.AddDate(0, 0, offset)}
+		}
+		for _, day := range r.ByDay {
+			days = append(days, weekStart.AddDate(0, 0, (int(day.Day)-int(r.WeekStart)+7)%7))
+		}
+	case Monthly:
+		first := time.Date(start.Year(), start.Month()+time.Month(k), 1, 0, 0, 0, 0, start.Location())
+		days = r.expandMonth(first, start.Day())
+	case Yearly:
+		year := start.Year() + k
+		if len(r.ByMonth) == 0 && len(r.ByDay) > 0 && len(r.ByMonthDay) == 0 {
+			days = r.expandYearByDay(year, start.Location())
+			break
+		}
+		months := r.ByMonth
+		if len(months) == 0 {
+			months = []time.Month{start.Month()}
+		}
+		for _, month := range months {
+			days = append(days, r.expandMonth(time.Date(year, month, 1, 0, 0, 0, 0, start.Location()), start.Day())...)
+		}
+	}
+
+	var result []time.Time
+	for _, day := range days {
+		if !r.matches(day) {
+			continue
+		}
+		result = append(result, time.Date(day.Year(), day.Month(), day.Day(),
+			start.Hour(), start.Minute(), start.Second(), 0, start.Location()))
+	}
+
+	sort.Slice(result, func(i, j int) bool { return result[i].Before(result[j]) })
+	return result
+}
+
+// expandMonth lists the days of the month starting at first selected by
+// BYMONTHDAY or BYDAY, falling back to the series' day of month.
+func (r *Rule) expandMonth(first time.Time, defaultDay int) []time.Time {
+	length := daysIn(first)
+	var days []time.Time
+
+	switch {
+	case len(r.ByMonthDay) > 0:
+		for _, d := range r.ByMonthDay {
+			if d < 0 {
+				d = length + d + 1
+			}
+			if d >= 1 && d <= length {
+				days = append(days, first.AddDate(0, 0, d-1))
+			}
+		}
+	case len(r.ByDay) > 0:
+		for _, wd := range r.ByDay {
+			days = append(days, nthWeekdays(first, length, wd)...)
+		}
+	default:
+		if defaultDay <= length {
+			days = append(days, first.AddDate(0, 0, defaultDay-1))
+		}
+	}
+
+	return days
+}
+
+// expandYearByDay lists the days of a year selected by BYDAY alone.
+func (r *Rule) expandYearByDay(year int, loc *time.Location) []time.Time {
+	first := time.Date(year, time.January, 1, 0, 0, 0, 0, loc)
+	length := int(time.Date(year+1, time.January, 1, 0, 0, 0, 0, loc).Sub(first).Hours()/24 + 0.5)
+
+	var days []time.Time
+	for _, wd := range r.ByDay {
+		days = append(days, nthWeekdays(first, length, wd)...)
+	}
+	return days
+}
+
+// matches applies the BYxxx parts that limit rather than expand the set.
+func (r *Rule) matches(day time.Time) bool {
+	if len(r.ByMonth) > 0 && !containsMonth(r.ByMonth, day.Month()) {
+		return false
+	}
+
+	if len(r.ByMonthDay) > 0 {
+		length := daysIn(day)
+		found := false
+		for _, d := range r.ByMonthDay {
+			if d == day.Day() || (d < 0 && length+d+1 == day.Day()) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	// BYDAY limits DAILY rules and MONTHLY/YEARLY rules expanded by BYMONTHDAY
+	if len(r.ByDay) > 0 && (r.Freq == Daily || len(r.ByMonthDay) > 0) {
+		found := false
+		for _, wd := range r.ByDay {
+			if wd.Day == day.Weekday() {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	return true
+}
+
+// nthWeekdays returns the days in [first, first+length) matching wd. A zero
+// N selects every matching weekday, a negative N counts from the end.
+func nthWeekdays(first time.Time, length int, wd WeekdayNum) []time.Time {
+	var matches []time.Time
+	offset := (int(wd.Day) - int(first.Weekday()) + 7) % 7
+	for d := offset; d < length; d += 7 {
+		matches = append(matches, first.AddDate(0, 0, d))
+	}
+
+	switch {
+	case wd.N == 0:
+		return matches
+	case wd.N > 0 && wd.N <= len(matches):
+		return matches[wd.N-1 : wd.N]
+	case wd.N < 0 && -wd.N <= len(matches):
+		return matches[len(matches)+wd.N : len(matches)+wd.N+1]
+	}
+	return nil
+}
+
+func dateOf(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+func daysIn(t time.Time) int {
+	return time.Date(t.Year(), t.Month()+1, 0, 0, 0, 0, 0, t.Location()).Day()
+}
+
+func containsMonth(months []time.Month, month time.Month) bool {
+	for _, m := range months {
+		if m == month {
+			return true
+		}
+	}
+	return false
+}
+
+func positiveInt(value string) (int, error) {
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 1 {
+		return 0, fmt.Errorf("expected a positive integer, got %q", value)
+	}
+	return n, nil
+}
+
+func parseIntList(value string, min, max int) ([]int, error) {
+	var list []int
+	for _, item := range strings.Split(value, ",") {
+		n, err := strconv.Atoi(item)
+		if err != nil || n == 0 || n < min || n > max {
+			return nil, fmt.Errorf("invalid value %q, expected %d to %d", item, min, max)
+		}
+		list = append(list, n)
+	}
+	return list, nil
+}
+
+func parseByDay(value string) ([]WeekdayNum, error) {
+	var days []WeekdayNum
+	for _, item := range strings.Split(strings.ToUpper(value), ",") {
+		if len(item) < 2 {
+			return nil, fmt.Errorf("invalid BYDAY %q", item)
+		}
+		day, ok := weekdays[item[len(item)-2:]]
+		if !ok {
+			return nil, fmt.Errorf("invalid BYDAY %q", item)
+		}
+		wd := WeekdayNum{Day: day}
+		if prefix := item[:len(item)-2]; prefix != "" {
+			n, err := strconv.Atoi(prefix)
+			if err != nil || n == 0 || n < -53 || n > 53 {
+				return nil, fmt.Errorf("invalid BYDAY %q", item)
+			}
+			wd.N = n
+		}
+		days = append(days, wd)
+	}
+	return days, nil
+}
+
+func parseUntil(value string) (time.Time, error) {
+	for _, layout := range []string{"20060102T150405Z", "20060102T150405", "20060102"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			if layout == "20060102" {
+				t = t.Add(24*time.Hour - time.Second)
+			}
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid UNTIL %q", value)
+}
+
+func joinInts(values []int) string {
+	parts := make([]string, len(values))
+	for i, v := range values {
+		parts[i] = strconv.Itoa(v)
+	}
+	return strings.Join(parts, ",")
+}
@@ -0,0 +1,170 @@
+package recurrence
+
+import (
+	"testing"
+	"time"
+)
+
+func date(y int, m time.Month, d, h, min int) time.Time {
+	return time.Date(y, m, d, h, min, 0, 0, time.UTC)
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		rule    string
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "Weekly with days",
+			rule: "RRULE:FREQ=WEEKLY;BYDAY=MO,WE",
+			want: "FREQ=WEEKLY;BYDAY=MO,WE",
+		},
+		{
+			name: "Monthly on the last Friday",
+			rule: "freq=monthly;byday=-1fr;interval=2",
+			want: "FREQ=MONTHLY;INTERVAL=2;BYDAY=-1FR",
+		},
+		{
+			name:    "Missing frequency",
+			rule:    "INTERVAL=2",
+			wantErr: true,
+		},
+		{
+			name:    "Count and until together",
+			rule:    "FREQ=DAILY;COUNT=3;UNTIL=20260101",
+			wantErr: true,
+		},
+		{
+			name:    "Unsupported part",
+			rule:    "FREQ=DAILY;BYHOUR=9",
+			wantErr: true,
+		},
+		{
+			name:    "Numbered weekday on a weekly rule",
+			rule:    "FREQ=WEEKLY;BYDAY=2MO",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, err := Parse(tt.rule)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Parse() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && rule.String() != tt.want {
+				t.Errorf("Parse().String() = %v, want %v", rule.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestNext(t *testing.T) {
+	tests := []struct {
+		name   string
+		rule   string
+		start  time.Time
+		after  time.Time
+		want   time.Time
+		wantOK bool
+	}{
+		{
+			name:   "Every other day",
+			rule:   "FREQ=DAILY;INTERVAL=2",
+			start:  date(2026, 3, 1, 9, 0),
+			after:  date(2026, 3, 1, 9, 0),
+			want:   date(2026, 3, 3, 9, 0),
+			wantOK: true,
+		},
+		{
+			name:   "Weekly on Monday and Wednesday",
+			rule:   "FREQ=WEEKLY;BYDAY=MO,WE",
+			start:  date(2026, 3, 2, 8, 0),
+			after:  date(2026, 3, 2, 8, 0),
+			want:   date(2026, 3, 4, 8, 0),
+			wantOK: true,
+		},
+		{
+			name:   "Fortnightly wraps to the next active week",
+			rule:   "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,WE",
+			start:  date(2026, 3, 4, 8, 0),
+			after:  date(2026, 3, 4, 8, 0),
+			want:   date(2026, 3, 16, 8, 0),
+			wantOK: true,
+		},
+		{
+			name:   "Monthly on the 31st skips short months",
+			rule:   "FREQ=MONTHLY;BYMONTHDAY=31",
+			start:  date(2026, 1, 31, 12, 0),
+			after:  date(2026, 1, 31, 12, 0),
+			want:   date(2026, 3, 31, 12, 0),
+			wantOK: true,
+		},
+		{
+			name:   "Last day of the month",
+			rule:   "FREQ=MONTHLY;BYMONTHDAY=-1",
+			start:  date(2026, 1, 31, 12, 0),
+			after:  date(2026, 1, 31, 12, 0),
+			want:   date(2026, 2, 28, 12, 0),
+			wantOK: true,
+		},
+		{
+			name:   "Second Tuesday of the month",
+			rule:   "FREQ=MONTHLY;BYDAY=2TU",
+			start:  date(2026, 1, 13, 10, 0),
+			after:  date(2026, 1, 13, 10, 0),
+			want:   date(2026, 2, 10, 10, 0),
+			wantOK: true,
+		},
+		{
+			name:   "Yearly birthday on a leap day",
+			rule:   "FREQ=YEARLY",
+			start:  date(2024, 2, 29, 0, 0),
+			after:  date(2024, 2, 29, 0, 0),
+			want:   date(2028, 2, 29, 0, 0),
+			wantOK: true,
+		},
+		{
+			name:   "Long overdue series catches up",
+			rule:   "FREQ=DAILY",
+			start:  date(2020, 1, 1, 7, 30),
+			after:  date(2026, 6, 15, 12, 0),
+			want:   date(2026, 6, 16, 7, 30),
+			wantOK: true,
+		},
+		{
+			name:   "Series ends at UNTIL",
+			rule:   "FREQ=WEEKLY;UNTIL=20260305T000000Z",
+			start:  date(2026, 3, 2, 8, 0),
+			after:  date(2026, 3, 2, 8, 0),
+			wantOK: false,
+		},
+		{
+			name:   "Impossible date never matches",
+			rule:   "FREQ=YEARLY;BYMONTH=2;BYMONTHDAY=30",
+			start:  date(2026, 1, 1, 0, 0),
+			after:  date(2026, 1, 1, 0, 0),
+			wantOK: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, err := Parse(tt.rule)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+
+			got, ok := rule.Next(tt.start, tt.after)
+			if ok != tt.wantOK {
+				t.Fatalf("Next() ok = %v, want %v (got %v)", ok, tt.wantOK, got)
+			}
+			if ok && !got.Equal(tt.want) {
+				t.Errorf("Next() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,190 @@
+// Package reminders delivers due reminders from the database.
+package reminders
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"todo-api/internal/models"
+	"todo-api/internal/notify"
+)
+
+// Dispatcher sends reminders whose time has come. Several replicas may run
+// a dispatcher against the same database: each reminder is claimed with a
+// conditional update before it is sent, so only one of them delivers it.
+type Dispatcher struct {
+	DB       *gorm.DB
+	Notifier notify.Notifier
+	// WorkerID identifies this replica in claims
+	WorkerID string
+	// Lease is how long a claim is honoured before another replica may
+	// take over a reminder whose delivery never finished
+	Lease       time.Duration
+	MaxAttempts int
+	BatchSize   int
+	Now         func() time.Time
+}
+
+// Run delivers every reminder that is due. It is meant to be registered as a
+// scheduler job.
+func (d *Dispatcher) Run(ctx context.Context) error {
+	now := d.now()
+
+	var due []models.Reminder
+	err := d.DB.WithContext(ctx).
+		Where("fire_at <= ?", now).
+		Where("status = ? OR (status = ? AND claimed_until < ?)", models.ReminderPending, models.ReminderSending, now).
+		Order("fire_at").
+		Limit(d.batchSize()).
+		Find(&due).Error
+	if err != nil {
+		return err
+	}
+
+	for i := range due {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		claimed, err := d.claim(ctx, &due[i])
+		if err != nil {
+			return err
+		}
+		if claimed {
+			if err := d.deliver(ctx, &due[i]); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// claim marks a reminder as being sent by this worker. It reports false when
+// another worker got there first. The lease starts now rather than when the
+// batch was read, since earlier deliveries in the batch may have taken a while.
+func (d *Dispatcher) claim(ctx context.Context, reminder *models.Reminder) (bool, error) {
+	now := d.now()
+	until := now.Add(d.lease())
+	result := d.DB.WithContext(ctx).Model(&models.Reminder{}).
+		Where("id = ?", reminder.ID).
+		Where("status = ? OR (status = ? AND claimed_until < ?)", models.ReminderPending, models.ReminderSending, now).
+		Updates(map[string]interface{}{
+			"status":        models.ReminderSending,
+			"claimed_by":    d.WorkerID,
+			"claimed_until": until,
+			"attempts":      gorm.Expr("attempts + 1"),
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+
+	reminder.Attempts++
+	return result.RowsAffected == 1, nil
+}
+
+// deliver sends a claimed reminder and records the outcome.
+func (d *Dispatcher) deliver(ctx context.Context, reminder *models.Reminder) error {
+	var todo models.Todo
+	if err := d.DB.WithContext(ctx).First(&todo, reminder.TodoID).Error; err != nil || todo.Completed {
+		return d.finish(ctx, reminder, models.ReminderCancelled, "")
+	}
+	// Reminders go to whoever set them, who may be a member of the todo's
+	// project rather than its creator
+	var recipient models.User
+	if err := d.DB.WithContext(ctx).Limit(1).Find(&recipient, reminder.UserID).Error; err != nil {
+		return err
+	}
+
+	msg := notify.Message{
+		Channel: reminder.Channel,
+		Event:   models.EventReminder,
+		UserID:  reminder.UserID,
+		Email:   recipient.Email,
+		TodoID:  todo.ID,
+		Subject: "Reminder: " + todo.Title,
+		Body:    reminderBody(&todo),
+	}
+	if reminder.Event == models.EventUnsnoozed {
+		msg.Event = models.EventUnsnoozed
+		msg.Subject = "Back from snooze: " + todo.Title
+		msg.Body = todo.Title + " is back on your list"
+	}
+
+	// Give up on the delivery well before the claim runs out, so another
+	// replica never takes over a reminder that is still being sent
+	sendCtx, cancel := context.WithTimeout(ctx, d.lease()/2)
+	err := d.Notifier.Notify(sendCtx, msg)
+	cancel()
+	if err != nil {
+		if reminder.Attempts >= d.maxAttempts() {
+			return d.finish(ctx, reminder, models.ReminderFailed, err.Error())
+		}
+		// Back off before the next attempt
+		retryAt := d.now().Add(time.Duration(reminder.Attempts) * time.Minute)
+		return d.claimed(ctx, reminder).Updates(map[string]interface{}{
+			"status":        models.ReminderPending,
+			"fire_at":       retryAt,
+			"last_error":    err.Error(),
+			"claimed_by":    "",
+			"claimed_until": nil,
+		}).Error
+	}
+
+	return d.finish(ctx, reminder, models.ReminderSent, "")
+}
+
+func (d *Dispatcher) finish(ctx context.Context, reminder *models.Reminder, status string, lastError string) error {
+	updates := map[string]interface{}{
+		"status":        status,
+		"last_error":    lastError,
+		"claimed_until": nil,
+	}
+	if status == models.ReminderSent {
+		updates["sent_at"] = d.now()
+	}
+	return d.claimed(ctx, reminder).Updates(updates).Error
+}
+
+// claimed scopes an update to a reminder this worker still holds, so a worker
+// whose lease ran out cannot overwrite the outcome of the one that took over.
+func (d *Dispatcher) claimed(ctx context.Context, reminder *models.Reminder) *gorm.DB {
+	return d.DB.WithContext(ctx).Model(&models.Reminder{}).
+		Where("id = ? AND claimed_by = ?", reminder.ID, d.WorkerID)
+}
+
+func reminderBody(todo *models.Todo) string {
+	if todo.DueDate == nil {
+		return todo.Title
+	}
+	return fmt.Sprintf("%s is due at %s", todo.Title, todo.DueDate.UTC().Format("2006-01-02 15:04 MST"))
+}
+
+func (d *Dispatcher) now() time.Time {
+	if d.Now != nil {
+		return d.Now()
+	}
+	return time.Now()
+}
+
+func (d *Dispatcher) lease() time.Duration {
+	if d.Lease > 0 {
+		return d.Lease
+	}
+	return 5 * time.Minute
+}
+
+func (d *Dispatcher) maxAttempts() int {
+	if d.MaxAttempts > 0 {
+		return d.MaxAttempts
+	}
+	return 5
+}
+
+func (d *Dispatcher) batchSize() int {
+	if d.BatchSize > 0 {
+		return d.BatchSize
+	}
+	return 100
+}
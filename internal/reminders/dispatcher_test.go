@@ -0,0 +1,225 @@
+package reminders
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"todo-api/internal/models"
+	"todo-api/internal/notify"
+	"todo-api/internal/test"
+)
+
+type recordingNotifier struct {
+	sent []notify.Message
+	err  error
+}
+
+func (n *recordingNotifier) Notify(ctx context.Context, msg notify.Message) error {
+	if n.err != nil {
+		return n.err
+	}
+	n.sent = append(n.sent, msg)
+	return nil
+}
+
+func TestDispatcherRun(t *testing.T) {
+	db, err := test.SetupTestDB()
+	assert.NoError(t, err)
+	testUser, err := test.CreateTestUser(db)
+	assert.NoError(t, err)
+
+	now := time.Now().UTC().Truncate(time.Second)
+	past := now.Add(-time.Minute)
+	future := now.Add(time.Hour)
+
+	tests := []struct {
+		name       string
+		fireAt     time.Time
+		event      string
+		completed  bool
+		notifyErr  error
+		attempts   int
+		wantStatus string
+		wantSent   int
+		wantEvent  string
+	}{
+		{
+			name:       "Due reminder is sent",
+			fireAt:     past,
+			wantStatus: models.ReminderSent,
+			wantSent:   1,
+			wantEvent:  models.EventReminder,
+		},
+		{
+			name:       "Snoozed todo comes back",
+			fireAt:     past,
+			event:      models.EventUnsnoozed,
+			wantStatus: models.ReminderSent,
+			wantSent:   1,
+			wantEvent:  models.EventUnsnoozed,
+		},
+		{
+			name:       "Future reminder waits",
+			fireAt:     future,
+			wantStatus: models.ReminderPending,
+		},
+		{
+			name:       "Reminder for a completed todo is cancelled",
+			fireAt:     past,
+			completed:  true,
+			wantStatus: models.ReminderCancelled,
+		},
+		{
+			name:       "Failed delivery is retried later",
+			fireAt:     past,
+			notifyErr:  errors.New("smtp unavailable"),
+			wantStatus: models.ReminderPending,
+		},
+		{
+			name:       "Delivery gives up after the last attempt",
+			fireAt:     past,
+			notifyErr:  errors.New("smtp unavailable"),
+			attempts:   4,
+			wantStatus: models.ReminderFailed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			test.ClearTestData(db)
+			todo := &models.Todo{Title: "Pay rent", UserID: testUser.ID, Completed: tt.completed}
+			db.Create(todo)
+			reminder := &models.Reminder{
+				TodoID:   todo.ID,
+				UserID:   testUser.ID,
+				Channel:  notify.ChannelInApp,
+				Event:    tt.event,
+				FireAt:   &tt.fireAt,
+				Status:   models.ReminderPending,
+				Attempts: tt.attempts,
+			}
+			db.Create(reminder)
+
+			notifier := &recordingNotifier{err: tt.notifyErr}
+			dispatcher := &Dispatcher{DB: db, Notifier: notifier, WorkerID: "test", Now: func() time.Time { return now }}
+			assert.NoError(t, dispatcher.Run(context.Background()))
+
+			var reloaded models.Reminder
+			db.First(&reloaded, reminder.ID)
+			assert.Equal(t, tt.wantStatus, reloaded.Status)
+			assert.Len(t, notifier.sent, tt.wantSent)
+			if tt.wantSent > 0 {
+				assert.Equal(t, tt.wantEvent, notifier.sent[0].Event)
+			}
+			if tt.notifyErr != nil && tt.wantStatus == models.ReminderPending {
+				assert.True(t, reloaded.FireAt.After(now))
+				assert.Equal(t, tt.notifyErr.Error(), reloaded.LastError)
+			}
+		})
+	}
+}
+
+func TestDispatcherDeliversOnce(t *testing.T) {
+	db, err := test.SetupTestDB()
+	assert.NoError(t, err)
+	testUser, err := test.CreateTestUser(db)
+	assert.NoError(t, err)
+	test.ClearTestData(db)
+
+	now := time.Now().UTC()
+	past := now.Add(-time.Minute)
+	todo := &models.Todo{Title: "Pay rent", UserID: testUser.ID}
+	db.Create(todo)
+	reminder := &models.Reminder{TodoID: todo.ID, UserID: testUser.ID, Channel: notify.ChannelInApp, FireAt: &past, Status: models.ReminderPending}
+	db.Create(reminder)
+
+	notifier := &recordingNotifier{}
+	first := &Dispatcher{DB: db, Notifier: notifier, WorkerID: "a"}
+	second := &Dispatcher{DB: db, Notifier: notifier, WorkerID: "b"}
+
+	// The second worker read the reminder before the first one claimed it
+	claimed, err := first.claim(context.Background(), reminder)
+	assert.NoError(t, err)
+	assert.True(t, claimed)
+
+	stale := *reminder
+	claimed, err = second.claim(context.Background(), &stale)
+	assert.NoError(t, err)
+	assert.False(t, claimed)
+
+	// An expired claim can be taken over
+	second.Now = func() time.Time { return now.Add(time.Hour) }
+	claimed, err = second.claim(context.Background(), &stale)
+	assert.NoError(t, err)
+	assert.True(t, claimed)
+
+	// The first worker's outcome no longer counts
+	assert.NoError(t, first.finish(context.Background(), reminder, models.ReminderSent, ""))
+	var reloaded models.Reminder
+	db.First(&reloaded, reminder.ID)
+	assert.Equal(t, models.ReminderSending, reloaded.Status)
+	assert.Equal(t, "b", reloaded.ClaimedBy)
+}
+
+// hangingNotifier never answers, like a webhook endpoint that stalls.
+type hangingNotifier struct{}
+
+func (hangingNotifier) Notify(ctx context.Context, msg notify.Message) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func TestDispatcherTimesOutBeforeLease(t *testing.T) {
+	db, err := test.SetupTestDB()
+	assert.NoError(t, err)
+	testUser, err := test.CreateTestUser(db)
+	assert.NoError(t, err)
+	test.ClearTestData(db)
+
+	now := time.Now().UTC()
+	past := now.Add(-time.Minute)
+	todo := &models.Todo{Title: "Pay rent", UserID: testUser.ID}
+	db.Create(todo)
+	reminder := &models.Reminder{TodoID: todo.ID, UserID: testUser.ID, Channel: notify.ChannelWebhook, FireAt: &past, Status: models.ReminderPending}
+	db.Create(reminder)
+
+	lease := 200 * time.Millisecond
+	dispatcher := &Dispatcher{DB: db, Notifier: hangingNotifier{}, WorkerID: "a", Lease: lease}
+	started := time.Now()
+	assert.NoError(t, dispatcher.Run(context.Background()))
+	assert.Less(t, time.Since(started), lease)
+
+	// The attempt is given up and retried, rather than left claimed
+	var reloaded models.Reminder
+	db.First(&reloaded, reminder.ID)
+	assert.Equal(t, models.ReminderPending, reloaded.Status)
+	assert.Equal(t, context.DeadlineExceeded.Error(), reloaded.LastError)
+	assert.Nil(t, reloaded.ClaimedUntil)
+}
+
+func TestDispatcherSendsToWhoeverSetTheReminder(t *testing.T) {
+	db, err := test.SetupTestDB()
+	assert.NoError(t, err)
+	owner, err := test.CreateTestUser(db)
+	assert.NoError(t, err)
+	test.ClearTestData(db)
+	member := &models.User{Email: "member@example.com"}
+	db.Create(member)
+
+	past := time.Now().UTC().Add(-time.Minute)
+	todo := &models.Todo{Title: "Fix the sink", UserID: owner.ID}
+	db.Create(todo)
+	db.Create(&models.Reminder{TodoID: todo.ID, UserID: member.ID, Channel: notify.ChannelEmail, FireAt: &past, Status: models.ReminderPending})
+
+	notifier := &recordingNotifier{}
+	dispatcher := &Dispatcher{DB: db, Notifier: notifier, WorkerID: "a"}
+	assert.NoError(t, dispatcher.Run(context.Background()))
+
+	if assert.Len(t, notifier.sent, 1) {
+		assert.Equal(t, member.ID, notifier.sent[0].UserID)
+		assert.Equal(t, "member@example.com", notifier.sent[0].Email)
+	}
+}
@@ -23,9 +23,158 @@ func SetupRoutes(r *gin.Engine) {
 		{
 			todos.POST("", handlers.CreateTodo)
 			todos.GET("", handlers.GetTodos)
+			todos.GET("/export", handlers.ExportTodos)
+			todos.POST("/bulk", handlers.BulkUpdateTodos)
+			todos.POST("/quick-add", handlers.QuickAdd)
+			todos.POST("/quick-add/preview", handlers.PreviewQuickAdd)
+			todos.GET("/trash", handlers.GetTrash)
+			todos.DELETE("/trash/:id", handlers.PurgeTodo)
 			todos.GET("/:id", handlers.GetTodo)
 			todos.PUT("/:id", handlers.UpdateTodo)
 			todos.DELETE("/:id", handlers.DeleteTodo)
+			todos.POST("/:id/restore", handlers.RestoreTodo)
+			todos.POST("/:id/archive", handlers.ArchiveTodo)
+			todos.POST("/:id/unarchive", handlers.UnarchiveTodo)
+			todos.POST("/:id/snooze", handlers.SnoozeTodo)
+			todos.DELETE("/:id/snooze", handlers.UnsnoozeTodo)
+			todos.GET("/:id/subtree", handlers.GetSubtree)
+			todos.POST("/:id/checklist/:index", handlers.SetChecklistItem)
+			todos.POST("/:id/move", handlers.MoveTodo)
+			todos.POST("/:id/template", handlers.SaveAsTemplate)
+			todos.POST("/:id/duplicate", handlers.DuplicateTodo)
+			todos.POST("/:id/status", handlers.ChangeStatus)
+			todos.POST("/:id/skip", handlers.SkipOccurrence)
+			todos.GET("/:id/occurrences", handlers.GetOccurrences)
+			todos.GET("/:id/history", handlers.GetTodoHistory)
+			todos.POST("/:id/revert/:revision", handlers.RevertTodo)
+			todos.POST("/:id/timer/start", handlers.StartTimer)
+			todos.GET("/:id/time-entries", handlers.GetTimeEntries)
+			todos.POST("/:id/time-entries", handlers.CreateTimeEntry)
+			todos.POST("/:id/reminders", handlers.CreateReminder)
+			todos.GET("/:id/reminders", handlers.GetReminders)
+			todos.GET("/:id/comments", handlers.GetComments)
+			todos.POST("/:id/comments", handlers.CreateComment)
+			todos.GET("/:id/attachments", handlers.GetAttachments)
+			todos.POST("/:id/attachments", handlers.UploadAttachment)
+			todos.GET("/:id/attachments/:attachmentId", handlers.DownloadAttachment)
+			todos.DELETE("/:id/attachments/:attachmentId", handlers.DeleteAttachment)
+			todos.GET("/:id/dependencies", handlers.GetDependencies)
+			todos.POST("/:id/dependencies", handlers.AddDependency)
+			todos.DELETE("/:id/dependencies/:blockerId", handlers.RemoveDependency)
+			todos.POST("/:id/tags/:tagId", handlers.AttachTag)
+			todos.DELETE("/:id/tags/:tagId", handlers.DetachTag)
+			todos.POST("/:id/assignees/:userId", handlers.AssignTodo)
+			todos.DELETE("/:id/assignees/:userId", handlers.UnassignTodo)
+		}
+
+		tags := api.Group("/tags")
+		{
+			tags.POST("", handlers.CreateTag)
+			tags.GET("", handlers.GetTags)
+			tags.GET("/:id", handlers.GetTag)
+			tags.PUT("/:id", handlers.UpdateTag)
+			tags.DELETE("/:id", handlers.DeleteTag)
+			tags.POST("/:id/merge", handlers.MergeTag)
+		}
+
+		templates := api.Group("/templates")
+		{
+			templates.POST("", handlers.CreateTemplate)
+			templates.GET("", handlers.GetTemplates)
+			templates.GET("/:id", handlers.GetTemplate)
+			templates.PUT("/:id", handlers.UpdateTemplate)
+			templates.DELETE("/:id", handlers.DeleteTemplate)
+			templates.POST("/:id/instantiate", handlers.InstantiateTemplate)
+		}
+
+		customFields := api.Group("/custom-fields")
+		{
+			customFields.POST("", handlers.CreateCustomField)
+			customFields.GET("", handlers.GetCustomFields)
+			customFields.GET("/:id", handlers.GetCustomField)
+			customFields.PUT("/:id", handlers.UpdateCustomField)
+			customFields.DELETE("/:id", handlers.DeleteCustomField)
+		}
+
+		projects := api.Group("/projects")
+		{
+			projects.POST("", handlers.CreateProject)
+			projects.GET("", handlers.GetProjects)
+			projects.GET("/:id", handlers.GetProject)
+			projects.PUT("/:id", handlers.UpdateProject)
+			projects.DELETE("/:id", handlers.DeleteProject)
+			projects.GET("/:id/stats", handlers.GetProjectStats)
+			projects.GET("/:id/members", handlers.GetProjectMembers)
+			projects.POST("/:id/members", handlers.AddProjectMember)
+			projects.DELETE("/:id/members/:userId", handlers.RemoveProjectMember)
+		}
+
+		workflows := api.Group("/workflows")
+		{
+			workflows.POST("", handlers.CreateWorkflow)
+			workflows.GET("", handlers.GetWorkflows)
+			workflows.GET("/:id", handlers.GetWorkflow)
+			workflows.PUT("/:id", handlers.UpdateWorkflow)
+			workflows.DELETE("/:id", handlers.DeleteWorkflow)
+			workflows.GET("/:id/board", handlers.GetBoard)
+		}
+
+		views := api.Group("/views")
+		{
+			views.POST("", handlers.CreateView)
+			views.GET("", handlers.GetViews)
+			views.GET("/:id", handlers.GetView)
+			views.PUT("/:id", handlers.UpdateView)
+			views.DELETE("/:id", handlers.DeleteView)
+			views.POST("/:id/pin", handlers.PinView)
+			views.DELETE("/:id/pin", handlers.UnpinView)
+			views.GET("/:id/todos", handlers.RunView)
+		}
+
+		rules := api.Group("/rules")
+		{
+			rules.POST("", handlers.CreateRule)
+			rules.GET("", handlers.GetRules)
+			rules.GET("/:id", handlers.GetRule)
+			rules.PUT("/:id", handlers.UpdateRule)
+			rules.DELETE("/:id", handlers.DeleteRule)
+			rules.GET("/:id/executions", handlers.GetRuleExecutions)
+		}
+
+		habits := api.Group("/habits")
+		{
+			habits.POST("", handlers.CreateHabit)
+			habits.GET("", handlers.GetHabits)
+			habits.GET("/:id", handlers.GetHabit)
+			habits.PUT("/:id", handlers.UpdateHabit)
+			habits.DELETE("/:id", handlers.DeleteHabit)
+			habits.POST("/:id/checkins", handlers.CheckInHabit)
+			habits.DELETE("/:id/checkins/:day", handlers.DeleteCheckIn)
+			habits.GET("/:id/heatmap", handlers.GetHabitHeatmap)
+		}
+
+		comments := api.Group("/comments")
+		{
+			comments.PUT("/:id", handlers.UpdateComment)
+			comments.DELETE("/:id", handlers.DeleteComment)
+			comments.GET("/:id/history", handlers.GetCommentHistory)
+		}
+
+		api.GET("/me", handlers.GetMe)
+		api.PUT("/me/settings", handlers.UpdateSettings)
+
+		api.DELETE("/reminders/:id", handlers.DeleteReminder)
+
+		api.GET("/timer", handlers.GetTimer)
+		api.POST("/timer/stop", handlers.StopTimer)
+		api.PUT("/time-entries/:id", handlers.UpdateTimeEntry)
+		api.DELETE("/time-entries/:id", handlers.DeleteTimeEntry)
+		api.GET("/time/report", handlers.GetTimeReport)
+
+		notifications := api.Group("/notifications")
+		{
+			notifications.GET("", handlers.GetNotifications)
+			notifications.POST("/:id/read", handlers.MarkNotificationRead)
 		}
 	}
 }
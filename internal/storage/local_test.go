@@ -0,0 +1,33 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLocal(t *testing.T) {
+	ctx := context.Background()
+	store := &Local{Root: t.TempDir()}
+	key := "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"
+
+	_, err := store.Get(ctx, key)
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	assert.NoError(t, store.Put(ctx, key, strings.NewReader("test")))
+	blob, err := store.Get(ctx, key)
+	assert.NoError(t, err)
+	contents, _ := io.ReadAll(blob)
+	blob.Close()
+	assert.Equal(t, "test", string(contents))
+
+	assert.NoError(t, store.Delete(ctx, key))
+	assert.NoError(t, store.Delete(ctx, key))
+	_, err = store.Get(ctx, key)
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	assert.Error(t, store.Put(ctx, "../../etc/passwd", strings.NewReader("x")))
+}
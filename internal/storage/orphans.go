@@ -0,0 +1,29 @@
+package storage
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+	"todo-api/internal/models"
+)
+
+// RemoveOrphans deletes the given blobs once no attachment refers to them
+// any more. The file is deleted before the row's deletion is committed, so
+// an upload of the same contents that waited on the row writes the file
+// again rather than finding it gone later.
+func RemoveOrphans(ctx context.Context, db *gorm.DB, store BlobStore, checksums []string) error {
+	for _, checksum := range checksums {
+		err := db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			referenced := tx.Model(&models.Attachment{}).Select("1").Where("checksum = ?", checksum)
+			result := tx.Where("checksum = ? AND NOT EXISTS (?)", checksum, referenced).Delete(&models.Blob{})
+			if result.Error != nil || result.RowsAffected == 0 {
+				return result.Error
+			}
+			return store.Delete(ctx, checksum)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
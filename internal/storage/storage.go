@@ -0,0 +1,42 @@
+// Package storage keeps uploaded files behind a pluggable blob store.
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+)
+
+// ErrNotFound is returned when a blob does not exist.
+var ErrNotFound = errors.New("blob not found")
+
+// BlobStore stores file contents under content-addressed keys.
+type BlobStore interface {
+	// Put stores the contents of r under key, replacing any existing blob.
+	Put(ctx context.Context, key string, r io.Reader) error
+	// Get opens the blob stored under key.
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete removes the blob stored under key. Deleting a missing blob is
+	// not an error.
+	Delete(ctx context.Context, key string) error
+}
+
+var Store BlobStore
+
+// Limits applied to uploads. They can be changed before the server starts.
+var (
+	MaxUploadSize int64 = 10 << 20
+	UserQuota     int64 = 100 << 20
+)
+
+func InitStore(store BlobStore) {
+	Store = store
+}
+
+func GetStore() BlobStore {
+	if Store == nil {
+		log.Fatal("Blob store not initialized")
+	}
+	return Store
+}
@@ -17,7 +17,7 @@ func SetupTestDB() (*gorm.DB, error) {
 	}
 
 	// Auto migrate the schemas
-	err = db.AutoMigrate(&models.User{}, &models.Todo{})
+	err = db.AutoMigrate(&models.User{}, &models.Todo{}, &models.Tag{}, &models.Project{}, &models.Reminder{}, &models.Notification{}, &models.TodoDependency{}, &models.Workflow{}, &models.WorkflowStatus{}, &models.WorkflowTransition{}, &models.Comment{}, &models.CommentRevision{}, &models.Blob{}, &models.Attachment{}, &models.TodoRevision{}, &models.TimeEntry{}, &models.CustomField{}, &models.CustomFieldValue{}, &models.Template{}, &models.ProjectMember{}, &models.SavedView{}, &models.Rule{}, &models.RuleExecution{}, &models.Habit{}, &models.HabitCheckIn{})
 	if err != nil {
 		return nil, err
 	}
@@ -30,7 +30,132 @@ func SetupTestDB() (*gorm.DB, error) {
 
 // ClearTestData cleans up test data from the database
 func ClearTestData(db *gorm.DB) error {
-	err := db.Exec("DELETE FROM todos").Error
+	err := db.Exec("DELETE FROM reminders").Error
+	if err != nil {
+		return err
+	}
+
+	err = db.Exec("DELETE FROM notifications").Error
+	if err != nil {
+		return err
+	}
+
+	err = db.Exec("DELETE FROM attachments").Error
+	if err != nil {
+		return err
+	}
+
+	err = db.Exec("DELETE FROM blobs").Error
+	if err != nil {
+		return err
+	}
+
+	err = db.Exec("DELETE FROM habit_check_ins").Error
+	if err != nil {
+		return err
+	}
+
+	err = db.Exec("DELETE FROM habits").Error
+	if err != nil {
+		return err
+	}
+
+	err = db.Exec("DELETE FROM rule_executions").Error
+	if err != nil {
+		return err
+	}
+
+	err = db.Exec("DELETE FROM rules").Error
+	if err != nil {
+		return err
+	}
+
+	err = db.Exec("DELETE FROM saved_views").Error
+	if err != nil {
+		return err
+	}
+
+	err = db.Exec("DELETE FROM templates").Error
+	if err != nil {
+		return err
+	}
+
+	err = db.Exec("DELETE FROM custom_field_values").Error
+	if err != nil {
+		return err
+	}
+
+	err = db.Exec("DELETE FROM custom_fields").Error
+	if err != nil {
+		return err
+	}
+
+	err = db.Exec("DELETE FROM time_entries").Error
+	if err != nil {
+		return err
+	}
+
+	err = db.Exec("DELETE FROM todo_revisions").Error
+	if err != nil {
+		return err
+	}
+
+	err = db.Exec("DELETE FROM comment_revisions").Error
+	if err != nil {
+		return err
+	}
+
+	err = db.Exec("DELETE FROM comments").Error
+	if err != nil {
+		return err
+	}
+
+	err = db.Exec("DELETE FROM todo_dependencies").Error
+	if err != nil {
+		return err
+	}
+
+	err = db.Exec("DELETE FROM workflow_transitions").Error
+	if err != nil {
+		return err
+	}
+
+	err = db.Exec("DELETE FROM workflow_statuses").Error
+	if err != nil {
+		return err
+	}
+
+	err = db.Exec("DELETE FROM workflows").Error
+	if err != nil {
+		return err
+	}
+
+	err = db.Exec("DELETE FROM todo_assignees").Error
+	if err != nil {
+		return err
+	}
+
+	err = db.Exec("DELETE FROM project_members").Error
+	if err != nil {
+		return err
+	}
+
+	err = db.Exec("DELETE FROM todo_tags").Error
+	if err != nil {
+		return err
+	}
+
+	err = db.Exec("DELETE FROM tags").Error
+	if err != nil {
+		return err
+	}
+
+	err = db.Exec("DELETE FROM todos").Error
+	if err != nil {
+		return err
+	}
+
+	err = db.Exec("DELETE FROM projects").Error
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,159 @@
+// Package trash permanently removes todos that were deleted.
+package trash
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"todo-api/internal/models"
+	"todo-api/internal/storage"
+)
+
+// Purge permanently deletes the given todos together with the rows that
+// belong to them. It returns the checksums of the blobs their attachments
+// used, which may now be orphaned; pass them to storage.RemoveOrphans once
+// the transaction has committed.
+func Purge(tx *gorm.DB, ids []uint) ([]string, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	var checksums []string
+	if err := tx.Model(&models.Attachment{}).Distinct().Where("todo_id IN ?", ids).Pluck("checksum", &checksums).Error; err != nil {
+		return nil, err
+	}
+	if err := tx.Where("todo_id IN ?", ids).Delete(&models.Attachment{}).Error; err != nil {
+		return nil, err
+	}
+
+	if err := tx.Exec("DELETE FROM todo_tags WHERE todo_id IN ?", ids).Error; err != nil {
+		return nil, err
+	}
+	if err := tx.Exec("DELETE FROM todo_assignees WHERE todo_id IN ?", ids).Error; err != nil {
+		return nil, err
+	}
+	comments := tx.Unscoped().Model(&models.Comment{}).Select("id").Where("todo_id IN ?", ids)
+	if err := tx.Where("comment_id IN (?)", comments).Delete(&models.CommentRevision{}).Error; err != nil {
+		return nil, err
+	}
+	if err := tx.Unscoped().Where("todo_id IN ?", ids).Delete(&models.Comment{}).Error; err != nil {
+		return nil, err
+	}
+	if err := tx.Where("todo_id IN ?", ids).Delete(&models.CustomFieldValue{}).Error; err != nil {
+		return nil, err
+	}
+	if err := tx.Where("todo_id IN ?", ids).Delete(&models.TimeEntry{}).Error; err != nil {
+		return nil, err
+	}
+	if err := tx.Where("todo_id IN ?", ids).Delete(&models.TodoRevision{}).Error; err != nil {
+		return nil, err
+	}
+	if err := tx.Where("todo_id IN ? OR blocked_by_id IN ?", ids, ids).Delete(&models.TodoDependency{}).Error; err != nil {
+		return nil, err
+	}
+	if err := tx.Unscoped().Where("todo_id IN ?", ids).Delete(&models.Reminder{}).Error; err != nil {
+		return nil, err
+	}
+	if err := tx.Where("todo_id IN ?", ids).Delete(&models.RuleExecution{}).Error; err != nil {
+		return nil, err
+	}
+	// Notifications stay in the inbox without a link to the todo
+	if err := tx.Model(&models.Notification{}).Where("todo_id IN ?", ids).Update("todo_id", nil).Error; err != nil {
+		return nil, err
+	}
+	if err := repointSeries(tx, ids); err != nil {
+		return nil, err
+	}
+	if err := tx.Unscoped().Where("id IN ?", ids).Delete(&models.Todo{}).Error; err != nil {
+		return nil, err
+	}
+	return checksums, nil
+}
+
+// repointSeries moves the remaining occurrences of a series whose first
+// occurrence is being purged onto the earliest occurrence that is left.
+func repointSeries(tx *gorm.DB, ids []uint) error {
+	var remaining []models.Todo
+	err := tx.Unscoped().Select("id", "series_id").
+		Where("series_id IN ? AND id NOT IN ?", ids, ids).
+		Order("id").
+		Find(&remaining).Error
+	if err != nil {
+		return err
+	}
+
+	moved := make(map[uint]bool)
+	for _, todo := range remaining {
+		if moved[*todo.SeriesID] {
+			continue
+		}
+		moved[*todo.SeriesID] = true
+		err := tx.Unscoped().Model(&models.Todo{}).
+			Where("series_id = ? AND id NOT IN ?", *todo.SeriesID, ids).
+			Update("series_id", todo.ID).Error
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Purger permanently deletes todos that have been in the trash for longer
+// than the retention period.
+type Purger struct {
+	DB        *gorm.DB
+	Store     storage.BlobStore
+	Retention time.Duration
+	BatchSize int
+	Now       func() time.Time
+}
+
+// Run purges expired todos in batches. It is meant to be registered as a
+// scheduler job.
+func (p *Purger) Run(ctx context.Context) error {
+	cutoff := p.now().Add(-p.Retention)
+
+	for {
+		var ids []uint
+		err := p.DB.WithContext(ctx).Unscoped().Model(&models.Todo{}).
+			Where("deleted_at IS NOT NULL AND deleted_at < ?", cutoff).
+			Limit(p.batchSize()).
+			Pluck("id", &ids).Error
+		if err != nil {
+			return err
+		}
+		if len(ids) == 0 {
+			return nil
+		}
+
+		var checksums []string
+		err = p.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			checksums, err = Purge(tx, ids)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+		if err := storage.RemoveOrphans(ctx, p.DB, p.Store, checksums); err != nil {
+			return err
+		}
+		if len(ids) < p.batchSize() {
+			return nil
+		}
+	}
+}
+
+func (p *Purger) now() time.Time {
+	if p.Now != nil {
+		return p.Now()
+	}
+	return time.Now()
+}
+
+func (p *Purger) batchSize() int {
+	if p.BatchSize > 0 {
+		return p.BatchSize
+	}
+	return 500
+}
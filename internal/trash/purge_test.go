@@ -0,0 +1,79 @@
+package trash
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"todo-api/internal/models"
+	"todo-api/internal/test"
+)
+
+func TestPurgerRun(t *testing.T) {
+	db, err := test.SetupTestDB()
+	assert.NoError(t, err)
+	testUser, err := test.CreateTestUser(db)
+	assert.NoError(t, err)
+	test.ClearTestData(db)
+
+	now := time.Now()
+	expired := &models.Todo{Title: "Old", UserID: testUser.ID}
+	recent := &models.Todo{Title: "Recent", UserID: testUser.ID}
+	live := &models.Todo{Title: "Live", UserID: testUser.ID}
+	db.Create(expired)
+	db.Create(recent)
+	db.Create(live)
+
+	offset := 10
+	db.Create(&models.Reminder{TodoID: expired.ID, UserID: testUser.ID, OffsetMinutes: &offset, Status: models.ReminderPending})
+	db.Unscoped().Model(expired).Update("deleted_at", now.Add(-31*24*time.Hour))
+	db.Unscoped().Model(recent).Update("deleted_at", now.Add(-24*time.Hour))
+
+	purger := &Purger{DB: db, Retention: 30 * 24 * time.Hour, BatchSize: 1, Now: func() time.Time { return now }}
+	assert.NoError(t, purger.Run(context.Background()))
+
+	var count int64
+	db.Unscoped().Model(&models.Todo{}).Where("id = ?", expired.ID).Count(&count)
+	assert.Equal(t, int64(0), count)
+	db.Unscoped().Model(&models.Reminder{}).Where("todo_id = ?", expired.ID).Count(&count)
+	assert.Equal(t, int64(0), count)
+	db.Unscoped().Model(&models.Todo{}).Where("id IN ?", []uint{recent.ID, live.ID}).Count(&count)
+	assert.Equal(t, int64(2), count)
+}
+
+func TestPurgeUnlinksNotificationsAndSeries(t *testing.T) {
+	db, err := test.SetupTestDB()
+	assert.NoError(t, err)
+	test.ClearTestData(db)
+	testUser, err := test.CreateTestUser(db)
+	assert.NoError(t, err)
+
+	first := &models.Todo{Title: "Water plants", UserID: testUser.ID, Recurrence: "FREQ=DAILY", Occurrence: 1}
+	db.Create(first)
+	db.Model(first).Update("series_id", first.ID)
+	second := &models.Todo{Title: "Water plants", UserID: testUser.ID, Recurrence: "FREQ=DAILY", Occurrence: 2, SeriesID: &first.ID}
+	third := &models.Todo{Title: "Water plants", UserID: testUser.ID, Recurrence: "FREQ=DAILY", Occurrence: 3, SeriesID: &first.ID}
+	db.Create(second)
+	db.Create(third)
+	notification := &models.Notification{UserID: testUser.ID, TodoID: &first.ID, Event: "reminder", Title: "Reminder: Water plants"}
+	db.Create(notification)
+
+	_, err = Purge(db, []uint{first.ID})
+	assert.NoError(t, err)
+
+	// The notification stays in the inbox without its todo
+	var kept models.Notification
+	assert.NoError(t, db.First(&kept, notification.ID).Error)
+	assert.Nil(t, kept.TodoID)
+
+	// The rest of the series now starts at the earliest occurrence left
+	var series []models.Todo
+	db.Order("id").Find(&series, []uint{second.ID, third.ID})
+	assert.Len(t, series, 2)
+	for _, todo := range series {
+		if assert.NotNil(t, todo.SeriesID) {
+			assert.Equal(t, second.ID, *todo.SeriesID)
+		}
+	}
+}
@@ -1,14 +1,25 @@
 package main
 
 import (
+	"context"
+	"fmt"
 	"log"
+	"net/smtp"
 	"os"
+	"time"
 
 	"todo-api/docs"
+	"todo-api/internal/archive"
 	"todo-api/internal/config"
 	"todo-api/internal/database"
+	"todo-api/internal/handlers"
 	"todo-api/internal/models"
+	"todo-api/internal/notify"
+	"todo-api/internal/reminders"
 	"todo-api/internal/routes"
+	"todo-api/internal/scheduler"
+	"todo-api/internal/storage"
+	"todo-api/internal/trash"
 
 	"github.com/gin-gonic/gin"
 	swaggerFiles "github.com/swaggo/files"
@@ -55,11 +66,34 @@ func main() {
 
 	// Auto Migrate the schemas
 	db := database.GetDB()
-	err = db.AutoMigrate(&models.User{}, &models.Todo{})
+	err = db.AutoMigrate(&models.User{}, &models.Todo{}, &models.Tag{}, &models.Project{}, &models.Reminder{}, &models.Notification{}, &models.TodoDependency{}, &models.Workflow{}, &models.WorkflowStatus{}, &models.WorkflowTransition{}, &models.Comment{}, &models.CommentRevision{}, &models.Blob{}, &models.Attachment{}, &models.TodoRevision{}, &models.TimeEntry{}, &models.CustomField{}, &models.CustomFieldValue{}, &models.Template{}, &models.ProjectMember{}, &models.SavedView{}, &models.Rule{}, &models.RuleExecution{}, &models.Habit{}, &models.HabitCheckIn{})
 	if err != nil {
 		log.Fatal("Failed to migrate database:", err)
 	}
 
+	// Initialize blob storage
+	storage.InitStore(&storage.Local{Root: cfg.StoragePath})
+	storage.MaxUploadSize = cfg.MaxUploadSize
+	storage.UserQuota = cfg.StorageQuota
+
+	handlers.MaxBulkSize = cfg.MaxBulkSize
+
+	// Start background jobs
+	jobs := scheduler.New()
+	dispatcher := &reminders.Dispatcher{
+		DB:       db,
+		Notifier: notifiers(cfg),
+		WorkerID: workerID(),
+	}
+	jobs.Every("reminders", cfg.ReminderPollInterval, dispatcher.Run)
+	purger := &trash.Purger{DB: db, Store: storage.GetStore(), Retention: cfg.TrashRetention}
+	jobs.Every("trash-purge", time.Hour, purger.Run)
+	archiver := &archive.AutoArchiver{DB: db}
+	jobs.Every("auto-archive", time.Hour, archiver.Run)
+	dueSoon := &handlers.DueSoonRules{DB: db}
+	jobs.Every("due-soon-rules", time.Minute, dueSoon.Run)
+	jobs.Start(context.Background())
+
 	// Initialize Gin router
 	r := gin.Default()
 
@@ -77,3 +111,38 @@ func main() {
 		log.Fatal("Failed to start server:", err)
 	}
 }
+
+// notifiers routes reminder deliveries to the channels that are configured.
+// In-app notifications are always available.
+func notifiers(cfg *config.Config) notify.Router {
+	router := notify.Router{
+		notify.ChannelInApp: &notify.InApp{DB: database.GetDB()},
+	}
+
+	if cfg.SMTPHost != "" {
+		var auth smtp.Auth
+		if cfg.SMTPUsername != "" {
+			auth = smtp.PlainAuth("", cfg.SMTPUsername, cfg.SMTPPassword, cfg.SMTPHost)
+		}
+		router[notify.ChannelEmail] = &notify.Email{
+			Addr: cfg.SMTPHost + ":" + cfg.SMTPPort,
+			Auth: auth,
+			From: cfg.SMTPFrom,
+		}
+	}
+
+	if cfg.NotifyWebhookURL != "" {
+		router[notify.ChannelWebhook] = &notify.Webhook{URL: cfg.NotifyWebhookURL}
+	}
+
+	return router
+}
+
+// workerID identifies this process when claiming reminders.
+func workerID() string {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+	return fmt.Sprintf("%s-%d", host, os.Getpid())
+}